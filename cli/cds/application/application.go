@@ -18,6 +18,8 @@ func Cmd() *cobra.Command {
 	cmd.AddCommand(applicationRenameCmd())
 	cmd.AddCommand(applicationListCmd())
 	cmd.AddCommand(applicationShowCmd())
+	cmd.AddCommand(applicationFlakyCmd())
+	cmd.AddCommand(applicationReleaseNotesCmd())
 	cmd.AddCommand(applicationVariableCmd)
 	cmd.AddCommand(applicationGroupCmd)
 	cmd.AddCommand(applicationPipelineCmd)
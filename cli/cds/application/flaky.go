@@ -0,0 +1,56 @@
+package application
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func applicationFlakyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flaky",
+		Short: "cds application flaky <projectKey> <applicationName> [branch]",
+		Long:  `List tests whose result flip-flops between builds of the same branch`,
+		Run:   listFlakyTests,
+	}
+
+	return cmd
+}
+
+func listFlakyTests(cmd *cobra.Command, args []string) {
+	if len(args) != 2 && len(args) != 3 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+
+	var branch string
+	if len(args) == 3 {
+		branch = args[2]
+	}
+
+	tests, err := sdk.GetFlakyTests(args[0], args[1], branch)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve flaky tests of application %s (%s)\n", args[1], err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Branch", "Pipeline", "Environment", "Test", "Failures", "Flips", "Last failure"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, t := range tests {
+		table.Append([]string{
+			t.Branch,
+			t.Pipeline,
+			t.Environment,
+			fmt.Sprintf("%s/%s", t.Suite, t.Name),
+			fmt.Sprintf("%d/%d (%.0f%%)", t.Failures, t.Runs, t.FailureRate*100),
+			fmt.Sprintf("%d", t.Flips),
+			fmt.Sprintf("#%d", t.LastFailure),
+		})
+	}
+	table.Render()
+}
@@ -45,22 +45,38 @@ func init() {
 	applicationPipelineCmd.AddCommand(cmdApplicationAddPipeline)
 	applicationPipelineCmd.AddCommand(cmdApplicationRemovePipeline)
 	applicationPipelineCmd.AddCommand(cmdApplicationPipelineScheduler)
+	applicationPipelineCmd.AddCommand(cmdApplicationPipelineOptions)
 
 	cmdApplicationPipelineScheduler.AddCommand(cmdApplicationPipelineSchedulerList)
 	cmdApplicationPipelineScheduler.AddCommand(cmdApplicationPipelineSchedulerAdd)
 	cmdApplicationPipelineScheduler.AddCommand(cmdApplicationPipelineSchedulerUpdate)
 	cmdApplicationPipelineScheduler.AddCommand(cmdApplicationPipelineSchedulerDelete)
+	cmdApplicationPipelineScheduler.AddCommand(cmdApplicationPipelineSchedulerNext)
 
 	cmdApplicationAddPipeline.Flags().StringSliceVarP(&cmdApplicationAddPipelineParams, "parameter", "p", nil, "Pipeline parameters")
 	cmdApplicationShowPipeline.Flags().BoolVarP(&cmdApplicationShowPipelineDetails, "details", "", false, "Show pipeline details")
+	cmdApplicationPipelineOptions.Flags().StringVarP(&cmdApplicationPipelineOptionsRetryFlaky, "retry-flaky-tests", "", "", "Restart once jobs failing only on known flaky tests")
+	cmdApplicationPipelineOptions.Flags().StringVarP(&cmdApplicationPipelineOptionsCancelSuperseded, "cancel-superseded", "", "", "Stop older building or waiting builds of a branch when a new build starts on it")
+	cmdApplicationPipelineOptions.Flags().StringVarP(&cmdApplicationPipelineOptionsCommentPullRequests, "comment-pull-requests", "", "", "Comment the pull requests with the summary of their finished builds")
+	cmdApplicationPipelineOptions.Flags().StringVarP(&cmdApplicationPipelineOptionsTagPatterns, "tag-patterns", "", "", "Comma separated tag patterns (ex: v*) triggering the pipeline instead of branches, none to trigger it on branches")
+	cmdApplicationPipelineOptions.Flags().StringVarP(&cmdApplicationPipelineOptionsVersionFromTag, "version-from-tag", "", "", "Use the semantic version of the tag as cds.version")
 
 	cmdApplicationPipelineSchedulerAdd.Flags().StringSliceVarP(&cmdApplicationAddPipelineParams, "parameter", "p", nil, "Pipeline parameters")
 	cmdApplicationPipelineSchedulerAdd.Flags().StringVarP(&cmdApplicationPipelineSchedulerAddEnv, "environment", "e", "", "Set environment")
+	cmdApplicationPipelineSchedulerAdd.Flags().StringVarP(&cmdApplicationPipelineSchedulerTimezone, "timezone", "", "", "Timezone of the cron expression (default: server time)")
+	cmdApplicationPipelineSchedulerAdd.Flags().DurationVarP(&cmdApplicationPipelineSchedulerJitter, "jitter", "", 0, "Delay each execution by a random duration up to this value, shorter than the interval between two executions")
+	cmdApplicationPipelineSchedulerAdd.Flags().StringVarP(&cmdApplicationPipelineSchedulerSkipIfRunning, "skip-if-running", "", "", "Skip an execution while the previous one is still running")
+	cmdApplicationPipelineSchedulerAdd.Flags().Lookup("skip-if-running").NoOptDefVal = "true"
 
 	cmdApplicationPipelineSchedulerUpdate.Flags().StringVarP(&cmdApplicationPipelineSchedulerAddEnv, "environment", "e", "", "Set environment")
 	cmdApplicationPipelineSchedulerUpdate.Flags().StringSliceVarP(&cmdApplicationAddPipelineParams, "parameter", "p", nil, "Pipeline parameters")
 	cmdApplicationPipelineSchedulerUpdate.Flags().StringVarP(&cmdApplicationPipelineSchedulerUpdateCronExpr, "cron", "c", "", "Set cron expr")
 	cmdApplicationPipelineSchedulerUpdate.Flags().StringVarP(&cmdApplicationPipelineSchedulerUpdateDisable, "disable", "", "", "Disable scheduler")
+	cmdApplicationPipelineSchedulerUpdate.Flags().StringVarP(&cmdApplicationPipelineSchedulerTimezone, "timezone", "", "", "Timezone of the cron expression")
+	cmdApplicationPipelineSchedulerUpdate.Flags().DurationVarP(&cmdApplicationPipelineSchedulerJitter, "jitter", "", 0, "Delay each execution by a random duration up to this value, shorter than the interval between two executions")
+	cmdApplicationPipelineSchedulerUpdate.Flags().StringVarP(&cmdApplicationPipelineSchedulerSkipIfRunning, "skip-if-running", "", "", "Skip an execution while the previous one is still running")
+
+	cmdApplicationPipelineSchedulerNext.Flags().IntVarP(&cmdApplicationPipelineSchedulerNextCount, "count", "n", 10, "Number of executions to preview")
 }
 
 func showPipelineInApplication(cmd *cobra.Command, args []string) {
@@ -0,0 +1,90 @@
+package application
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var (
+	cmdApplicationPipelineOptions = &cobra.Command{
+		Use:   "options",
+		Short: "cds application pipeline options <projectKey> <applicationName> <pipelineName> [--retry-flaky-tests=true|false] [--cancel-superseded=true|false] [--comment-pull-requests=true|false] [--tag-patterns=v*,release-*|none] [--version-from-tag=true|false]",
+		Run:   applicationPipelineOptions,
+	}
+	cmdApplicationPipelineOptionsRetryFlaky          string
+	cmdApplicationPipelineOptionsCancelSuperseded    string
+	cmdApplicationPipelineOptionsCommentPullRequests string
+	cmdApplicationPipelineOptionsTagPatterns         string
+	cmdApplicationPipelineOptionsVersionFromTag      string
+)
+
+func applicationPipelineOptions(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	projectKey := args[0]
+	appName := args[1]
+	pipelineName := args[2]
+
+	opts, err := sdk.GetApplicationPipelineOptions(projectKey, appName, pipelineName)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve options of pipeline %s in application %s (%s)\n", pipelineName, appName, err)
+	}
+
+	if cmdApplicationPipelineOptionsRetryFlaky != "" || cmdApplicationPipelineOptionsCancelSuperseded != "" || cmdApplicationPipelineOptionsCommentPullRequests != "" ||
+		cmdApplicationPipelineOptionsTagPatterns != "" || cmdApplicationPipelineOptionsVersionFromTag != "" {
+		if cmdApplicationPipelineOptionsRetryFlaky != "" {
+			retry, err := strconv.ParseBool(cmdApplicationPipelineOptionsRetryFlaky)
+			if err != nil {
+				sdk.Exit("Error: invalid value for --retry-flaky-tests (%s)\n", err)
+			}
+			opts.RetryFlakyTests = retry
+		}
+		if cmdApplicationPipelineOptionsCancelSuperseded != "" {
+			cancel, err := strconv.ParseBool(cmdApplicationPipelineOptionsCancelSuperseded)
+			if err != nil {
+				sdk.Exit("Error: invalid value for --cancel-superseded (%s)\n", err)
+			}
+			opts.CancelSuperseded = cancel
+		}
+		if cmdApplicationPipelineOptionsCommentPullRequests != "" {
+			comment, err := strconv.ParseBool(cmdApplicationPipelineOptionsCommentPullRequests)
+			if err != nil {
+				sdk.Exit("Error: invalid value for --comment-pull-requests (%s)\n", err)
+			}
+			opts.CommentPullRequests = comment
+		}
+		if cmdApplicationPipelineOptionsTagPatterns != "" {
+			opts.TagPatterns = nil
+			if cmdApplicationPipelineOptionsTagPatterns != "none" {
+				for _, p := range strings.Split(cmdApplicationPipelineOptionsTagPatterns, ",") {
+					if p = strings.TrimSpace(p); p != "" {
+						opts.TagPatterns = append(opts.TagPatterns, p)
+					}
+				}
+			}
+		}
+		if cmdApplicationPipelineOptionsVersionFromTag != "" {
+			fromTag, err := strconv.ParseBool(cmdApplicationPipelineOptionsVersionFromTag)
+			if err != nil {
+				sdk.Exit("Error: invalid value for --version-from-tag (%s)\n", err)
+			}
+			opts.VersionFromTag = fromTag
+		}
+
+		if err := sdk.UpdateApplicationPipelineOptions(projectKey, appName, pipelineName, *opts); err != nil {
+			sdk.Exit("Error: cannot update options of pipeline %s in application %s (%s)\n", pipelineName, appName, err)
+		}
+	}
+
+	fmt.Printf("retry-flaky-tests: %t\n", opts.RetryFlakyTests)
+	fmt.Printf("cancel-superseded: %t\n", opts.CancelSuperseded)
+	fmt.Printf("comment-pull-requests: %t\n", opts.CommentPullRequests)
+	fmt.Printf("tag-patterns: %s\n", strings.Join(opts.TagPatterns, ","))
+	fmt.Printf("version-from-tag: %t\n", opts.VersionFromTag)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -26,15 +27,19 @@ var (
 
 	cmdApplicationPipelineSchedulerAdd = &cobra.Command{
 		Use:   "add",
-		Short: "cds application pipeline scheduler add <projectKey> <applicationName> <pipelineName> <cron expression> [-e environment] [-p <pipelineParam>=<value>]",
+		Short: "cds application pipeline scheduler add <projectKey> <applicationName> <pipelineName> <cron expression> [-e environment] [-p <pipelineParam>=<value>] [--timezone Europe/Paris] [--jitter 5m] [--skip-if-running]",
 		Run:   applicationPipelineSchedulerAdd,
 	}
 
 	cmdApplicationPipelineSchedulerAddEnv string
 
+	cmdApplicationPipelineSchedulerTimezone      string
+	cmdApplicationPipelineSchedulerJitter        time.Duration
+	cmdApplicationPipelineSchedulerSkipIfRunning string
+
 	cmdApplicationPipelineSchedulerUpdate = &cobra.Command{
 		Use:   "update",
-		Short: "cds application pipeline scheduler update <projectKey> <applicationName> <pipelineName> <ID> [-c <cron expression>] [-e environment] [-p <pipelineParam>=<value>] [--disable true|false]",
+		Short: "cds application pipeline scheduler update <projectKey> <applicationName> <pipelineName> <ID> [-c <cron expression>] [-e environment] [-p <pipelineParam>=<value>] [--disable true|false] [--timezone Europe/Paris] [--jitter 5m] [--skip-if-running true|false]",
 		Run:   applicationPipelineSchedulerUpdate,
 	}
 
@@ -45,6 +50,15 @@ var (
 		Short: "cds application pipeline scheduler delete <projectKey> <applicationName> <pipelineName> <ID>",
 		Run:   applicationPipelineSchedulerDelete,
 	}
+
+	cmdApplicationPipelineSchedulerNext = &cobra.Command{
+		Use:   "next",
+		Short: "cds application pipeline scheduler next <projectKey> <applicationName> <pipelineName> [-n 10]",
+		Long:  "Preview the next planned executions of the pipeline schedulers. A jitter delays each execution by a random duration up to its value.",
+		Run:   applicationPipelineSchedulerNext,
+	}
+
+	cmdApplicationPipelineSchedulerNextCount int
 )
 
 func applicationPipelineSchedulerList(cmd *cobra.Command, args []string) {
@@ -61,7 +75,7 @@ func applicationPipelineSchedulerList(cmd *cobra.Command, args []string) {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Frequency", "Parameters", "Environment", "Enabled", "Last Execution", "Next Execution"})
+	table.SetHeader([]string{"ID", "Frequency", "Timezone", "Jitter", "Skip If Running", "Parameters", "Environment", "Enabled", "Last Execution", "Next Execution"})
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 
@@ -76,6 +90,14 @@ func applicationPipelineSchedulerList(cmd *cobra.Command, args []string) {
 
 		if ps[i].LastExecution != nil {
 			last = fmt.Sprintf("%v", ps[i].LastExecution.ExecutionDate)
+			if ps[i].LastExecution.Skipped {
+				last += " (skipped)"
+			}
+		}
+
+		tz := ps[i].Timezone
+		if tz == "" {
+			tz = "server"
 		}
 
 		if ps[i].NextExecution != nil {
@@ -84,7 +106,11 @@ func applicationPipelineSchedulerList(cmd *cobra.Command, args []string) {
 
 		table.Append([]string{
 			fmt.Sprintf("%d", ps[i].ID),
-			ps[i].Crontab, string(args),
+			ps[i].Crontab,
+			tz,
+			(time.Duration(ps[i].Jitter) * time.Second).String(),
+			fmt.Sprintf("%v", ps[i].SkipIfRunning),
+			string(args),
 			ps[i].EnvironmentName,
 			fmt.Sprintf("%v", !ps[i].Disabled),
 			last,
@@ -113,7 +139,15 @@ func applicationPipelineSchedulerAdd(cmd *cobra.Command, args []string) {
 		params = append(params, p)
 	}
 
-	if _, err := sdk.AddPipelineScheduler(projectKey, appName, pipelineName, cronExpr, cmdApplicationPipelineSchedulerAddEnv, params); err != nil {
+	s := &sdk.PipelineScheduler{
+		Crontab:       cronExpr,
+		Args:          params,
+		Timezone:      cmdApplicationPipelineSchedulerTimezone,
+		Jitter:        int64(cmdApplicationPipelineSchedulerJitter.Seconds()),
+		SkipIfRunning: cmdApplicationPipelineSchedulerSkipIfRunning == "true",
+	}
+
+	if _, err := sdk.CreatePipelineScheduler(projectKey, appName, pipelineName, cmdApplicationPipelineSchedulerAddEnv, s); err != nil {
 		sdk.Exit("Error: cannot add pipeline scheduler : %s\n", err)
 	}
 
@@ -177,6 +211,20 @@ func applicationPipelineSchedulerUpdate(cmd *cobra.Command, args []string) {
 		s.Args = params
 	}
 
+	if cmdApplicationPipelineSchedulerTimezone != "" {
+		s.Timezone = cmdApplicationPipelineSchedulerTimezone
+	}
+
+	if cmd.Flags().Changed("jitter") {
+		s.Jitter = int64(cmdApplicationPipelineSchedulerJitter.Seconds())
+	}
+
+	if cmdApplicationPipelineSchedulerSkipIfRunning == "true" {
+		s.SkipIfRunning = true
+	} else if cmdApplicationPipelineSchedulerSkipIfRunning == "false" {
+		s.SkipIfRunning = false
+	}
+
 	if cmdApplicationPipelineSchedulerAddEnv != "" {
 		s.EnvironmentName = cmdApplicationPipelineSchedulerAddEnv
 	}
@@ -226,3 +274,42 @@ func applicationPipelineSchedulerDelete(cmd *cobra.Command, args []string) {
 
 	fmt.Println("OK")
 }
+
+func applicationPipelineSchedulerNext(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	projectKey := args[0]
+	appName := args[1]
+	pipelineName := args[2]
+
+	planned, err := sdk.GetPipelineSchedulerNextExecutions(projectKey, appName, pipelineName, cmdApplicationPipelineSchedulerNextCount)
+	if err != nil {
+		sdk.Exit("Error: cannot compute next executions: (%s)\n", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Date", "ID", "Frequency", "Timezone", "Jitter", "Environment"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, p := range planned {
+		tz := p.Timezone
+		if tz == "" {
+			tz = "server"
+		}
+		jitter := ""
+		if p.Jitter > 0 {
+			jitter = "+ up to " + (time.Duration(p.Jitter) * time.Second).String()
+		}
+		table.Append([]string{
+			p.Date.Format(time.RFC1123Z),
+			fmt.Sprintf("%d", p.PipelineSchedulerID),
+			p.Crontab,
+			tz,
+			jitter,
+			p.EnvironmentName,
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,65 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var releaseNotesQuery sdk.ReleaseNotesQuery
+var releaseNotesFormat string
+
+func applicationReleaseNotesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "releasenotes",
+		Short: "cds application releasenotes <projectKey> <applicationName> [--pipeline <pipelineName> --from-build <n> --to-build <n> [--env <envName>] | --from-env <envName> --to-env <envName>]",
+		Long: `Generate release notes from the commits between two builds or two environments.
+Commits are grouped by conventional commit type (feat, fix...) or by issue key:
+	cds application releasenotes PRJ myapp --pipeline build --from-build 12 --to-build 15
+	cds application releasenotes PRJ myapp --from-env production --to-env preprod --group-by issue`,
+		Run: applicationReleaseNotes,
+	}
+
+	cmd.Flags().StringVarP(&releaseNotesQuery.Pipeline, "pipeline", "", "", "Pipeline of the builds")
+	cmd.Flags().StringVarP(&releaseNotesQuery.Env, "env", "", "", "Environment of the builds")
+	cmd.Flags().IntVarP(&releaseNotesQuery.FromBuild, "from-build", "", 0, "Build number to start from")
+	cmd.Flags().IntVarP(&releaseNotesQuery.ToBuild, "to-build", "", 0, "Build number to end at")
+	cmd.Flags().StringVarP(&releaseNotesQuery.FromEnv, "from-env", "", "", "Environment to start from")
+	cmd.Flags().StringVarP(&releaseNotesQuery.ToEnv, "to-env", "", "", "Environment to end at")
+	cmd.Flags().StringVarP(&releaseNotesQuery.GroupBy, "group-by", "", sdk.ReleaseNotesByType, "Group commits by type or issue")
+	cmd.Flags().StringVarP(&releaseNotesQuery.IssuePattern, "issue-pattern", "", "", "Regular expression of issue keys")
+	cmd.Flags().StringVarP(&releaseNotesFormat, "format", "", "markdown", "Output format: markdown or json")
+	return cmd
+}
+
+func applicationReleaseNotes(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	q := releaseNotesQuery
+	if (q.FromEnv == "") != (q.ToEnv == "") || (q.FromEnv == "" && (q.Pipeline == "" || q.FromBuild == 0 || q.ToBuild == 0)) {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+
+	rn, err := sdk.GetReleaseNotes(args[0], args[1], q)
+	if err != nil {
+		sdk.Exit("Error: cannot generate release notes (%s)\n", err)
+	}
+	printReleaseNotes(rn, releaseNotesFormat)
+}
+
+func printReleaseNotes(rn *sdk.ReleaseNotes, format string) {
+	if format == "json" {
+		rn.Markdown = ""
+		data, err := json.MarshalIndent(rn, "", "  ")
+		if err != nil {
+			sdk.Exit("Error: %s\n", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Print(rn.Markdown)
+}
@@ -19,8 +19,13 @@ func Cmd() *cobra.Command {
 	cmd.AddCommand(environmentListCmd())
 	cmd.AddCommand(environmentShowCmd())
 	cmd.AddCommand(environmentCloneCmd())
+	cmd.AddCommand(environmentLockCmd())
+	cmd.AddCommand(environmentUnlockCmd())
+	cmd.AddCommand(environmentStatusCmd())
+	cmd.AddCommand(environmentInventoryCmd())
 	cmd.AddCommand(environmentVariableCmd)
 	cmd.AddCommand(environmentGroupCmd)
+	cmd.AddCommand(environmentFreezeCmd)
 
 	return cmd
 }
@@ -0,0 +1,139 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var environmentFreezeCmd = &cobra.Command{
+	Use:   "freeze",
+	Short: "cds environment freeze",
+	Long:  `Manage deployment freeze windows of an environment`,
+}
+
+var freezeWindow sdk.FreezeWindow
+var freezeOverrideDuration string
+
+func init() {
+	environmentFreezeCmd.AddCommand(cmdEnvironmentListFreeze())
+	environmentFreezeCmd.AddCommand(cmdEnvironmentAddFreeze())
+	environmentFreezeCmd.AddCommand(cmdEnvironmentRemoveFreeze())
+	environmentFreezeCmd.AddCommand(cmdEnvironmentOverrideFreeze())
+}
+
+func cmdEnvironmentListFreeze() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "cds environment freeze list <projectKey> <environmentName>",
+		Run:   listFreezeWindows,
+	}
+	return cmd
+}
+
+func cmdEnvironmentAddFreeze() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "cds environment freeze add <projectKey> <environmentName> <reason> [--cron <expr> --duration <duration> | --start <date> --end <date>] [--timezone <tz>]",
+		Long: `Add a freeze window on an environment.
+A recurring window starts on each occurrence of a cron expression and lasts the given duration:
+	cds environment freeze add PRJ production "Weekend" --cron "0 18 * * 5" --duration 62h --timezone Europe/Paris
+An absolute window is defined by its start and end dates (format "2006-01-02 15:04"):
+	cds environment freeze add PRJ production "Holidays" --start "2017-12-22 18:00" --end "2018-01-02 08:00"`,
+		Run: addFreezeWindow,
+	}
+
+	cmd.Flags().StringVarP(&freezeWindow.Cron, "cron", "", "", "Cron expression of the window start")
+	cmd.Flags().StringVarP(&freezeWindow.Duration, "duration", "", "", "Duration of a recurring window")
+	cmd.Flags().StringVarP(&freezeWindow.Start, "start", "", "", "Start date of an absolute window")
+	cmd.Flags().StringVarP(&freezeWindow.End, "end", "", "", "End date of an absolute window")
+	cmd.Flags().StringVarP(&freezeWindow.Timezone, "timezone", "", "UTC", "Timezone of the window")
+	return cmd
+}
+
+func cmdEnvironmentRemoveFreeze() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove",
+		Short:   "cds environment freeze remove <projectKey> <environmentName> <windowID>",
+		Aliases: []string{"delete", "rm", "del"},
+		Run:     removeFreezeWindow,
+	}
+	return cmd
+}
+
+func cmdEnvironmentOverrideFreeze() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "override",
+		Short: "cds environment freeze override <projectKey> <environmentName> <reason> [--duration <duration>]",
+		Long:  `Grant a temporary override token to deploy on a frozen environment (admin only). Use it with 'cds pipeline run --freeze-override <token>'`,
+		Run:   overrideFreezeWindow,
+	}
+
+	cmd.Flags().StringVarP(&freezeOverrideDuration, "duration", "", "1h", "Validity of the override")
+	return cmd
+}
+
+func listFreezeWindows(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	windows, err := sdk.GetFreezeWindows(args[0], args[1])
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve freeze windows (%s)\n", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Reason", "Cron", "Duration", "Start", "End", "Timezone"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	for _, w := range windows {
+		table.Append([]string{fmt.Sprintf("%d", w.ID), w.Reason, w.Cron, w.Duration, w.Start, w.End, w.Timezone})
+	}
+	table.Render()
+}
+
+func addFreezeWindow(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	freezeWindow.Reason = args[2]
+	if err := sdk.AddFreezeWindow(args[0], args[1], freezeWindow); err != nil {
+		sdk.Exit("Error: cannot add freeze window (%s)\n", err)
+	}
+	fmt.Printf("Freeze window added on %s.\n", args[1])
+}
+
+func removeFreezeWindow(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	id, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: %s is not a valid window ID\n", args[2])
+	}
+
+	if err := sdk.DeleteFreezeWindow(args[0], args[1], id); err != nil {
+		sdk.Exit("Error: cannot remove freeze window (%s)\n", err)
+	}
+	fmt.Printf("Freeze window %d removed.\n", id)
+}
+
+func overrideFreezeWindow(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	o, err := sdk.GrantFreezeOverride(args[0], args[1], args[2], freezeOverrideDuration)
+	if err != nil {
+		sdk.Exit("Error: cannot grant override (%s)\n", err)
+	}
+	fmt.Printf("Override token (valid until %s): %s\n", o.Expires.Format("2006-01-02 15:04:05"), o.Token)
+}
@@ -0,0 +1,129 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var inventoryFrom, inventoryTo, inventoryApplication, inventoryPipeline string
+
+func environmentInventoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "cds environment inventory <projectKey> [--from <env> --to <env> [--application <appName> [--pipeline <pipelineName>]]]",
+		Long: `Show what is deployed where.
+Without flags, list the versions of applications currently deployed on each environment:
+	cds environment inventory PRJ
+Show drift between two environments:
+	cds environment inventory PRJ --from preprod --to production
+Show commits deployed on an environment but not yet on another one for an application:
+	cds environment inventory PRJ --from preprod --to production --application myapp`,
+		Run: inventoryEnvironment,
+	}
+
+	cmd.Flags().StringVarP(&inventoryFrom, "from", "", "", "Environment to compare")
+	cmd.Flags().StringVarP(&inventoryTo, "to", "", "", "Environment to compare with")
+	cmd.Flags().StringVarP(&inventoryApplication, "application", "", "", "Show commits between environments for this application")
+	cmd.Flags().StringVarP(&inventoryPipeline, "pipeline", "", "", "Deployment pipeline of the application")
+	return cmd
+}
+
+func inventoryEnvironment(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+	if (inventoryFrom == "") != (inventoryTo == "") || (inventoryApplication != "" && inventoryFrom == "") {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	switch {
+	case inventoryApplication != "":
+		inventoryDiff(args[0])
+	case inventoryFrom != "":
+		inventoryDrift(args[0])
+	default:
+		inventoryList(args[0])
+	}
+}
+
+func newInventoryTable(header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	return table
+}
+
+func shortHash(h string) string {
+	if len(h) > 8 {
+		return h[:8]
+	}
+	return h
+}
+
+func inventoryList(projectKey string) {
+	inventory, err := sdk.GetInventory(projectKey)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve inventory (%s)\n", err)
+	}
+
+	table := newInventoryTable([]string{"Environment", "Application", "Pipeline", "Build", "Version", "Branch", "Hash", "Deployer", "Date"})
+	for _, inv := range inventory {
+		for _, d := range inv.Deployments {
+			table.Append([]string{d.Environment, d.Application, d.Pipeline, fmt.Sprintf("#%d", d.BuildNumber), fmt.Sprintf("%d", d.Version),
+				d.Branch, shortHash(d.Hash), d.Deployer, d.Done.Format("2006-01-02 15:04:05")})
+		}
+	}
+	table.Render()
+}
+
+func deploymentString(d *sdk.Deployment) string {
+	if d == nil {
+		return "-"
+	}
+	return fmt.Sprintf("v%d %s (#%d, %s)", d.Version, shortHash(d.Hash), d.BuildNumber, d.Done.Format("2006-01-02"))
+}
+
+func inventoryDrift(projectKey string) {
+	drifts, err := sdk.GetInventoryDrift(projectKey, inventoryFrom, inventoryTo)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve drift (%s)\n", err)
+	}
+
+	table := newInventoryTable([]string{"Application", "Pipeline", inventoryFrom, inventoryTo, "Drift"})
+	for _, d := range drifts {
+		drift := ""
+		if d.Drift {
+			drift = "yes"
+		}
+		table.Append([]string{d.Application, d.Pipeline, deploymentString(d.From), deploymentString(d.To), drift})
+	}
+	table.Render()
+}
+
+func inventoryDiff(projectKey string) {
+	commits, err := sdk.GetInventoryDiff(projectKey, inventoryApplication, inventoryPipeline, inventoryFrom, inventoryTo)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve commits (%s)\n", err)
+	}
+
+	if len(commits) == 0 {
+		fmt.Printf("%s runs the same commit on %s and %s.\n", inventoryApplication, inventoryFrom, inventoryTo)
+		return
+	}
+
+	table := newInventoryTable([]string{"Hash", "Author", "Date", "Message"})
+	for _, c := range commits {
+		message := strings.SplitN(c.Message, "\n", 2)[0]
+		date := time.Unix(c.Timestamp/1000, 0).Format("2006-01-02 15:04")
+		table.Append([]string{shortHash(c.Hash), c.Author.Name, date, message})
+	}
+	table.Render()
+}
@@ -0,0 +1,94 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func environmentLockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "cds environment lock <projectKey> <environmentName> <reason>",
+		Long:  `Freeze deployments on an environment (admin only)`,
+		Run:   lockEnvironment,
+	}
+	return cmd
+}
+
+func environmentUnlockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "cds environment unlock <projectKey> <environmentName>",
+		Long:  `Remove the manual lock of an environment (admin only)`,
+		Run:   unlockEnvironment,
+	}
+	return cmd
+}
+
+func environmentStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "cds environment status <projectKey> <environmentName>",
+		Long:  `Show lock, running and queued deployments of an environment`,
+		Run:   statusEnvironment,
+	}
+	return cmd
+}
+
+func lockEnvironment(cmd *cobra.Command, args []string) {
+	if len(args) < 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	reason := strings.Join(args[2:], " ")
+	if err := sdk.LockEnvironment(args[0], args[1], reason); err != nil {
+		sdk.Exit("Error: cannot lock environment %s (%s)\n", args[1], err)
+	}
+	fmt.Printf("Environment %s locked.\n", args[1])
+}
+
+func unlockEnvironment(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	if err := sdk.UnlockEnvironment(args[0], args[1]); err != nil {
+		sdk.Exit("Error: cannot unlock environment %s (%s)\n", args[1], err)
+	}
+	fmt.Printf("Environment %s unlocked.\n", args[1])
+}
+
+func statusEnvironment(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	status, err := sdk.GetEnvironmentStatus(args[0], args[1])
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve environment status (%s)\n", err)
+	}
+
+	if status.Lock != nil {
+		fmt.Printf("Locked by %s since %s: %s\n\n", status.Lock.Username, status.Lock.Locked.Format("2006-01-02 15:04:05"), status.Lock.Reason)
+	} else {
+		fmt.Printf("Not locked\n\n")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Application", "Pipeline", "Build", "Version", "Status", "Start"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, list := range [][]sdk.PipelineBuild{status.Running, status.Queued} {
+		for _, pb := range list {
+			table.Append([]string{pb.Application.Name, pb.Pipeline.Name, fmt.Sprintf("%d", pb.BuildNumber), fmt.Sprintf("%d", pb.Version), string(pb.Status), pb.Start.Format("2006-01-02 15:04:05")})
+		}
+	}
+	table.Render()
+}
@@ -12,6 +12,7 @@ func init() {
 	Cmd.AddCommand(cmdGroupList)
 	Cmd.AddCommand(cmdGroupSetAdmin())
 	Cmd.AddCommand(cmdGroupUnsetAdmin())
+	Cmd.AddCommand(cmdGroupSync())
 }
 
 // Cmd group
@@ -0,0 +1,93 @@
+package group
+
+import (
+	"fmt"
+
+	"github.com/ovh/cds/sdk"
+
+	"github.com/spf13/cobra"
+)
+
+var syncDryRun bool
+
+func cmdGroupSync() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "cds group sync [--dry-run]",
+		Long:  `Sync CDS groups with LDAP groups now. With --dry-run, only show the changes the sync would make.`,
+		Run:   syncGroups,
+	}
+
+	cmd.Flags().BoolVarP(&syncDryRun, "dry-run", "", false, "Only show the changes")
+	cmd.AddCommand(cmdGroupSyncLog())
+	return cmd
+}
+
+func syncGroups(cmd *cobra.Command, args []string) {
+	l, err := sdk.SyncGroups(syncDryRun)
+	if err != nil {
+		sdk.Exit("Error: Cannot sync groups (%s)\n", err)
+	}
+
+	if len(l.Changes) == 0 {
+		fmt.Printf("Groups are up to date\n")
+		return
+	}
+	for _, c := range l.Changes {
+		fmt.Printf("%s\n", syncChange(c))
+	}
+	if l.DryRun {
+		fmt.Printf("Dry run: nothing changed\n")
+	}
+}
+
+func cmdGroupSyncLog() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "cds group sync log",
+		Long:  `Show the last group syncs: periodic, at login, or run by an administrator.`,
+		Run:   syncLog,
+	}
+
+	return cmd
+}
+
+func syncLog(cmd *cobra.Command, args []string) {
+	logs, err := sdk.GetGroupSyncLogs()
+	if err != nil {
+		sdk.Exit("Error: Cannot load group sync logs (%s)\n", err)
+	}
+
+	for _, l := range logs {
+		result := fmt.Sprintf("%d changes", len(l.Changes))
+		if l.DryRun {
+			result += " (dry run)"
+		}
+		if l.Error != "" {
+			result = "error: " + l.Error
+		}
+		fmt.Printf("%s %s %s: %s\n", l.Started.Format("2006-01-02 15:04:05"), l.Origin, l.Trigger, result)
+		for _, c := range l.Changes {
+			fmt.Printf("  %s\n", syncChange(c))
+		}
+	}
+}
+
+func syncChange(c sdk.GroupSyncChange) string {
+	switch c.Action {
+	case sdk.GroupSyncCreateGroup:
+		return fmt.Sprintf("create group %s", c.Group)
+	case sdk.GroupSyncAddMember:
+		if c.Admin {
+			return fmt.Sprintf("add %s in %s as admin", c.Username, c.Group)
+		}
+		return fmt.Sprintf("add %s in %s", c.Username, c.Group)
+	case sdk.GroupSyncRemoveMember:
+		return fmt.Sprintf("remove %s from %s", c.Username, c.Group)
+	case sdk.GroupSyncSetAdmin:
+		return fmt.Sprintf("set %s admin of %s", c.Username, c.Group)
+	case sdk.GroupSyncUnsetAdmin:
+		return fmt.Sprintf("unset %s admin of %s", c.Username, c.Group)
+	}
+	return fmt.Sprintf("%s %s %s", c.Action, c.Username, c.Group)
+}
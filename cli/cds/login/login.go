@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/howeyc/gopass"
 	"github.com/spf13/cobra"
@@ -18,11 +19,13 @@ import (
 var (
 	defaultEndPoint string
 	defaultUser     string
+	withOIDC        bool
 )
 
 func init() {
 	Cmd.Flags().StringVarP(&defaultEndPoint, "host", "", "", "CDS API URL")
 	Cmd.Flags().StringVarP(&defaultUser, "user", "", "", "CDS User")
+	Cmd.Flags().BoolVarP(&withOIDC, "oidc", "", false, "Login with the OpenID Connect provider of CDS")
 }
 
 // Cmd login
@@ -64,6 +67,11 @@ func runLogin() {
 		conf.Host = defaultEndPoint
 	}
 
+	if withOIDC {
+		runOIDCLogin(conf)
+		return
+	}
+
 	//Take the user from flags or ask for on command line
 	if defaultUser == "" {
 		fmt.Printf("Username: ")
@@ -80,11 +88,6 @@ func runLogin() {
 		sdk.Exit("Error: wrong usage (%s)\n", err)
 	}
 
-	//Create the config directory
-	if err := os.Mkdir(filepath.Dir(sdk.CDSConfigFile), 0700); err != nil && !os.IsExist(err) {
-		sdk.Exit("Error: Cannot create config folder (%s)\n", err)
-	}
-
 	//Configure sdk
 	sdk.Options(conf.Host, "", "", "")
 
@@ -103,6 +106,51 @@ func runLogin() {
 		conf.Password = string(password)
 	}
 
+	writeConfig(conf)
+}
+
+//runOIDCLogin logs in with the device flow: the user approves the login on the provider, from any browser
+func runOIDCLogin(conf config) {
+	sdk.Options(conf.Host, "", "", "")
+
+	a, err := sdk.OIDCDeviceAuthorize()
+	if err != nil {
+		sdk.Exit("Error: Cannot start login (%s)\n", err)
+	}
+
+	if a.VerificationURIComplete != "" {
+		fmt.Printf("Open %s to approve the login (code %s)\n", a.VerificationURIComplete, a.UserCode)
+	} else {
+		fmt.Printf("Open %s and enter the code %s\n", a.VerificationURI, a.UserCode)
+	}
+
+	deadline := time.Now().Add(time.Duration(a.ExpiresIn) * time.Second)
+	for {
+		time.Sleep(time.Duration(a.Interval) * time.Second)
+		res, err := sdk.OIDCDeviceLogin(a.DeviceCode)
+		if err != nil {
+			sdk.Exit("Error: Login failed (%s)\n", err)
+		}
+		if res != nil {
+			conf.User = res.User.Username
+			conf.Token = res.Token
+			break
+		}
+		if a.ExpiresIn > 0 && time.Now().After(deadline) {
+			sdk.Exit("Error: Login not approved in time\n")
+		}
+	}
+
+	fmt.Printf("Logged in as %s\n", conf.User)
+	writeConfig(conf)
+}
+
+func writeConfig(conf config) {
+	//Create the config directory
+	if err := os.Mkdir(filepath.Dir(sdk.CDSConfigFile), 0700); err != nil && !os.IsExist(err) {
+		sdk.Exit("Error: Cannot create config folder (%s)\n", err)
+	}
+
 	//Write conf in file
 	data, err := json.MarshalIndent(conf, " ", " ")
 	if err != nil {
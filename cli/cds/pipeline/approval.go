@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var approvalComment string
+
+func pipelineApproveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve",
+		Short: "cds pipeline approve <projectKey> <appName> <pipelineName> [envName] <buildNumber> [--comment <comment>]",
+		Long:  `Approve the pending approval gate of a pipeline build`,
+		Run: func(cmd *cobra.Command, args []string) {
+			decideApproval(cmd, args, sdk.ApproveBuild)
+		},
+	}
+
+	cmd.Flags().StringVarP(&approvalComment, "comment", "", "", "Comment recorded with the decision")
+	return cmd
+}
+
+func pipelineRejectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reject",
+		Short: "cds pipeline reject <projectKey> <appName> <pipelineName> [envName] <buildNumber> [--comment <comment>]",
+		Long:  `Reject the pending approval gate of a pipeline build`,
+		Run: func(cmd *cobra.Command, args []string) {
+			decideApproval(cmd, args, sdk.RejectBuild)
+		},
+	}
+
+	cmd.Flags().StringVarP(&approvalComment, "comment", "", "", "Comment recorded with the decision")
+	return cmd
+}
+
+func pipelineApprovalsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "cds pipeline approvals <projectKey> <appName> <pipelineName> [envName] <buildNumber>",
+		Long:  `List approval requests and decisions of a pipeline build`,
+		Run:   listApprovals,
+	}
+	return cmd
+}
+
+func approvalArgs(cmd *cobra.Command, args []string) (string, string, string, string, int) {
+	if len(args) < 4 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	var env, bnS string
+	if len(args) > 4 {
+		env = args[3]
+		bnS = args[4]
+	} else {
+		bnS = args[3]
+	}
+
+	bn, err := strconv.Atoi(bnS)
+	if err != nil {
+		sdk.Exit("%s is not a valid build number (%s)\n", bnS, err)
+	}
+	return args[0], args[1], args[2], env, bn
+}
+
+func decideApproval(cmd *cobra.Command, args []string, decide func(string, string, string, string, int, string) error) {
+	pk, app, name, env, bn := approvalArgs(cmd, args)
+
+	if err := decide(pk, app, name, env, bn, approvalComment); err != nil {
+		sdk.Exit("Error: %s\n", err)
+	}
+	fmt.Printf("OK\n")
+}
+
+func listApprovals(cmd *cobra.Command, args []string) {
+	pk, app, name, env, bn := approvalArgs(cmd, args)
+
+	approvals, err := sdk.GetBuildApprovals(pk, app, name, env, bn)
+	if err != nil {
+		sdk.Exit("Error: %s\n", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Target", "Groups", "Status", "Requested", "User", "Decided", "Comment"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, a := range approvals {
+		target := fmt.Sprintf("stage %s", a.StageName)
+		if a.TriggerID != 0 {
+			target = "triggers"
+		}
+		decided := ""
+		if a.Decided != nil {
+			decided = a.Decided.Format("2006-01-02 15:04:05")
+		}
+		table.Append([]string{target, fmt.Sprintf("%v", a.Groups), a.Status, a.Requested.Format("2006-01-02 15:04:05"), a.Username, decided, a.Comment})
+	}
+	table.Render()
+}
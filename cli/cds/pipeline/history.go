@@ -41,17 +41,42 @@ func historyPipeline(cmd *cobra.Command, args []string) {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 27, 1, 2, ' ', 0)
-	titles := []string{"BUILD", "VERSION", "STATUS", "BRANCH"}
+	titles := []string{"BUILD", "VERSION", "STATUS", "BRANCH", "COVERAGE", "DEPLOYMENT"}
 	fmt.Fprintln(w, strings.Join(titles, "\t"))
 
 	for _, b := range builds {
-		fmt.Fprintf(w, "#%d\t%d\t%s\t%s\n",
+		fmt.Fprintf(w, "#%d\t%d\t%s\t%s\t%s\t%s\n",
 			b.BuildNumber,
 			b.Version,
 			b.Status,
 			b.Trigger.VCSChangesBranch,
+			coverageString(b.Coverage),
+			verificationString(b.Verifications),
 		)
 
 		w.Flush()
 	}
 }
+
+// coverageString displays line coverage and its delta with previous build on the branch
+func coverageString(c *sdk.Coverage) string {
+	if c == nil {
+		return "-"
+	}
+	if c.Delta == nil {
+		return fmt.Sprintf("%.2f%%", c.Percent())
+	}
+	return fmt.Sprintf("%.2f%% (%+.2f)", c.Percent(), *c.Delta)
+}
+
+// verificationString displays the outcome of the last progressive deployment verification of a build
+func verificationString(verifications []sdk.DeploymentVerification) string {
+	if len(verifications) == 0 {
+		return "-"
+	}
+	v := verifications[len(verifications)-1]
+	if v.RollbackBuildNumber != 0 {
+		return fmt.Sprintf("%s %s (rollback #%d)", v.Strategy, v.Status, v.RollbackBuildNumber)
+	}
+	return fmt.Sprintf("%s %s", v.Strategy, v.Status)
+}
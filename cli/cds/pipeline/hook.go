@@ -16,6 +16,10 @@ func init() {
 	pipelineHookCmd.AddCommand(pipelineAddHookCmd())
 	pipelineHookCmd.AddCommand(pipelineDeleteHookCmd())
 	pipelineHookCmd.AddCommand(pipelineListHookCmd())
+	pipelineHookCmd.AddCommand(pipelinePathsHookCmd())
+	pipelineHookCmd.AddCommand(pipelineDeliveriesHookCmd())
+	pipelineHookCmd.AddCommand(pipelineReplayHookCmd())
+	pipelineHookCmd.AddCommand(pipelineSecretHookCmd())
 }
 
 var pipelineHookCmd = &cobra.Command{
@@ -49,7 +53,7 @@ func pipelineDeleteHookCmd() *cobra.Command {
 	return cmd
 }
 
-var showURLOnly bool
+var showURLOnly, showWebhooks bool
 
 func pipelineListHookCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -60,6 +64,24 @@ func pipelineListHookCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&showURLOnly, "show-url-only", "", false, "Shows only URL")
+	cmd.Flags().BoolVarP(&showWebhooks, "webhooks", "", false, "Shows the signed GitHub, Bitbucket and GitLab webhooks URL")
+
+	return cmd
+}
+
+var hookInclude, hookExclude []string
+
+func pipelinePathsHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "paths",
+		Short: "cds pipeline hook paths <projectKey> <applicationName> <pipelineName> <idHook> [--include <glob>] [--exclude <glob>]",
+		Long: `Only trigger the pipeline when files matching the include globs, and not the exclude globs, changed since the last build on the branch.
+Globs are relative to the root of the repository, ** matches any number of directories. Without any glob, every push triggers the pipeline.`,
+		Run: pathsPipelineHook,
+	}
+
+	cmd.Flags().StringSliceVarP(&hookInclude, "include", "", nil, "Build only when files matching these globs changed (ex: services/api/**)")
+	cmd.Flags().StringSliceVarP(&hookExclude, "exclude", "", nil, "Ignore the changes of files matching these globs (ex: **/*.md)")
 
 	return cmd
 }
@@ -103,6 +125,7 @@ func addPipelineHook(cmd *cobra.Command, args []string) {
 		if err != nil {
 			sdk.Exit("✘ Error: Cannot add hook to pipeline %s-%s-%s (%s)\n", pipelineProject, appName, pipelineName, err)
 		}
+		printHookWebhooks(h)
 		if strings.Contains(t[0], "stash") {
 			fmt.Printf(`Hook created on CDS.
 	You now need to configure hook on stash. Use "Http Request Post Receive Hook" to create:
@@ -113,6 +136,44 @@ func addPipelineHook(cmd *cobra.Command, args []string) {
 	}
 }
 
+func pipelineSecretHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "cds pipeline hook secret <projectKey> <applicationName> <pipelineName> <idHook>",
+		Long: `Generate a new secret signing the GitHub, Bitbucket and GitLab webhooks of the hook. Secrets are only shown once:
+the webhooks configured with the previous secret are rejected until they use the new one.`,
+		Run: secretPipelineHook,
+	}
+
+	return cmd
+}
+
+func secretPipelineHook(cmd *cobra.Command, args []string) {
+	if len(args) != 4 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	hookID, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		sdk.Exit("✘ Error: Invalid hook id %s\n", args[3])
+	}
+
+	h, err := sdk.RegenerateHookSecret(args[0], args[1], args[2], hookID)
+	if err != nil {
+		sdk.Exit("✘ Error: Cannot generate a new secret for hook %d (%s)\n", hookID, err)
+	}
+	printHookWebhooks(h)
+}
+
+//printHookWebhooks shows the signed webhooks URL of a hook with their secret, only known on creation
+func printHookWebhooks(h *sdk.Hook) {
+	fmt.Printf("Signed webhooks of hook %d, keep the secret, it won't be shown again:\n", h.ID)
+	fmt.Printf("  GitHub (application/json):  %s\n", h.Webhooks["github"])
+	fmt.Printf("  Bitbucket:                  %s\n", h.Webhooks["bitbucket"])
+	fmt.Printf("  GitLab (secret token):      %s\n", h.Webhooks["gitlab"])
+	fmt.Printf("  Secret:                     %s\n", h.Secret)
+}
+
 func deletePipelineHook(cmd *cobra.Command, args []string) {
 
 	if len(args) < 3 {
@@ -169,6 +230,39 @@ func deletePipelineHook(cmd *cobra.Command, args []string) {
 	}
 }
 
+func pathsPipelineHook(cmd *cobra.Command, args []string) {
+	if len(args) != 4 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	pipelineProject := args[0]
+	appName := args[1]
+	pipelineName := args[2]
+	hookID, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		sdk.Exit("Hook id must be a number (%s)\n", err)
+	}
+
+	hooks, err := sdk.GetHooks(pipelineProject, appName, pipelineName)
+	if err != nil {
+		sdk.Exit("Cannot retrieve hooks from %s/%s/%s (%s)\n", pipelineProject, appName, pipelineName, err)
+	}
+
+	for _, h := range hooks {
+		if h.ID != hookID {
+			continue
+		}
+		h.Paths = sdk.PathFilter{Include: hookInclude, Exclude: hookExclude}
+		if err := sdk.UpdateHook(pipelineProject, appName, pipelineName, h); err != nil {
+			sdk.Exit("Cannot update hook %d on %s/%s/%s (%s)\n", hookID, pipelineProject, appName, pipelineName, err)
+		}
+		fmt.Println("✔ Success")
+		return
+	}
+
+	sdk.Exit("Hook %d not found on %s/%s/%s\n", hookID, pipelineProject, appName, pipelineName)
+}
+
 func listPipelineHook(cmd *cobra.Command, args []string) {
 
 	if len(args) != 3 {
@@ -191,8 +285,19 @@ func listPipelineHook(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if showWebhooks {
+		for _, h := range hooks {
+			fmt.Printf("Hook %d on %s/%s/%s\n", h.ID, h.Host, h.Project, h.Repository)
+			fmt.Printf("  GitHub (application/json):  %s\n", h.Webhooks["github"])
+			fmt.Printf("  Bitbucket:                  %s\n", h.Webhooks["bitbucket"])
+			fmt.Printf("  GitLab (secret token):      %s\n", h.Webhooks["gitlab"])
+		}
+		fmt.Printf("Secrets are only shown on creation, get a new one with: cds pipeline hook secret\n")
+		return
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Repository", "URL"})
+	table.SetHeader([]string{"ID", "Repository", "Paths", "URL"})
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 
@@ -200,9 +305,25 @@ func listPipelineHook(cmd *cobra.Command, args []string) {
 		table.Append([]string{
 			fmt.Sprintf("%d", h.ID),
 			fmt.Sprintf("%s/%s/%s", h.Host, h.Project, h.Repository),
+			pathsString(h.Paths),
 			h.Link,
 		})
 	}
 	table.Render()
 
 }
+
+// pathsString displays the paths filter of a hook
+func pathsString(f sdk.PathFilter) string {
+	if f.IsEmpty() {
+		return "*"
+	}
+	var s []string
+	for _, g := range f.Include {
+		s = append(s, "+"+g)
+	}
+	for _, g := range f.Exclude {
+		s = append(s, "-"+g)
+	}
+	return strings.Join(s, " ")
+}
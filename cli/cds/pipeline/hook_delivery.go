@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var showPayloads bool
+
+func pipelineDeliveriesHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deliveries",
+		Short: "cds pipeline hook deliveries <projectKey> <applicationName> <pipelineName> <idHook> [--payload]",
+		Long:  `List the last pushes received by a hook, and the build they triggered or why nothing ran`,
+		Run:   deliveriesPipelineHook,
+	}
+
+	cmd.Flags().BoolVarP(&showPayloads, "payload", "", false, "Shows the payload of each delivery")
+
+	return cmd
+}
+
+func pipelineReplayHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "cds pipeline hook replay <projectKey> <applicationName> <pipelineName> <idHook> <idDelivery>",
+		Long:  `Process again a push received by a hook`,
+		Run:   replayPipelineHook,
+	}
+
+	return cmd
+}
+
+func deliveriesPipelineHook(cmd *cobra.Command, args []string) {
+	if len(args) != 4 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	hookID, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		sdk.Exit("Hook id must be a number (%s)\n", err)
+	}
+
+	deliveries, err := sdk.GetHookDeliveries(args[0], args[1], args[2], hookID)
+	if err != nil {
+		sdk.Exit("Cannot retrieve deliveries of hook %d (%s)\n", hookID, err)
+	}
+
+	if showPayloads {
+		for _, d := range deliveries {
+			fmt.Printf("Delivery %d received %s: %s\n", d.ID, d.Received.Format("2006-01-02 15:04:05"), deliveryResult(d))
+			fmt.Printf("%s\n%s\n\n", d.Link, d.Payload)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 1, 2, ' ', 0)
+	titles := []string{"ID", "DATE", "BRANCH", "HASH", "AUTHOR", "RESULT"}
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
+
+	for _, d := range deliveries {
+		ref := d.Branch
+		if tag := sdk.ParameterValue(d.Args, "git.tag"); tag != "" {
+			ref = "tag " + tag
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			d.ID,
+			d.Received.Format("2006-01-02 15:04:05"),
+			ref,
+			d.Hash,
+			d.Author,
+			deliveryResult(d),
+		)
+	}
+	w.Flush()
+}
+
+func replayPipelineHook(cmd *cobra.Command, args []string) {
+	if len(args) != 5 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	hookID, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		sdk.Exit("Hook id must be a number (%s)\n", err)
+	}
+	deliveryID, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		sdk.Exit("Delivery id must be a number (%s)\n", err)
+	}
+
+	d, err := sdk.ReplayHookDelivery(args[0], args[1], args[2], hookID, deliveryID)
+	if err != nil {
+		sdk.Exit("Cannot replay delivery %d of hook %d (%s)\n", deliveryID, hookID, err)
+	}
+	fmt.Printf("Delivery %d: %s\n", d.ID, deliveryResult(*d))
+}
+
+// deliveryResult displays the build triggered by a delivery, or why nothing ran
+func deliveryResult(d sdk.HookDelivery) string {
+	s := d.Status
+	switch {
+	case d.Status == "":
+		s = "Unknown"
+	case d.BuildNumber != 0:
+		s = fmt.Sprintf("%s #%d", d.Status, d.BuildNumber)
+	case d.Reason != "":
+		s = fmt.Sprintf("%s: %s", d.Status, d.Reason)
+	}
+	if d.ReplayOf != 0 {
+		s += fmt.Sprintf(" (replay of %d)", d.ReplayOf)
+	}
+	return s
+}
@@ -18,9 +18,15 @@ func Cmd() *cobra.Command {
 	cmd.AddCommand(pipelineDeleteCmd())
 	cmd.AddCommand(pipelineGroupCmd)
 	cmd.AddCommand(pipelineHistoryCmd())
+	cmd.AddCommand(pipelineSkippedCmd())
 	cmd.AddCommand(pipelineListCmd())
 	cmd.AddCommand(pipelineRunCmd())
 	cmd.AddCommand(pipelineRestartCmd())
+	cmd.AddCommand(pipelineApproveCmd())
+	cmd.AddCommand(pipelineRejectCmd())
+	cmd.AddCommand(pipelineApprovalsCmd())
+	cmd.AddCommand(pipelineVerificationsCmd())
+	cmd.AddCommand(pipelineReleaseNotesCmd())
 	cmd.AddCommand(pipelineShowBuildCmd())
 	cmd.AddCommand(pipelineCommitsCmd())
 	cmd.AddCommand(pipelineShowCmd())
@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var progressive sdk.ProgressiveDeployment
+var progressiveDisable bool
+
+func pipelineProgressiveStageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "progressive",
+		Short: "cds pipeline stage progressive <projectKey> <pipelineName> <pipelineStageID> [--strategy canary|blue_green] [--check http|metric|plugin] [--disable]",
+		Long: `Make a stage of a deployment pipeline a progressive deployment.
+Jobs of the stage receive {{.cds.deploy.strategy}}, {{.cds.deploy.slot}} and, for canaries, {{.cds.deploy.weight}}.
+Once deployed, the stage is verified by a health check during the verification window, then promoted, or rolled back if health checks fail:
+
+	cds pipeline stage progressive PRJ deploy 42 --strategy canary --weight 10 --window 15m \
+		--check http --url https://canary.example.com/health --auto-rollback
+
+	cds pipeline stage progressive PRJ deploy 42 --strategy blue_green --window 10m \
+		--check metric --url http://prometheus:9090/api/v1/query --query 'sum(rate(http_errors[1m]))' --operator '<' --threshold 1
+
+Following stages receive the promoted {{.cds.deploy.slot}}, to switch traffic for instance.`,
+		Run: setStageProgressive,
+	}
+
+	cmd.Flags().StringVarP(&progressive.Strategy, "strategy", "", sdk.DeploymentCanary, "Deployment strategy: canary or blue_green")
+	cmd.Flags().IntVarP(&progressive.Weight, "weight", "", 10, "Percentage of the canary deployment")
+	cmd.Flags().StringVarP(&progressive.VerificationWindow, "window", "", "10m", "Duration of the verification window")
+	cmd.Flags().StringVarP(&progressive.Interval, "interval", "", "30s", "Interval between health checks")
+	cmd.Flags().IntVarP(&progressive.MaxFailures, "max-failures", "", 0, "Number of failed health checks tolerated")
+	cmd.Flags().BoolVarP(&progressive.AutoRollback, "auto-rollback", "", false, "Redeploy the last successful build when verification fails")
+	cmd.Flags().StringVarP(&progressive.HealthCheck.Type, "check", "", sdk.HealthCheckHTTP, "Health check type: http, metric or plugin")
+	cmd.Flags().StringVarP(&progressive.HealthCheck.URL, "url", "", "", "Health check or metric endpoint url")
+	cmd.Flags().IntVarP(&progressive.HealthCheck.ExpectedStatus, "expected-status", "", 200, "Expected HTTP status of an http health check")
+	cmd.Flags().StringVarP(&progressive.HealthCheck.Timeout, "timeout", "", "", "Health check timeout")
+	cmd.Flags().StringVarP(&progressive.HealthCheck.Query, "query", "", "", "Metric query")
+	cmd.Flags().StringVarP(&progressive.HealthCheck.Operator, "operator", "", "<=", "Comparison of the metric with the threshold")
+	cmd.Flags().Float64VarP(&progressive.HealthCheck.Threshold, "threshold", "", 0, "Metric threshold")
+	cmd.Flags().StringVarP(&progressive.HealthCheck.Plugin, "plugin", "", "", "Health check plugin name")
+	cmd.Flags().BoolVarP(&progressiveDisable, "disable", "", false, "Make the stage a plain stage again")
+	return cmd
+}
+
+func setStageProgressive(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+
+	var p *sdk.ProgressiveDeployment
+	if !progressiveDisable {
+		p = &progressive
+	}
+
+	if err := sdk.SetStageProgressive(args[0], args[1], args[2], p); err != nil {
+		sdk.Exit("Error: cannot update stage (%s)\n", err)
+	}
+	fmt.Printf("Stage updated.\n")
+}
+
+func pipelineVerificationsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verifications",
+		Short: "cds pipeline verifications <projectKey> <appName> <pipelineName> [envName] <buildNumber>",
+		Long:  `List progressive deployment verifications of a pipeline build, with the reason of rollbacks`,
+		Run:   listVerifications,
+	}
+	return cmd
+}
+
+func listVerifications(cmd *cobra.Command, args []string) {
+	pk, app, name, env, bn := approvalArgs(cmd, args)
+
+	verifications, err := sdk.GetBuildVerifications(pk, app, name, env, bn)
+	if err != nil {
+		sdk.Exit("Error: %s\n", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Stage", "Strategy", "Slot", "Status", "Started", "Checks", "Failures", "Last result", "Reason", "Rollback"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, v := range verifications {
+		rollback := ""
+		if v.RollbackBuildNumber != 0 {
+			rollback = fmt.Sprintf("#%d", v.RollbackBuildNumber)
+		}
+		table.Append([]string{v.StageName, v.Strategy, v.Slot, v.Status, v.Started.Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%d", v.Checks), fmt.Sprintf("%d", v.Failures), v.LastResult, v.Reason, rollback})
+	}
+	table.Render()
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var releaseNotesRequest sdk.ReleaseNotesRequest
+
+func pipelineReleaseNotesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "releasenotes",
+		Short: "cds pipeline releasenotes <projectKey> <appName> <pipelineName> [envName] <buildNumber> [--artifact] [--tag <tag>]",
+		Long: `Generate release notes of a build since the previous build on its branch.
+With --artifact, they are attached to the build as RELEASE_NOTES.md. With --tag, they are published
+as a release on the repository, creating the tag on the built commit:
+	cds pipeline releasenotes PRJ myapp build 42 --artifact --tag v1.3.0`,
+		Run: pipelineReleaseNotes,
+	}
+
+	cmd.Flags().StringVarP(&releaseNotesRequest.GroupBy, "group-by", "", sdk.ReleaseNotesByType, "Group commits by type or issue")
+	cmd.Flags().StringVarP(&releaseNotesRequest.IssuePattern, "issue-pattern", "", "", "Regular expression of issue keys")
+	cmd.Flags().BoolVarP(&releaseNotesRequest.Artifact, "artifact", "", false, "Attach release notes to the build as an artifact")
+	cmd.Flags().StringVarP(&releaseNotesRequest.ArtifactTag, "artifact-tag", "", "", "Tag of the artifact, build version by default")
+	cmd.Flags().StringVarP(&releaseNotesRequest.Tag, "tag", "", "", "Publish release notes on this repository tag")
+	return cmd
+}
+
+func pipelineReleaseNotes(cmd *cobra.Command, args []string) {
+	pk, app, name, env, bn := approvalArgs(cmd, args)
+
+	rn, err := sdk.GenerateBuildReleaseNotes(pk, app, name, env, bn, releaseNotesRequest)
+	if err != nil {
+		sdk.Exit("Error: cannot generate release notes (%s)\n", err)
+	}
+	fmt.Print(rn.Markdown)
+}
@@ -16,6 +16,7 @@ var batch bool
 var env string
 var parentInfo string
 var parentBuildNumber int64
+var freezeOverride string
 
 func pipelineRunCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -30,6 +31,7 @@ func pipelineRunCmd() *cobra.Command {
 	cmd.Flags().StringSliceVarP(&cmdPipelineRunArguments, "parameter", "p", nil, "Pipeline parameters")
 	cmd.Flags().StringVarP(&parentInfo, "parent", "", "", "Parent build (format: app/pip[/env])")
 	cmd.Flags().Int64VarP(&parentBuildNumber, "parent-build", "", 0, "Parent build number")
+	cmd.Flags().StringVarP(&freezeOverride, "freeze-override", "", "", "Override token to deploy on a frozen environment")
 
 	return cmd
 }
@@ -132,6 +134,7 @@ func runPipeline(cmd *cobra.Command, args []string) {
 		ParentPipelineID:    ppipID,
 		ParentApplicationID: pappID,
 		ParentEnvironmentID: penvID,
+		FreezeOverride:      freezeOverride,
 	}
 
 	ch, err := sdk.RunPipeline(projectKey, appName, name, envName, stream, r, false)
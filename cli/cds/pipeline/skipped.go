@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func pipelineSkippedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "skipped",
+		Short: "cds pipeline skipped <projectKey> <applicationName> <pipelineName>",
+		Long:  `List the commits received by hooks and pollers which did not trigger the pipeline, and why`,
+		Run:   skippedPipeline,
+	}
+
+	return cmd
+}
+
+func skippedPipeline(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	skipped, err := sdk.GetSkippedBuilds(args[0], args[1], args[2])
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve skipped builds (%s)\n", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 1, 2, ' ', 0)
+	titles := []string{"DATE", "ORIGIN", "BRANCH", "HASH", "AUTHOR", "REASON"}
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
+
+	for _, s := range skipped {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Skipped.Format("2006-01-02 15:04:05"),
+			s.Origin,
+			s.Branch,
+			s.Hash,
+			s.Author,
+			s.Reason,
+		)
+	}
+	w.Flush()
+}
@@ -23,6 +23,7 @@ func init() {
 	pipelineStageCmd.AddCommand(pipelineMoveStageCmd())
 	pipelineStageCmd.AddCommand(pipelineRenameStageCmd())
 	pipelineStageCmd.AddCommand(pipelineChangeStateStageCmd())
+	pipelineStageCmd.AddCommand(pipelineProgressiveStageCmd())
 }
 
 func cmdPipelineAddStage() *cobra.Command {
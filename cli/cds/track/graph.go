@@ -0,0 +1,82 @@
+package track
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// printWorkflowRuns displays the execution graph of the workflow runs the given pipeline builds belong to
+func printWorkflowRuns(pbs []sdk.PipelineBuild) {
+	roots := map[int64]bool{}
+	for _, pb := range pbs {
+		run, err := sdk.GetWorkflowRun(pb.Pipeline.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber)
+		if err != nil {
+			fmt.Printf("Cannot load workflow of %s/%s #%d: %s\n", pb.Application.Name, pb.Pipeline.Name, pb.BuildNumber, err)
+			continue
+		}
+		if roots[run.RootID] {
+			continue
+		}
+		roots[run.RootID] = true
+		fmt.Print(formatWorkflowRun(run))
+	}
+}
+
+func formatWorkflowRun(run *sdk.WorkflowRun) string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("%s workflow %s\n", statusChar(run.Status), time.Duration(run.Duration)*time.Second))
+
+	var roots []sdk.WorkflowRunNode
+	for _, n := range run.Nodes {
+		if n.ParentID == 0 {
+			roots = append(roots, n)
+		}
+	}
+	for i, n := range roots {
+		formatWorkflowRunNode(&buf, run, n, "", i == len(roots)-1)
+	}
+	return buf.String()
+}
+
+func formatWorkflowRunNode(buf *bytes.Buffer, run *sdk.WorkflowRun, n sdk.WorkflowRunNode, prefix string, last bool) {
+	cyan := color.New(color.FgCyan).SprintfFunc()
+	magenta := color.New(color.FgMagenta).SprintfFunc()
+
+	connector, childPrefix := "├─ ", "│  "
+	if last {
+		connector, childPrefix = "└─ ", "   "
+	}
+
+	line := fmt.Sprintf("%s/%s", n.Application, n.Pipeline)
+	if n.Environment != "" && n.Environment != sdk.DefaultEnv.Name {
+		line += fmt.Sprintf("[%s]", magenta(n.Environment))
+	}
+	line += cyan(" #%d", n.Version)
+	if n.Branch != "" {
+		line += fmt.Sprintf(" (%s)", magenta(n.Branch))
+	}
+	buf.WriteString(fmt.Sprintf("%s%s%s %s %s\n", prefix, connector, statusChar(n.Status), line, time.Duration(n.Duration)*time.Second))
+
+	children := run.Children(n.ID)
+	for i, c := range children {
+		formatWorkflowRunNode(buf, run, c, prefix+childPrefix, i == len(children)-1)
+	}
+}
+
+func statusChar(s sdk.Status) string {
+	switch s {
+	case sdk.StatusSuccess:
+		return color.New(color.FgGreen).SprintfFunc()("✓")
+	case sdk.StatusFail:
+		return color.New(color.FgRed).SprintfFunc()("✗")
+	case sdk.StatusSkipped, sdk.StatusDisabled:
+		return color.New(color.FgYellow).SprintfFunc()("-")
+	default:
+		return color.New(color.FgBlue).SprintfFunc()("↻")
+	}
+}
@@ -53,6 +53,7 @@ func track(hash string) {
 
 	//fmt.Printf("Found %d pipeline builds\n", len(pbs))
 	var pbI int
+	var tracked []sdk.PipelineBuild
 	for pbI < len(pbs) {
 		pb := pbs[pbI]
 
@@ -75,6 +76,7 @@ func track(hash string) {
 			if pb.Status != sdk.StatusBuilding {
 				fmt.Printf("\n")
 				//fmt.Printf(" <- %s Done !\n", pb.Pipeline.Name)
+				tracked = append(tracked, pb)
 				pbI++
 				break
 			}
@@ -84,6 +86,7 @@ func track(hash string) {
 	// Pipeline finished, display result long enough
 	time.Sleep(1 * time.Second)
 	fmt.Printf("\n")
+	printWorkflowRuns(tracked)
 	os.Exit(0)
 }
 
@@ -123,6 +126,9 @@ func formatDisplay(pb sdk.PipelineBuild) {
 	if env != "NoEnv" {
 		display += fmt.Sprintf(" %s", magenta(env))
 	}
+	if pb.StopReason != "" {
+		display += fmt.Sprintf(" %s", red("(%s)", pb.StopReason))
+	}
 
 	// Format actions
 	for _, s := range pb.Stages {
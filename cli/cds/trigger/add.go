@@ -11,18 +11,34 @@ import (
 var cmdTriggerAddParams []string
 var cmdTriggerAddPrerequisites []string
 var cmdTriggerManual bool
+var cmdTriggerCondition string
 
 func addTriggerCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add",
-		Short: "cds trigger add <srcproject>/<srcapp>/<srcpip>[/<srcenv>] <destproject>/<destapp>/<desstpip>[/<destenv>] [-p <paramName>=<paramValue>] [--prerequisite <pipelineParamName>=<expectedValue>] [--manual]",
-		Long:  ``,
-		Run:   addTrigger,
+		Short: "cds trigger add <srcproject>/<srcapp>/<srcpip>[/<srcenv>] <destproject>/<destapp>/<desstpip>[/<destenv>] [-p <paramName>=<paramValue>] [--prerequisite <pipelineParamName>=<expectedValue>] [--condition <expression>] [--manual]",
+		Long: `Add a trigger between two pipelines.
+
+A condition is a boolean expression evaluated when the source pipeline build ends, for instance:
+
+  cds trigger add PRJ/app/build PRJ/app/deploy/staging --condition 'glob(branch, "release/*") && tests.ko == 0'
+  cds trigger add PRJ/app/build PRJ/app/notify --condition 'status == "Fail" && hour >= 8 && hour < 19'
+  cds trigger add PRJ/app/build PRJ/app/doc --condition 'changed("docs/**", "*.md")'
+
+Variables: status, branch, hash, author, application, pipeline, environment, build_number, version,
+tests.total, tests.ok, tests.ko, tests.skipped, hour, minute, weekday and any build parameter (git.branch, cds.version...).
+Functions: glob(value, pattern), match(value, regexp), contains(value, substring), changed(pattern...).
+Patterns: * and ? match within a path segment and ** any number of directories; changed() patterns also match
+the files below a matching directory, as the path filters of hooks and pollers do.
+Operators: ==, !=, <, <=, >, >=, =~, !~, &&, ||, ! and parentheses.
+Without status in its condition, a trigger only runs after a successful build.`,
+		Run: addTrigger,
 	}
 
 	cmd.Flags().BoolVarP(&cmdTriggerManual, "manual", "", false, "Manual Trigger or not")
 	cmd.Flags().StringSliceVarP(&cmdTriggerAddParams, "parameter", "p", nil, "Trigger parameter")
 	cmd.Flags().StringSliceVarP(&cmdTriggerAddPrerequisites, "prerequisite", "", nil, "Trigger prerequisite")
+	cmd.Flags().StringVarP(&cmdTriggerCondition, "condition", "", "", "Trigger condition expression")
 	return cmd
 }
 
@@ -56,6 +72,7 @@ func addTrigger(cmd *cobra.Command, args []string) {
 	}
 
 	t.Manual = cmdTriggerManual
+	t.Condition = cmdTriggerCondition
 
 	err = sdk.AddTrigger(t)
 	if err != nil {
@@ -49,6 +49,7 @@ func copyTrigger(cmd *cobra.Command, args []string) {
 
 	dstTrigger.Parameters = append(dstTrigger.Parameters, trigger.Parameters...)
 	dstTrigger.Prerequisites = append(dstTrigger.Prerequisites, trigger.Prerequisites...)
+	dstTrigger.Condition = trigger.Condition
 	dstTrigger.Manual = trigger.Manual
 
 	if err := sdk.AddTrigger(dstTrigger); err != nil {
@@ -0,0 +1,154 @@
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var cmdJoinWindow time.Duration
+var cmdJoinMatchOn string
+var cmdJoinParams []string
+
+func joinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "join",
+		Short: "Manage fan-in triggers: run a pipeline once several pipelines have succeeded",
+		Long:  ``,
+	}
+
+	add := &cobra.Command{
+		Use:   "add",
+		Short: "cds trigger join add <destproject>/<destapp>/<destpip>[/<destenv>] <srcapp>/<srcpip>[/<srcenv>] <srcapp>/<srcpip>[/<srcenv>]... [--window 1h] [--match-on hash|version] [-p <paramName>=<paramValue>]",
+		Long: `Add a fan-in trigger: the destination pipeline runs once all the source pipelines, in the same project,
+have succeeded on the same git hash (or the same version with --match-on version) within the wait window.`,
+		Run: addJoin,
+	}
+	add.Flags().DurationVarP(&cmdJoinWindow, "window", "", time.Hour, "Time to wait for all the sources")
+	add.Flags().StringVarP(&cmdJoinMatchOn, "match-on", "", sdk.JoinMatchOnHash, "Value the source builds must share: hash or version")
+	add.Flags().StringSliceVarP(&cmdJoinParams, "parameter", "p", nil, "Trigger parameter")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "cds trigger join list <destproject>/<destapp>/<destpip>",
+		Long:  ``,
+		Run:   listJoins,
+	}
+
+	del := &cobra.Command{
+		Use:   "delete",
+		Short: "cds trigger join delete <destproject>/<destapp>/<destpip> <id>",
+		Long:  ``,
+		Run:   deleteJoin,
+	}
+
+	cmd.AddCommand(add)
+	cmd.AddCommand(list)
+	cmd.AddCommand(del)
+	return cmd
+}
+
+func joinDestination(dest string) (string, string, string, string) {
+	t := strings.Split(dest, "/")
+	if len(t) < 3 || len(t) > 4 {
+		sdk.Exit("Error: wrong destination pipeline format, should be <project>/<application>/<pipeline>[/<env>]\n")
+	}
+	env := sdk.DefaultEnv.Name
+	if len(t) == 4 {
+		env = t[3]
+	}
+	return t[0], t[1], t[2], env
+}
+
+func addJoin(cmd *cobra.Command, args []string) {
+	if len(args) < 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	j := &sdk.TriggerJoin{
+		MatchOn:    cmdJoinMatchOn,
+		WaitWindow: int64(cmdJoinWindow.Seconds()),
+	}
+	j.DestProject.Key, j.DestApplication.Name, j.DestPipeline.Name, j.DestEnvironment.Name = joinDestination(args[0])
+
+	for _, src := range args[1:] {
+		t := strings.Split(src, "/")
+		if len(t) < 2 || len(t) > 3 {
+			sdk.Exit("Error: wrong source pipeline format '%s', should be <application>/<pipeline>[/<env>]\n", src)
+		}
+		var s sdk.TriggerJoinSource
+		s.Application.Name = t[0]
+		s.Pipeline.Name = t[1]
+		s.Environment.Name = sdk.DefaultEnv.Name
+		if len(t) == 3 {
+			s.Environment.Name = t[2]
+		}
+		j.Sources = append(j.Sources, s)
+	}
+
+	for _, param := range cmdJoinParams {
+		p, err := sdk.NewStringParameter(param)
+		if err != nil {
+			sdk.Exit("Error: cannot parse parameter '%s' (%s)\n", param, err)
+		}
+		j.Parameters = append(j.Parameters, p)
+	}
+
+	if err := sdk.AddTriggerJoin(j); err != nil {
+		sdk.Exit("Error: %s\n", err)
+	}
+
+	fmt.Printf("Fan-in trigger %d created.\n", j.ID)
+}
+
+func listJoins(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+	projectKey, appName, pipelineName, _ := joinDestination(args[0])
+
+	joins, err := sdk.GetTriggerJoins(projectKey, appName, pipelineName)
+	if err != nil {
+		sdk.Exit("Error: %s\n", err)
+	}
+
+	for _, j := range joins {
+		var sources []string
+		for _, s := range j.Sources {
+			sources = append(sources, fmt.Sprintf("%s/%s[%s]", s.Application.Name, s.Pipeline.Name, s.Environment.Name))
+		}
+		fmt.Printf("#%d %s/%s[%s] <- %s (same %s within %s)\n", j.ID, j.DestApplication.Name, j.DestPipeline.Name, j.DestEnvironment.Name,
+			strings.Join(sources, " + "), j.MatchOn, time.Duration(j.WaitWindow)*time.Second)
+
+		for _, p := range j.Pending {
+			var missing []string
+			for _, s := range p.Missing {
+				missing = append(missing, fmt.Sprintf("%s/%s[%s]", s.Application.Name, s.Pipeline.Name, s.Environment.Name))
+			}
+			fmt.Printf("\t%s: %d/%d succeeded, waiting for %s until %s\n", p.Key, len(p.Arrived), len(j.Sources),
+				strings.Join(missing, ", "), p.Expires.Format(time.RFC3339))
+		}
+	}
+}
+
+func deleteJoin(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+	projectKey, appName, pipelineName, _ := joinDestination(args[0])
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: invalid id '%s'\n", args[1])
+	}
+
+	if err := sdk.DeleteTriggerJoin(projectKey, appName, pipelineName, id); err != nil {
+		sdk.Exit("Error: %s\n", err)
+	}
+	fmt.Printf("Fan-in trigger %d deleted.\n", id)
+}
@@ -22,6 +22,7 @@ func Cmd() *cobra.Command {
 	cmd.AddCommand(deleteTriggerCmd())
 	cmd.AddCommand(copyTriggerCmd())
 	cmd.AddCommand(paramTriggerCmd())
+	cmd.AddCommand(joinCmd())
 
 	return cmd
 }
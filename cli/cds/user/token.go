@@ -0,0 +1,132 @@
+package user
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenScope    string
+	tokenProjects []string
+	tokenExpire   int64
+)
+
+func cmdUserToken() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Personal access tokens management",
+		Long: `Personal access tokens let bots and scripts call CDS as you, without your password.
+Use them with the access_token key of the CDS config file, or the CDS_ACCESS_TOKEN environment variable.`,
+	}
+
+	cmd.AddCommand(cmdUserTokenList())
+	cmd.AddCommand(cmdUserTokenCreate())
+	cmd.AddCommand(cmdUserTokenRevoke())
+	return cmd
+}
+
+func cmdUserTokenList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "cds user token list",
+		Aliases: []string{"ls"},
+		Run:     listTokens,
+	}
+
+	return cmd
+}
+
+func listTokens(cmd *cobra.Command, args []string) {
+	tokens, err := sdk.ListAccessTokens()
+	if err != nil {
+		sdk.Exit("Error: Cannot list access tokens (%s)\n", err)
+	}
+
+	now := time.Now()
+	for _, t := range tokens {
+		status := "valid"
+		switch {
+		case t.Revoked != nil:
+			status = "revoked"
+		case !t.IsValid(now):
+			status = "expired"
+		}
+		projects := "all projects"
+		if len(t.Projects) > 0 {
+			projects = strings.Join(t.Projects, ",")
+		}
+		expire, lastUsed := "never", "never"
+		if t.Expire != nil {
+			expire = t.Expire.Format("2006-01-02")
+		}
+		if t.LastUsed != nil {
+			lastUsed = t.LastUsed.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%d %s %s (%s) %s, expires %s, last used %s\n", t.ID, t.Name, t.Scope, projects, status, expire, lastUsed)
+	}
+}
+
+func cmdUserTokenCreate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "cds user token create <name> [--scope read|run|admin] [--project KEY] [--expire days]",
+		Long: `Create a personal access token. Its value is only shown once.
+The read scope only allows reading, run also allows running pipelines, admin allows everything you can do.
+With --project, the token only works on the given projects. With --expire 0, the token never expires.`,
+		Run: createToken,
+	}
+
+	cmd.Flags().StringVarP(&tokenScope, "scope", "", sdk.AccessTokenScopeRead, "Token scope: read, run or admin")
+	cmd.Flags().StringSliceVarP(&tokenProjects, "project", "", nil, "Restrict the token to a project key, can be repeated")
+	cmd.Flags().Int64VarP(&tokenExpire, "expire", "", 90, "Days before the token expires, 0 for never")
+	return cmd
+}
+
+func createToken(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+
+	t, err := sdk.CreateAccessToken(sdk.AccessTokenRequest{
+		Name:         args[0],
+		Scope:        tokenScope,
+		Projects:     tokenProjects,
+		ExpireInDays: tokenExpire,
+	})
+	if err != nil {
+		sdk.Exit("Error: Cannot create access token (%s)\n", err)
+	}
+
+	fmt.Printf("Access token %s created, it won't be shown again:\n%s\n", t.Name, t.Token)
+}
+
+func cmdUserTokenRevoke() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "cds user token revoke <id>",
+		Run:   revokeToken,
+	}
+
+	return cmd
+}
+
+func revokeToken(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: Invalid token id %s\n", args[0])
+	}
+
+	if err := sdk.RevokeAccessToken(id); err != nil {
+		sdk.Exit("Error: Cannot revoke access token %d (%s)\n", id, err)
+	}
+	fmt.Printf("Access token %d revoked\n", id)
+}
@@ -16,6 +16,7 @@ func init() {
 	Cmd.AddCommand(cmdUserVerify())
 	Cmd.AddCommand(cmdUserUpdate())
 	Cmd.AddCommand(cmdUserDelete())
+	Cmd.AddCommand(cmdUserToken())
 }
 
 // Cmd user
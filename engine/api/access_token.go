@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/user"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// checkAccessTokenScope checks a call authenticated with a personal access token is allowed by its scope and projects.
+// Tokens without the admin scope never get the CDS admin rights of their user.
+func checkAccessTokenScope(rc *routerConfig, req *http.Request, c *context.Context) bool {
+	t := c.AccessToken
+	switch t.Scope {
+	case sdk.AccessTokenScopeAdmin:
+	case sdk.AccessTokenScopeRun:
+		if req.Method != "GET" && !(req.Method == "POST" && rc.isExecution) {
+			return false
+		}
+		c.User.Admin = false
+	case sdk.AccessTokenScopeRead:
+		if req.Method != "GET" {
+			return false
+		}
+		c.User.Admin = false
+	default:
+		return false
+	}
+
+	if len(t.Projects) == 0 {
+		return true
+	}
+	vars := mux.Vars(req)
+	key := vars["key"]
+	if key == "" {
+		key = vars["permProjectKey"]
+	}
+	return key != "" && t.AllowsProject(key)
+}
+
+func getAccessTokensHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	if c.AccessToken != nil {
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	tokens, err := user.LoadAccessTokens(db, c.User.ID)
+	if err != nil {
+		log.Warning("getAccessTokensHandler> Cannot load access tokens of %s: %s\n", c.User.Username, err)
+		WriteError(w, r, err)
+		return
+	}
+	WriteJSON(w, r, tokens, http.StatusOK)
+}
+
+func addAccessTokenHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	// Access tokens can't be used to create other tokens
+	if c.AccessToken != nil {
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("addAccessTokenHandler> Cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var req sdk.AccessTokenRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Warning("addAccessTokenHandler> Cannot unmarshal request: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || req.ExpireInDays < 0 || !validAccessTokenScope(req.Scope) {
+		WriteError(w, r, sdk.ErrInvalidAccessToken)
+		return
+	}
+	for _, key := range req.Projects {
+		exist, err := project.Exist(db, key)
+		if err != nil {
+			log.Warning("addAccessTokenHandler> Cannot check project %s: %s\n", key, err)
+			WriteError(w, r, err)
+			return
+		}
+		if !exist {
+			WriteError(w, r, sdk.ErrInvalidAccessToken)
+			return
+		}
+	}
+
+	token, hash, err := user.GenerateAccessToken()
+	if err != nil {
+		log.Warning("addAccessTokenHandler> Cannot generate access token: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	t := &sdk.AccessToken{
+		Name:     req.Name,
+		Scope:    req.Scope,
+		Projects: req.Projects,
+		Created:  time.Now(),
+	}
+	if req.ExpireInDays > 0 {
+		expire := t.Created.Add(time.Duration(req.ExpireInDays) * 24 * time.Hour)
+		t.Expire = &expire
+	}
+	if err := user.InsertAccessToken(db, c.User.ID, t, hash); err != nil {
+		log.Warning("addAccessTokenHandler> Cannot insert access token: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	t.Token = token
+	WriteJSON(w, r, t, http.StatusCreated)
+}
+
+func revokeAccessTokenHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	if c.AccessToken != nil {
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	idString := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		log.Warning("revokeAccessTokenHandler> Cannot parse id %s: %s\n", idString, err)
+		WriteError(w, r, sdk.ErrInvalidID)
+		return
+	}
+
+	if err := user.RevokeAccessToken(db, c.User.ID, id); err != nil {
+		log.Warning("revokeAccessTokenHandler> Cannot revoke access token %d: %s\n", id, err)
+		WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func validAccessTokenScope(scope string) bool {
+	for _, s := range sdk.AccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/auth"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/engine/api/user"
+	"github.com/ovh/cds/sdk"
+)
+
+// checkRouteScope routes the request on the given route of the router, and checks the access token scope with the route configuration
+func checkRouteScope(t *testing.T, route, method, uri string, token sdk.AccessToken) (bool, *context.Context) {
+	rc := mapRouterConfigs[router.prefix+route]
+	if rc == nil {
+		t.Fatalf("unknown route %s", route)
+	}
+
+	c := &context.Context{User: &sdk.User{Admin: true}, AccessToken: &token}
+	var allowed, routed bool
+	m := mux.NewRouter()
+	m.HandleFunc(router.prefix+route, func(w http.ResponseWriter, req *http.Request) {
+		routed = true
+		allowed = checkAccessTokenScope(rc, req, c)
+	})
+	req, _ := http.NewRequest(method, router.prefix+uri, nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if !routed {
+		t.Fatalf("%s is not routed on %s", uri, route)
+	}
+	return allowed, c
+}
+
+func Test_checkAccessTokenScope(t *testing.T) {
+	router = &Router{auth.TestLocalAuth(t), mux.NewRouter(), "/Test_checkAccessTokenScope"}
+	router.init()
+
+	environments := "/project/{permProjectKey}/environment"
+	coverage := "/project/{key}/application/{app}/pipeline/{permPipelineKey}/build/{build}/coverage"
+	read := sdk.AccessToken{Scope: sdk.AccessTokenScopeRead}
+	run := sdk.AccessToken{Scope: sdk.AccessTokenScopeRun}
+	admin := sdk.AccessToken{Scope: sdk.AccessTokenScopeAdmin}
+
+	// Read tokens only read
+	allowed, c := checkRouteScope(t, environments, "GET", "/project/PROJ/environment", read)
+	assert.True(t, allowed)
+	assert.False(t, c.User.Admin, "read tokens never get admin rights")
+	allowed, _ = checkRouteScope(t, environments, "POST", "/project/PROJ/environment", read)
+	assert.False(t, allowed)
+	allowed, _ = checkRouteScope(t, coverage, "POST", "/project/PROJ/application/app/pipeline/build/build/1/coverage", read)
+	assert.False(t, allowed)
+
+	// Run tokens only post on execution routes
+	allowed, c = checkRouteScope(t, coverage, "POST", "/project/PROJ/application/app/pipeline/build/build/1/coverage", run)
+	assert.True(t, allowed)
+	assert.False(t, c.User.Admin, "run tokens never get admin rights")
+	allowed, _ = checkRouteScope(t, environments, "POST", "/project/PROJ/environment", run)
+	assert.False(t, allowed)
+	allowed, _ = checkRouteScope(t, environments, "PUT", "/project/PROJ/environment", run)
+	assert.False(t, allowed)
+	allowed, _ = checkRouteScope(t, "/project/{permProjectKey}", "DELETE", "/project/PROJ", run)
+	assert.False(t, allowed)
+
+	// Admin tokens keep the rights of their user
+	allowed, c = checkRouteScope(t, environments, "PUT", "/project/PROJ/environment", admin)
+	assert.True(t, allowed)
+	assert.True(t, c.User.Admin)
+	allowed, _ = checkRouteScope(t, environments, "GET", "/project/PROJ/environment", sdk.AccessToken{Scope: "unknown"})
+	assert.False(t, allowed)
+
+	// Tokens restricted to projects can't be used on other projects, nor on routes outside of projects
+	restricted := sdk.AccessToken{Scope: sdk.AccessTokenScopeAdmin, Projects: []string{"PROJ"}}
+	allowed, _ = checkRouteScope(t, environments, "GET", "/project/PROJ/environment", restricted)
+	assert.True(t, allowed)
+	allowed, _ = checkRouteScope(t, coverage, "POST", "/project/PROJ/application/app/pipeline/build/build/1/coverage", restricted)
+	assert.True(t, allowed)
+	allowed, _ = checkRouteScope(t, environments, "GET", "/project/OTHER/environment", restricted)
+	assert.False(t, allowed)
+	allowed, _ = checkRouteScope(t, coverage, "POST", "/project/OTHER/application/app/pipeline/build/build/1/coverage", restricted)
+	assert.False(t, allowed)
+	allowed, _ = checkRouteScope(t, "/project", "GET", "/project", restricted)
+	assert.False(t, allowed)
+	allowed, _ = checkRouteScope(t, "/user/token", "GET", "/user/token", restricted)
+	assert.False(t, allowed)
+}
+
+func Test_accessTokenRoutes(t *testing.T) {
+	db := test.SetupPG(t)
+
+	router = &Router{auth.TestLocalAuth(t), mux.NewRouter(), "/Test_accessTokenRoutes"}
+	router.init()
+
+	u, _ := test.InsertAdminUser(t, db)
+	proj := test.InsertTestProject(t, db, test.RandomString(t, 10), test.RandomString(t, 10))
+	other := test.InsertTestProject(t, db, test.RandomString(t, 10), test.RandomString(t, 10))
+	test.NoError(t, group.InsertUserInGroup(db, proj.ProjectGroups[0].Group.ID, u.ID, false))
+
+	newToken := func(scope string, projects ...string) string {
+		token, hash, err := user.GenerateAccessToken()
+		test.NoError(t, err)
+		test.NoError(t, user.InsertAccessToken(db, u.ID, &sdk.AccessToken{Name: scope, Scope: scope, Projects: projects}, hash))
+		return token
+	}
+	do := func(token, method string, handler Handler, vars map[string]string) int {
+		uri := router.getRoute(method, handler, vars)
+		test.NotEmpty(t, uri)
+		req, _ := http.NewRequest(method, uri, nil)
+		req.Header.Set(sdk.AccessTokenHeader, token)
+		w := httptest.NewRecorder()
+		router.mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	read := newToken(sdk.AccessTokenScopeRead)
+	admin := newToken(sdk.AccessTokenScopeAdmin)
+	restricted := newToken(sdk.AccessTokenScopeAdmin, proj.Key)
+
+	vars := map[string]string{"permProjectKey": proj.Key}
+	assert.Equal(t, http.StatusOK, do(read, "GET", getEnvironmentsHandler, vars))
+	assert.Equal(t, http.StatusForbidden, do(read, "POST", addEnvironmentHandler, vars))
+	assert.Equal(t, http.StatusOK, do(restricted, "GET", getEnvironmentsHandler, vars))
+	assert.Equal(t, http.StatusForbidden, do(restricted, "GET", getEnvironmentsHandler, map[string]string{"permProjectKey": other.Key}))
+
+	// The admin rights of the user are dropped with a read token
+	assert.Equal(t, http.StatusForbidden, do(read, "GET", getGroupSyncLogsHandler, nil))
+	assert.Equal(t, http.StatusOK, do(admin, "GET", getGroupSyncLogsHandler, nil))
+
+	// Access tokens can't manage access tokens
+	assert.Equal(t, http.StatusForbidden, do(admin, "GET", getAccessTokensHandler, nil))
+	assert.Equal(t, http.StatusForbidden, do(admin, "POST", addAccessTokenHandler, nil))
+	assert.Equal(t, http.StatusForbidden, do(admin, "DELETE", revokeAccessTokenHandler, map[string]string{"id": "1"}))
+
+	assert.Equal(t, http.StatusUnauthorized, do("unknown", "GET", getEnvironmentsHandler, vars))
+}
@@ -5,9 +5,12 @@ import (
 
 	"github.com/go-gorp/gorp"
 
+	"github.com/ovh/cds/engine/api/auth"
 	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
 )
 
 func adminTruncateWarningsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
@@ -30,3 +33,30 @@ func getAdminMaintenanceHandler(w http.ResponseWriter, r *http.Request, db *gorp
 func deleteAdminMaintenanceHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
 	cache.Delete("maintenance")
 }
+
+func getGroupSyncLogsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	logs, err := group.LoadSyncLogs(db, 50)
+	if err != nil {
+		log.Warning("getGroupSyncLogsHandler> Cannot load group sync logs: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	WriteJSON(w, r, logs, http.StatusOK)
+}
+
+// syncGroupsHandler runs the LDAP group sync now, dryRun=true only reports the changes it would make
+func syncGroupsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	ldap, ok := router.authDriver.(*auth.LDAPClient)
+	if !ok || !ldap.GroupSyncEnabled() {
+		WriteError(w, r, sdk.ErrGroupSyncNotEnabled)
+		return
+	}
+
+	l, err := ldap.RunGroupSync(db, sdk.GroupSyncManual, r.FormValue("dryRun") == "true")
+	if err != nil {
+		log.Warning("syncGroupsHandler> Group sync failed: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	WriteJSON(w, r, l, http.StatusOK)
+}
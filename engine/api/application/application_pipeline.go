@@ -75,7 +75,7 @@ func GetAllPipelines(db gorp.SqlExecutor, projectKey, applicationName string) ([
 // GetAllPipelinesByID Get all pipelines for the given application
 func GetAllPipelinesByID(db gorp.SqlExecutor, applicationID int64) ([]sdk.ApplicationPipeline, error) {
 	appPipelines := []sdk.ApplicationPipeline{}
-	query := `SELECT pipeline.id, pipeline.name, application_pipeline.args, pipeline.type, application_pipeline.last_modified, pipeline.last_modified
+	query := `SELECT pipeline.id, pipeline.name, application_pipeline.args, pipeline.type, application_pipeline.last_modified, pipeline.last_modified, application_pipeline.options
 	          FROM application_pipeline
 	          JOIN application ON application.id = application_pipeline.application_id
 	          JOIN pipeline ON pipeline.id = application_pipeline.pipeline_id
@@ -95,10 +95,16 @@ func GetAllPipelinesByID(db gorp.SqlExecutor, applicationID int64) ([]sdk.Applic
 		var args string
 		var typePipeline string
 		var lastModified, pLastModified time.Time
-		err = rows.Scan(&p.Pipeline.ID, &p.Pipeline.Name, &args, &typePipeline, &lastModified, &pLastModified)
+		var options sql.NullString
+		err = rows.Scan(&p.Pipeline.ID, &p.Pipeline.Name, &args, &typePipeline, &lastModified, &pLastModified, &options)
 		if err != nil {
 			return nil, err
 		}
+		if options.Valid && options.String != "" {
+			if err := json.Unmarshal([]byte(options.String), &p.Options); err != nil {
+				return nil, err
+			}
+		}
 		p.Pipeline.Type = sdk.PipelineTypeFromString(typePipeline)
 		p.LastModified = lastModified.Unix()
 		p.Pipeline.LastModified = pLastModified.Unix()
@@ -408,9 +414,39 @@ func LoadCDTree(db gorp.SqlExecutor, projectkey, appName string, user *sdk.User)
 			cdTrees = append(cdTrees, root)
 		}
 	}
+
+	if len(cdTrees) > 0 {
+		joins, err := trigger.LoadJoinsByApplication(db, cdTrees[0].Application.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range cdTrees {
+			attachJoins(&cdTrees[i], joins)
+		}
+	}
 	return cdTrees, nil
 }
 
+// attachJoins adds to each node of the tree the fan-in triggers it is a source or the destination of
+func attachJoins(node *sdk.CDPipeline, joins []sdk.TriggerJoin) {
+	for _, j := range joins {
+		if j.DestApplication.ID == node.Application.ID && j.DestPipeline.ID == node.Pipeline.ID &&
+			(j.DestEnvironment.ID == node.Environment.ID || node.Environment.ID == sdk.DefaultEnv.ID) {
+			node.Joins = append(node.Joins, j)
+			continue
+		}
+		for _, s := range j.Sources {
+			if s.Application.ID == node.Application.ID && s.Pipeline.ID == node.Pipeline.ID && s.Environment.ID == node.Environment.ID {
+				node.Joins = append(node.Joins, j)
+				break
+			}
+		}
+	}
+	for i := range node.SubPipelines {
+		attachJoins(&node.SubPipelines[i], joins)
+	}
+}
+
 func getChild(db gorp.SqlExecutor, parent *sdk.CDPipeline, user *sdk.User) error {
 	listTrigger := []sdk.CDPipeline{}
 
@@ -550,3 +586,43 @@ func buildTreeOrder(parent *sdk.CDPipeline, listChild []sdk.CDPipeline, user *sd
 	}
 	return *parent
 }
+
+// LoadPipelineOptions loads the options of a pipeline attached to an application
+func LoadPipelineOptions(db gorp.SqlExecutor, applicationID, pipelineID int64) (*sdk.ApplicationPipelineOptions, error) {
+	query := `SELECT options FROM application_pipeline WHERE application_id=$1 AND pipeline_id=$2`
+
+	var data sql.NullString
+	if err := db.QueryRow(query, applicationID, pipelineID).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sdk.ErrNoAttachedPipeline
+		}
+		return nil, err
+	}
+
+	opts := &sdk.ApplicationPipelineOptions{}
+	if data.Valid && data.String != "" {
+		if err := json.Unmarshal([]byte(data.String), opts); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// UpdatePipelineOptions updates the options of a pipeline attached to an application
+func UpdatePipelineOptions(db gorp.SqlExecutor, app *sdk.Application, pipelineID int64, opts sdk.ApplicationPipelineOptions) error {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE application_pipeline SET options = $1, last_modified = current_timestamp WHERE application_id=$2 AND pipeline_id=$3`
+	res, err := db.Exec(query, string(data), app.ID, pipelineID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sdk.ErrNoAttachedPipeline
+	}
+
+	return UpdateLastModified(db, app)
+}
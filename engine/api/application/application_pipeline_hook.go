@@ -11,8 +11,8 @@ import (
 	"github.com/ovh/cds/sdk"
 )
 
-// TriggerPipeline linked to received hook
-func TriggerPipeline(tx gorp.SqlExecutor, h sdk.Hook, branch string, hash string, author string, p *sdk.Pipeline, projectData *sdk.Project) (bool, error) {
+// TriggerPipeline linked to received hook, it returns the triggered build or why nothing ran
+func TriggerPipeline(tx gorp.SqlExecutor, h sdk.Hook, branch string, hash string, author string, p *sdk.Pipeline, projectData *sdk.Project, extraArgs ...sdk.Parameter) (*sdk.PipelineBuild, string, error) {
 
 	// Create pipeline args
 	var args []sdk.Parameter
@@ -36,29 +36,41 @@ func TriggerPipeline(tx gorp.SqlExecutor, h sdk.Hook, branch string, hash string
 		Name:  "git.project",
 		Value: h.Project,
 	})
+	args = append(args, extraArgs...)
+
+	trigger := sdk.PipelineBuildTrigger{
+		ManualTrigger:    false,
+		VCSChangesBranch: branch,
+		VCSChangesHash:   hash,
+		VCSChangesAuthor: author,
+	}
+
+	// Release pipelines are only triggered by tags
+	tagArgs, reason, err := RefTriggerArgs(tx, h.ApplicationID, p.ID, sdk.ParameterValue(extraArgs, "git.tag"), &trigger)
+	if err != nil {
+		return nil, "", err
+	}
+	if reason != "" {
+		log.Debug("hook> Skipping build of %s/%s for commit %s by %s: %s", projectData.Key, p.Name, hash, author, reason)
+		return nil, reason, pipeline.InsertSkippedBuild(tx, h.ApplicationID, p.ID, trigger, pipeline.SkippedByHook, reason)
+	}
+	args = append(args, tagArgs...)
 
 	// Load pipeline Argument
 	parameters, err := pipeline.GetAllParametersInPipeline(tx, p.ID)
 	if err != nil {
-		return false, err
+		return nil, "", err
 	}
 	p.Parameter = parameters
 
 	// get application
 	a, err := LoadApplicationByID(tx, h.ApplicationID)
 	if err != nil {
-		return false, err
+		return nil, "", err
 	}
 	applicationPipelineArgs, err := GetAllPipelineParam(tx, h.ApplicationID, p.ID)
 	if err != nil {
-		return false, err
-	}
-
-	trigger := sdk.PipelineBuildTrigger{
-		ManualTrigger:    false,
-		VCSChangesBranch: branch,
-		VCSChangesHash:   hash,
-		VCSChangesAuthor: author,
+		return nil, "", err
 	}
 
 	// Get commit message to check if we have to skip the build
@@ -77,7 +89,14 @@ func TriggerPipeline(tx gorp.SqlExecutor, h sdk.Hook, branch string, hash string
 				}
 				if match {
 					log.Notice("hook> Skipping build of %s/%s for commit %s by %s", projectData.Key, a.Name, hash, author)
-					return false, nil
+					reason := "commit message asks to skip the build"
+					return nil, reason, pipeline.InsertSkippedBuild(tx, a.ID, p.ID, trigger, pipeline.SkippedByHook, reason)
+				}
+
+				// Only build the application if the files it cares about have changed
+				if reason := PathFilterSkipReason(tx, client, a, p.ID, h.Paths, branch, hash); reason != "" {
+					log.Notice("hook> Skipping build of %s/%s for commit %s by %s: %s", projectData.Key, a.Name, hash, author, reason)
+					return nil, reason, pipeline.InsertSkippedBuild(tx, a.ID, p.ID, trigger, pipeline.SkippedByHook, reason)
 				}
 			}
 		} else {
@@ -86,10 +105,10 @@ func TriggerPipeline(tx gorp.SqlExecutor, h sdk.Hook, branch string, hash string
 	}
 
 	// FIXME add possibility to trigger a pipeline on a specific env
-	_, err = pipeline.InsertPipelineBuild(tx, projectData, p, a, applicationPipelineArgs, args, &sdk.DefaultEnv, 0, trigger)
+	pb, err := pipeline.InsertPipelineBuild(tx, projectData, p, a, applicationPipelineArgs, args, &sdk.DefaultEnv, 0, trigger)
 	if err != nil {
-		return false, err
+		return nil, "", err
 	}
 
-	return true, nil
+	return pb, "", nil
 }
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// PathFilterSkipReason checks the files changed on the branch since the last build of the pipeline against the paths filter.
+// It returns why the build has to be skipped, or an empty string if it has to be triggered.
+// If the changed files can't be computed, the build is triggered.
+func PathFilterSkipReason(db gorp.SqlExecutor, client sdk.RepositoriesManagerClient, a *sdk.Application, pipelineID int64, filter sdk.PathFilter, branch, hash string) string {
+	if filter.IsEmpty() || client == nil || a.RepositoryFullname == "" {
+		return ""
+	}
+
+	var since string
+	pbs, err := pipeline.LoadPipelineBuildsByApplicationAndPipeline(db, a.ID, pipelineID, sdk.DefaultEnv.ID, 1, "", branch)
+	if err != nil {
+		log.Warning("PathFilterSkipReason> Cannot load last build of %s on %s: %s\n", a.Name, branch, err)
+	} else if len(pbs) > 0 && pbs[0].Trigger.VCSChangesHash != hash {
+		since = pbs[0].Trigger.VCSChangesHash
+	}
+
+	files, err := client.ChangedFiles(a.RepositoryFullname, since, hash)
+	if err != nil {
+		log.Warning("PathFilterSkipReason> Cannot get files changed on %s between %s and %s: %s\n", a.RepositoryFullname, since, hash, err)
+		return ""
+	}
+
+	if _, ok := filter.MatchAny(files); ok {
+		return ""
+	}
+
+	if since == "" {
+		return fmt.Sprintf("no file changed by %s matches the paths filter", hash)
+	}
+	return fmt.Sprintf("no file changed since %s matches the paths filter", since)
+}
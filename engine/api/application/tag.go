@@ -0,0 +1,37 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// RefTriggerArgs checks a pushed branch or tag against the tag patterns of the application pipeline.
+// Pipelines with tag patterns are release pipelines, only triggered by the tags matching one of them;
+// other pipelines are only triggered by branches. It returns the parameters of the tag,
+// or why the build has to be skipped. With VersionFromTag, the version of the tag is set on the trigger.
+func RefTriggerArgs(db gorp.SqlExecutor, applicationID, pipelineID int64, tag string, trigger *sdk.PipelineBuildTrigger) ([]sdk.Parameter, string, error) {
+	opts, err := LoadPipelineOptions(db, applicationID, pipelineID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if tag == "" {
+		if len(opts.TagPatterns) > 0 {
+			return nil, "release pipeline only triggered by tags", nil
+		}
+		return nil, "", nil
+	}
+
+	if !sdk.MatchTagPattern(opts.TagPatterns, tag) {
+		return nil, fmt.Sprintf("tag %s does not match the tag patterns", tag), nil
+	}
+
+	params := sdk.TagParameters(tag)
+	if opts.VersionFromTag {
+		trigger.TagVersion = sdk.ParameterValue(params, "git.tag.version")
+	}
+	return params, "", nil
+}
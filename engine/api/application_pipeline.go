@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"path"
 
 	"github.com/go-gorp/gorp"
 	"github.com/gorilla/mux"
@@ -460,3 +461,87 @@ func updateUserNotificationApplicationPipelineHandler(w http.ResponseWriter, r *
 	WriteJSON(w, r, notifs, http.StatusOK)
 	return
 }
+
+func getApplicationPipelineOptionsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	pip, err := pipeline.LoadPipeline(db, key, pipelineName, false)
+	if err != nil {
+		log.Warning("getApplicationPipelineOptionsHandler> Cannot load pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, sdk.ErrPipelineNotFound)
+		return
+	}
+
+	app, err := application.LoadApplicationByName(db, key, appName)
+	if err != nil {
+		log.Warning("getApplicationPipelineOptionsHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, sdk.ErrApplicationNotFound)
+		return
+	}
+
+	opts, err := application.LoadPipelineOptions(db, app.ID, pip.ID)
+	if err != nil {
+		log.Warning("getApplicationPipelineOptionsHandler> Cannot load options of %s/%s: %s\n", appName, pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, opts, http.StatusOK)
+}
+
+func updateApplicationPipelineOptionsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	pip, err := pipeline.LoadPipeline(db, key, pipelineName, false)
+	if err != nil {
+		log.Warning("updateApplicationPipelineOptionsHandler> Cannot load pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, sdk.ErrPipelineNotFound)
+		return
+	}
+
+	app, err := application.LoadApplicationByName(db, key, appName)
+	if err != nil {
+		log.Warning("updateApplicationPipelineOptionsHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, sdk.ErrApplicationNotFound)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("updateApplicationPipelineOptionsHandler> Cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var opts sdk.ApplicationPipelineOptions
+	if err := json.Unmarshal(data, &opts); err != nil {
+		log.Warning("updateApplicationPipelineOptionsHandler> Cannot unmarshal options: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	for _, p := range opts.TagPatterns {
+		if _, err := path.Match(p, ""); err != nil {
+			log.Warning("updateApplicationPipelineOptionsHandler> Invalid tag pattern %s: %s\n", p, err)
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	if err := application.UpdatePipelineOptions(db, app, pip.ID, opts); err != nil {
+		log.Warning("updateApplicationPipelineOptionsHandler> Cannot update options of %s/%s: %s\n", appName, pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	k := cache.Key("application", key, "*"+appName+"*")
+	cache.DeleteAll(k)
+
+	WriteJSON(w, r, opts, http.StatusOK)
+}
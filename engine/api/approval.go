@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/approval"
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/queue"
+	"github.com/ovh/cds/engine/api/trigger"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// loadRequestPipelineBuild loads the pipeline build designated by the request and its envName, and checks environment permission
+func loadRequestPipelineBuild(db gorp.SqlExecutor, r *http.Request, c *context.Context, access int) (*sdk.PipelineBuild, error) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+	pipName := vars["permPipelineKey"]
+
+	if err := r.ParseForm(); err != nil {
+		log.Warning("loadRequestPipelineBuild> Cannot parse form: %s\n", err)
+		return nil, sdk.ErrUnknownError
+	}
+	envName := r.Form.Get("envName")
+
+	buildNumber, err := strconv.ParseInt(vars["build"], 10, 64)
+	if err != nil {
+		log.Warning("loadRequestPipelineBuild> buildNumber is not a int: %s\n", err)
+		return nil, sdk.ErrInvalidID
+	}
+
+	pip, err := pipeline.LoadPipeline(db, projectKey, pipName, false)
+	if err != nil {
+		log.Warning("loadRequestPipelineBuild> Cannot load pipeline: %s\n", err)
+		return nil, err
+	}
+
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("loadRequestPipelineBuild> Cannot load application: %s\n", err)
+		return nil, err
+	}
+
+	env := &sdk.DefaultEnv
+	if pip.Type != sdk.BuildPipeline {
+		if envName == "" || envName == sdk.DefaultEnv.Name {
+			return nil, sdk.ErrNoEnvironmentProvided
+		}
+		env, err = environment.LoadEnvironmentByName(db, projectKey, envName)
+		if err != nil {
+			log.Warning("loadRequestPipelineBuild> Cannot load environment %s: %s\n", envName, err)
+			return nil, err
+		}
+		if !permission.AccessToEnvironment(env.ID, c.User, access) {
+			log.Warning("loadRequestPipelineBuild> No enought right on this environment %s\n", env.Name)
+			return nil, sdk.ErrForbidden
+		}
+	}
+
+	pb, err := pipeline.LoadPipelineBuildByApplicationPipelineEnvBuildNumber(db, app.ID, pip.ID, env.ID, buildNumber)
+	if err != nil {
+		log.Warning("loadRequestPipelineBuild> Cannot load pipeline build: %s\n", err)
+		return nil, err
+	}
+	return pb, nil
+}
+
+func getPipelineBuildApprovalsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	pb, err := loadRequestPipelineBuild(db, r, c, permission.PermissionRead)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	approvals, err := approval.LoadBuildApprovals(db, pb.ID)
+	if err != nil {
+		log.Warning("getPipelineBuildApprovalsHandler> Cannot load approvals of pipeline build %d: %s\n", pb.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, approvals, http.StatusOK)
+}
+
+func approvePipelineBuildHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	decidePipelineBuildApproval(w, r, db, c, sdk.ApprovalApproved)
+}
+
+func rejectPipelineBuildHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	decidePipelineBuildApproval(w, r, db, c, sdk.ApprovalRejected)
+}
+
+func decidePipelineBuildApproval(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context, status string) {
+	var decision sdk.ApprovalDecision
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &decision); err != nil {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	pb, err := loadRequestPipelineBuild(db, r, c, permission.PermissionReadExecute)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("decidePipelineBuildApproval> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	a, err := approval.LoadWaitingApproval(tx, pb.ID)
+	if err != nil {
+		log.Warning("decidePipelineBuildApproval> Cannot load pending approval of pipeline build %d: %s\n", pb.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if !approval.CanDecide(a, c.User) {
+		log.Warning("decidePipelineBuildApproval> User %s is not member of groups %v\n", c.User.Username, a.Groups)
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	if err := approval.Decide(tx, a, c.User, status, decision.Comment); err != nil {
+		log.Warning("decidePipelineBuildApproval> Cannot record decision on approval %d: %s\n", a.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if a.TriggerID != 0 {
+		if status == sdk.ApprovalApproved {
+			t, err := trigger.LoadTrigger(tx, a.TriggerID)
+			if err != nil {
+				log.Warning("decidePipelineBuildApproval> Cannot load trigger %d: %s\n", a.TriggerID, err)
+				WriteError(w, r, err)
+				return
+			}
+			if err := queue.RunTrigger(tx, t, *pb); err != nil {
+				log.Warning("decidePipelineBuildApproval> Cannot run trigger %d: %s\n", a.TriggerID, err)
+				WriteError(w, r, err)
+				return
+			}
+		}
+	} else {
+		newStatus := sdk.StatusBuilding
+		for i := range pb.Stages {
+			if pb.Stages[i].ID != a.StageID {
+				continue
+			}
+			if status == sdk.ApprovalApproved {
+				pb.Stages[i].Status = sdk.StatusWaiting
+			} else {
+				pb.Stages[i].Status = sdk.StatusFail
+				newStatus = sdk.StatusFail
+			}
+		}
+		if err := pipeline.UpdatePipelineBuildStatusAndStage(tx, pb, newStatus); err != nil {
+			log.Warning("decidePipelineBuildApproval> Cannot update pipeline build %d: %s\n", pb.ID, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("decidePipelineBuildApproval> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	k := cache.Key("application", pb.Application.ProjectKey, "builds", "*")
+	cache.DeleteAll(k)
+
+	WriteJSON(w, r, a, http.StatusOK)
+}
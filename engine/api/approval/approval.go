@@ -0,0 +1,232 @@
+package approval
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/notification"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+const approvalFields = `id, pipeline_build_id, build_number, stage_id, stage_name, trigger_id, groups, message, status, requested, decided, username, comment`
+
+// InsertStageApproval records a waiting approval request on a stage of a pipeline build
+func InsertStageApproval(db gorp.SqlExecutor, pb *sdk.PipelineBuild, s *sdk.Stage) (*sdk.Approval, error) {
+	a := &sdk.Approval{
+		StageID:   s.ID,
+		StageName: s.Name,
+		Groups:    s.Approval.Groups,
+		Message:   s.Approval.Message,
+	}
+	return a, insert(db, pb, a)
+}
+
+// InsertTriggerApproval records a waiting approval request on a trigger of a pipeline build
+func InsertTriggerApproval(db gorp.SqlExecutor, pb *sdk.PipelineBuild, t *sdk.PipelineTrigger) (*sdk.Approval, error) {
+	a := &sdk.Approval{
+		TriggerID: t.ID,
+		Groups:    t.Approval.Groups,
+		Message:   t.Approval.Message,
+	}
+	return a, insert(db, pb, a)
+}
+
+func insert(db gorp.SqlExecutor, pb *sdk.PipelineBuild, a *sdk.Approval) error {
+	groups, err := json.Marshal(a.Groups)
+	if err != nil {
+		return err
+	}
+
+	a.PipelineBuildID = pb.ID
+	a.BuildNumber = pb.BuildNumber
+	a.Status = sdk.ApprovalWaiting
+	a.Requested = time.Now()
+
+	query := `INSERT INTO pipeline_build_approval (pipeline_build_id, application_id, pipeline_id, environment_id, build_number,
+		stage_id, stage_name, trigger_id, groups, message, status, requested)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+	return db.QueryRow(query, pb.ID, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, pb.BuildNumber,
+		a.StageID, a.StageName, a.TriggerID, string(groups), a.Message, a.Status, a.Requested).Scan(&a.ID)
+}
+
+// LoadBuildApprovals loads all approval requests of a pipeline build, oldest first
+func LoadBuildApprovals(db gorp.SqlExecutor, pbID int64) ([]sdk.Approval, error) {
+	query := `SELECT ` + approvalFields + ` FROM pipeline_build_approval WHERE pipeline_build_id = $1 ORDER BY id ASC`
+	rows, err := db.Query(query, pbID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []sdk.Approval{}
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, *a)
+	}
+	return approvals, nil
+}
+
+// LoadLastStageApproval loads the last approval request of a stage in a pipeline build
+func LoadLastStageApproval(db gorp.SqlExecutor, pbID, stageID int64) (*sdk.Approval, error) {
+	query := `SELECT ` + approvalFields + ` FROM pipeline_build_approval
+		WHERE pipeline_build_id = $1 AND stage_id = $2 ORDER BY id DESC LIMIT 1`
+	a, err := scan(db.QueryRow(query, pbID, stageID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// LoadWaitingApproval loads the pending approval request of a pipeline build
+func LoadWaitingApproval(db gorp.SqlExecutor, pbID int64) (*sdk.Approval, error) {
+	query := `SELECT ` + approvalFields + ` FROM pipeline_build_approval
+		WHERE pipeline_build_id = $1 AND status = $2 ORDER BY id ASC LIMIT 1
+		FOR UPDATE`
+	a, err := scan(db.QueryRow(query, pbID, sdk.ApprovalWaiting))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrNoApprovalPending
+	}
+	return a, err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(s scanner) (*sdk.Approval, error) {
+	var a sdk.Approval
+	var groups string
+	var decided pq.NullTime
+	var username, comment sql.NullString
+	if err := s.Scan(&a.ID, &a.PipelineBuildID, &a.BuildNumber, &a.StageID, &a.StageName, &a.TriggerID,
+		&groups, &a.Message, &a.Status, &a.Requested, &decided, &username, &comment); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(groups), &a.Groups); err != nil {
+		return nil, err
+	}
+	if decided.Valid {
+		a.Decided = &decided.Time
+	}
+	a.Username = username.String
+	a.Comment = comment.String
+	return &a, nil
+}
+
+// Decide records the decision of a user on an approval request
+func Decide(db gorp.SqlExecutor, a *sdk.Approval, u *sdk.User, status, comment string) error {
+	now := time.Now()
+	a.Status = status
+	a.Decided = &now
+	a.Username = u.Username
+	a.Comment = comment
+
+	query := `UPDATE pipeline_build_approval SET status = $1, decided = $2, user_id = $3, username = $4, comment = $5 WHERE id = $6`
+	_, err := db.Exec(query, a.Status, now, u.ID, u.Username, a.Comment, a.ID)
+	return err
+}
+
+// CanDecide checks that the user is allowed to approve or reject the request
+func CanDecide(a *sdk.Approval, u *sdk.User) bool {
+	if u.Admin {
+		return true
+	}
+	for _, g := range u.Groups {
+		for _, name := range a.Groups {
+			if g.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DeleteBuildApprovals removes approval requests of a pipeline build
+func DeleteBuildApprovals(db gorp.SqlExecutor, pbID int64) error {
+	_, err := db.Exec(`DELETE FROM pipeline_build_approval WHERE pipeline_build_id = $1`, pbID)
+	return err
+}
+
+// loadGroupsUsers loads users members of the given groups
+func loadGroupsUsers(db gorp.SqlExecutor, groups []string) ([]sdk.User, error) {
+	query := `
+		SELECT 	DISTINCT "user".id, "user".username, "user".data
+		FROM 	"group"
+		JOIN	group_user ON "group".id = group_user.group_id
+		JOIN 	"user" ON group_user.user_id = "user".id
+		WHERE	"group".name = ANY(string_to_array($1, ','))
+	`
+	rows, err := db.Query(query, strings.Join(groups, ","))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []sdk.User
+	for rows.Next() {
+		var id int64
+		var username, data string
+		if err := rows.Scan(&id, &username, &data); err != nil {
+			return nil, err
+		}
+		u, err := sdk.NewUser(username).FromJSON([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		u.ID = id
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// Notify sends a mail to members of the designated groups of an approval request
+func Notify(db gorp.SqlExecutor, pb *sdk.PipelineBuild, a *sdk.Approval) {
+	users, err := loadGroupsUsers(db, a.Groups)
+	if err != nil {
+		log.Warning("approval.Notify> cannot load users of groups %v: %s\n", a.Groups, err)
+		return
+	}
+
+	recipients := []string{}
+	for _, u := range users {
+		if u.Email != "" {
+			recipients = append(recipients, u.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		log.Notice("approval.Notify> no recipient for approval %d on build %d\n", a.ID, pb.ID)
+		return
+	}
+
+	target := fmt.Sprintf("stage %s", a.StageName)
+	if a.TriggerID != 0 {
+		target = "triggered pipelines"
+	}
+
+	notif := sdk.EventNotif{
+		Recipients: recipients,
+		Subject: fmt.Sprintf("[CDS] Approval required: %s/%s/%s[%s] #%d", pb.Application.ProjectKey, pb.Application.Name,
+			pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber),
+		Body: fmt.Sprintf(`Build #%d of %s/%s/%s[%s] is waiting for approval before running %s.
+
+%s
+
+Approve with: cds pipeline approve %s %s %s %s --build %d
+Reject with:  cds pipeline reject %s %s %s %s --build %d
+`, pb.BuildNumber, pb.Application.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, target,
+			a.Message,
+			pb.Application.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber,
+			pb.Application.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber),
+	}
+	go notification.SendMailNotif(notif)
+}
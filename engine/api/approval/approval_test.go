@@ -0,0 +1,28 @@
+package approval
+
+import (
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestCanDecide(t *testing.T) {
+	a := &sdk.Approval{Groups: []string{"ops", "release"}}
+
+	tests := []struct {
+		name string
+		user *sdk.User
+		want bool
+	}{
+		{"admin", &sdk.User{Admin: true}, true},
+		{"member", &sdk.User{Groups: []sdk.Group{{Name: "dev"}, {Name: "release"}}}, true},
+		{"not member", &sdk.User{Groups: []sdk.Group{{Name: "dev"}}}, false},
+		{"no group", &sdk.User{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanDecide(a, tt.user); got != tt.want {
+			t.Errorf("%s: CanDecide() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
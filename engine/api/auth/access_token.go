@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/user"
+	"github.com/ovh/cds/engine/log"
+)
+
+//accessTokenLastUsedDelay avoids writing the last use of a token on every call
+const accessTokenLastUsedDelay = time.Minute
+
+//CheckAccessToken authenticates a call with a personal access token, which must be neither revoked nor expired
+func CheckAccessToken(db gorp.SqlExecutor, token string, ctx *context.Context) error {
+	t, userID, err := user.LoadAccessTokenByHash(db, user.HashAccessToken(token))
+	if err != nil {
+		return fmt.Errorf("cannot load access token: %s", err)
+	}
+
+	now := time.Now()
+	if !t.IsValid(now) {
+		return fmt.Errorf("access token %d is revoked or expired", t.ID)
+	}
+
+	u, err := user.LoadUserWithoutAuthByID(db, userID)
+	if err != nil {
+		return fmt.Errorf("cannot load user of access token %d: %s", t.ID, err)
+	}
+	if err := user.LoadUserPermissions(db, u); err != nil {
+		return fmt.Errorf("cannot load permissions of %s: %s", u.Username, err)
+	}
+
+	if t.LastUsed == nil || now.Sub(*t.LastUsed) > accessTokenLastUsedDelay {
+		if err := user.UpdateAccessTokenLastUsed(db, t.ID, now); err != nil {
+			log.Warning("CheckAccessToken> Cannot update last use of access token %d: %s\n", t.ID, err)
+		}
+		t.LastUsed = &now
+	}
+
+	ctx.User = u
+	ctx.AccessToken = t
+	return nil
+}
@@ -41,6 +41,8 @@ func GetDriver(mode string, options interface{}, storeOptions sessionstore.Optio
 	switch mode {
 	case "ldap":
 		d = &LDAPClient{}
+	case "oidc":
+		d = &OIDCClient{}
 	default:
 		d = &LocalClient{}
 	}
@@ -32,6 +32,12 @@ type LDAPConfig struct {
 	DN           string
 	SSL          bool
 	UserFullname string
+	//Group sync: groups are searched with a filter under the base, and/or given by DN
+	GroupFilter          string
+	GroupDNs             []string
+	GroupNameAttribute   string
+	GroupMemberAttribute string
+	GroupAdminAttribute  string
 }
 
 //LDAPDriver is the LDAP client interface
@@ -309,10 +315,18 @@ func (c *LDAPClient) Authentify(username, password string) (bool, error) {
 	log.Debug("LDAP> Bind sucessfull %s", username)
 
 	//Search user, refresh data and update database
-	if _, err := c.searchAndInsertOrUpdateUser(username); err != nil {
+	u, err := c.searchAndInsertOrUpdateUser(username)
+	if err != nil {
 		return false, err
 	}
 
+	//Sync user groups, the login doesn't fail if LDAP groups can't be read
+	if c.GroupSyncEnabled() && u.Origin == "ldap" {
+		if err := c.syncUserGroups(database.DBMap(database.DB()), username); err != nil {
+			log.Warning("LDAP> Cannot sync groups of %s: %s", username, err)
+		}
+	}
+
 	return true, nil
 }
 
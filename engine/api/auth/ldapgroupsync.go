@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"gopkg.in/ldap.v2"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+//ldapGroup is a LDAP group with its members usernames, mapped to their admin flag
+type ldapGroup struct {
+	Name    string          `json:"name"`
+	Members map[string]bool `json:"members"`
+}
+
+//GroupSyncEnabled returns true if CDS groups are synced from LDAP groups
+func (c *LDAPClient) GroupSyncEnabled() bool {
+	return c.conf.GroupFilter != "" || len(c.conf.GroupDNs) > 0
+}
+
+//searchGroups loads the synced LDAP groups, from cache unless fresh is true
+func (c *LDAPClient) searchGroups(fresh bool) ([]ldapGroup, error) {
+	groups := []ldapGroup{}
+	key := cache.Key("ldap", "groups")
+	if !fresh && cache.Get(key, &groups) && len(groups) > 0 {
+		return groups, nil
+	}
+
+	attributes := []string{c.groupNameAttribute(), c.groupMemberAttribute()}
+	if c.conf.GroupAdminAttribute != "" {
+		attributes = append(attributes, c.conf.GroupAdminAttribute)
+	}
+
+	var requests []*ldap.SearchRequest
+	if c.conf.GroupFilter != "" {
+		requests = append(requests, ldap.NewSearchRequest(
+			c.conf.Base,
+			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			c.conf.GroupFilter,
+			attributes,
+			nil,
+		))
+	}
+	for _, dn := range c.conf.GroupDNs {
+		requests = append(requests, ldap.NewSearchRequest(
+			strings.Replace(dn, "{{.ldap-base}}", c.conf.Base, -1),
+			ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+			"(objectClass=*)",
+			attributes,
+			nil,
+		))
+	}
+
+	var entries []*ldap.Entry
+	for _, req := range requests {
+		sr, err := c.conn.Search(req)
+		if shoudRetry(err) {
+			if err = c.openLDAP(c.conf); err != nil {
+				return nil, err
+			}
+			sr, err = c.conn.Search(req)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot search groups in %s: %s", req.BaseDN, err)
+		}
+		entries = append(entries, sr.Entries...)
+	}
+
+	groups = c.ldapGroups(entries)
+	//Put ldap groups in cache for 5 minutes to avoid LDAP flood at login
+	cache.SetWithTTL(key, groups, 300)
+	return groups, nil
+}
+
+func (c *LDAPClient) groupNameAttribute() string {
+	if c.conf.GroupNameAttribute == "" {
+		return "cn"
+	}
+	return c.conf.GroupNameAttribute
+}
+
+func (c *LDAPClient) groupMemberAttribute() string {
+	if c.conf.GroupMemberAttribute == "" {
+		return "member"
+	}
+	return c.conf.GroupMemberAttribute
+}
+
+//ldapGroups maps LDAP entries to groups. Admins are members too
+func (c *LDAPClient) ldapGroups(entries []*ldap.Entry) []ldapGroup {
+	groups := []ldapGroup{}
+	for _, e := range entries {
+		g := ldapGroup{
+			Name:    e.GetAttributeValue(c.groupNameAttribute()),
+			Members: map[string]bool{},
+		}
+		if g.Name == "" {
+			log.Warning("LDAP> Ignoring group %s: no %s attribute", e.DN, c.groupNameAttribute())
+			continue
+		}
+		for _, m := range e.GetAttributeValues(c.groupMemberAttribute()) {
+			if u := ldapUsername(m); u != "" {
+				g.Members[u] = false
+			}
+		}
+		if c.conf.GroupAdminAttribute != "" {
+			for _, m := range e.GetAttributeValues(c.conf.GroupAdminAttribute) {
+				if u := ldapUsername(m); u != "" {
+					g.Members[u] = true
+				}
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+//ldapUsername returns the username of a group member, given either as a DN such as uid=jdoe,ou=people,dc=example,dc=com or as a uid
+func ldapUsername(member string) string {
+	member = strings.TrimSpace(member)
+	if !strings.Contains(member, "=") {
+		return member
+	}
+	rdn := strings.SplitN(member, ",", 2)[0]
+	kv := strings.SplitN(rdn, "=", 2)
+	return strings.TrimSpace(kv[1])
+}
+
+//SyncGroups computes the changes making the members and admins of CDS groups match the LDAP groups,
+//and applies them unless dryRun is true. Memberships granted from CDS are never changed
+func (c *LDAPClient) SyncGroups(db gorp.SqlExecutor, trigger string, dryRun bool) (*sdk.GroupSyncLog, error) {
+	l := &sdk.GroupSyncLog{
+		Origin:  "ldap",
+		Trigger: trigger,
+		DryRun:  dryRun,
+		Started: time.Now(),
+		Changes: []sdk.GroupSyncChange{},
+	}
+
+	groups, err := c.searchGroups(true)
+	if err != nil {
+		return l, err
+	}
+
+	synced := map[string]bool{}
+	for _, g := range groups {
+		changes, err := group.PlanGroupSync(db, g.Name, "ldap", g.Members)
+		if err != nil {
+			return l, err
+		}
+		l.Changes = append(l.Changes, changes...)
+		synced[g.Name] = true
+	}
+
+	//Without any LDAP group, the search is more likely wrong than all groups deleted: memberships are kept
+	if len(groups) > 0 {
+		changes, err := group.PlanStaleMembershipsRemoval(db, "ldap", synced)
+		if err != nil {
+			return l, err
+		}
+		l.Changes = append(l.Changes, changes...)
+	}
+
+	if dryRun {
+		return l, nil
+	}
+	return l, group.ApplySyncChanges(db, "ldap", l.Changes)
+}
+
+//RunGroupSync runs a group sync in a transaction and records its log, even if it failed
+func (c *LDAPClient) RunGroupSync(db *gorp.DbMap, trigger string, dryRun bool) (*sdk.GroupSyncLog, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	l, errSync := c.SyncGroups(tx, trigger, dryRun)
+	if errSync == nil {
+		errSync = tx.Commit()
+	}
+	if errSync != nil {
+		log.Warning("LDAP> Group sync failed: %s", errSync)
+		l.Error = errSync.Error()
+	}
+
+	if err := group.InsertSyncLog(db, l); err != nil {
+		log.Warning("LDAP> Cannot record group sync: %s", err)
+	}
+	return l, errSync
+}
+
+//GroupSyncRoutine syncs groups from LDAP periodically
+func (c *LDAPClient) GroupSyncRoutine(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		db := database.DBMap(database.DB())
+		if db == nil {
+			continue
+		}
+		c.RunGroupSync(db, sdk.GroupSyncPeriodic, false)
+	}
+}
+
+//syncUserGroups syncs the groups of a user logging in
+func (c *LDAPClient) syncUserGroups(db gorp.SqlExecutor, username string) error {
+	groups, err := c.searchGroups(false)
+	if err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, g := range groups {
+		if admin, ok := g.Members[username]; ok {
+			wanted[g.Name] = admin
+		}
+	}
+
+	l := &sdk.GroupSyncLog{Origin: "ldap", Trigger: sdk.GroupSyncLogin, Started: time.Now()}
+	l.Changes, err = group.PlanUserSync(db, username, "ldap", wanted, true)
+	if err != nil {
+		return err
+	}
+	if len(l.Changes) == 0 {
+		return nil
+	}
+	if err := group.ApplySyncChanges(db, "ldap", l.Changes); err != nil {
+		return err
+	}
+	return group.InsertSyncLog(db, l)
+}
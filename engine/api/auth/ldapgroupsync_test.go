@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ldap.v2"
+)
+
+func TestLDAPUsername(t *testing.T) {
+	assert.Equal(t, "jdoe", ldapUsername("uid=jdoe,ou=people,dc=example,dc=com"))
+	assert.Equal(t, "jdoe", ldapUsername("UID = jdoe , ou=people"))
+	assert.Equal(t, "jdoe", ldapUsername(" jdoe "))
+	assert.Equal(t, "", ldapUsername(""))
+}
+
+func TestLDAPGroups(t *testing.T) {
+	c := &LDAPClient{conf: LDAPConfig{GroupAdminAttribute: "owner"}}
+	groups := c.ldapGroups([]*ldap.Entry{
+		ldap.NewEntry("cn=cds-team,ou=groups,dc=example,dc=com", map[string][]string{
+			"cn":     {"cds-team"},
+			"member": {"uid=jdoe,ou=people,dc=example,dc=com", "uid=asmith,ou=people,dc=example,dc=com"},
+			"owner":  {"uid=asmith,ou=people,dc=example,dc=com", "uid=boss,ou=people,dc=example,dc=com"},
+		}),
+		ldap.NewEntry("ou=nameless,dc=example,dc=com", map[string][]string{
+			"member": {"uid=jdoe,ou=people,dc=example,dc=com"},
+		}),
+	})
+
+	assert.Len(t, groups, 1)
+	assert.Equal(t, "cds-team", groups[0].Name)
+	assert.Equal(t, map[string]bool{"jdoe": false, "asmith": true, "boss": true}, groups[0].Members)
+
+	c = &LDAPClient{conf: LDAPConfig{GroupMemberAttribute: "memberUid"}}
+	groups = c.ldapGroups([]*ldap.Entry{
+		ldap.NewEntry("cn=ops,ou=groups,dc=example,dc=com", map[string][]string{
+			"cn":        {"ops"},
+			"memberUid": {"jdoe"},
+		}),
+	})
+	assert.Equal(t, map[string]bool{"jdoe": false}, groups[0].Members)
+}
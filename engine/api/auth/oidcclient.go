@@ -0,0 +1,584 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/sessionstore"
+	"github.com/ovh/cds/engine/api/user"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+//ErrAuthorizationPending is returned while the user has not approved a device authorization yet
+var ErrAuthorizationPending = errors.New("authorization pending")
+
+//OIDCConfig handles all config to connect to an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        []string
+	UsernameClaim string
+	FullnameClaim string
+	EmailClaim    string
+	GroupsClaim   string
+}
+
+//OIDCClient authenticates users on an OpenID Connect provider, with the authorization code flow for the UI
+//and the device flow for the CLI. Local users still log in with their password.
+type OIDCClient struct {
+	store    sessionstore.Store
+	conf     OIDCConfig
+	local    *LocalClient
+	provider oidcProvider
+	client   *http.Client
+	mutex    sync.Mutex
+	keys     map[string]*rsa.PublicKey
+}
+
+type oidcProvider struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	JWKSURI                     string `json:"jwks_uri"`
+}
+
+type oidcAuthRequest struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type oidcClaims map[string]interface{}
+
+//Open fetches the provider configuration
+func (c *OIDCClient) Open(options interface{}, store sessionstore.Store) error {
+	log.Notice("Auth> Connecting to session store")
+	c.store = store
+	//OIDC Client needs a local client to check local users
+	c.local = &LocalClient{}
+	c.local.Open(options, store)
+
+	conf, ok := options.(OIDCConfig)
+	if !ok {
+		return fmt.Errorf("invalid OIDC configuration")
+	}
+	if conf.Issuer == "" || conf.ClientID == "" {
+		return fmt.Errorf("OIDC issuer and client id are mandatory")
+	}
+	var scopes []string
+	for _, s := range conf.Scopes {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	conf.Scopes = scopes
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "profile", "email"}
+	}
+	if conf.UsernameClaim == "" {
+		conf.UsernameClaim = "preferred_username"
+	}
+	if conf.FullnameClaim == "" {
+		conf.FullnameClaim = "name"
+	}
+	if conf.EmailClaim == "" {
+		conf.EmailClaim = "email"
+	}
+	c.conf = conf
+	c.client = &http.Client{Timeout: 10 * time.Second}
+	c.keys = map[string]*rsa.PublicKey{}
+
+	return c.discover()
+}
+
+func (c *OIDCClient) discover() error {
+	u := strings.TrimSuffix(c.conf.Issuer, "/") + "/.well-known/openid-configuration"
+	log.Notice("Auth> Loading OpenID Connect configuration from %s", u)
+	resp, err := c.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("cannot load %s: HTTP %d", u, resp.StatusCode)
+	}
+
+	var p oidcProvider
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return err
+	}
+	if p.Issuer != c.conf.Issuer {
+		return fmt.Errorf("provider issuer %s does not match %s", p.Issuer, c.conf.Issuer)
+	}
+	c.provider = p
+	return nil
+}
+
+//Store returns store
+func (c *OIDCClient) Store() sessionstore.Store {
+	return c.store
+}
+
+//Authentify check username and password of local users
+func (c *OIDCClient) Authentify(username, password string) (bool, error) {
+	return c.local.Authentify(username, password)
+}
+
+//AuthentifyUser check password in database
+func (c *OIDCClient) AuthentifyUser(u *sdk.User, password string) (bool, error) {
+	return c.local.AuthentifyUser(u, password)
+}
+
+//GetCheckAuthHeaderFunc returns the func to heck http headers.
+//OIDC users have no password, so only sessions are checked
+func (c *OIDCClient) GetCheckAuthHeaderFunc(options interface{}) func(db *gorp.DbMap, headers http.Header, ctx *context.Context) error {
+	return c.local.GetCheckAuthHeaderFunc(LocalClientSessionMode)
+}
+
+//AuthorizeURL returns the provider page the user has to be redirected to, and its state.
+//The PKCE code verifier and the nonce are kept in cache until the user comes back
+func (c *OIDCClient) AuthorizeURL() (string, string, error) {
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	req := oidcAuthRequest{}
+	if req.Verifier, err = randomString(); err != nil {
+		return "", "", err
+	}
+	if req.Nonce, err = randomString(); err != nil {
+		return "", "", err
+	}
+	cache.SetWithTTL(cache.Key("auth", "oidc", state), req, 600)
+
+	challenge := sha256.Sum256([]byte(req.Verifier))
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", c.conf.ClientID)
+	v.Set("redirect_uri", c.conf.RedirectURL)
+	v.Set("scope", strings.Join(c.conf.Scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", req.Nonce)
+	v.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(c.provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.provider.AuthorizationEndpoint + sep + v.Encode(), state, nil
+}
+
+//Exchange exchanges the code sent back on the redirect URL, and returns the user it belongs to
+func (c *OIDCClient) Exchange(db gorp.SqlExecutor, state, code string) (*sdk.User, error) {
+	claims, err := c.exchange(state, code)
+	if err != nil {
+		return nil, err
+	}
+	return c.insertOrUpdateUser(db, claims)
+}
+
+func (c *OIDCClient) exchange(state, code string) (oidcClaims, error) {
+	key := cache.Key("auth", "oidc", state)
+	var req oidcAuthRequest
+	if state == "" || !cache.Get(key, &req) || req.Verifier == "" {
+		return nil, fmt.Errorf("unknown or expired state")
+	}
+	cache.Delete(key)
+
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", c.conf.RedirectURL)
+	v.Set("code_verifier", req.Verifier)
+	t, err := c.token(c.provider.TokenEndpoint, v)
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyIDToken(t.IDToken, req.Nonce)
+}
+
+//DeviceAuthorize starts a device authorization, the user approves it on the provider with the user code
+func (c *OIDCClient) DeviceAuthorize() (*sdk.OIDCDeviceAuthorization, error) {
+	if c.provider.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("provider does not support the device flow")
+	}
+
+	v := url.Values{}
+	v.Set("scope", strings.Join(c.conf.Scopes, " "))
+	body, code, err := c.postForm(c.provider.DeviceAuthorizationEndpoint, v)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("device authorization failed: HTTP %d", code)
+	}
+
+	a := &sdk.OIDCDeviceAuthorization{}
+	if err := json.Unmarshal(body, a); err != nil {
+		return nil, err
+	}
+	if a.Interval == 0 {
+		a.Interval = 5
+	}
+	return a, nil
+}
+
+//PollDeviceToken returns the user of an approved device authorization, ErrAuthorizationPending until the user approves it
+func (c *OIDCClient) PollDeviceToken(db gorp.SqlExecutor, deviceCode string) (*sdk.User, error) {
+	claims, err := c.pollDeviceToken(deviceCode)
+	if err != nil {
+		return nil, err
+	}
+	return c.insertOrUpdateUser(db, claims)
+}
+
+func (c *OIDCClient) pollDeviceToken(deviceCode string) (oidcClaims, error) {
+	v := url.Values{}
+	v.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+	v.Set("device_code", deviceCode)
+	t, err := c.token(c.provider.TokenEndpoint, v)
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyIDToken(t.IDToken, "")
+}
+
+func (c *OIDCClient) postForm(endpoint string, v url.Values) ([]byte, int, error) {
+	v.Set("client_id", c.conf.ClientID)
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.conf.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.conf.ClientID), url.QueryEscape(c.conf.ClientSecret))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
+
+func (c *OIDCClient) token(endpoint string, v url.Values) (*oidcTokenResponse, error) {
+	body, code, err := c.postForm(endpoint, v)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &oidcTokenResponse{}
+	if err := json.Unmarshal(body, t); err != nil {
+		return nil, fmt.Errorf("invalid token response (HTTP %d): %s", code, err)
+	}
+	switch t.Error {
+	case "":
+	case "authorization_pending", "slow_down":
+		return nil, ErrAuthorizationPending
+	default:
+		return nil, fmt.Errorf("token request failed: %s %s", t.Error, t.ErrorDescription)
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("token request failed: HTTP %d", code)
+	}
+	if t.IDToken == "" {
+		return nil, fmt.Errorf("no id_token in token response")
+	}
+	return t, nil
+}
+
+//verifyIDToken checks the signature of the ID token with the provider keys, its issuer, audience, expiry and nonce
+func (c *OIDCClient) verifyIDToken(raw, nonce string) (oidcClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	var hash crypto.Hash
+	switch header.Alg {
+	case "RS256":
+		hash = crypto.SHA256
+	case "RS384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("unsupported id_token algorithm %s", header.Alg)
+	}
+
+	key, err := c.publicKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), sig); err != nil {
+		return nil, fmt.Errorf("invalid id_token signature")
+	}
+
+	claims := oidcClaims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.String("iss") != c.provider.Issuer {
+		return nil, fmt.Errorf("invalid id_token issuer %s", claims.String("iss"))
+	}
+	audience := false
+	for _, a := range claims.Strings("aud") {
+		if a == c.conf.ClientID {
+			audience = true
+		}
+	}
+	if !audience {
+		return nil, fmt.Errorf("id_token not issued for %s", c.conf.ClientID)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().Add(-time.Minute).After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("id_token expired")
+	}
+	if nonce != "" && claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("invalid id_token nonce")
+	}
+	return claims, nil
+}
+
+//publicKey returns the provider key, the keys are reloaded when the provider rotates them
+func (c *OIDCClient) publicKey(kid string) (*rsa.PublicKey, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if k, ok := c.keys[kid]; ok {
+		return k, nil
+	}
+
+	resp, err := c.client.Get(c.provider.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("cannot load provider keys: HTTP %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			log.Warning("Auth> Ignoring invalid provider key %s", k.Kid)
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	c.keys = keys
+
+	if k, ok := c.keys[kid]; ok {
+		return k, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown provider key %s", kid)
+}
+
+//claimsUser maps the ID token claims to a user and the groups given by the provider
+func (c *OIDCClient) claimsUser(claims oidcClaims) (*sdk.User, []string, error) {
+	username := claims.String(c.conf.UsernameClaim)
+	if username == "" {
+		return nil, nil, fmt.Errorf("no %s claim in id_token", c.conf.UsernameClaim)
+	}
+	u := &sdk.User{
+		Username: username,
+		Fullname: claims.String(c.conf.FullnameClaim),
+		Email:    claims.String(c.conf.EmailClaim),
+		Origin:   "oidc",
+	}
+	if u.Fullname == "" {
+		u.Fullname = username
+	}
+
+	var groups []string
+	if c.conf.GroupsClaim != "" {
+		//Some providers give groups as paths such as /team
+		for _, g := range claims.Strings(c.conf.GroupsClaim) {
+			groups = append(groups, strings.TrimPrefix(g, "/"))
+		}
+	}
+	return u, groups, nil
+}
+
+//externalID identifies the user on the provider. Usernames can be changed on most providers, so they can't identify accounts
+func (c *OIDCClient) externalID(claims oidcClaims) (string, error) {
+	sub := claims.String("sub")
+	if sub == "" {
+		return "", fmt.Errorf("no sub claim in id_token")
+	}
+	return claims.String("iss") + "|" + sub, nil
+}
+
+func (c *OIDCClient) insertOrUpdateUser(db gorp.SqlExecutor, claims oidcClaims) (*sdk.User, error) {
+	externalID, err := c.externalID(claims)
+	if err != nil {
+		return nil, err
+	}
+	claimed, groups, err := c.claimsUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := user.LoadUserAndAuthByExternalID(db, externalID)
+	if err == sql.ErrNoRows {
+		//The username claim is only the username of new users, it never gives access to an existing account
+		if _, err := user.FindUserIDByName(db, claimed.Username); err == nil {
+			log.Warning("OIDC> Username %s of %s is already taken", claimed.Username, externalID)
+			return nil, sdk.ErrUserConflict
+		} else if err != sql.ErrNoRows {
+			log.Warning("OIDC> Cannot load user %s: %s", claimed.Username, err)
+			return nil, err
+		}
+
+		u = claimed
+		a := &sdk.Auth{
+			EmailVerified: true,
+		}
+		if err := user.InsertUser(db, u, a); err != nil {
+			log.Critical("OIDC> Error inserting user %s: %s", u.Username, err)
+			return nil, err
+		}
+		if err := user.UpdateUserExternalID(db, u.ID, externalID); err != nil {
+			log.Critical("OIDC> Error linking user %s to %s: %s", u.Username, externalID, err)
+			return nil, err
+		}
+		u.Auth = *a
+	} else if err != nil {
+		log.Warning("OIDC> Cannot load user %s: %s", externalID, err)
+		return nil, err
+	} else {
+		u.Fullname = claimed.Fullname
+		u.Email = claimed.Email
+		if err := user.UpdateUser(db, *u); err != nil {
+			log.Critical("OIDC> Unable to update user %s : %s", u.Username, err)
+			return nil, err
+		}
+	}
+
+	if c.conf.GroupsClaim != "" {
+		started := time.Now()
+		changes, err := group.SyncUserGroups(db, u.Username, "oidc", groups)
+		if err != nil {
+			log.Warning("OIDC> Cannot sync groups of %s: %s", u.Username, err)
+			return nil, err
+		}
+		if len(changes) > 0 {
+			l := &sdk.GroupSyncLog{Origin: "oidc", Trigger: sdk.GroupSyncLogin, Started: started, Changes: changes}
+			if err := group.InsertSyncLog(db, l); err != nil {
+				log.Warning("OIDC> Cannot record groups sync of %s: %s", u.Username, err)
+			}
+		}
+	}
+	return u, nil
+}
+
+//String returns a string claim
+func (c oidcClaims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+//Strings returns a claim which is either a string or a list of strings
+func (c oidcClaims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var s []string
+		for _, i := range v {
+			if str, ok := i.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
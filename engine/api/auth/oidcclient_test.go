@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/sessionstore"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/sdk"
+)
+
+// mockIdP is a minimal OpenID Connect provider supporting the authorization code flow with PKCE and the device flow
+type mockIdP struct {
+	*httptest.Server
+	key        *rsa.PrivateKey
+	mutex      sync.Mutex
+	challenges map[string]string
+	nonces     map[string]string
+	approved   bool
+	claims     map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{
+		key:        key,
+		challenges: map[string]string{},
+		nonces:     map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        idp.URL,
+			"authorization_endpoint":        idp.URL + "/authorize",
+			"token_endpoint":                idp.URL + "/token",
+			"device_authorization_endpoint": idp.URL + "/device",
+			"jwks_uri":                      idp.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": idp.URL + "/activate",
+			"expires_in":       600,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mutex.Lock()
+		defer idp.mutex.Unlock()
+
+		if r.Form.Get("client_id") != "cds" {
+			tokenError(w, "invalid_client")
+			return
+		}
+
+		var nonce string
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			code := r.Form.Get("code")
+			challenge, ok := idp.challenges[code]
+			if !ok {
+				tokenError(w, "invalid_grant")
+				return
+			}
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				tokenError(w, "invalid_grant")
+				return
+			}
+			delete(idp.challenges, code)
+			nonce = idp.nonces[code]
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			if r.Form.Get("device_code") != "device-code" {
+				tokenError(w, "invalid_grant")
+				return
+			}
+			if !idp.approved {
+				tokenError(w, "authorization_pending")
+				return
+			}
+		default:
+			tokenError(w, "unsupported_grant_type")
+			return
+		}
+
+		claims := map[string]interface{}{
+			"iss": idp.URL,
+			"aud": "cds",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if nonce != "" {
+			claims["nonce"] = nonce
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, idp.key, claims),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	return idp
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+// authorize simulates the user logging in on the provider authorization page, it returns the code sent to the redirect URL
+func (idp *mockIdP) authorize(t *testing.T, authorizeURL string) (string, url.Values) {
+	u, err := url.Parse(authorizeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+	idp.challenges["auth-code"] = q.Get("code_challenge")
+	idp.nonces["auth-code"] = q.Get("nonce")
+	return "auth-code", q
+}
+
+func (idp *mockIdP) sign(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func testOIDCClient(t *testing.T, idp *mockIdP) *OIDCClient {
+	cache.Initialize("local", "", "", 60)
+	store, err := sessionstore.Get("local", "", "", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &OIDCClient{}
+	err = c.Open(OIDCConfig{
+		Issuer:      idp.URL,
+		ClientID:    "cds",
+		RedirectURL: "http://cds.local/oidc/callback",
+		GroupsClaim: "groups",
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	defer idp.Close()
+	idp.claims = map[string]interface{}{
+		"sub":                "1234",
+		"preferred_username": "jdoe",
+		"name":               "John Doe",
+		"email":              "jdoe@example.com",
+		"groups":             []string{"/team-a", "team-b"},
+	}
+	c := testOIDCClient(t, idp)
+
+	authorizeURL, state, err := c.AuthorizeURL()
+	assert.NoError(t, err)
+	code, q := idp.authorize(t, authorizeURL)
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "cds", q.Get("client_id"))
+	assert.Equal(t, "http://cds.local/oidc/callback", q.Get("redirect_uri"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, state, q.Get("state"))
+
+	_, err = c.exchange("forged-state", code)
+	assert.Error(t, err)
+
+	claims, err := c.exchange(state, code)
+	assert.NoError(t, err)
+
+	u, groups, err := c.claimsUser(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, "jdoe", u.Username)
+	assert.Equal(t, "John Doe", u.Fullname)
+	assert.Equal(t, "jdoe@example.com", u.Email)
+	assert.Equal(t, "oidc", u.Origin)
+	assert.Equal(t, []string{"team-a", "team-b"}, groups)
+
+	externalID, err := c.externalID(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, idp.URL+"|1234", externalID)
+	delete(claims, "sub")
+	_, err = c.externalID(claims)
+	assert.Error(t, err)
+
+	// A state can only be used once
+	_, err = c.exchange(state, code)
+	assert.Error(t, err)
+}
+
+func TestOIDCDeviceFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	defer idp.Close()
+	idp.claims = map[string]interface{}{"sub": "1234", "preferred_username": "jdoe"}
+	c := testOIDCClient(t, idp)
+
+	a, err := c.DeviceAuthorize()
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCD-EFGH", a.UserCode)
+	assert.Equal(t, int64(5), a.Interval)
+
+	_, err = c.pollDeviceToken(a.DeviceCode)
+	assert.Equal(t, ErrAuthorizationPending, err)
+
+	idp.mutex.Lock()
+	idp.approved = true
+	idp.mutex.Unlock()
+
+	claims, err := c.pollDeviceToken(a.DeviceCode)
+	assert.NoError(t, err)
+	u, groups, err := c.claimsUser(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, "jdoe", u.Username)
+	assert.Equal(t, "jdoe", u.Fullname)
+	assert.Len(t, groups, 0)
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	idp := newMockIdP(t)
+	defer idp.Close()
+	c := testOIDCClient(t, idp)
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   idp.URL,
+			"aud":   []string{"other", "cds"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	_, err := c.verifyIDToken(idp.sign(t, idp.key, valid()), "nonce")
+	assert.NoError(t, err)
+
+	_, err = c.verifyIDToken(idp.sign(t, idp.key, valid()), "other-nonce")
+	assert.Error(t, err, "wrong nonce")
+
+	claims := valid()
+	claims["aud"] = "other"
+	_, err = c.verifyIDToken(idp.sign(t, idp.key, claims), "")
+	assert.Error(t, err, "wrong audience")
+
+	claims = valid()
+	claims["iss"] = "http://evil.local"
+	_, err = c.verifyIDToken(idp.sign(t, idp.key, claims), "")
+	assert.Error(t, err, "wrong issuer")
+
+	claims = valid()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = c.verifyIDToken(idp.sign(t, idp.key, claims), "")
+	assert.Error(t, err, "expired")
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	_, err = c.verifyIDToken(idp.sign(t, other, valid()), "")
+	assert.Error(t, err, "wrong signature")
+
+	_, err = c.verifyIDToken("not.a-token", "")
+	assert.Error(t, err)
+}
+
+func TestOIDCInsertOrUpdateUser(t *testing.T) {
+	db := test.SetupPG(t)
+	c := &OIDCClient{conf: OIDCConfig{UsernameClaim: "preferred_username", FullnameClaim: "name", EmailClaim: "email"}}
+	issuer := "http://idp.local"
+	username := test.RandomString(t, 10)
+	sub := test.RandomString(t, 10)
+
+	u, err := c.insertOrUpdateUser(db, oidcClaims{"iss": issuer, "sub": sub, "preferred_username": username, "name": "John Doe"})
+	test.NoError(t, err)
+	assert.Equal(t, username, u.Username)
+	assert.Equal(t, "oidc", u.Origin)
+
+	// The account is found by its subject, even when the user changes its username on the provider
+	renamed, err := c.insertOrUpdateUser(db, oidcClaims{"iss": issuer, "sub": sub, "preferred_username": "renamed", "name": "John Smith"})
+	test.NoError(t, err)
+	assert.Equal(t, u.ID, renamed.ID)
+	assert.Equal(t, username, renamed.Username)
+	assert.Equal(t, "John Smith", renamed.Fullname)
+
+	// Another subject claiming the same username can't take the account over
+	_, err = c.insertOrUpdateUser(db, oidcClaims{"iss": issuer, "sub": "other-" + sub, "preferred_username": username})
+	assert.Equal(t, sdk.ErrUserConflict, err)
+	_, err = c.insertOrUpdateUser(db, oidcClaims{"iss": "http://evil.local", "sub": sub, "preferred_username": username})
+	assert.Equal(t, sdk.ErrUserConflict, err)
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-gorp/gorp"
 	"github.com/gorilla/mux"
@@ -77,6 +78,94 @@ func getPipelineBuildTriggeredHandler(w http.ResponseWriter, r *http.Request, db
 
 }
 
+func getPipelineBuildWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	pipelineName := vars["permPipelineKey"]
+	buildNumberS := vars["build"]
+	appName := vars["permApplicationName"]
+
+	envName := r.FormValue("envName")
+
+	buildNumber, err := strconv.ParseInt(buildNumberS, 10, 64)
+	if err != nil {
+		log.Warning("getPipelineBuildWorkflowHandler> BuildNumber %s is not an integer: %s\n", buildNumberS, err)
+		WriteError(w, r, sdk.ErrInvalidID)
+		return
+	}
+
+	p, err := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
+	if err != nil {
+		log.Warning("getPipelineBuildWorkflowHandler> Cannot load pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, sdk.ErrPipelineNotFound)
+		return
+	}
+
+	a, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getPipelineBuildWorkflowHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, sdk.ErrApplicationNotFound)
+		return
+	}
+
+	env := &sdk.DefaultEnv
+	if envName != sdk.DefaultEnv.Name && envName != "" {
+		env, err = environment.LoadEnvironmentByName(db, projectKey, envName)
+		if err != nil {
+			log.Warning("getPipelineBuildWorkflowHandler> Cannot load environment %s: %s\n", envName, err)
+			WriteError(w, r, sdk.ErrNoEnvironment)
+			return
+		}
+	}
+
+	pbID, err := pipeline.LoadPipelineBuildID(db, a.ID, p.ID, env.ID, buildNumber)
+	if err != nil {
+		log.Warning("getPipelineBuildWorkflowHandler> Cannot load pipeline build: %s\n", err)
+		WriteError(w, r, sdk.ErrNoPipelineBuild)
+		return
+	}
+
+	rootID, err := pipeline.LoadWorkflowRunRootID(db, pbID)
+	if err != nil {
+		log.Warning("getPipelineBuildWorkflowHandler> Cannot load root pipeline build of %d: %s\n", pbID, err)
+		WriteError(w, r, sdk.ErrNoPipelineBuild)
+		return
+	}
+
+	run, err := pipeline.LoadWorkflowRun(db, rootID)
+	if err != nil {
+		log.Warning("getPipelineBuildWorkflowHandler> Cannot load workflow run %d: %s\n", rootID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, filterWorkflowRun(run, c.User), http.StatusOK)
+}
+
+// filterWorkflowRun removes from the workflow run the pipeline builds of applications the user cannot read
+func filterWorkflowRun(run *sdk.WorkflowRun, u *sdk.User) *sdk.WorkflowRun {
+	hidden := map[int64]bool{}
+	nodes := []sdk.WorkflowRunNode{}
+	for _, n := range run.Nodes {
+		if !permission.AccessToApplication(n.ApplicationID, u, permission.PermissionRead) {
+			hidden[n.ID] = true
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+
+	edges := []sdk.WorkflowRunEdge{}
+	for _, e := range run.Edges {
+		if hidden[e.From] || hidden[e.To] {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	run.Nodes = nodes
+	run.Edges = edges
+	return run
+}
+
 func deleteBuildHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
 	vars := mux.Vars(r)
 	projectKey := vars["key"]
@@ -305,6 +394,27 @@ func addQueueResultHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMa
 		// We want to update ActionBuild status anyway
 	}
 
+	// Restart once jobs failing only because of known flaky tests
+	if res.Status == sdk.StatusFail && pbJob.Retry < 1 {
+		retry, flaky, err := shouldRetryFlakyJob(tx, pbJob, res)
+		if err != nil {
+			log.Warning("addQueueResultHandler> Cannot check flaky tests of %d: %s\n", id, err)
+		}
+		if retry {
+			if err := retryFlakyJob(tx, pbJob, flaky); err != nil {
+				log.Warning("addQueueResultHandler> Cannot restart %d: %s\n", id, err)
+				WriteError(w, r, err)
+				return
+			}
+
+			if err := tx.Commit(); err != nil {
+				log.Warning("addQueueResultHandler> Cannot commit tx: %s\n", err)
+				WriteError(w, r, sdk.ErrUnknownError)
+			}
+			return
+		}
+	}
+
 	// Update action status
 	log.Debug("Updating %s to %s in queue\n", id, res.Status)
 	err = pipeline.UpdatePipelineBuildJobStatus(tx, pbJob, res.Status)
@@ -323,6 +433,63 @@ func addQueueResultHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMa
 
 }
 
+// shouldRetryFlakyJob returns true when retry of flaky tests is enabled on the application pipeline,
+// the job failed on its test report step and all the tests failed by the job are known to be flaky on its branch
+func shouldRetryFlakyJob(db gorp.SqlExecutor, pbJob *sdk.PipelineBuildJob, res sdk.Result) (bool, []string, error) {
+	if res.FailedAction != sdk.JUnitAction && res.FailedAction != sdk.TestReportAction {
+		return false, nil, nil
+	}
+
+	pb, err := pipeline.LoadPipelineBuildByID(db, pbJob.PipelineBuildID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	opts, err := application.LoadPipelineOptions(db, pb.Application.ID, pb.Pipeline.ID)
+	if err != nil {
+		return false, nil, err
+	}
+	if !opts.RetryFlakyTests {
+		return false, nil, nil
+	}
+
+	failed, err := pipeline.LoadJobFailedTestCases(db, pbJob.ID)
+	if err != nil {
+		return false, nil, err
+	}
+	if len(failed) == 0 {
+		return false, nil, nil
+	}
+
+	flakyTests, err := pipeline.LoadFlakyTests(db, pb.Application.ID, pb.Trigger.VCSChangesBranch, 50, 2)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var failing []string
+	for _, t := range failed {
+		if !pipeline.IsFlaky(flakyTests, pb.Pipeline.ID, pb.Environment.ID, pb.Trigger.VCSChangesBranch, t.Suite, t.Name) {
+			return false, nil, nil
+		}
+		failing = append(failing, t.Suite+"/"+t.Name)
+	}
+	return true, failing, nil
+}
+
+// retryFlakyJob queues up again a job which failed because of flaky tests
+func retryFlakyJob(db gorp.SqlExecutor, pbJob *sdk.PipelineBuildJob, flaky []string) error {
+	if err := pipeline.RestartPipelineBuildJob(db, pbJob.ID); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`UPDATE pipeline_build_job SET retry = retry + 1 WHERE id = $1`, pbJob.ID); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Job restarted: failing tests are known to be flaky (%s)\n", strings.Join(flaky, ", "))
+	return pipeline.InsertLog(db, pbJob.ID, "SYSTEM", msg, pbJob.PipelineBuildID)
+}
+
 func takeActionBuildHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
 
 	// Get action name in URL
@@ -754,6 +921,13 @@ func addBuildTestResultsHandler(w http.ResponseWriter, r *http.Request, db *gorp
 	if err := pipeline.UpdateTestResults(db, pb.ID, tests); err != nil {
 		log.Warning("addBuildTestsResultsHandler> Cannot insert tests results: %s\n", err)
 		WriteError(w, r, err)
+		return
+	}
+
+	// Workers give the job reporting the tests, so the flaky tests it fails on can be retried
+	pbJobID, _ := strconv.ParseInt(r.FormValue("jobID"), 10, 64)
+	if err := pipeline.InsertTestCases(db, pb, pbJobID, new); err != nil {
+		log.Warning("addBuildTestsResultsHandler> Cannot index test cases: %s\n", err)
 	}
 
 	stats.TestEvent(db, p.ProjectID, a.ID, tests)
@@ -841,3 +1015,43 @@ func getBuildTestResultsHandler(w http.ResponseWriter, r *http.Request, db *gorp
 
 	WriteJSON(w, r, tests, http.StatusOK)
 }
+
+func getApplicationFlakyTestsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+
+	builds, minFlips := 50, 2
+	if s := r.FormValue("builds"); s != "" {
+		var err error
+		builds, err = strconv.Atoi(s)
+		if err != nil || builds <= 0 {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+	if s := r.FormValue("flips"); s != "" {
+		var err error
+		minFlips, err = strconv.Atoi(s)
+		if err != nil || minFlips < 0 {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	a, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getApplicationFlakyTestsHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	flaky, err := pipeline.LoadFlakyTests(db, a.ID, r.FormValue("branch"), builds, minFlips)
+	if err != nil {
+		log.Warning("getApplicationFlakyTestsHandler> Cannot compute flaky tests: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, flaky, http.StatusOK)
+}
@@ -9,4 +9,6 @@ type Context struct {
 	Agent  sdk.Agent
 	User   *sdk.User
 	Worker sdk.Worker
+	// AccessToken is set when the call is authenticated with a personal access token
+	AccessToken *sdk.AccessToken
 }
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// loadCoveragePipelineBuild loads the pipeline build targeted by coverage handlers
+func loadCoveragePipelineBuild(r *http.Request, db gorp.SqlExecutor, c *context.Context, perm int) (*sdk.PipelineBuild, error) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	pipelineName := vars["permPipelineKey"]
+	buildNumberS := vars["build"]
+	appName := vars["app"]
+
+	env := &sdk.DefaultEnv
+	envName := r.FormValue("envName")
+	if envName != "" && envName != sdk.DefaultEnv.Name {
+		var err error
+		env, err = environment.LoadEnvironmentByName(db, projectKey, envName)
+		if err != nil {
+			log.Warning("loadCoveragePipelineBuild> Cannot load environment %s: %s\n", envName, err)
+			return nil, sdk.ErrUnknownEnv
+		}
+	}
+
+	if env.ID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(env.ID, c.User, perm) {
+		log.Warning("loadCoveragePipelineBuild> No enought right on this environment %s: \n", envName)
+		return nil, sdk.ErrForbidden
+	}
+
+	p, err := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
+	if err != nil {
+		log.Warning("loadCoveragePipelineBuild> Cannot load pipeline %s: %s\n", pipelineName, err)
+		return nil, err
+	}
+
+	a, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("loadCoveragePipelineBuild> Cannot load application %s: %s\n", appName, err)
+		return nil, err
+	}
+
+	var buildNumber int64
+	if buildNumberS == "last" {
+		buildNumber, err = pipeline.GetLastBuildNumber(db, p.ID, a.ID, env.ID)
+	} else {
+		buildNumber, err = strconv.ParseInt(buildNumberS, 10, 64)
+	}
+	if err != nil {
+		log.Warning("loadCoveragePipelineBuild> Cannot get build number %s: %s\n", buildNumberS, err)
+		return nil, sdk.ErrNoPipelineBuild
+	}
+
+	pb, err := pipeline.LoadPipelineBuildByApplicationPipelineEnvBuildNumber(db, a.ID, p.ID, env.ID, buildNumber)
+	if err != nil {
+		log.Warning("loadCoveragePipelineBuild> Cannot load pipeline build for %s/%s[%s] %d: %s\n", a.Name, p.Name, env.Name, buildNumber, err)
+		return nil, sdk.ErrNoPipelineBuild
+	}
+	return pb, nil
+}
+
+func addBuildCoverageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	pb, err := loadCoveragePipelineBuild(r, db, c, permission.PermissionReadExecute)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var new sdk.Coverage
+	if err := json.Unmarshal(data, &new); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot unmarshal coverage: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the build so coverage uploaded at the same time by other jobs is merged one at a time
+	if err := pipeline.SelectPipelineBuildForUpdate(tx, pb.ID); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot lock pipeline build %d: %s\n", pb.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	// Merge with coverage already uploaded by other jobs of the build
+	coverage, err := pipeline.LoadCoverage(tx, pb.ID, true)
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot load coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	if coverage == nil {
+		coverage = &sdk.Coverage{}
+	}
+	coverage.Format = new.Format
+	coverage.Add(new.Files...)
+
+	if err := pipeline.InsertCoverage(tx, pb, coverage); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot insert coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	coverage, err = pipeline.LoadCoverage(db, pb.ID, false)
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot load coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, coverage, http.StatusOK)
+}
+
+func getBuildCoverageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	pb, err := loadCoveragePipelineBuild(r, db, c, permission.PermissionRead)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	coverage, err := pipeline.LoadCoverage(db, pb.ID, true)
+	if err != nil {
+		log.Warning("getBuildCoverageHandler> Cannot load coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	if coverage == nil {
+		WriteError(w, r, sdk.ErrNotFound)
+		return
+	}
+
+	WriteJSON(w, r, coverage, http.StatusOK)
+}
+
+const maxCoverageHistory = 500
+
+func getApplicationCoverageHistoryHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+
+	limit := 50
+	if limitString := r.FormValue("limit"); limitString != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitString); err != nil || limit <= 0 {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+	if limit > maxCoverageHistory {
+		limit = maxCoverageHistory
+	}
+
+	a, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getApplicationCoverageHistoryHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cs, err := pipeline.LoadApplicationCoverageHistory(db, a.ID, r.FormValue("branch"), limit)
+	if err != nil {
+		log.Warning("getApplicationCoverageHistoryHandler> Cannot load coverage history: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, cs, http.StatusOK)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/deployment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/log"
+)
+
+func getPipelineBuildVerificationsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	pb, err := loadRequestPipelineBuild(db, r, c, permission.PermissionRead)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	verifications, err := deployment.LoadBuildVerifications(db, pb.ID)
+	if err != nil {
+		log.Warning("getPipelineBuildVerificationsHandler> Cannot load verifications of pipeline build %d: %s\n", pb.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, verifications, http.StatusOK)
+}
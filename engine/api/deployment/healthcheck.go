@@ -0,0 +1,194 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// MaxTimeout is the longest timeout of a health check
+const MaxTimeout = 30 * time.Second
+
+// HealthChecker verifies a deployment, it returns an error if the deployment is unhealthy
+type HealthChecker func(hc sdk.HealthCheck, pb *sdk.PipelineBuild) error
+
+var (
+	pluginsMutex sync.RWMutex
+	plugins      = map[string]HealthChecker{}
+)
+
+// RegisterPlugin makes a health checker available to stages with a plugin health check
+func RegisterPlugin(name string, c HealthChecker) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	plugins[name] = c
+}
+
+// Check runs the health check of a progressive deployment
+func Check(hc sdk.HealthCheck, pb *sdk.PipelineBuild) error {
+	switch hc.Type {
+	case sdk.HealthCheckHTTP:
+		return checkHTTP(hc, pb)
+	case sdk.HealthCheckMetric:
+		return checkMetric(hc, pb)
+	case sdk.HealthCheckPlugin:
+		pluginsMutex.RLock()
+		c, ok := plugins[hc.Plugin]
+		pluginsMutex.RUnlock()
+		if !ok {
+			return fmt.Errorf("health check plugin %s is not registered", hc.Plugin)
+		}
+		return c(hc, pb)
+	}
+	return fmt.Errorf("unknown health check type %s", hc.Type)
+}
+
+func client(hc sdk.HealthCheck) *http.Client {
+	timeout := defaultTimeout
+	if d, err := time.ParseDuration(hc.Timeout); err == nil && d > 0 && d <= MaxTimeout {
+		timeout = d
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// checkHTTP probes the health check url and expects the configured status code
+func checkHTTP(hc sdk.HealthCheck, pb *sdk.PipelineBuild) error {
+	expected := hc.ExpectedStatus
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+
+	resp, err := client(hc).Get(hc.URL)
+	if err != nil {
+		return fmt.Errorf("GET %s: %s", hc.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return fmt.Errorf("GET %s: HTTP %d, expected %d", hc.URL, resp.StatusCode, expected)
+	}
+	return nil
+}
+
+// checkMetric queries a metric endpoint and compares the returned value with the threshold.
+// The endpoint is called with the query in the "query" parameter and may answer either a plain number
+// or a Prometheus-like instant query result.
+func checkMetric(hc sdk.HealthCheck, pb *sdk.PipelineBuild) error {
+	u, err := url.Parse(hc.URL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("query", hc.Query)
+	u.RawQuery = q.Encode()
+
+	resp, err := client(hc).Get(u.String())
+	if err != nil {
+		return fmt.Errorf("GET %s: %s", hc.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("GET %s: HTTP %d", hc.URL, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	value, err := parseMetric(body)
+	if err != nil {
+		return fmt.Errorf("cannot read metric %s: %s", hc.Query, err)
+	}
+
+	ok, err := compare(value, hc.Operator, hc.Threshold)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s = %g, expected %s %g", hc.Query, value, operator(hc.Operator), hc.Threshold)
+	}
+	return nil
+}
+
+// parseMetric reads a plain number or the first value of a Prometheus instant query result
+func parseMetric(body []byte) (float64, error) {
+	if v, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64); err == nil {
+		return v, nil
+	}
+
+	var res struct {
+		Data struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, err
+	}
+
+	var value []interface{}
+	switch res.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(res.Data.Result, &value); err != nil {
+			return 0, err
+		}
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(res.Data.Result, &vector); err != nil {
+			return 0, err
+		}
+		if len(vector) == 0 {
+			return 0, fmt.Errorf("empty result")
+		}
+		value = vector[0].Value
+	default:
+		return 0, fmt.Errorf("unsupported result type %s", res.Data.ResultType)
+	}
+
+	if len(value) != 2 {
+		return 0, fmt.Errorf("invalid value")
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid value")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func operator(op string) string {
+	if op == "" {
+		return "<="
+	}
+	return op
+}
+
+// compare returns value <op> threshold, op defaults to <=
+func compare(value float64, op string, threshold float64) (bool, error) {
+	switch operator(op) {
+	case "<":
+		return value < threshold, nil
+	case "<=":
+		return value <= threshold, nil
+	case ">":
+		return value > threshold, nil
+	case ">=":
+		return value >= threshold, nil
+	case "==":
+		return value == threshold, nil
+	}
+	return false, fmt.Errorf("unknown operator %s", op)
+}
@@ -0,0 +1,71 @@
+package deployment
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestCheckHTTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	assert.NoError(t, Check(sdk.HealthCheck{Type: sdk.HealthCheckHTTP, URL: ts.URL + "/health"}, nil))
+	assert.Error(t, Check(sdk.HealthCheck{Type: sdk.HealthCheckHTTP, URL: ts.URL + "/down"}, nil))
+	assert.NoError(t, Check(sdk.HealthCheck{Type: sdk.HealthCheckHTTP, URL: ts.URL + "/down", ExpectedStatus: 503}, nil))
+}
+
+func TestCheckMetric(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("query") {
+		case "plain":
+			fmt.Fprint(w, "0.5\n")
+		case "vector":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1488362400,"3"]}]}}`)
+		case "scalar":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1488362400,"1.5"]}}`)
+		default:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		}
+	}))
+	defer ts.Close()
+
+	hc := sdk.HealthCheck{Type: sdk.HealthCheckMetric, URL: ts.URL, Query: "plain", Threshold: 1}
+	assert.NoError(t, Check(hc, nil))
+
+	hc.Query = "vector"
+	assert.Error(t, Check(hc, nil))
+	hc.Operator = ">"
+	assert.NoError(t, Check(hc, nil))
+
+	hc.Query = "scalar"
+	hc.Operator = ">="
+	assert.NoError(t, Check(hc, nil))
+
+	hc.Query = "empty"
+	assert.Error(t, Check(hc, nil))
+}
+
+func TestCheckPlugin(t *testing.T) {
+	hc := sdk.HealthCheck{Type: sdk.HealthCheckPlugin, Plugin: "test-smoke", Options: map[string]string{"fail": "true"}}
+	assert.Error(t, Check(hc, nil), "plugin not registered")
+
+	RegisterPlugin("test-smoke", func(hc sdk.HealthCheck, pb *sdk.PipelineBuild) error {
+		if hc.Options["fail"] == "true" {
+			return fmt.Errorf("smoke tests failed")
+		}
+		return nil
+	})
+	assert.Error(t, Check(hc, nil))
+	hc.Options["fail"] = "false"
+	assert.NoError(t, Check(hc, nil))
+}
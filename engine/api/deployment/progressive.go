@@ -0,0 +1,98 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Verification decisions
+const (
+	DecisionWait     = ""
+	DecisionPromote  = "promote"
+	DecisionRollback = "rollback"
+)
+
+const defaultInterval = 30 * time.Second
+
+// Validate checks the progressive deployment configuration of a stage
+func Validate(p *sdk.ProgressiveDeployment) error {
+	switch p.Strategy {
+	case sdk.DeploymentCanary:
+		if p.Weight <= 0 || p.Weight >= 100 {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("canary weight must be between 1 and 99"))
+		}
+	case sdk.DeploymentBlueGreen:
+	default:
+		return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("unknown strategy %s", p.Strategy))
+	}
+
+	if d, err := time.ParseDuration(p.VerificationWindow); err != nil || d <= 0 {
+		return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("invalid verification window %s", p.VerificationWindow))
+	}
+	if p.Interval != "" {
+		if d, err := time.ParseDuration(p.Interval); err != nil || d <= 0 {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("invalid interval %s", p.Interval))
+		}
+	}
+	if p.MaxFailures < 0 {
+		return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("max failures cannot be negative"))
+	}
+
+	hc := p.HealthCheck
+	switch hc.Type {
+	case sdk.HealthCheckHTTP:
+		if hc.URL == "" {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("http health check requires an url"))
+		}
+	case sdk.HealthCheckMetric:
+		if hc.URL == "" || hc.Query == "" {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("metric health check requires an url and a query"))
+		}
+		if _, err := compare(0, hc.Operator, hc.Threshold); err != nil {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, err)
+		}
+	case sdk.HealthCheckPlugin:
+		if hc.Plugin == "" {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("plugin health check requires a plugin name"))
+		}
+	default:
+		return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("unknown health check type %s", hc.Type))
+	}
+	if hc.Timeout != "" {
+		if d, err := time.ParseDuration(hc.Timeout); err != nil || d <= 0 || d > MaxTimeout {
+			return sdk.NewError(sdk.ErrInvalidProgressiveDeployment, fmt.Errorf("invalid health check timeout %s, maximum is %s", hc.Timeout, MaxTimeout))
+		}
+	}
+	return nil
+}
+
+// Due returns true if a new health check has to be run on the verification at t
+func Due(v *sdk.DeploymentVerification, p *sdk.ProgressiveDeployment, t time.Time) bool {
+	if v.LastCheck == nil {
+		return true
+	}
+	interval := defaultInterval
+	if d, err := time.ParseDuration(p.Interval); err == nil && d > 0 {
+		interval = d
+	}
+	return !t.Before(v.LastCheck.Add(interval))
+}
+
+// Evaluate decides whether a verification has to be rolled back, promoted, or continued at t.
+// A deployment is rolled back as soon as it has more failed checks than allowed,
+// and promoted once the verification window is over with at least one check done.
+func Evaluate(v *sdk.DeploymentVerification, p *sdk.ProgressiveDeployment, t time.Time) string {
+	if v.Failures > p.MaxFailures {
+		return DecisionRollback
+	}
+	window, err := time.ParseDuration(p.VerificationWindow)
+	if err != nil {
+		return DecisionRollback
+	}
+	if v.Checks > 0 && !t.Before(v.Started.Add(window)) {
+		return DecisionPromote
+	}
+	return DecisionWait
+}
@@ -0,0 +1,80 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestValidate(t *testing.T) {
+	canary := func() *sdk.ProgressiveDeployment {
+		return &sdk.ProgressiveDeployment{
+			Strategy:           sdk.DeploymentCanary,
+			Weight:             10,
+			VerificationWindow: "10m",
+			HealthCheck:        sdk.HealthCheck{Type: sdk.HealthCheckHTTP, URL: "http://canary/health"},
+		}
+	}
+
+	assert.NoError(t, Validate(canary()))
+
+	p := canary()
+	p.Weight = 100
+	assert.Error(t, Validate(p))
+
+	p = canary()
+	p.Strategy = "rolling"
+	assert.Error(t, Validate(p))
+
+	p = canary()
+	p.VerificationWindow = "soon"
+	assert.Error(t, Validate(p))
+
+	p = canary()
+	p.HealthCheck.Timeout = "5s"
+	assert.NoError(t, Validate(p))
+	p.HealthCheck.Timeout = "10m"
+	assert.Error(t, Validate(p), "health checks can't hold the scheduling for minutes")
+	p.HealthCheck.Timeout = "-1s"
+	assert.Error(t, Validate(p))
+
+	p = canary()
+	p.HealthCheck = sdk.HealthCheck{Type: sdk.HealthCheckMetric, URL: "http://prometheus/api/v1/query", Query: "errors", Operator: "<"}
+	assert.NoError(t, Validate(p))
+
+	p.HealthCheck.Operator = "~"
+	assert.Error(t, Validate(p))
+
+	p = canary()
+	p.Strategy = sdk.DeploymentBlueGreen
+	p.HealthCheck = sdk.HealthCheck{Type: sdk.HealthCheckPlugin}
+	assert.Error(t, Validate(p))
+
+	p.HealthCheck.Plugin = "smoke"
+	assert.NoError(t, Validate(p))
+}
+
+func TestEvaluate(t *testing.T) {
+	p := &sdk.ProgressiveDeployment{VerificationWindow: "10m", Interval: "1m", MaxFailures: 1}
+	start := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+	v := &sdk.DeploymentVerification{Started: start}
+
+	assert.True(t, Due(v, p, start))
+	assert.Equal(t, DecisionWait, Evaluate(v, p, start.Add(11*time.Minute)), "no check done yet")
+
+	last := start.Add(5 * time.Minute)
+	v.LastCheck = &last
+	v.Checks = 5
+	assert.False(t, Due(v, p, last.Add(30*time.Second)))
+	assert.True(t, Due(v, p, last.Add(time.Minute)))
+
+	v.Failures = 1
+	assert.Equal(t, DecisionWait, Evaluate(v, p, last))
+	assert.Equal(t, DecisionPromote, Evaluate(v, p, start.Add(10*time.Minute)))
+
+	v.Failures = 2
+	assert.Equal(t, DecisionRollback, Evaluate(v, p, last))
+}
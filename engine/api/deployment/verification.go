@@ -0,0 +1,184 @@
+package deployment
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const verificationFields = `id, pipeline_build_id, build_number, stage_id, stage_name, strategy, slot, status, started, last_check, ended,
+	checks, failures, last_result, reason, rollback_build_number`
+
+// InsertVerification starts the verification of a progressive deployment stage
+func InsertVerification(db gorp.SqlExecutor, pb *sdk.PipelineBuild, s *sdk.Stage, slot string) (*sdk.DeploymentVerification, error) {
+	v := &sdk.DeploymentVerification{
+		PipelineBuildID: pb.ID,
+		BuildNumber:     pb.BuildNumber,
+		StageID:         s.ID,
+		StageName:       s.Name,
+		Strategy:        s.Progressive.Strategy,
+		Slot:            slot,
+		Status:          sdk.VerificationRunning,
+		Started:         time.Now(),
+	}
+
+	query := `INSERT INTO pipeline_build_verification (pipeline_build_id, application_id, pipeline_id, environment_id, build_number,
+		stage_id, stage_name, strategy, slot, status, started)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	if err := db.QueryRow(query, pb.ID, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, pb.BuildNumber,
+		v.StageID, v.StageName, v.Strategy, v.Slot, v.Status, v.Started).Scan(&v.ID); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateVerification saves checks results and outcome of a verification
+func UpdateVerification(db gorp.SqlExecutor, v *sdk.DeploymentVerification) error {
+	query := `UPDATE pipeline_build_verification SET status = $1, last_check = $2, ended = $3, checks = $4, failures = $5,
+		last_result = $6, reason = $7, rollback_build_number = $8 WHERE id = $9`
+	_, err := db.Exec(query, v.Status, v.LastCheck, v.Ended, v.Checks, v.Failures, v.LastResult, v.Reason, v.RollbackBuildNumber, v.ID)
+	return err
+}
+
+// EndVerification saves the outcome of a verification, without overwriting the checks recorded in the meantime
+func EndVerification(db gorp.SqlExecutor, v *sdk.DeploymentVerification) error {
+	query := `UPDATE pipeline_build_verification SET status = $1, ended = $2, reason = $3 WHERE id = $4`
+	_, err := db.Exec(query, v.Status, v.Ended, v.Reason, v.ID)
+	return err
+}
+
+// LoadRunningVerifications loads all the verifications which are not over yet
+func LoadRunningVerifications(db gorp.SqlExecutor) ([]sdk.DeploymentVerification, error) {
+	query := `SELECT ` + verificationFields + ` FROM pipeline_build_verification WHERE status = $1 ORDER BY id ASC`
+	rows, err := db.Query(query, sdk.VerificationRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifications := []sdk.DeploymentVerification{}
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, *v)
+	}
+	return verifications, nil
+}
+
+// ClaimCheck sets the date of the next check of a running verification. It returns false if the check
+// has already been claimed since the verification was loaded, by another instance of the API.
+func ClaimCheck(db gorp.SqlExecutor, v *sdk.DeploymentVerification, t time.Time) (bool, error) {
+	query := `UPDATE pipeline_build_verification SET last_check = $1
+		WHERE id = $2 AND status = $3 AND last_check IS NOT DISTINCT FROM $4`
+	res, err := db.Exec(query, t, v.ID, sdk.VerificationRunning, v.LastCheck)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	v.LastCheck = &t
+	return true, nil
+}
+
+// RecordCheck saves the result of a health check on a running verification
+func RecordCheck(db gorp.SqlExecutor, id int64, checkErr error) error {
+	failures, result := 0, "OK"
+	if checkErr != nil {
+		failures, result = 1, checkErr.Error()
+	}
+	query := `UPDATE pipeline_build_verification SET checks = checks + 1, failures = failures + $1, last_result = $2
+		WHERE id = $3 AND status = $4`
+	_, err := db.Exec(query, failures, result, id, sdk.VerificationRunning)
+	return err
+}
+
+// LoadBuildVerifications loads all deployment verifications of a pipeline build, oldest first
+func LoadBuildVerifications(db gorp.SqlExecutor, pbID int64) ([]sdk.DeploymentVerification, error) {
+	query := `SELECT ` + verificationFields + ` FROM pipeline_build_verification WHERE pipeline_build_id = $1 ORDER BY id ASC`
+	rows, err := db.Query(query, pbID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifications := []sdk.DeploymentVerification{}
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, *v)
+	}
+	return verifications, nil
+}
+
+// LoadLastVerification loads the last deployment verification of a pipeline build, nil if there is none
+func LoadLastVerification(db gorp.SqlExecutor, pbID int64) (*sdk.DeploymentVerification, error) {
+	query := `SELECT ` + verificationFields + ` FROM pipeline_build_verification
+		WHERE pipeline_build_id = $1 ORDER BY id DESC LIMIT 1`
+	v, err := scan(db.QueryRow(query, pbID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// NextSlot returns the idle blue/green slot of an application pipeline on an environment:
+// the opposite of the last promoted one, blue if none has been promoted yet
+func NextSlot(db gorp.SqlExecutor, appID, pipID, envID int64) (string, error) {
+	query := `SELECT slot FROM pipeline_build_verification
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND strategy = $4 AND status = $5
+		ORDER BY id DESC LIMIT 1`
+	var live string
+	err := db.QueryRow(query, appID, pipID, envID, sdk.DeploymentBlueGreen, sdk.VerificationPromoted).Scan(&live)
+	if err == sql.ErrNoRows {
+		return sdk.SlotBlue, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if live == sdk.SlotBlue {
+		return sdk.SlotGreen, nil
+	}
+	return sdk.SlotBlue, nil
+}
+
+// DeleteBuildVerifications removes deployment verifications of a pipeline build
+func DeleteBuildVerifications(db gorp.SqlExecutor, pbID int64) error {
+	_, err := db.Exec(`DELETE FROM pipeline_build_verification WHERE pipeline_build_id = $1`, pbID)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(s scanner) (*sdk.DeploymentVerification, error) {
+	var v sdk.DeploymentVerification
+	var lastCheck, ended pq.NullTime
+	var lastResult, reason sql.NullString
+	if err := s.Scan(&v.ID, &v.PipelineBuildID, &v.BuildNumber, &v.StageID, &v.StageName, &v.Strategy, &v.Slot, &v.Status,
+		&v.Started, &lastCheck, &ended, &v.Checks, &v.Failures, &lastResult, &reason, &v.RollbackBuildNumber); err != nil {
+		return nil, err
+	}
+	if lastCheck.Valid {
+		v.LastCheck = &lastCheck.Time
+	}
+	if ended.Valid {
+		v.Ended = &ended.Time
+	}
+	v.LastResult = lastResult.String
+	v.Reason = reason.String
+	return &v, nil
+}
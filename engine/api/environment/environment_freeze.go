@@ -0,0 +1,175 @@
+package environment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorhill/cronexpr"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// ValidateFreezeWindow checks a freeze window is either a recurring or an absolute window
+func ValidateFreezeWindow(w *sdk.FreezeWindow) error {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return sdk.NewError(sdk.ErrInvalidFreezeWindow, err)
+	}
+
+	switch {
+	case w.Cron != "" && w.Start == "" && w.End == "":
+		if _, err := cronexpr.Parse(w.Cron); err != nil {
+			return sdk.NewError(sdk.ErrInvalidFreezeWindow, err)
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return sdk.NewError(sdk.ErrInvalidFreezeWindow, err)
+		}
+		if d <= 0 {
+			return sdk.ErrInvalidFreezeWindow
+		}
+	case w.Cron == "" && w.Duration == "" && w.Start != "" && w.End != "":
+		start, err := time.ParseInLocation(sdk.FreezeWindowDateLayout, w.Start, loc)
+		if err != nil {
+			return sdk.NewError(sdk.ErrInvalidFreezeWindow, err)
+		}
+		end, err := time.ParseInLocation(sdk.FreezeWindowDateLayout, w.End, loc)
+		if err != nil {
+			return sdk.NewError(sdk.ErrInvalidFreezeWindow, err)
+		}
+		if !end.After(start) {
+			return sdk.ErrInvalidFreezeWindow
+		}
+	default:
+		return sdk.ErrInvalidFreezeWindow
+	}
+	return nil
+}
+
+// FreezeWindowEnd returns the end of the freeze window if it is active at the given time
+func FreezeWindowEnd(w sdk.FreezeWindow, t time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t = t.In(loc)
+
+	if w.Cron != "" {
+		expr, err := cronexpr.Parse(w.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return time.Time{}, false
+		}
+		// The window is active if it started during the last duration
+		start := expr.Next(t.Add(-d))
+		if start.IsZero() || start.After(t) {
+			return time.Time{}, false
+		}
+		return start.Add(d), true
+	}
+
+	start, err := time.ParseInLocation(sdk.FreezeWindowDateLayout, w.Start, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.ParseInLocation(sdk.FreezeWindowDateLayout, w.End, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if t.Before(start) || !t.Before(end) {
+		return time.Time{}, false
+	}
+	return end, true
+}
+
+// ActiveFreezeWindow returns the first freeze window active at the given time, and its end
+func ActiveFreezeWindow(windows []sdk.FreezeWindow, t time.Time) (*sdk.FreezeWindow, time.Time) {
+	for i := range windows {
+		if end, ok := FreezeWindowEnd(windows[i], t); ok {
+			return &windows[i], end
+		}
+	}
+	return nil, time.Time{}
+}
+
+// InsertFreezeWindow adds a freeze window on an environment
+func InsertFreezeWindow(db gorp.SqlExecutor, envID int64, w *sdk.FreezeWindow) error {
+	if err := ValidateFreezeWindow(w); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO environment_freeze_window (environment_id, reason, timezone, cron, duration, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	return db.QueryRow(query, envID, w.Reason, w.Timezone, w.Cron, w.Duration, w.Start, w.End).Scan(&w.ID)
+}
+
+// LoadFreezeWindows loads freeze windows of an environment
+func LoadFreezeWindows(db gorp.SqlExecutor, envID int64) ([]sdk.FreezeWindow, error) {
+	query := `SELECT id, reason, timezone, cron, duration, start_date, end_date
+		FROM environment_freeze_window WHERE environment_id = $1 ORDER BY id`
+	rows, err := db.Query(query, envID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []sdk.FreezeWindow{}
+	for rows.Next() {
+		var w sdk.FreezeWindow
+		if err := rows.Scan(&w.ID, &w.Reason, &w.Timezone, &w.Cron, &w.Duration, &w.Start, &w.End); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// DeleteFreezeWindow removes a freeze window from an environment
+func DeleteFreezeWindow(db gorp.SqlExecutor, envID, windowID int64) error {
+	res, err := db.Exec(`DELETE FROM environment_freeze_window WHERE environment_id = $1 AND id = $2`, envID, windowID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sdk.ErrNotFound
+	}
+	return nil
+}
+
+// InsertFreezeOverride grants a temporary override token to deploy on a frozen environment
+func InsertFreezeOverride(db gorp.SqlExecutor, envID int64, u *sdk.User, reason string, d time.Duration) (*sdk.FreezeOverride, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	o := &sdk.FreezeOverride{
+		Token:    hex.EncodeToString(b),
+		Reason:   reason,
+		Username: u.Username,
+		Expires:  time.Now().Add(d),
+	}
+	query := `INSERT INTO environment_freeze_override (environment_id, token, reason, user_id, username, expires) VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := db.Exec(query, envID, o.Token, o.Reason, u.ID, o.Username, o.Expires); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// IsValidFreezeOverride checks an override token has been granted on the environment and has not expired
+func IsValidFreezeOverride(db gorp.SqlExecutor, envID int64, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	var count int
+	query := `SELECT count(id) FROM environment_freeze_override WHERE environment_id = $1 AND token = $2 AND expires > $3`
+	if err := db.QueryRow(query, envID, token, time.Now()).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
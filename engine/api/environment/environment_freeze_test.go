@@ -0,0 +1,71 @@
+package environment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestValidateFreezeWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  sdk.FreezeWindow
+		wantErr bool
+	}{
+		{"recurring", sdk.FreezeWindow{Cron: "0 18 * * 5", Duration: "62h", Timezone: "Europe/Paris"}, false},
+		{"absolute", sdk.FreezeWindow{Start: "2017-12-22 18:00", End: "2018-01-02 08:00"}, false},
+		{"empty", sdk.FreezeWindow{}, true},
+		{"both", sdk.FreezeWindow{Cron: "0 18 * * 5", Duration: "62h", Start: "2017-12-22 18:00", End: "2018-01-02 08:00"}, true},
+		{"bad cron", sdk.FreezeWindow{Cron: "every friday", Duration: "62h"}, true},
+		{"bad duration", sdk.FreezeWindow{Cron: "0 18 * * 5", Duration: "-1h"}, true},
+		{"end before start", sdk.FreezeWindow{Start: "2018-01-02 08:00", End: "2017-12-22 18:00"}, true},
+		{"bad timezone", sdk.FreezeWindow{Start: "2017-12-22 18:00", End: "2018-01-02 08:00", Timezone: "Mars/Olympus"}, true},
+	}
+
+	for _, tt := range tests {
+		w := tt.window
+		if err := ValidateFreezeWindow(&w); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateFreezeWindow() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestActiveFreezeWindow(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+
+	weekend := sdk.FreezeWindow{Reason: "weekend", Cron: "0 18 * * 5", Duration: "62h", Timezone: "Europe/Paris"}
+	holidays := sdk.FreezeWindow{Reason: "holidays", Start: "2017-12-22 18:00", End: "2018-01-02 08:00", Timezone: "Europe/Paris"}
+	windows := []sdk.FreezeWindow{weekend, holidays}
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		reason string
+		end    time.Time
+	}{
+		{"friday before", time.Date(2017, 11, 17, 17, 59, 0, 0, paris), "", time.Time{}},
+		{"friday evening", time.Date(2017, 11, 17, 18, 0, 0, 0, paris), "weekend", time.Date(2017, 11, 20, 8, 0, 0, 0, paris)},
+		{"sunday in UTC", time.Date(2017, 11, 19, 12, 0, 0, 0, time.UTC), "weekend", time.Date(2017, 11, 20, 8, 0, 0, 0, paris)},
+		{"monday morning", time.Date(2017, 11, 20, 8, 0, 0, 0, paris), "", time.Time{}},
+		{"christmas", time.Date(2017, 12, 27, 10, 0, 0, 0, paris), "holidays", time.Date(2018, 1, 2, 8, 0, 0, 0, paris)},
+	}
+
+	for _, tt := range tests {
+		w, end := ActiveFreezeWindow(windows, tt.t)
+		reason := ""
+		if w != nil {
+			reason = w.Reason
+		}
+		if reason != tt.reason {
+			t.Errorf("%s: ActiveFreezeWindow() = %q, want %q", tt.name, reason, tt.reason)
+			continue
+		}
+		if w != nil && !end.Equal(tt.end) {
+			t.Errorf("%s: ActiveFreezeWindow() end = %s, want %s", tt.name, end, tt.end)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package environment
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// LockEnvironment sets a manual lock on an environment, replacing any existing one
+func LockEnvironment(db gorp.SqlExecutor, envID int64, u *sdk.User, reason string) (*sdk.EnvironmentLock, error) {
+	if _, err := db.Exec(`DELETE FROM environment_lock WHERE environment_id = $1`, envID); err != nil {
+		return nil, err
+	}
+
+	lock := &sdk.EnvironmentLock{
+		Reason:   reason,
+		Username: u.Username,
+		Locked:   time.Now(),
+	}
+	query := `INSERT INTO environment_lock (environment_id, reason, user_id, username, locked) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := db.Exec(query, envID, lock.Reason, u.ID, lock.Username, lock.Locked); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// UnlockEnvironment removes the manual lock of an environment
+func UnlockEnvironment(db gorp.SqlExecutor, envID int64) error {
+	_, err := db.Exec(`DELETE FROM environment_lock WHERE environment_id = $1`, envID)
+	return err
+}
+
+// LoadLock loads the manual lock of an environment, nil if the environment is not locked
+func LoadLock(db gorp.SqlExecutor, envID int64) (*sdk.EnvironmentLock, error) {
+	var lock sdk.EnvironmentLock
+	query := `SELECT reason, username, locked FROM environment_lock WHERE environment_id = $1`
+	if err := db.QueryRow(query, envID).Scan(&lock.Reason, &lock.Username, &lock.Locked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// SelectEnvironmentForUpdate locks the environment row until the end of the transaction,
+// so deployments on the same environment are started one at a time
+func SelectEnvironmentForUpdate(db gorp.SqlExecutor, envID int64) error {
+	var id int64
+	return db.QueryRow(`SELECT id FROM environment WHERE id = $1 FOR UPDATE`, envID).Scan(&id)
+}
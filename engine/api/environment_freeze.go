@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func getFreezeWindowsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("getFreezeWindowsHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	windows, err := environment.LoadFreezeWindows(db, env.ID)
+	if err != nil {
+		log.Warning("getFreezeWindowsHandler> Cannot load freeze windows of environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, windows, http.StatusOK)
+}
+
+func addFreezeWindowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var window sdk.FreezeWindow
+	if err := json.Unmarshal(data, &window); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("addFreezeWindowHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := environment.InsertFreezeWindow(db, env.ID, &window); err != nil {
+		log.Warning("addFreezeWindowHandler> Cannot insert freeze window on environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, window, http.StatusCreated)
+}
+
+func deleteFreezeWindowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	windowID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		WriteError(w, r, sdk.ErrInvalidID)
+		return
+	}
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("deleteFreezeWindowHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := environment.DeleteFreezeWindow(db, env.ID, windowID); err != nil {
+		log.Warning("deleteFreezeWindowHandler> Cannot delete freeze window %d of environment %s: %s\n", windowID, envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func grantFreezeOverrideHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var request sdk.FreezeOverrideRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	d, err := time.ParseDuration(request.Duration)
+	if err != nil || d <= 0 || request.Reason == "" {
+		log.Warning("grantFreezeOverrideHandler> Invalid override request on environment %s: %+v\n", envName, request)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("grantFreezeOverrideHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	o, err := environment.InsertFreezeOverride(db, env.ID, c.User, request.Reason, d)
+	if err != nil {
+		log.Warning("grantFreezeOverrideHandler> Cannot grant override on environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	log.Notice("grantFreezeOverrideHandler> %s granted a freeze override on %s/%s until %s: %s\n", c.User.Username, projectKey, envName, o.Expires, o.Reason)
+	WriteJSON(w, r, o, http.StatusOK)
+}
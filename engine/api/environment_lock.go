@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func lockEnvironmentHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var request sdk.EnvironmentLock
+	if err := json.Unmarshal(data, &request); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	if request.Reason == "" {
+		log.Warning("lockEnvironmentHandler> A reason is required to lock environment %s\n", envName)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("lockEnvironmentHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	lock, err := environment.LockEnvironment(db, env.ID, c.User, request.Reason)
+	if err != nil {
+		log.Warning("lockEnvironmentHandler> Cannot lock environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, lock, http.StatusOK)
+}
+
+func unlockEnvironmentHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("unlockEnvironmentHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := environment.UnlockEnvironment(db, env.ID); err != nil {
+		log.Warning("unlockEnvironmentHandler> Cannot unlock environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func getEnvironmentStatusHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	envName := vars["permEnvironmentName"]
+
+	env, err := environment.LoadEnvironmentByName(db, projectKey, envName)
+	if err != nil {
+		log.Warning("getEnvironmentStatusHandler> Cannot load environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	status := sdk.EnvironmentStatus{Environment: env.Name}
+	status.Lock, err = environment.LoadLock(db, env.ID)
+	if err != nil {
+		log.Warning("getEnvironmentStatusHandler> Cannot load lock of environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	status.Running, err = pipeline.LoadEnvironmentBuilds(db, env.ID, sdk.StatusBuilding, sdk.StatusWaitingApproval)
+	if err != nil {
+		log.Warning("getEnvironmentStatusHandler> Cannot load running builds of environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	status.Queued, err = pipeline.LoadEnvironmentBuilds(db, env.ID, sdk.StatusWaiting)
+	if err != nil {
+		log.Warning("getEnvironmentStatusHandler> Cannot load queued builds of environment %s: %s\n", envName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, status, http.StatusOK)
+}
@@ -86,3 +86,23 @@ func PublishPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild, previous *
 
 	Publish(e)
 }
+
+// PublishPullRequestComment sends the summary of a pull request build, commented by the repositories manager
+func PublishPullRequestComment(pb *sdk.PipelineBuild, pullRequestID int64, body string) {
+	if pb.Application.RepositoriesManager == nil {
+		return
+	}
+
+	e := sdk.EventPullRequestComment{
+		ProjectKey:            pb.Pipeline.ProjectKey,
+		ApplicationName:       pb.Application.Name,
+		PipelineName:          pb.Pipeline.Name,
+		BuildNumber:           pb.BuildNumber,
+		RepositoryManagerName: pb.Application.RepositoriesManager.Name,
+		RepositoryFullname:    pb.Application.RepositoryFullname,
+		PullRequestID:         pullRequestID,
+		Body:                  body,
+	}
+
+	Publish(e)
+}
@@ -0,0 +1,327 @@
+package group
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+type membership struct {
+	group    string
+	username string
+	admin    bool
+	origin   string
+}
+
+func loadMemberships(db gorp.SqlExecutor, where string, arg interface{}) ([]membership, error) {
+	query := `
+		SELECT "group".name, "user".username, COALESCE("group_user".group_admin, false), COALESCE("group_user".origin, '')
+		FROM "group"
+		JOIN "group_user" ON "group".id = "group_user".group_id
+		JOIN "user" ON "user".id = "group_user".user_id
+		WHERE ` + where
+	rows, err := db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []membership
+	for rows.Next() {
+		var m membership
+		if err := rows.Scan(&m.group, &m.username, &m.admin, &m.origin); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, nil
+}
+
+// InsertUserInGroupFromOrigin insert user in group, the membership being managed by an external origin such as oidc or ldap
+func InsertUserInGroupFromOrigin(db gorp.SqlExecutor, groupID, userID int64, admin bool, origin string) error {
+	query := `INSERT INTO group_user (group_id,user_id,group_admin,origin) VALUES($1,$2,$3,$4)`
+	_, err := db.Exec(query, groupID, userID, admin, origin)
+	return err
+}
+
+// validSyncedGroups keeps the group names given by an origin which are valid CDS group names
+func validSyncedGroups(origin string, wanted map[string]bool) map[string]bool {
+	pattern := regexp.MustCompile(sdk.NamePattern)
+	valid := map[string]bool{}
+	for n, admin := range wanted {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if !pattern.MatchString(n) {
+			log.Warning("validSyncedGroups> Ignoring group %s given by %s: invalid name\n", n, origin)
+			continue
+		}
+		valid[n] = valid[n] || admin
+	}
+	return valid
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PlanUserSync computes the changes giving a user exactly the groups an origin (oidc, ldap...) grants, with
+// wanted mapping each group name to the admin flag. Admin flags are left as they are when admins is false.
+// Memberships granted from CDS are never changed.
+func PlanUserSync(db gorp.SqlExecutor, username, origin string, wanted map[string]bool, admins bool) ([]sdk.GroupSyncChange, error) {
+	memberships, err := loadMemberships(db, `"user".username = $1`, username)
+	if err != nil {
+		return nil, err
+	}
+	wanted = validSyncedGroups(origin, wanted)
+
+	current := map[string]membership{}
+	for _, m := range memberships {
+		current[m.group] = m
+	}
+
+	changes := []sdk.GroupSyncChange{}
+	for _, g := range sortedKeys(wanted) {
+		m, ok := current[g]
+		switch {
+		case !ok:
+			exists, err := groupExists(db, g)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				changes = append(changes, sdk.GroupSyncChange{Group: g, Action: sdk.GroupSyncCreateGroup})
+			}
+			changes = append(changes, sdk.GroupSyncChange{Group: g, Username: username, Action: sdk.GroupSyncAddMember, Admin: admins && wanted[g]})
+		case m.origin == origin && admins && m.admin != wanted[g]:
+			changes = append(changes, adminChange(g, username, wanted[g]))
+		}
+	}
+	for _, m := range memberships {
+		if _, ok := wanted[m.group]; !ok && m.origin == origin {
+			changes = append(changes, sdk.GroupSyncChange{Group: m.group, Username: username, Action: sdk.GroupSyncRemoveMember})
+		}
+	}
+	return changes, nil
+}
+
+// PlanGroupSync computes the changes making the members of a group given by an origin exactly the wanted
+// ones, mapping each username to the admin flag. Users unknown in CDS are ignored, they are synced when they log in.
+// Memberships granted from CDS are never changed.
+func PlanGroupSync(db gorp.SqlExecutor, name, origin string, wanted map[string]bool) ([]sdk.GroupSyncChange, error) {
+	if len(validSyncedGroups(origin, map[string]bool{name: false})) == 0 {
+		return nil, nil
+	}
+	memberships, err := loadMemberships(db, `"group".name = $1`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[string]membership{}
+	for _, m := range memberships {
+		current[m.username] = m
+	}
+
+	changes := []sdk.GroupSyncChange{}
+	exists, err := groupExists(db, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		changes = append(changes, sdk.GroupSyncChange{Group: name, Action: sdk.GroupSyncCreateGroup})
+	}
+
+	for _, u := range sortedKeys(wanted) {
+		m, ok := current[u]
+		switch {
+		case !ok:
+			known, err := userExists(db, u)
+			if err != nil {
+				return nil, err
+			}
+			if known {
+				changes = append(changes, sdk.GroupSyncChange{Group: name, Username: u, Action: sdk.GroupSyncAddMember, Admin: wanted[u]})
+			}
+		case m.origin == origin && m.admin != wanted[u]:
+			changes = append(changes, adminChange(name, u, wanted[u]))
+		}
+	}
+	for _, m := range memberships {
+		if _, ok := wanted[m.username]; !ok && m.origin == origin {
+			changes = append(changes, sdk.GroupSyncChange{Group: name, Username: m.username, Action: sdk.GroupSyncRemoveMember})
+		}
+	}
+	return changes, nil
+}
+
+// PlanStaleMembershipsRemoval computes the removal of the memberships given by an origin in groups it does not sync anymore
+func PlanStaleMembershipsRemoval(db gorp.SqlExecutor, origin string, synced map[string]bool) ([]sdk.GroupSyncChange, error) {
+	memberships, err := loadMemberships(db, `"group_user".origin = $1`, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []sdk.GroupSyncChange{}
+	for _, m := range memberships {
+		if !synced[m.group] {
+			changes = append(changes, sdk.GroupSyncChange{Group: m.group, Username: m.username, Action: sdk.GroupSyncRemoveMember})
+		}
+	}
+	return changes, nil
+}
+
+func adminChange(group, username string, admin bool) sdk.GroupSyncChange {
+	c := sdk.GroupSyncChange{Group: group, Username: username, Action: sdk.GroupSyncUnsetAdmin}
+	if admin {
+		c.Action = sdk.GroupSyncSetAdmin
+		c.Admin = true
+	}
+	return c
+}
+
+// ApplySyncChanges applies the changes computed by a group sync. A change that can't be applied,
+// such as removing the last admin of a group, is logged and skipped
+func ApplySyncChanges(db gorp.SqlExecutor, origin string, changes []sdk.GroupSyncChange) error {
+	for _, c := range changes {
+		if c.Action == sdk.GroupSyncCreateGroup {
+			if _, err := LoadGroup(db, c.Group); err == sdk.ErrGroupNotFound {
+				if err := InsertGroup(db, &sdk.Group{Name: c.Group}); err != nil {
+					log.Warning("ApplySyncChanges> Cannot create group %s: %s\n", c.Group, err)
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+			continue
+		}
+
+		g, err := LoadGroup(db, c.Group)
+		if err != nil {
+			return err
+		}
+		userID, err := loadUserID(db, c.Username)
+		if err != nil {
+			return err
+		}
+
+		switch c.Action {
+		case sdk.GroupSyncAddMember:
+			err = InsertUserInGroupFromOrigin(db, g.ID, userID, c.Admin, origin)
+		case sdk.GroupSyncRemoveMember:
+			err = DeleteUserFromGroup(db, g.ID, userID)
+		case sdk.GroupSyncSetAdmin:
+			err = SetUserGroupAdmin(db, g.ID, userID)
+		case sdk.GroupSyncUnsetAdmin:
+			err = RemoveUserGroupAdmin(db, g.ID, userID)
+		}
+		if err == sdk.ErrNotEnoughAdmin {
+			log.Warning("ApplySyncChanges> Keeping %s in group %s: last admin\n", c.Username, c.Group)
+			continue
+		}
+		if err != nil {
+			log.Warning("ApplySyncChanges> Cannot %s %s in group %s: %s\n", c.Action, c.Username, c.Group, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncUserGroups sets the groups of a user given by an external origin (oidc, ldap...) and returns the changes.
+// Missing groups are created, the user is added to the groups given by the origin and removed from the
+// groups the origin gave before but not anymore. Memberships granted from CDS are never removed.
+func SyncUserGroups(db gorp.SqlExecutor, username, origin string, names []string) ([]sdk.GroupSyncChange, error) {
+	wanted := map[string]bool{}
+	for _, n := range names {
+		wanted[n] = false
+	}
+	changes, err := PlanUserSync(db, username, origin, wanted, false)
+	if err != nil {
+		return nil, err
+	}
+	return changes, ApplySyncChanges(db, origin, changes)
+}
+
+// InsertSyncLog records a group sync, logs older than 30 days are purged at the same time
+func InsertSyncLog(db gorp.SqlExecutor, l *sdk.GroupSyncLog) error {
+	changes, err := json.Marshal(l.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO group_sync_log (origin, trigger, dry_run, started, changes, error) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := db.QueryRow(query, l.Origin, l.Trigger, l.DryRun, l.Started, string(changes), l.Error).Scan(&l.ID); err != nil {
+		return err
+	}
+
+	query = `DELETE FROM group_sync_log WHERE started < current_timestamp - interval '30 days'`
+	_, err = db.Exec(query)
+	return err
+}
+
+// LoadSyncLogs loads the last group syncs
+func LoadSyncLogs(db gorp.SqlExecutor, limit int) ([]sdk.GroupSyncLog, error) {
+	query := `SELECT id, origin, trigger, dry_run, started, changes, error FROM group_sync_log ORDER BY id DESC LIMIT $1`
+	rows, err := db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []sdk.GroupSyncLog{}
+	for rows.Next() {
+		var l sdk.GroupSyncLog
+		var changes, errMsg sql.NullString
+		if err := rows.Scan(&l.ID, &l.Origin, &l.Trigger, &l.DryRun, &l.Started, &changes, &errMsg); err != nil {
+			return nil, err
+		}
+		l.Error = errMsg.String
+		if changes.Valid && changes.String != "" {
+			if err := json.Unmarshal([]byte(changes.String), &l.Changes); err != nil {
+				return nil, err
+			}
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func groupExists(db gorp.SqlExecutor, name string) (bool, error) {
+	_, err := LoadGroup(db, name)
+	if err == sdk.ErrGroupNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func userExists(db gorp.SqlExecutor, username string) (bool, error) {
+	_, err := loadUserID(db, username)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func loadUserID(db gorp.SqlExecutor, username string) (int64, error) {
+	var id int64
+	if err := db.QueryRow(`SELECT id FROM "user" WHERE username = $1`, username).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, err
+		}
+		return 0, fmt.Errorf("cannot load user %s: %s", username, err)
+	}
+	return id, nil
+}
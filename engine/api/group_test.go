@@ -9,6 +9,7 @@ import (
 
 	"github.com/ovh/cds/engine/api/auth"
 	"github.com/ovh/cds/engine/api/bootstrap"
+	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/api/test"
 	"github.com/ovh/cds/sdk"
 )
@@ -43,3 +44,38 @@ func Test_getPublicGroups(t *testing.T) {
 
 	t.Logf("Body: %s", w.Body.String())
 }
+
+func Test_groupSync(t *testing.T) {
+	db := test.SetupPG(t, bootstrap.InitiliazeDB)
+
+	g := &sdk.Group{Name: test.RandomString(t, 10)}
+	manual, _ := test.InsertLambaUser(t, db, g)
+	synced, _ := test.InsertLambaUser(t, db)
+
+	//Members granted from CDS are kept, unknown users are ignored
+	changes, err := group.PlanGroupSync(db, g.Name, "ldap", map[string]bool{synced.Username: true, "unknown-user": false})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.GroupSyncChange{{Group: g.Name, Username: synced.Username, Action: sdk.GroupSyncAddMember, Admin: true}}, changes)
+	assert.NoError(t, group.ApplySyncChanges(db, "ldap", changes))
+
+	changes, err = group.PlanGroupSync(db, g.Name, "ldap", map[string]bool{synced.Username: false})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.GroupSyncChange{{Group: g.Name, Username: synced.Username, Action: sdk.GroupSyncUnsetAdmin}}, changes)
+
+	changes, err = group.PlanGroupSync(db, g.Name, "ldap", map[string]bool{})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.GroupSyncChange{{Group: g.Name, Username: synced.Username, Action: sdk.GroupSyncRemoveMember}}, changes)
+
+	//Groups given at login are created, and left when not given anymore
+	newGroup := test.RandomString(t, 10)
+	changes, err = group.SyncUserGroups(db, manual.Username, "oidc", []string{newGroup, g.Name})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.GroupSyncChange{
+		{Group: newGroup, Action: sdk.GroupSyncCreateGroup},
+		{Group: newGroup, Username: manual.Username, Action: sdk.GroupSyncAddMember},
+	}, changes)
+
+	changes, err = group.SyncUserGroups(db, manual.Username, "oidc", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.GroupSyncChange{{Group: newGroup, Username: manual.Username, Action: sdk.GroupSyncRemoveMember}}, changes)
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-gorp/gorp"
@@ -18,6 +19,7 @@ import (
 	"github.com/ovh/cds/engine/api/hook"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogitlab"
 	"github.com/ovh/cds/engine/log"
 	"github.com/ovh/cds/sdk"
 )
@@ -47,6 +49,37 @@ func receiveHook(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *cont
 		UID:        r.FormValue("uid"),
 	}
 
+	// Gitlab does not substitute the variables of the hook link, read them from the push payload
+	if r.Header.Get("X-Gitlab-Event") == "Push Hook" {
+		var push repogitlab.PushHook
+		if err := json.Unmarshal(data, &push); err != nil {
+			log.Warning("receiveHook> cannot unmarshal gitlab push: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rh.Branch = strings.TrimPrefix(push.Ref, "refs/heads/")
+		rh.Hash = push.After
+		rh.Author = push.UserUsername
+		switch {
+		case push.After == repogitlab.NullSHA:
+			rh.Message = "DELETE"
+		case push.Before == repogitlab.NullSHA:
+			rh.Message = "ADD"
+		default:
+			rh.Message = "UPDATE"
+		}
+	}
+
+	// Tag pushes trigger the release pipelines, deleting a tag does not remove any build
+	if strings.HasPrefix(rh.Branch, "refs/tags/") {
+		if rh.Message == "DELETE" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		rh.Args = append(rh.Args, sdk.Parameter{Name: "git.tag", Type: sdk.StringParameter, Value: strings.TrimPrefix(rh.Branch, "refs/tags/")})
+		rh.Branch = ""
+	}
+
 	if db == nil {
 		hook.Recovery(rh, fmt.Errorf("database not available"))
 		WriteError(w, r, err)
@@ -198,6 +231,132 @@ func deleteHook(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *conte
 	}
 }
 
+// loadPipelineHook loads a hook from the request, checking it belongs to the application pipeline of the route
+func loadPipelineHook(db gorp.SqlExecutor, r *http.Request) (sdk.Hook, error) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		return sdk.Hook{}, sdk.ErrWrongRequest
+	}
+
+	p, err := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
+	if err != nil {
+		return sdk.Hook{}, err
+	}
+
+	a, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		return sdk.Hook{}, err
+	}
+
+	h, err := hook.LoadHook(db, id)
+	if err != nil {
+		return h, sdk.ErrNoHook
+	}
+	if h.ApplicationID != a.ID || h.Pipeline.ID != p.ID {
+		return h, sdk.ErrNoHook
+	}
+	return h, nil
+}
+
+const maxHookDeliveries = 100
+
+func getHookDeliveriesHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	h, err := loadPipelineHook(db, r)
+	if err != nil {
+		log.Warning("getHookDeliveriesHandler> cannot load hook: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	limit := 20
+	if l := r.FormValue("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+	// Deliveries come with their payload
+	if limit > maxHookDeliveries {
+		limit = maxHookDeliveries
+	}
+
+	deliveries, err := hook.LoadHookDeliveries(db, h.ID, limit)
+	if err != nil {
+		log.Warning("getHookDeliveriesHandler> cannot load deliveries of hook %d: %s\n", h.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, deliveries, http.StatusOK)
+}
+
+// regenerateHookSecretHandler replaces the secret signing the native webhooks of a hook and returns it
+func regenerateHookSecretHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	h, err := loadPipelineHook(db, r)
+	if err != nil {
+		log.Warning("regenerateHookSecretHandler> cannot load hook: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := hook.RegenerateSecret(db, &h); err != nil {
+		log.Warning("regenerateHookSecretHandler> cannot regenerate secret of hook %d: %s\n", h.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, h, http.StatusOK)
+}
+
+func replayHookDeliveryHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	h, err := loadPipelineHook(db, r)
+	if err != nil {
+		log.Warning("replayHookDeliveryHandler> cannot load hook: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(mux.Vars(r)["deliveryID"], 10, 64)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	rh, err := hook.LoadReceivedHook(db, h.ID, deliveryID)
+	if err != nil {
+		log.Warning("replayHookDeliveryHandler> cannot load delivery %d of hook %d: %s\n", deliveryID, h.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	// A failing replay is recorded as a delivery in error, it is not retried
+	id, err := processHookDelivery(rh)
+	if id == 0 {
+		log.Warning("replayHookDeliveryHandler> cannot replay delivery %d of hook %d: %s\n", deliveryID, h.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	deliveries, err := hook.LoadHookDeliveries(db, h.ID, 1)
+	if err != nil {
+		log.Warning("replayHookDeliveryHandler> cannot load deliveries of hook %d: %s\n", h.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+	for _, d := range deliveries {
+		if d.ID == id {
+			WriteJSON(w, r, d, http.StatusOK)
+			return
+		}
+	}
+	WriteError(w, r, sdk.ErrNotFound)
+}
+
 //hookRecoverer is the go-routine which catches on-error hook
 func hookRecoverer() {
 	for {
@@ -214,17 +373,37 @@ func hookRecoverer() {
 
 //processHook is the core function for hook processing
 func processHook(h hook.ReceivedHook) error {
+	_, err := processHookDelivery(h)
+	return err
+}
+
+//processHookDelivery records the received hook, processes it and records its result. It returns the id of the delivery.
+func processHookDelivery(h hook.ReceivedHook) (int64, error) {
 	db := database.DBMap(database.DB())
 	if db == nil {
-		return fmt.Errorf("database not available")
+		return 0, fmt.Errorf("database not available")
 	}
 
 	// Logging stuff
-	if err := hook.InsertReceivedHook(db, h.URL.String(), string(h.Data)); err != nil {
+	deliveryID, err := hook.InsertReceivedHook(db, h)
+	if err != nil {
 		log.Warning("processHook> cannot insert received hook in db: %s\n", err)
-		return err
+		return 0, err
 	}
 
+	d := sdk.HookDelivery{ID: deliveryID}
+	if err = triggerHook(db, h, &d); err != nil {
+		d.Status, d.Reason = sdk.HookDeliveryError, err.Error()
+	}
+	if errU := hook.UpdateReceivedHook(db, d.ID, d.HookID, d.Status, d.BuildNumber, d.Reason); errU != nil {
+		log.Warning("processHook> cannot update received hook %d: %s\n", d.ID, errU)
+	}
+
+	return deliveryID, err
+}
+
+//triggerHook triggers the pipeline of the hook matching the received hook, and fills the result of the delivery
+func triggerHook(db *gorp.DbMap, h hook.ReceivedHook, d *sdk.HookDelivery) error {
 	// Actual search of hook binding
 	hooks, err := hook.LoadHooks(db, h.ProjectKey, h.Repository)
 	if err != nil {
@@ -232,12 +411,19 @@ func processHook(h hook.ReceivedHook) error {
 		return err
 	}
 
+	for i := range hooks {
+		if hooks[i].UID == h.UID {
+			d.HookID = hooks[i].ID
+		}
+	}
+
 	// If branch is DELETE'd, remove all builds related to this branch
 	if h.Message == "DELETE" {
 		log.Warning("processHook> Removing builds in %s/%s on branch %s\n", h.ProjectKey, h.Repository, h.Branch)
 		if err := hook.DeleteBranchBuilds(db, hooks, h.Branch); err != nil {
 			return err
 		}
+		d.Status, d.Reason = sdk.HookDeliverySkipped, "branch deleted, its builds have been removed"
 		return nil
 	}
 
@@ -246,21 +432,22 @@ func processHook(h hook.ReceivedHook) error {
 	//begin a tx
 	tx, err := db.Begin()
 	if err != nil {
-
+		return err
 	}
 	defer tx.Rollback()
 
 	for i := range hooks {
-		if !hooks[i].Enabled {
-			continue
-		}
-
 		if hooks[i].UID != h.UID {
 			continue
 		}
 
 		found = true
 
+		if !hooks[i].Enabled {
+			d.Status, d.Reason = sdk.HookDeliverySkipped, "hook disabled"
+			continue
+		}
+
 		// create pipeline object
 		p, err := pipeline.LoadPipelineByID(tx, hooks[i].Pipeline.ID, true)
 		if err != nil {
@@ -283,15 +470,17 @@ func processHook(h hook.ReceivedHook) error {
 		}
 		projectData.Variable = projectsVar
 
-		ok, err := application.TriggerPipeline(tx, hooks[i], h.Branch, h.Hash, h.Author, p, projectData)
+		pb, reason, err := application.TriggerPipeline(tx, hooks[i], h.Branch, h.Hash, h.Author, p, projectData, h.Args...)
 		if err != nil {
 			log.Warning("processHook> cannot trigger pipeline %d: %s\n", hooks[i].Pipeline.ID, err)
 			return err
 		}
-		if ok {
+		if pb != nil {
 			log.Debug("processHook> Triggered %s/%s/%s", h.ProjectKey, h.Repository, h.Branch)
+			d.Status, d.BuildNumber = sdk.HookDeliveryTriggered, pb.BuildNumber
 		} else {
-			log.Notice("processHook> Did not trigger %s/%s/%s", h.ProjectKey, h.Repository, h.Branch)
+			log.Notice("processHook> Did not trigger %s/%s/%s: %s", h.ProjectKey, h.Repository, h.Branch, reason)
+			d.Status, d.Reason = sdk.HookDeliverySkipped, reason
 		}
 	}
 
@@ -4,11 +4,13 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
 
 	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
 	"github.com/spf13/viper"
 
 	"github.com/ovh/cds/engine/api/cache"
@@ -29,28 +31,23 @@ type ReceivedHook struct {
 	Author     string
 	Message    string
 	UID        string
+	Args       []sdk.Parameter
+	ReplayOf   int64
 }
 
 // HookLink format in stash/bitbucket
 const HookLink = "/hook?uid=%s&project=%s&name=%s&branch=${refChange.name}&hash=${refChange.toHash}&message=${refChange.type}&author=${user.name}"
 
-// InsertReceivedHook insert raw data received from public handler in database
-func InsertReceivedHook(db gorp.SqlExecutor, link string, data string) error {
-	query := `INSERT INTO received_hook (link, data) VALUES ($1, $2)`
+// UpdateHook update the given hook
+func UpdateHook(db gorp.SqlExecutor, h sdk.Hook) error {
+	query := `UPDATE hook set pipeline_id=$1, kind=$2, host=$3, project=$4, repository=$5, application_id=$6, enabled=$7, paths=$8 WHERE id=$9`
 
-	_, err := db.Exec(query, link, data)
+	paths, err := json.Marshal(h.Paths)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// UpdateHook update the given hook
-func UpdateHook(db gorp.SqlExecutor, h sdk.Hook) error {
-	query := `UPDATE hook set pipeline_id=$1, kind=$2, host=$3, project=$4, repository=$5, application_id=$6, enabled=$7 WHERE id=$8`
-
-	res, err := db.Exec(query, h.Pipeline.ID, h.Kind, h.Host, h.Project, h.Repository, h.ApplicationID, h.Enabled, h.ID)
+	res, err := db.Exec(query, h.Pipeline.ID, h.Kind, h.Host, h.Project, h.Repository, h.ApplicationID, h.Enabled, string(paths), h.ID)
 	if err != nil {
 		return err
 	}
@@ -66,7 +63,7 @@ func UpdateHook(db gorp.SqlExecutor, h sdk.Hook) error {
 
 // InsertHook add link between git repository and pipeline in database
 func InsertHook(db gorp.SqlExecutor, h *sdk.Hook) error {
-	query := `INSERT INTO hook (pipeline_id, kind, host, project, repository, application_id,enabled, uid) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	query := `INSERT INTO hook (pipeline_id, kind, host, project, repository, application_id,enabled, uid, secret, paths) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
 
 	// Generate UID
 	uid, err := generateHash()
@@ -75,7 +72,20 @@ func InsertHook(db gorp.SqlExecutor, h *sdk.Hook) error {
 	}
 	h.UID = uid
 
-	err = db.QueryRow(query, h.Pipeline.ID, h.Kind, h.Host, h.Project, h.Repository, h.ApplicationID, h.Enabled, h.UID).Scan(&h.ID)
+	// Generate the secret signing native webhooks
+	secret, err := generateHash()
+	if err != nil {
+		return err
+	}
+	h.Secret = secret[:64]
+	h.Webhooks = webhookLinks(h.UID)
+
+	paths, err := json.Marshal(h.Paths)
+	if err != nil {
+		return err
+	}
+
+	err = db.QueryRow(query, h.Pipeline.ID, h.Kind, h.Host, h.Project, h.Repository, h.ApplicationID, h.Enabled, h.UID, h.Secret, string(paths)).Scan(&h.ID)
 	if err != nil {
 		return err
 	}
@@ -83,17 +93,62 @@ func InsertHook(db gorp.SqlExecutor, h *sdk.Hook) error {
 	return nil
 }
 
-// LoadHook loads a single hook
+// LoadHook loads a single hook, without its secret
 func LoadHook(db gorp.SqlExecutor, id int64) (sdk.Hook, error) {
 	h := sdk.Hook{ID: id}
-	query := `SELECT application_id, pipeline_id, kind, host, project, repository FROM hook WHERE id = $1`
+	query := `SELECT application_id, pipeline_id, kind, host, project, repository, enabled, uid, paths FROM hook WHERE id = $1`
 
-	err := db.QueryRow(query, id).Scan(&h.ApplicationID, &h.Pipeline.ID, &h.Kind, &h.Host, &h.Project, &h.Repository)
+	var paths sql.NullString
+	err := db.QueryRow(query, id).Scan(&h.ApplicationID, &h.Pipeline.ID, &h.Kind, &h.Host, &h.Project, &h.Repository, &h.Enabled, &h.UID, &paths)
 	if err != nil {
 		return h, err
 	}
 
-	return h, nil
+	return h, unmarshalPaths(paths, &h.Paths)
+}
+
+// LoadHookByUID loads a hook and its secret from its uid
+func LoadHookByUID(db gorp.SqlExecutor, uid string) (sdk.Hook, error) {
+	h := sdk.Hook{UID: uid}
+	query := `SELECT id, application_id, pipeline_id, kind, host, project, repository, enabled, secret, paths FROM hook WHERE uid = $1`
+
+	var secret, paths sql.NullString
+	if err := db.QueryRow(query, uid).Scan(&h.ID, &h.ApplicationID, &h.Pipeline.ID, &h.Kind, &h.Host, &h.Project, &h.Repository, &h.Enabled, &secret, &paths); err != nil {
+		if err == sql.ErrNoRows {
+			return h, sdk.ErrNoHook
+		}
+		return h, err
+	}
+	h.Secret = secret.String
+	return h, unmarshalPaths(paths, &h.Paths)
+}
+
+// RegenerateSecret replaces the secret signing the native webhooks of a hook. As on creation, the new secret
+// is only given back here: hooks are loaded without their secret
+func RegenerateSecret(db gorp.SqlExecutor, h *sdk.Hook) error {
+	secret, err := generateHash()
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`UPDATE hook SET secret = $1 WHERE id = $2`, secret[:64], h.ID); err != nil {
+		return err
+	}
+	h.Secret = secret[:64]
+	h.Webhooks = webhookLinks(h.UID)
+	return nil
+}
+
+// InsertDelivery records a delivery of a native webhook, it returns false if the delivery has already been received
+func InsertDelivery(db gorp.SqlExecutor, hookID int64, deliveryID, event string) (bool, error) {
+	query := `INSERT INTO hook_delivery (hook_id, delivery_id, event, received) VALUES ($1, $2, $3, current_timestamp)`
+	if _, err := db.Exec(query, hookID, deliveryID, event); err != nil {
+		if errPG, ok := err.(*pq.Error); ok && errPG.Code == "23505" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 //FindHook loads a hook from its attributes
@@ -134,10 +189,10 @@ func DeleteHook(db gorp.SqlExecutor, id int64) error {
 	return nil
 }
 
-// LoadApplicationHooks will load all hooks related to given application
+// LoadApplicationHooks will load all hooks related to given application, without their secret
 func LoadApplicationHooks(db gorp.SqlExecutor, applicationID int64) ([]sdk.Hook, error) {
 	hooks := []sdk.Hook{}
-	query := `SELECT hook.id, hook.kind, hook.host, hook.project, hook.repository, hook.enabled, hook.uid, pipeline.id, pipeline.name
+	query := `SELECT hook.id, hook.kind, hook.host, hook.project, hook.repository, hook.enabled, hook.uid, hook.paths, pipeline.id, pipeline.name
 		  FROM hook
 		  JOIN pipeline ON pipeline.id = hook.pipeline_id
 		  WHERE application_id= $1
@@ -152,21 +207,26 @@ func LoadApplicationHooks(db gorp.SqlExecutor, applicationID int64) ([]sdk.Hook,
 	for rows.Next() {
 		var h sdk.Hook
 		h.ApplicationID = applicationID
-		err = rows.Scan(&h.ID, &h.Kind, &h.Host, &h.Project, &h.Repository, &h.Enabled, &h.UID, &h.Pipeline.ID, &h.Pipeline.Name)
+		var paths sql.NullString
+		err = rows.Scan(&h.ID, &h.Kind, &h.Host, &h.Project, &h.Repository, &h.Enabled, &h.UID, &paths, &h.Pipeline.ID, &h.Pipeline.Name)
 		if err != nil {
 			return hooks, err
 		}
+		if err := unmarshalPaths(paths, &h.Paths); err != nil {
+			return hooks, err
+		}
 		link := viper.GetString("api_url") + HookLink
 		h.Link = fmt.Sprintf(link, h.UID, h.Project, h.Repository)
+		h.Webhooks = webhookLinks(h.UID)
 		hooks = append(hooks, h)
 	}
 
 	return hooks, nil
 }
 
-// LoadPipelineHooks will load all hooks related to given pipeline
+// LoadPipelineHooks will load all hooks related to given pipeline, without their secret
 func LoadPipelineHooks(db gorp.SqlExecutor, pipelineID int64, applicationID int64) ([]sdk.Hook, error) {
-	query := `SELECT id, kind, host, project, repository, uid FROM hook WHERE pipeline_id = $1 AND application_id= $2`
+	query := `SELECT id, kind, host, project, repository, enabled, uid, paths FROM hook WHERE pipeline_id = $1 AND application_id= $2`
 
 	rows, err := db.Query(query, pipelineID, applicationID)
 	if err != nil {
@@ -179,11 +239,16 @@ func LoadPipelineHooks(db gorp.SqlExecutor, pipelineID int64, applicationID int6
 		var h sdk.Hook
 		h.Pipeline.ID = pipelineID
 		h.ApplicationID = applicationID
-		if err = rows.Scan(&h.ID, &h.Kind, &h.Host, &h.Project, &h.Repository, &h.UID); err != nil {
+		var paths sql.NullString
+		if err = rows.Scan(&h.ID, &h.Kind, &h.Host, &h.Project, &h.Repository, &h.Enabled, &h.UID, &paths); err != nil {
+			return nil, err
+		}
+		if err := unmarshalPaths(paths, &h.Paths); err != nil {
 			return nil, err
 		}
 		link := viper.GetString("api_url") + HookLink
 		h.Link = fmt.Sprintf(link, h.UID, h.Project, h.Repository)
+		h.Webhooks = webhookLinks(h.UID)
 		hooks = append(hooks, h)
 	}
 
@@ -192,7 +257,7 @@ func LoadPipelineHooks(db gorp.SqlExecutor, pipelineID int64, applicationID int6
 
 // LoadHooks related to given repository
 func LoadHooks(db gorp.SqlExecutor, project string, repository string) ([]sdk.Hook, error) {
-	query := `SELECT id, pipeline_id, application_id, kind, host, enabled, uid FROM hook WHERE project = $1 AND repository = $2`
+	query := `SELECT id, pipeline_id, application_id, kind, host, enabled, uid, paths FROM hook WHERE project = $1 AND repository = $2`
 
 	rows, err := db.Query(query, project, repository)
 	if err != nil {
@@ -205,16 +270,28 @@ func LoadHooks(db gorp.SqlExecutor, project string, repository string) ([]sdk.Ho
 		var h sdk.Hook
 		h.Project = project
 		h.Repository = repository
-		err = rows.Scan(&h.ID, &h.Pipeline.ID, &h.ApplicationID, &h.Kind, &h.Host, &h.Enabled, &h.UID)
+		var paths sql.NullString
+		err = rows.Scan(&h.ID, &h.Pipeline.ID, &h.ApplicationID, &h.Kind, &h.Host, &h.Enabled, &h.UID, &paths)
 		if err != nil {
 			return nil, err
 		}
+		if err := unmarshalPaths(paths, &h.Paths); err != nil {
+			return nil, err
+		}
 		hooks = append(hooks, h)
 	}
 
 	return hooks, nil
 }
 
+// unmarshalPaths reads the paths filter of a hook, hooks created before path filters have none
+func unmarshalPaths(paths sql.NullString, f *sdk.PathFilter) error {
+	if !paths.Valid || paths.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(paths.String), f)
+}
+
 func generateHash() (string, error) {
 	size := 128
 	bs := make([]byte, size)
@@ -0,0 +1,143 @@
+package hook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// InsertReceivedHook insert raw data received from public handler in database, with its parsed push.
+// Deliveries are kept 30 days, see ReceivedHooksCleaner.
+func InsertReceivedHook(db gorp.SqlExecutor, h ReceivedHook) (int64, error) {
+	args, err := json.Marshal(h.Args)
+	if err != nil {
+		return 0, err
+	}
+
+	var replayOf sql.NullInt64
+	if h.ReplayOf != 0 {
+		replayOf = sql.NullInt64{Int64: h.ReplayOf, Valid: true}
+	}
+
+	query := `INSERT INTO received_hook (link, data, uid, branch, hash, author, message, args, replay_of, received)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, current_timestamp) RETURNING id`
+	var id int64
+	if err := db.QueryRow(query, h.URL.String(), string(h.Data), h.UID, h.Branch, h.Hash, h.Author, h.Message, string(args), replayOf).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// PurgeReceivedHooks deletes the deliveries received before the given date
+func PurgeReceivedHooks(db gorp.SqlExecutor, before time.Time) (int64, error) {
+	res, err := db.Exec(`DELETE FROM received_hook WHERE received < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ReceivedHooksCleaner purges the deliveries older than 30 days every hour
+func ReceivedHooksCleaner() {
+	for {
+		db := database.DBMap(database.DB())
+		if db != nil {
+			if _, err := PurgeReceivedHooks(db, time.Now().Add(-30*24*time.Hour)); err != nil {
+				log.Warning("ReceivedHooksCleaner> Cannot purge received hooks: %s\n", err)
+			}
+		}
+		time.Sleep(1 * time.Hour)
+	}
+}
+
+// UpdateReceivedHook records what has been done with a received hook: the hook it matched, and the build it triggered or why nothing ran
+func UpdateReceivedHook(db gorp.SqlExecutor, id, hookID int64, status string, buildNumber int64, reason string) error {
+	var hook, build sql.NullInt64
+	if hookID != 0 {
+		hook = sql.NullInt64{Int64: hookID, Valid: true}
+	}
+	if buildNumber != 0 {
+		build = sql.NullInt64{Int64: buildNumber, Valid: true}
+	}
+
+	query := `UPDATE received_hook SET hook_id = $2, status = $3, build_number = $4, reason = $5 WHERE id = $1`
+	_, err := db.Exec(query, id, hook, status, build, reason)
+	return err
+}
+
+// LoadHookDeliveries loads the last pushes received by a hook
+func LoadHookDeliveries(db gorp.SqlExecutor, hookID int64, limit int) ([]sdk.HookDelivery, error) {
+	query := `SELECT id, received, link, data, branch, hash, author, message, args, status, build_number, reason, replay_of
+		FROM received_hook
+		WHERE hook_id = $1
+		ORDER BY id DESC
+		LIMIT $2`
+
+	rows, err := db.Query(query, hookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []sdk.HookDelivery{}
+	for rows.Next() {
+		d := sdk.HookDelivery{HookID: hookID}
+		var link, data, branch, hash, author, message, args, status, reason sql.NullString
+		var buildNumber, replayOf sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.Received, &link, &data, &branch, &hash, &author, &message, &args, &status, &buildNumber, &reason, &replayOf); err != nil {
+			return nil, err
+		}
+		d.Link, d.Payload = link.String, data.String
+		d.Branch, d.Hash, d.Author, d.Message = branch.String, hash.String, author.String, message.String
+		d.Status, d.BuildNumber, d.Reason, d.ReplayOf = status.String, buildNumber.Int64, reason.String, replayOf.Int64
+		if args.Valid && args.String != "" {
+			if err := json.Unmarshal([]byte(args.String), &d.Args); err != nil {
+				return nil, err
+			}
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// LoadReceivedHook loads a push received by a hook to process it again
+func LoadReceivedHook(db gorp.SqlExecutor, hookID, id int64) (ReceivedHook, error) {
+	query := `SELECT received_hook.link, received_hook.data, received_hook.uid, received_hook.branch, received_hook.hash,
+		received_hook.author, received_hook.message, received_hook.args, hook.project, hook.repository
+		FROM received_hook
+		JOIN hook ON hook.id = received_hook.hook_id
+		WHERE received_hook.hook_id = $1 AND received_hook.id = $2`
+
+	var h ReceivedHook
+	var link, data, uid, branch, hash, author, message, args sql.NullString
+	if err := db.QueryRow(query, hookID, id).Scan(&link, &data, &uid, &branch, &hash, &author, &message, &args, &h.ProjectKey, &h.Repository); err != nil {
+		if err == sql.ErrNoRows {
+			return h, sdk.ErrNotFound
+		}
+		return h, err
+	}
+
+	u, err := url.Parse(link.String)
+	if err != nil {
+		return h, err
+	}
+	h.URL = *u
+	h.Data = []byte(data.String)
+	h.UID, h.Branch, h.Hash, h.Author, h.Message = uid.String, branch.String, hash.String, author.String, message.String
+	if args.Valid && args.String != "" {
+		if err := json.Unmarshal([]byte(args.String), &h.Args); err != nil {
+			return h, err
+		}
+	}
+	h.ReplayOf = id
+	return h, nil
+}
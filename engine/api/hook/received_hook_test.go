@@ -0,0 +1,78 @@
+package hook_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/hook"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/sdk"
+)
+
+func TestHookDeliveries(t *testing.T) {
+	db := test.SetupPG(t)
+	key := test.RandomString(t, 8)
+	proj := test.InsertTestProject(t, db, key, key)
+
+	pip := &sdk.Pipeline{Name: "build", Type: sdk.BuildPipeline, ProjectID: proj.ID, ProjectKey: proj.Key}
+	test.NoError(t, pipeline.InsertPipeline(db, pip))
+	app := &sdk.Application{Name: "app"}
+	test.NoError(t, application.InsertApplication(db, proj, app))
+
+	h := sdk.Hook{Pipeline: *pip, ApplicationID: app.ID, Kind: "stash", Host: "stash", Project: "team", Repository: "repo", Enabled: true}
+	test.NoError(t, hook.InsertHook(db, &h))
+
+	u, _ := url.Parse("http://cds/hook?uid=" + h.UID)
+	var ids []int64
+	for _, branch := range []string{"master", "feat", "fix"} {
+		id, err := hook.InsertReceivedHook(db, hook.ReceivedHook{URL: *u, Data: []byte(`{}`), UID: h.UID, Branch: branch, Hash: "abc", ProjectKey: "team", Repository: "repo"})
+		test.NoError(t, err)
+		test.NoError(t, hook.UpdateReceivedHook(db, id, h.ID, sdk.HookDeliveryTriggered, 1, ""))
+		ids = append(ids, id)
+	}
+
+	// Last deliveries first
+	deliveries, err := hook.LoadHookDeliveries(db, h.ID, 2)
+	test.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, ids[2], deliveries[0].ID)
+		assert.Equal(t, "fix", deliveries[0].Branch)
+		assert.Equal(t, sdk.HookDeliveryTriggered, deliveries[0].Status)
+		assert.Equal(t, int64(1), deliveries[0].BuildNumber)
+	}
+
+	// A replay is recorded as a new delivery pointing to the replayed one
+	rh, err := hook.LoadReceivedHook(db, h.ID, ids[0])
+	test.NoError(t, err)
+	assert.Equal(t, "master", rh.Branch)
+	assert.Equal(t, ids[0], rh.ReplayOf)
+	replay, err := hook.InsertReceivedHook(db, rh)
+	test.NoError(t, err)
+	test.NoError(t, hook.UpdateReceivedHook(db, replay, h.ID, sdk.HookDeliveryTriggered, 2, ""))
+	deliveries, err = hook.LoadHookDeliveries(db, h.ID, 1)
+	test.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, replay, deliveries[0].ID)
+		assert.Equal(t, ids[0], deliveries[0].ReplayOf)
+	}
+
+	_, err = hook.LoadReceivedHook(db, h.ID, 0)
+	assert.Equal(t, sdk.ErrNotFound, err)
+
+	// Only deliveries received before the date are purged
+	_, err = db.Exec(`UPDATE received_hook SET received = current_timestamp - interval '31 days' WHERE id = $1`, ids[0])
+	test.NoError(t, err)
+	_, err = hook.PurgeReceivedHooks(db, time.Now().Add(-30*24*time.Hour))
+	test.NoError(t, err)
+	deliveries, err = hook.LoadHookDeliveries(db, h.ID, 10)
+	test.NoError(t, err)
+	assert.Len(t, deliveries, 3)
+	for _, d := range deliveries {
+		assert.NotEqual(t, ids[0], d.ID)
+	}
+}
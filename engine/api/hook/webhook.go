@@ -0,0 +1,463 @@
+package hook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Webhook event types
+const (
+	WebhookPush        = "push"
+	WebhookTag         = "tag"
+	WebhookPullRequest = "pull_request"
+)
+
+// Webhook providers, the last part of the native webhook urls
+const (
+	WebhookGithub    = "github"
+	WebhookBitbucket = "bitbucket"
+	WebhookGitlab    = "gitlab"
+)
+
+// WebhookLink format of native webhooks, with the provider and the hook uid
+const WebhookLink = "/hook/%s/%s"
+
+// webhookLinks returns the urls of the native webhooks of a hook by provider
+func webhookLinks(uid string) map[string]string {
+	link := viper.GetString("api_url") + WebhookLink
+	return map[string]string{
+		WebhookGithub:    fmt.Sprintf(link, WebhookGithub, uid),
+		WebhookBitbucket: fmt.Sprintf(link, WebhookBitbucket, uid),
+		WebhookGitlab:    fmt.Sprintf(link, WebhookGitlab, uid),
+	}
+}
+
+// WebhookEvent is a push, tag or pull request event parsed from a native webhook payload
+type WebhookEvent struct {
+	Type        string
+	Project     string
+	Repository  string
+	Branch      string
+	Tag         string
+	Hash        string
+	Author      string
+	Change      string // ADD, UPDATE or DELETE, as stash refChange.type
+	PullRequest *PullRequest
+}
+
+// PullRequest details a pull request event
+type PullRequest struct {
+	ID           int64
+	Action       string
+	Title        string
+	URL          string
+	Author       string
+	SourceBranch string
+	TargetBranch string
+	Fork         bool
+}
+
+// CheckSignature checks a "sha256=<hex>" or "sha1=<hex>" HMAC signature of the body computed with the hook secret
+func CheckSignature(secret string, body []byte, signature string) error {
+	if secret == "" || signature == "" {
+		return sdk.ErrInvalidHookSignature
+	}
+
+	var h func() hash.Hash
+	switch {
+	case strings.HasPrefix(signature, "sha256="):
+		h = sha256.New
+	case strings.HasPrefix(signature, "sha1="):
+		h = sha1.New
+	default:
+		return sdk.ErrInvalidHookSignature
+	}
+
+	expected, err := hex.DecodeString(signature[strings.Index(signature, "=")+1:])
+	if err != nil {
+		return sdk.ErrInvalidHookSignature
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return sdk.ErrInvalidHookSignature
+	}
+	return nil
+}
+
+// CheckToken checks the secret token sent as is by gitlab
+func CheckToken(secret, token string) error {
+	if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+		return sdk.ErrInvalidHookSignature
+	}
+	return nil
+}
+
+// Args returns the git.pr.* build parameters of a pull request
+func (pr *PullRequest) Args() []sdk.Parameter {
+	return []sdk.Parameter{
+		{Name: "git.pr.number", Type: sdk.StringParameter, Value: fmt.Sprintf("%d", pr.ID)},
+		{Name: "git.pr.title", Type: sdk.StringParameter, Value: pr.Title},
+		{Name: "git.pr.url", Type: sdk.StringParameter, Value: pr.URL},
+		{Name: "git.pr.author", Type: sdk.StringParameter, Value: pr.Author},
+		{Name: "git.pr.source", Type: sdk.StringParameter, Value: pr.SourceBranch},
+		{Name: "git.pr.target", Type: sdk.StringParameter, Value: pr.TargetBranch},
+	}
+}
+
+type githubRepository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+}
+
+// ParseGithubEvent parses the payload of a github push or pull_request event
+// https://developer.github.com/v3/activity/events/types/
+func ParseGithubEvent(event string, body []byte) ([]WebhookEvent, error) {
+	switch event {
+	case "push":
+		var push struct {
+			Ref        string           `json:"ref"`
+			After      string           `json:"after"`
+			Created    bool             `json:"created"`
+			Deleted    bool             `json:"deleted"`
+			Repository githubRepository `json:"repository"`
+			Pusher     struct {
+				Name string `json:"name"`
+			} `json:"pusher"`
+			HeadCommit *struct {
+				ID string `json:"id"`
+			} `json:"head_commit"`
+		}
+		if err := json.Unmarshal(body, &push); err != nil {
+			return nil, err
+		}
+
+		e := newGithubEvent(push.Repository)
+		e.Author = push.Pusher.Name
+		e.Hash = push.After
+		//On annotated tags, after is the tag object
+		if push.HeadCommit != nil {
+			e.Hash = push.HeadCommit.ID
+		}
+		switch {
+		case push.Deleted:
+			e.Change = "DELETE"
+		case push.Created:
+			e.Change = "ADD"
+		default:
+			e.Change = "UPDATE"
+		}
+		switch {
+		case strings.HasPrefix(push.Ref, "refs/heads/"):
+			e.Type = WebhookPush
+			e.Branch = strings.TrimPrefix(push.Ref, "refs/heads/")
+		case strings.HasPrefix(push.Ref, "refs/tags/"):
+			e.Type = WebhookTag
+			e.Tag = strings.TrimPrefix(push.Ref, "refs/tags/")
+		default:
+			return nil, nil
+		}
+		return []WebhookEvent{e}, nil
+
+	case "pull_request":
+		var pr struct {
+			Action      string `json:"action"`
+			PullRequest struct {
+				Number  int64  `json:"number"`
+				Title   string `json:"title"`
+				HTMLURL string `json:"html_url"`
+				User    struct {
+					Login string `json:"login"`
+				} `json:"user"`
+				Head struct {
+					Ref  string           `json:"ref"`
+					Sha  string           `json:"sha"`
+					Repo githubRepository `json:"repo"`
+				} `json:"head"`
+				Base struct {
+					Ref string `json:"ref"`
+				} `json:"base"`
+			} `json:"pull_request"`
+			Repository githubRepository `json:"repository"`
+			Sender     struct {
+				Login string `json:"login"`
+			} `json:"sender"`
+		}
+		if err := json.Unmarshal(body, &pr); err != nil {
+			return nil, err
+		}
+		//Only changes of the pull request code are built
+		if pr.Action != "opened" && pr.Action != "synchronize" && pr.Action != "reopened" {
+			return nil, nil
+		}
+
+		e := newGithubEvent(pr.Repository)
+		e.Type = WebhookPullRequest
+		e.Branch = pr.PullRequest.Head.Ref
+		e.Hash = pr.PullRequest.Head.Sha
+		e.Author = pr.Sender.Login
+		e.Change = "UPDATE"
+		e.PullRequest = &PullRequest{
+			ID:           pr.PullRequest.Number,
+			Action:       pr.Action,
+			Title:        pr.PullRequest.Title,
+			URL:          pr.PullRequest.HTMLURL,
+			Author:       pr.PullRequest.User.Login,
+			SourceBranch: pr.PullRequest.Head.Ref,
+			TargetBranch: pr.PullRequest.Base.Ref,
+			Fork:         pr.PullRequest.Head.Repo.FullName != pr.Repository.FullName,
+		}
+		return []WebhookEvent{e}, nil
+	}
+
+	//ping and other events do not trigger anything
+	return nil, nil
+}
+
+func newGithubEvent(repo githubRepository) WebhookEvent {
+	e := WebhookEvent{Repository: repo.Name}
+	if i := strings.Index(repo.FullName, "/"); i > 0 {
+		e.Project = repo.FullName[:i]
+	}
+	return e
+}
+
+type bitbucketRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+}
+
+type bitbucketRef struct {
+	ID           string              `json:"id"`
+	DisplayID    string              `json:"displayId"`
+	LatestCommit string              `json:"latestCommit"`
+	Repository   bitbucketRepository `json:"repository"`
+}
+
+// ParseBitbucketEvent parses the payload of a bitbucket server repo:refs_changed or pull request event
+// https://confluence.atlassian.com/bitbucketserver/event-payload-938025882.html
+func ParseBitbucketEvent(event string, body []byte) ([]WebhookEvent, error) {
+	var payload struct {
+		Actor struct {
+			Name string `json:"name"`
+		} `json:"actor"`
+		Repository bitbucketRepository `json:"repository"`
+		Changes    []struct {
+			Ref struct {
+				DisplayID string `json:"displayId"`
+				Type      string `json:"type"`
+			} `json:"ref"`
+			ToHash string `json:"toHash"`
+			Type   string `json:"type"`
+		} `json:"changes"`
+		PullRequest struct {
+			ID    int64  `json:"id"`
+			Title string `json:"title"`
+			Links struct {
+				Self []struct {
+					Href string `json:"href"`
+				} `json:"self"`
+			} `json:"links"`
+			Author struct {
+				User struct {
+					Name string `json:"name"`
+				} `json:"user"`
+			} `json:"author"`
+			FromRef bitbucketRef `json:"fromRef"`
+			ToRef   bitbucketRef `json:"toRef"`
+		} `json:"pullRequest"`
+	}
+
+	switch event {
+	case "repo:refs_changed":
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		events := []WebhookEvent{}
+		for _, c := range payload.Changes {
+			e := WebhookEvent{
+				Project:    payload.Repository.Project.Key,
+				Repository: payload.Repository.Slug,
+				Hash:       c.ToHash,
+				Author:     payload.Actor.Name,
+				Change:     c.Type,
+			}
+			switch c.Ref.Type {
+			case "BRANCH":
+				e.Type = WebhookPush
+				e.Branch = c.Ref.DisplayID
+			case "TAG":
+				e.Type = WebhookTag
+				e.Tag = c.Ref.DisplayID
+			default:
+				continue
+			}
+			events = append(events, e)
+		}
+		return events, nil
+
+	case "pr:opened", "pr:from_ref_updated":
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		pr := payload.PullRequest
+		e := WebhookEvent{
+			Type:       WebhookPullRequest,
+			Project:    pr.ToRef.Repository.Project.Key,
+			Repository: pr.ToRef.Repository.Slug,
+			Branch:     pr.FromRef.DisplayID,
+			Hash:       pr.FromRef.LatestCommit,
+			Author:     payload.Actor.Name,
+			Change:     "UPDATE",
+			PullRequest: &PullRequest{
+				ID:           pr.ID,
+				Action:       strings.TrimPrefix(event, "pr:"),
+				Title:        pr.Title,
+				Author:       pr.Author.User.Name,
+				SourceBranch: pr.FromRef.DisplayID,
+				TargetBranch: pr.ToRef.DisplayID,
+				Fork: pr.FromRef.Repository.Slug != pr.ToRef.Repository.Slug ||
+					pr.FromRef.Repository.Project.Key != pr.ToRef.Repository.Project.Key,
+			},
+		}
+		if len(pr.Links.Self) > 0 {
+			e.PullRequest.URL = pr.Links.Self[0].Href
+		}
+		return []WebhookEvent{e}, nil
+	}
+
+	//diagnostics:ping and other events do not trigger anything
+	return nil, nil
+}
+
+// ParseGitlabEvent parses the payload of a gitlab push, tag push or merge request event
+// https://docs.gitlab.com/ce/user/project/integrations/webhooks.html
+func ParseGitlabEvent(event string, body []byte) ([]WebhookEvent, error) {
+	var payload struct {
+		Ref          string  `json:"ref"`
+		Before       string  `json:"before"`
+		After        string  `json:"after"`
+		CheckoutSha  *string `json:"checkout_sha"`
+		UserUsername string  `json:"user_username"`
+		User         struct {
+			Username string `json:"username"`
+		} `json:"user"`
+		Project struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+		} `json:"project"`
+		ObjectAttributes struct {
+			IID             int64   `json:"iid"`
+			Title           string  `json:"title"`
+			URL             string  `json:"url"`
+			Action          string  `json:"action"`
+			OldRev          *string `json:"oldrev"`
+			SourceBranch    string  `json:"source_branch"`
+			TargetBranch    string  `json:"target_branch"`
+			SourceProjectID int64   `json:"source_project_id"`
+			TargetProjectID int64   `json:"target_project_id"`
+			LastCommit      struct {
+				ID string `json:"id"`
+			} `json:"last_commit"`
+		} `json:"object_attributes"`
+	}
+
+	switch event {
+	case "Push Hook", "Tag Push Hook":
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		e := newGitlabEvent(payload.Project.PathWithNamespace)
+		e.Author = payload.UserUsername
+		e.Hash = payload.After
+		//On annotated tags, after is the tag object
+		if payload.CheckoutSha != nil && *payload.CheckoutSha != "" {
+			e.Hash = *payload.CheckoutSha
+		}
+		switch {
+		case strings.Trim(payload.After, "0") == "":
+			e.Change = "DELETE"
+		case strings.Trim(payload.Before, "0") == "":
+			e.Change = "ADD"
+		default:
+			e.Change = "UPDATE"
+		}
+		switch {
+		case strings.HasPrefix(payload.Ref, "refs/heads/"):
+			e.Type = WebhookPush
+			e.Branch = strings.TrimPrefix(payload.Ref, "refs/heads/")
+		case strings.HasPrefix(payload.Ref, "refs/tags/"):
+			e.Type = WebhookTag
+			e.Tag = strings.TrimPrefix(payload.Ref, "refs/tags/")
+		default:
+			return nil, nil
+		}
+		return []WebhookEvent{e}, nil
+
+	case "Merge Request Hook":
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		mr := payload.ObjectAttributes
+		//Only changes of the merge request code are built: updates without oldrev only change its description
+		if mr.Action != "open" && mr.Action != "reopen" && !(mr.Action == "update" && mr.OldRev != nil) {
+			return nil, nil
+		}
+
+		e := newGitlabEvent(payload.Project.PathWithNamespace)
+		e.Type = WebhookPullRequest
+		e.Branch = mr.SourceBranch
+		e.Hash = mr.LastCommit.ID
+		e.Author = payload.User.Username
+		e.Change = "UPDATE"
+		e.PullRequest = &PullRequest{
+			ID:           mr.IID,
+			Action:       mr.Action,
+			Title:        mr.Title,
+			URL:          mr.URL,
+			Author:       payload.User.Username,
+			SourceBranch: mr.SourceBranch,
+			TargetBranch: mr.TargetBranch,
+			Fork:         mr.SourceProjectID != mr.TargetProjectID,
+		}
+		return []WebhookEvent{e}, nil
+	}
+
+	//Other events do not trigger anything
+	return nil, nil
+}
+
+func newGitlabEvent(pathWithNamespace string) WebhookEvent {
+	e := WebhookEvent{Repository: pathWithNamespace}
+	if i := strings.LastIndex(pathWithNamespace, "/"); i > 0 {
+		e.Project = pathWithNamespace[:i]
+		e.Repository = pathWithNamespace[i+1:]
+	}
+	return e
+}
+
+// ParseWebhookEvent parses the payload of an event sent by the given provider
+func ParseWebhookEvent(provider, event string, body []byte) ([]WebhookEvent, error) {
+	switch provider {
+	case WebhookGithub:
+		return ParseGithubEvent(event, body)
+	case WebhookBitbucket:
+		return ParseBitbucketEvent(event, body)
+	case WebhookGitlab:
+		return ParseGitlabEvent(event, body)
+	}
+	return nil, fmt.Errorf("unsupported webhook provider %s", provider)
+}
@@ -0,0 +1,257 @@
+package hook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestCheckSignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/master"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	sha256Signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	mac = hmac.New(sha1.New, []byte("secret"))
+	mac.Write(body)
+	sha1Signature := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.NoError(t, CheckSignature("secret", body, sha256Signature))
+	assert.NoError(t, CheckSignature("secret", body, sha1Signature))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckSignature("other", body, sha256Signature))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckSignature("secret", []byte(`{}`), sha256Signature))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckSignature("secret", body, ""))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckSignature("", body, sha256Signature))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckSignature("secret", body, "md5=abcd"))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckSignature("secret", body, "sha256=nothex"))
+}
+
+func TestParseGithubEvent(t *testing.T) {
+	push := `{
+		"ref": "refs/heads/feat/x", "after": "abc", "created": false, "deleted": false,
+		"repository": {"name": "repo", "full_name": "owner/repo"},
+		"pusher": {"name": "john"},
+		"head_commit": {"id": "abc"}
+	}`
+	events, err := ParseGithubEvent("push", []byte(push))
+	assert.NoError(t, err)
+	assert.Equal(t, []WebhookEvent{{
+		Type:       WebhookPush,
+		Project:    "owner",
+		Repository: "repo",
+		Branch:     "feat/x",
+		Hash:       "abc",
+		Author:     "john",
+		Change:     "UPDATE",
+	}}, events)
+
+	tag := `{
+		"ref": "refs/tags/v1.0.0", "after": "tagobject", "created": true,
+		"repository": {"name": "repo", "full_name": "owner/repo"},
+		"pusher": {"name": "john"},
+		"head_commit": {"id": "abc"}
+	}`
+	events, err = ParseGithubEvent("push", []byte(tag))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, WebhookTag, events[0].Type)
+	assert.Equal(t, "v1.0.0", events[0].Tag)
+	assert.Equal(t, "abc", events[0].Hash)
+	assert.Equal(t, "ADD", events[0].Change)
+
+	deleted := `{"ref": "refs/heads/old", "after": "0000000000000000000000000000000000000000", "deleted": true, "repository": {"name": "repo", "full_name": "owner/repo"}, "head_commit": null}`
+	events, err = ParseGithubEvent("push", []byte(deleted))
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE", events[0].Change)
+	assert.Equal(t, "old", events[0].Branch)
+
+	pr := `{
+		"action": "synchronize",
+		"pull_request": {
+			"number": 42, "title": "Add feature", "html_url": "https://github.com/owner/repo/pull/42",
+			"user": {"login": "jane"},
+			"head": {"ref": "feat/x", "sha": "def", "repo": {"full_name": "owner/repo"}},
+			"base": {"ref": "master"}
+		},
+		"repository": {"name": "repo", "full_name": "owner/repo"},
+		"sender": {"login": "jane"}
+	}`
+	events, err = ParseGithubEvent("pull_request", []byte(pr))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, WebhookPullRequest, events[0].Type)
+	assert.Equal(t, "feat/x", events[0].Branch)
+	assert.Equal(t, "def", events[0].Hash)
+	assert.Equal(t, "jane", events[0].Author)
+	assert.Equal(t, &PullRequest{
+		ID:           42,
+		Action:       "synchronize",
+		Title:        "Add feature",
+		URL:          "https://github.com/owner/repo/pull/42",
+		Author:       "jane",
+		SourceBranch: "feat/x",
+		TargetBranch: "master",
+	}, events[0].PullRequest)
+
+	closed := `{"action": "closed", "pull_request": {"number": 42}, "repository": {"name": "repo", "full_name": "owner/repo"}}`
+	events, err = ParseGithubEvent("pull_request", []byte(closed))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+
+	events, err = ParseGithubEvent("ping", []byte(`{"zen": "Keep it logically awesome."}`))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+
+	_, err = ParseGithubEvent("push", []byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestParseBitbucketEvent(t *testing.T) {
+	refsChanged := `{
+		"eventKey": "repo:refs_changed",
+		"actor": {"name": "john"},
+		"repository": {"slug": "repo", "project": {"key": "PRJ"}},
+		"changes": [
+			{"ref": {"id": "refs/heads/master", "displayId": "master", "type": "BRANCH"}, "fromHash": "aaa", "toHash": "bbb", "type": "UPDATE"},
+			{"ref": {"id": "refs/tags/v1.0.0", "displayId": "v1.0.0", "type": "TAG"}, "fromHash": "000", "toHash": "bbb", "type": "ADD"},
+			{"ref": {"id": "refs/heads/old", "displayId": "old", "type": "BRANCH"}, "fromHash": "ccc", "toHash": "0000000000000000000000000000000000000000", "type": "DELETE"}
+		]
+	}`
+	events, err := ParseBitbucketEvent("repo:refs_changed", []byte(refsChanged))
+	assert.NoError(t, err)
+	assert.Equal(t, []WebhookEvent{
+		{Type: WebhookPush, Project: "PRJ", Repository: "repo", Branch: "master", Hash: "bbb", Author: "john", Change: "UPDATE"},
+		{Type: WebhookTag, Project: "PRJ", Repository: "repo", Tag: "v1.0.0", Hash: "bbb", Author: "john", Change: "ADD"},
+		{Type: WebhookPush, Project: "PRJ", Repository: "repo", Branch: "old", Hash: "0000000000000000000000000000000000000000", Author: "john", Change: "DELETE"},
+	}, events)
+
+	pr := `{
+		"eventKey": "pr:opened",
+		"actor": {"name": "jane"},
+		"pullRequest": {
+			"id": 7, "title": "Add feature",
+			"author": {"user": {"name": "jane"}},
+			"links": {"self": [{"href": "https://bitbucket/projects/PRJ/repos/repo/pull-requests/7"}]},
+			"fromRef": {"id": "refs/heads/feat/x", "displayId": "feat/x", "latestCommit": "def", "repository": {"slug": "fork", "project": {"key": "~JANE"}}},
+			"toRef": {"id": "refs/heads/master", "displayId": "master", "latestCommit": "bbb", "repository": {"slug": "repo", "project": {"key": "PRJ"}}}
+		}
+	}`
+	events, err = ParseBitbucketEvent("pr:opened", []byte(pr))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "PRJ", events[0].Project)
+	assert.Equal(t, "repo", events[0].Repository)
+	assert.Equal(t, "feat/x", events[0].Branch)
+	assert.Equal(t, "def", events[0].Hash)
+	assert.Equal(t, &PullRequest{
+		ID:           7,
+		Action:       "opened",
+		Title:        "Add feature",
+		URL:          "https://bitbucket/projects/PRJ/repos/repo/pull-requests/7",
+		Author:       "jane",
+		SourceBranch: "feat/x",
+		TargetBranch: "master",
+		Fork:         true,
+	}, events[0].PullRequest)
+
+	events, err = ParseBitbucketEvent("diagnostics:ping", []byte(`{}`))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+}
+
+func TestCheckToken(t *testing.T) {
+	assert.NoError(t, CheckToken("secret", "secret"))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckToken("secret", "other"))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckToken("secret", ""))
+	assert.Equal(t, sdk.ErrInvalidHookSignature, CheckToken("", ""))
+}
+
+func TestParseGitlabEvent(t *testing.T) {
+	push := `{
+		"object_kind": "push", "ref": "refs/heads/feat/x", "before": "aaa", "after": "bbb", "checkout_sha": "bbb",
+		"user_username": "john",
+		"project": {"path_with_namespace": "group/repo"}
+	}`
+	events, err := ParseGitlabEvent("Push Hook", []byte(push))
+	assert.NoError(t, err)
+	assert.Equal(t, []WebhookEvent{{
+		Type:       WebhookPush,
+		Project:    "group",
+		Repository: "repo",
+		Branch:     "feat/x",
+		Hash:       "bbb",
+		Author:     "john",
+		Change:     "UPDATE",
+	}}, events)
+
+	tag := `{
+		"object_kind": "tag_push", "ref": "refs/tags/v1.0.0", "before": "0000000000000000000000000000000000000000", "after": "tagobject", "checkout_sha": "bbb",
+		"user_username": "john",
+		"project": {"path_with_namespace": "group/repo"}
+	}`
+	events, err = ParseGitlabEvent("Tag Push Hook", []byte(tag))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, WebhookTag, events[0].Type)
+	assert.Equal(t, "v1.0.0", events[0].Tag)
+	assert.Equal(t, "bbb", events[0].Hash)
+	assert.Equal(t, "ADD", events[0].Change)
+
+	deleted := `{"ref": "refs/heads/old", "before": "aaa", "after": "0000000000000000000000000000000000000000", "checkout_sha": null, "project": {"path_with_namespace": "group/repo"}}`
+	events, err = ParseGitlabEvent("Push Hook", []byte(deleted))
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE", events[0].Change)
+
+	mr := `{
+		"object_kind": "merge_request",
+		"user": {"username": "jane"},
+		"project": {"path_with_namespace": "group/repo"},
+		"object_attributes": {
+			"iid": 7, "title": "Add feature", "url": "https://gitlab/group/repo/merge_requests/7",
+			"action": "update", "oldrev": "ccc",
+			"source_branch": "feat/x", "target_branch": "master",
+			"source_project_id": 1, "target_project_id": 1,
+			"last_commit": {"id": "def"}
+		}
+	}`
+	events, err = ParseGitlabEvent("Merge Request Hook", []byte(mr))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, WebhookPullRequest, events[0].Type)
+	assert.Equal(t, "feat/x", events[0].Branch)
+	assert.Equal(t, "def", events[0].Hash)
+	assert.Equal(t, &PullRequest{
+		ID:           7,
+		Action:       "update",
+		Title:        "Add feature",
+		URL:          "https://gitlab/group/repo/merge_requests/7",
+		Author:       "jane",
+		SourceBranch: "feat/x",
+		TargetBranch: "master",
+	}, events[0].PullRequest)
+
+	//Updates of the description only are not built
+	described := `{"object_kind": "merge_request", "project": {"path_with_namespace": "group/repo"}, "object_attributes": {"iid": 7, "action": "update"}}`
+	events, err = ParseGitlabEvent("Merge Request Hook", []byte(described))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+}
+
+func TestPullRequestArgs(t *testing.T) {
+	pr := &PullRequest{ID: 42, Title: "Add feature", Author: "jane", SourceBranch: "feat/x", TargetBranch: "master"}
+	args := map[string]string{}
+	for _, a := range pr.Args() {
+		args[a.Name] = a.Value
+	}
+	assert.Equal(t, "42", args["git.pr.number"])
+	assert.Equal(t, "jane", args["git.pr.author"])
+	assert.Equal(t, "feat/x", args["git.pr.source"])
+	assert.Equal(t, "master", args["git.pr.target"])
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/repositoriesmanager"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// loadReadableDeployments loads deployments of a project on environments and applications readable by the user
+func loadReadableDeployments(db gorp.SqlExecutor, projectKey string, u *sdk.User) ([]sdk.Deployment, error) {
+	deployments, err := pipeline.LoadDeployments(db, projectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := []sdk.Deployment{}
+	for _, d := range deployments {
+		if !permission.AccessToApplication(d.ApplicationID, u, permission.PermissionRead) {
+			continue
+		}
+		if d.EnvironmentID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(d.EnvironmentID, u, permission.PermissionRead) {
+			continue
+		}
+		readable = append(readable, d)
+	}
+	return readable, nil
+}
+
+func getInventoryHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["permProjectKey"]
+
+	deployments, err := loadReadableDeployments(db, projectKey, c.User)
+	if err != nil {
+		log.Warning("getInventoryHandler> Cannot load deployments of project %s: %s\n", projectKey, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, pipeline.Inventory(deployments), http.StatusOK)
+}
+
+func getInventoryDriftHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["permProjectKey"]
+
+	from := r.FormValue("from")
+	to := r.FormValue("to")
+	if from == "" || to == "" {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	deployments, err := loadReadableDeployments(db, projectKey, c.User)
+	if err != nil {
+		log.Warning("getInventoryDriftHandler> Cannot load deployments of project %s: %s\n", projectKey, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, pipeline.Drift(deployments, from, to), http.StatusOK)
+}
+
+func getApplicationInventoryDiffHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+
+	from := r.FormValue("from")
+	to := r.FormValue("to")
+	pipName := r.FormValue("pipeline")
+	if from == "" || to == "" {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getApplicationInventoryDiffHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	deployments, err := loadReadableDeployments(db, projectKey, c.User)
+	if err != nil {
+		log.Warning("getApplicationInventoryDiffHandler> Cannot load deployments of project %s: %s\n", projectKey, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	var fromDeployment, toDeployment *sdk.Deployment
+	for i := range deployments {
+		d := &deployments[i]
+		if d.ApplicationID != app.ID || (pipName != "" && d.Pipeline != pipName) {
+			continue
+		}
+		if d.Environment == from && (fromDeployment == nil || d.Done.After(fromDeployment.Done)) {
+			fromDeployment = d
+		}
+		if d.Environment == to && (toDeployment == nil || d.Done.After(toDeployment.Done)) {
+			toDeployment = d
+		}
+	}
+
+	if fromDeployment == nil || toDeployment == nil {
+		log.Warning("getApplicationInventoryDiffHandler> Application %s is not deployed on both %s and %s\n", appName, from, to)
+		WriteError(w, r, sdk.ErrNoPipelineBuild)
+		return
+	}
+
+	commits := []sdk.VCSCommit{}
+	if fromDeployment.Hash == "" || toDeployment.Hash == "" || fromDeployment.Hash == toDeployment.Hash {
+		WriteJSON(w, r, commits, http.StatusOK)
+		return
+	}
+
+	if app.RepositoriesManager == nil || app.RepositoryFullname == "" {
+		WriteError(w, r, sdk.ErrNoReposManagerClientAuth)
+		return
+	}
+
+	client, err := repositoriesmanager.AuthorizedClient(db, projectKey, app.RepositoriesManager.Name)
+	if err != nil {
+		log.Warning("getApplicationInventoryDiffHandler> Cannot get client: %s\n", err)
+		WriteError(w, r, sdk.ErrNoReposManagerClientAuth)
+		return
+	}
+
+	commits, err = client.Commits(app.RepositoryFullname, fromDeployment.Branch, toDeployment.Hash, fromDeployment.Hash)
+	if err != nil {
+		log.Warning("getApplicationInventoryDiffHandler> Cannot get commits: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	WriteJSON(w, r, commits, http.StatusOK)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/auth"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/sessionstore"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func oidcDriver(w http.ResponseWriter, r *http.Request) (*auth.OIDCClient, bool) {
+	d, ok := router.authDriver.(*auth.OIDCClient)
+	if !ok {
+		WriteError(w, r, sdk.ErrOIDCNotEnabled)
+	}
+	return d, ok
+}
+
+// getOIDCAuthorizeHandler returns the provider page the UI redirects the user to
+func getOIDCAuthorizeHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	d, ok := oidcDriver(w, r)
+	if !ok {
+		return
+	}
+
+	u, state, err := d.AuthorizeURL()
+	if err != nil {
+		log.Warning("getOIDCAuthorizeHandler> Cannot prepare authorization: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, sdk.OIDCAuthorization{URL: u, State: state}, http.StatusOK)
+}
+
+// oidcCallbackHandler logs in the user with the code the provider sent back to the UI
+func oidcCallbackHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	d, ok := oidcDriver(w, r)
+	if !ok {
+		return
+	}
+
+	data, errr := ioutil.ReadAll(r.Body)
+	if errr != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var req sdk.OIDCCallbackRequest
+	if err := json.Unmarshal(data, &req); err != nil || req.Code == "" {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		WriteError(w, r, errb)
+		return
+	}
+	defer tx.Rollback()
+
+	u, erre := d.Exchange(tx, req.State, req.Code)
+	if erre != nil {
+		log.Warning("oidcCallbackHandler> Login failed: %s\n", erre)
+		if erre == sdk.ErrForbidden {
+			WriteError(w, r, erre)
+			return
+		}
+		WriteError(w, r, sdk.ErrInvalidUser)
+		return
+	}
+
+	oidcLogin(w, r, tx, d, u, false)
+}
+
+// oidcDeviceAuthorizeHandler starts a device authorization for the CLI
+func oidcDeviceAuthorizeHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	d, ok := oidcDriver(w, r)
+	if !ok {
+		return
+	}
+
+	a, err := d.DeviceAuthorize()
+	if err != nil {
+		log.Warning("oidcDeviceAuthorizeHandler> Cannot start device authorization: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, a, http.StatusOK)
+}
+
+// oidcDeviceTokenHandler logs in the CLI once the user approved the device authorization,
+// it answers 202 Accepted until then
+func oidcDeviceTokenHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	d, ok := oidcDriver(w, r)
+	if !ok {
+		return
+	}
+
+	data, errr := ioutil.ReadAll(r.Body)
+	if errr != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var req sdk.OIDCDeviceTokenRequest
+	if err := json.Unmarshal(data, &req); err != nil || req.DeviceCode == "" {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		WriteError(w, r, errb)
+		return
+	}
+	defer tx.Rollback()
+
+	u, errp := d.PollDeviceToken(tx, req.DeviceCode)
+	if errp == auth.ErrAuthorizationPending {
+		WriteJSON(w, r, nil, http.StatusAccepted)
+		return
+	}
+	if errp != nil {
+		log.Warning("oidcDeviceTokenHandler> Login failed: %s\n", errp)
+		if errp == sdk.ErrForbidden {
+			WriteError(w, r, errp)
+			return
+		}
+		WriteError(w, r, sdk.ErrInvalidUser)
+		return
+	}
+
+	oidcLogin(w, r, tx, d, u, true)
+}
+
+// oidcLogin creates the session of a user authenticated by the provider, persistent for the CLI
+func oidcLogin(w http.ResponseWriter, r *http.Request, tx *gorp.Transaction, d *auth.OIDCClient, u *sdk.User, persistent bool) {
+	if err := group.CheckUserInDefaultGroup(tx, u.ID); err != nil {
+		log.Warning("oidcLogin> Error while check user in default group:%s\n", err)
+	}
+
+	var sessionKey sessionstore.SessionKey
+	var errs error
+	if persistent {
+		sessionKey, errs = auth.NewPersistentSession(tx, d, u)
+	} else {
+		sessionKey, errs = auth.NewSession(d, u)
+	}
+	if errs != nil {
+		log.Critical("oidcLogin> Error while creating new session: %s\n", errs)
+		WriteError(w, r, errs)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	response := sdk.UserAPIResponse{
+		User:  *u,
+		Token: string(sessionKey),
+	}
+	response.User.Auth = sdk.Auth{}
+	w.Header().Set(sdk.SessionTokenHeader, string(sessionKey))
+	WriteJSON(w, r, response, http.StatusOK)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/ovh/cds/engine/api/event"
 	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/api/hatchery"
+	"github.com/ovh/cds/engine/api/hook"
 	"github.com/ovh/cds/engine/api/mail"
 	"github.com/ovh/cds/engine/api/objectstore"
 	"github.com/ovh/cds/engine/api/pipeline"
@@ -173,8 +174,8 @@ var mainCmd = &cobra.Command{
 		// Initialize the auth driver
 		var authMode string
 		var authOptions interface{}
-		switch viper.GetBool("ldap_enable") {
-		case true:
+		switch {
+		case viper.GetBool("ldap_enable"):
 			authMode = "ldap"
 			authOptions = auth.LDAPConfig{
 				Host:         viper.GetString("ldap_host"),
@@ -183,6 +184,25 @@ var mainCmd = &cobra.Command{
 				DN:           viper.GetString("ldap_dn"),
 				SSL:          viper.GetBool("ldap_ssl"),
 				UserFullname: viper.GetString("ldap_user_fullname"),
+
+				GroupFilter:          viper.GetString("ldap_group_filter"),
+				GroupDNs:             strings.FieldsFunc(viper.GetString("ldap_group_dns"), func(r rune) bool { return r == ';' }),
+				GroupNameAttribute:   viper.GetString("ldap_group_name_attribute"),
+				GroupMemberAttribute: viper.GetString("ldap_group_member_attribute"),
+				GroupAdminAttribute:  viper.GetString("ldap_group_admin_attribute"),
+			}
+		case viper.GetBool("oidc_enable"):
+			authMode = "oidc"
+			authOptions = auth.OIDCConfig{
+				Issuer:        viper.GetString("oidc_issuer"),
+				ClientID:      viper.GetString("oidc_client_id"),
+				ClientSecret:  viper.GetString("oidc_client_secret"),
+				RedirectURL:   viper.GetString("oidc_redirect_url"),
+				Scopes:        strings.Split(viper.GetString("oidc_scopes"), ","),
+				UsernameClaim: viper.GetString("oidc_username_claim"),
+				FullnameClaim: viper.GetString("oidc_fullname_claim"),
+				EmailClaim:    viper.GetString("oidc_email_claim"),
+				GroupsClaim:   viper.GetString("oidc_groups_claim"),
 			}
 		default:
 			authMode = "local"
@@ -195,7 +215,11 @@ var mainCmd = &cobra.Command{
 			RedisPassword: viper.GetString("redis_password"),
 		}
 
-		router.authDriver, _ = auth.GetDriver(authMode, authOptions, storeOptions)
+		var errDriver error
+		router.authDriver, errDriver = auth.GetDriver(authMode, authOptions, storeOptions)
+		if errDriver != nil {
+			log.Fatalf("Cannot initialize %s auth driver: %s\n", authMode, errDriver)
+		}
 
 		cache.Initialize(viper.GetString("cache"), viper.GetString("redis_host"), viper.GetString("redis_password"), viper.GetInt("cache_ttl"))
 
@@ -213,12 +237,15 @@ var mainCmd = &cobra.Command{
 		}
 
 		go queue.Pipelines()
+		go queue.HealthChecks()
 		go pipeline.AWOLPipelineKiller()
 		//go pipeline.HistoryCleaningRoutine(db)
 		go worker.Heartbeat()
 		go hatchery.Heartbeat()
 		go log.RemovalRoutine()
 		go auditCleanerRoutine()
+		go pipeline.SkippedBuildsCleaner()
+		go hook.ReceivedHooksCleaner()
 
 		go repositoriesmanager.ReceiveEvents()
 
@@ -227,6 +254,10 @@ var mainCmd = &cobra.Command{
 		go worker.ModelCapabilititiesCacheLoader(5)
 		go hookRecoverer()
 
+		if ldap, ok := router.authDriver.(*auth.LDAPClient); ok && ldap.GroupSyncEnabled() && viper.GetInt("ldap_group_sync_interval") > 0 {
+			go ldap.GroupSyncRoutine(time.Duration(viper.GetInt("ldap_group_sync_interval")) * time.Minute)
+		}
+
 		if !viper.GetBool("no_repo_cache_loader") {
 			go repositoriesmanager.RepositoriesCacheLoader(30)
 		} else {
@@ -264,6 +295,10 @@ var mainCmd = &cobra.Command{
 
 func (router *Router) init() {
 	router.Handle("/login", Auth(false), POST(LoginUser))
+	router.Handle("/login/oidc/authorize", Auth(false), GET(getOIDCAuthorizeHandler))
+	router.Handle("/login/oidc/callback", Auth(false), POST(oidcCallbackHandler))
+	router.Handle("/login/oidc/device", Auth(false), POST(oidcDeviceAuthorizeHandler))
+	router.Handle("/login/oidc/device/token", Auth(false), POST(oidcDeviceTokenHandler))
 
 	// Action
 	router.Handle("/action", GET(getActionsHandler))
@@ -275,6 +310,7 @@ func (router *Router) init() {
 
 	// Admin
 	router.Handle("/admin/warning", NeedAdmin(true), DELETE(adminTruncateWarningsHandler))
+	router.Handle("/admin/group/sync", NeedAdmin(true), GET(getGroupSyncLogsHandler), POST(syncGroupsHandler))
 	router.Handle("/admin/maintenance", NeedAdmin(true), POST(postAdminMaintenanceHandler), GET(getAdminMaintenanceHandler), DELETE(deleteAdminMaintenanceHandler))
 
 	// Action plugin
@@ -303,6 +339,9 @@ func (router *Router) init() {
 
 	// Hooks
 	router.Handle("/hook", Auth(false) /* Public handler called by third parties */, POST(receiveHook))
+	router.Handle("/hook/github/{uid}", Auth(false) /* Signed with the hook secret */, POST(receiveGithubHookHandler))
+	router.Handle("/hook/bitbucket/{uid}", Auth(false) /* Signed with the hook secret */, POST(receiveBitbucketHookHandler))
+	router.Handle("/hook/gitlab/{uid}", Auth(false) /* Authenticated by the hook secret token */, POST(receiveGitlabHookHandler))
 
 	// Overall health
 	router.Handle("/mon/status", Auth(false), GET(statusHandler))
@@ -335,15 +374,21 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/branches", GET(getApplicationBranchHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/version", GET(getApplicationBranchVersionHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/clone", POST(cloneApplicationHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/coverage", GET(getApplicationCoverageHistoryHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/group", POST(addGroupInApplicationHandler), PUT(updateGroupsInApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/group/{group}", PUT(updateGroupRoleOnApplicationHandler), DELETE(deleteGroupFromApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/history/branch", GET(getPipelineBuildBranchHistoryHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/history/env/deploy", GET(getApplicationDeployHistoryHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/inventory/diff", GET(getApplicationInventoryDiffHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/releasenotes", GET(getApplicationReleaseNotesHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline", GET(getPipelinesInApplicationHandler), PUT(updatePipelinesToApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}", POST(attachPipelineToApplicationHandler), PUT(updatePipelineToApplicationHandler), DELETE(removePipelineFromApplicationHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/options", GET(getApplicationPipelineOptionsHandler), PUT(updateApplicationPipelineOptionsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/notification", GET(getUserNotificationApplicationPipelineHandler), PUT(updateUserNotificationApplicationPipelineHandler), DELETE(deleteUserNotificationApplicationPipelineHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/scheduler", GET(getSchedulerApplicationPipelineHandler), POST(addSchedulerApplicationPipelineHandler), PUT(updateSchedulerApplicationPipelineHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/scheduler/next", GET(getSchedulerApplicationPipelineNextHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/scheduler/{id}", DELETE(deleteSchedulerApplicationPipelineHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/test/flaky", GET(getApplicationFlakyTestsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/tree", GET(getApplicationTreeHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/variable", GET(getVariablesInApplicationHandler), PUT(updateVariablesInApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/variable/audit", GET(getVariablesAuditInApplicationHandler))
@@ -352,14 +397,22 @@ func (router *Router) init() {
 
 	// Pipeline
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/history", GET(getPipelineHistoryHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/skipped", GET(getSkippedBuildsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/log", GET(getBuildLogsHandler))
 	router.Handle("/project/{key}/application/{app}/pipeline/{permPipelineKey}/build/{build}/test", POSTEXECUTE(addBuildTestResultsHandler), GET(getBuildTestResultsHandler))
+	router.Handle("/project/{key}/application/{app}/pipeline/{permPipelineKey}/build/{build}/coverage", POSTEXECUTE(addBuildCoverageHandler), GET(getBuildCoverageHandler))
 	router.Handle("/project/{key}/application/{app}/pipeline/{permPipelineKey}/build/{build}/variable", POSTEXECUTE(addBuildVariableHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/action/{actionID}/log", GET(getActionBuildLogsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}", GET(getBuildStateHandler), DELETE(deleteBuildHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/triggered", GET(getPipelineBuildTriggeredHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/workflow", GET(getPipelineBuildWorkflowHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/stop", POSTEXECUTE(stopPipelineBuildHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/restart", POSTEXECUTE(restartPipelineBuildHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/approval", GET(getPipelineBuildApprovalsHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/approval/approve", POSTEXECUTE(approvePipelineBuildHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/approval/reject", POSTEXECUTE(rejectPipelineBuildHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/verification", GET(getPipelineBuildVerificationsHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/releasenotes", POSTEXECUTE(generatePipelineBuildReleaseNotesHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/commits", GET(getPipelineBuildCommitsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/commits", GET(getPipelineCommitsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/run", POSTEXECUTE(runPipelineHandler))
@@ -389,11 +442,22 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/trigger", GET(getTriggersHandler), POST(addTriggerHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/trigger/source", GET(getTriggersAsSourceHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/trigger/{id}", GET(getTriggerHandler), DELETE(deleteTriggerHandler), PUT(updateTriggerHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/join", GET(getTriggerJoinsHandler), POST(addTriggerJoinHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/join/{id}", DELETE(deleteTriggerJoinHandler))
+
+	// Inventory
+	router.Handle("/project/{permProjectKey}/inventory", GET(getInventoryHandler))
+	router.Handle("/project/{permProjectKey}/inventory/drift", GET(getInventoryDriftHandler))
 
 	// Environment
 	router.Handle("/project/{permProjectKey}/environment", GET(getEnvironmentsHandler), POST(addEnvironmentHandler), PUT(updateEnvironmentsHandler))
 	router.Handle("/project/{key}/environment/{permEnvironmentName}", GET(getEnvironmentHandler), PUT(updateEnvironmentHandler), DELETE(deleteEnvironmentHandler))
 	router.Handle("/project/{key}/environment/{permEnvironmentName}/clone", POST(cloneEnvironmentHandler))
+	router.Handle("/project/{key}/environment/{permEnvironmentName}/lock", NeedAdmin(true), POST(lockEnvironmentHandler), DELETE(unlockEnvironmentHandler))
+	router.Handle("/project/{key}/environment/{permEnvironmentName}/status", GET(getEnvironmentStatusHandler))
+	router.Handle("/project/{key}/environment/{permEnvironmentName}/freeze", GET(getFreezeWindowsHandler), POST(addFreezeWindowHandler))
+	router.Handle("/project/{key}/environment/{permEnvironmentName}/freeze/override", NeedAdmin(true), POST(grantFreezeOverrideHandler))
+	router.Handle("/project/{key}/environment/{permEnvironmentName}/freeze/{id}", DELETE(deleteFreezeWindowHandler))
 	router.Handle("/project/{key}/environment/{permEnvironmentName}/audit", GET(getEnvironmentsAuditHandler))
 	router.Handle("/project/{key}/environment/{permEnvironmentName}/audit/{auditID}", PUT(restoreEnvironmentAuditHandler))
 	router.Handle("/project/{key}/environment/{permEnvironmentName}/group", POST(addGroupInEnvironmentHandler))
@@ -412,6 +476,9 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/hook", GET(getApplicationHooksHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook", POST(addHook), GET(getHooks))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook/{id}", PUT(updateHookHandler), DELETE(deleteHook))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook/{id}/secret", POST(regenerateHookSecretHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook/{id}/delivery", GET(getHookDeliveriesHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook/{id}/delivery/{deliveryID}/replay", POST(replayHookDeliveryHandler))
 
 	// Pollers
 	router.Handle("/project/{key}/application/{permApplicationName}/polling", GET(getApplicationPollersHandler))
@@ -467,6 +534,8 @@ func (router *Router) init() {
 	router.Handle("/user/signup", Auth(false), POST(AddUser))
 	router.Handle("/user/group", Auth(true), GET(getUserGroupsHandler))
 	router.Handle("/user/import", NeedAdmin(true), POST(importUsersHandler))
+	router.Handle("/user/token", GET(getAccessTokensHandler), POST(addAccessTokenHandler))
+	router.Handle("/user/token/{id}", DELETE(revokeAccessTokenHandler))
 	router.Handle("/user/{name}", NeedAdmin(true), GET(GetUserHandler), PUT(UpdateUserHandler), DELETE(DeleteUserHandler))
 	router.Handle("/user/{name}/confirm/{token}", Auth(false), GET(ConfirmUser))
 	router.Handle("/user/{name}/reset", Auth(false), POST(ResetUser))
@@ -585,6 +654,54 @@ func init() {
 	flags.String("ldap-user-fullname", "{{.givenName}} {{.sn}}", "LDAP User fullname")
 	viper.BindPFlag("ldap_user_fullname", flags.Lookup("ldap-user-fullname"))
 
+	flags.String("ldap-group-filter", "", "LDAP filter of the groups synced into CDS groups, such as (&(objectClass=groupOfNames)(cn=cds-*))")
+	viper.BindPFlag("ldap_group_filter", flags.Lookup("ldap-group-filter"))
+
+	flags.String("ldap-group-dns", "", "LDAP DNs of the groups synced into CDS groups, separated by ;")
+	viper.BindPFlag("ldap_group_dns", flags.Lookup("ldap-group-dns"))
+
+	flags.String("ldap-group-name-attribute", "cn", "LDAP group attribute used as CDS group name")
+	viper.BindPFlag("ldap_group_name_attribute", flags.Lookup("ldap-group-name-attribute"))
+
+	flags.String("ldap-group-member-attribute", "member", "LDAP group attribute listing the members, by DN or uid")
+	viper.BindPFlag("ldap_group_member_attribute", flags.Lookup("ldap-group-member-attribute"))
+
+	flags.String("ldap-group-admin-attribute", "", "LDAP group attribute listing the group admins, by DN or uid. Empty to disable")
+	viper.BindPFlag("ldap_group_admin_attribute", flags.Lookup("ldap-group-admin-attribute"))
+
+	flags.Int("ldap-group-sync-interval", 60, "LDAP group sync interval in minutes, 0 to only sync at login")
+	viper.BindPFlag("ldap_group_sync_interval", flags.Lookup("ldap-group-sync-interval"))
+
+	flags.Bool("oidc-enable", false, "Enable OpenID Connect Auth mode : true|false")
+	viper.BindPFlag("oidc_enable", flags.Lookup("oidc-enable"))
+
+	flags.String("oidc-issuer", "", "OpenID Connect issuer URL")
+	viper.BindPFlag("oidc_issuer", flags.Lookup("oidc-issuer"))
+
+	flags.String("oidc-client-id", "", "OpenID Connect client ID")
+	viper.BindPFlag("oidc_client_id", flags.Lookup("oidc-client-id"))
+
+	flags.String("oidc-client-secret", "", "OpenID Connect client secret, empty for a public client")
+	viper.BindPFlag("oidc_client_secret", flags.Lookup("oidc-client-secret"))
+
+	flags.String("oidc-redirect-url", "", "OpenID Connect redirect URL: the UI login callback page")
+	viper.BindPFlag("oidc_redirect_url", flags.Lookup("oidc-redirect-url"))
+
+	flags.String("oidc-scopes", "openid,profile,email", "OpenID Connect scopes, comma separated")
+	viper.BindPFlag("oidc_scopes", flags.Lookup("oidc-scopes"))
+
+	flags.String("oidc-username-claim", "preferred_username", "OpenID Connect claim used as username of new users, accounts are identified by the sub claim")
+	viper.BindPFlag("oidc_username_claim", flags.Lookup("oidc-username-claim"))
+
+	flags.String("oidc-fullname-claim", "name", "OpenID Connect claim used as user fullname")
+	viper.BindPFlag("oidc_fullname_claim", flags.Lookup("oidc-fullname-claim"))
+
+	flags.String("oidc-email-claim", "email", "OpenID Connect claim used as user email")
+	viper.BindPFlag("oidc_email_claim", flags.Lookup("oidc-email-claim"))
+
+	flags.String("oidc-groups-claim", "", "OpenID Connect claim listing the user groups, created and synced at login. Empty to disable")
+	viper.BindPFlag("oidc_groups_claim", flags.Lookup("oidc-groups-claim"))
+
 	flags.String("secret-backend", "", "Secret Backend plugin")
 	viper.BindPFlag("secret_backend", flags.Lookup("secret-backend"))
 
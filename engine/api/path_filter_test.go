@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/pipeline"
+	test "github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/sdk"
+)
+
+// changedFilesClient is a repositories manager client only able to list changed files
+type changedFilesClient struct {
+	sdk.RepositoriesManagerClient
+	files []string
+	err   error
+	since []string
+}
+
+func (c *changedFilesClient) ChangedFiles(repo, since, until string) ([]string, error) {
+	c.since = append(c.since, since)
+	return c.files, c.err
+}
+
+func Test_pathFilterSkipReason(t *testing.T) {
+	testApplicationPipelineNotifBoilerPlate(t, func(t *testing.T, db *gorp.DbMap, proj *sdk.Project, pip *sdk.Pipeline, app *sdk.Application, env *sdk.Environment) {
+		app.RepositoryFullname = "team/repo"
+		trigger := sdk.PipelineBuildTrigger{VCSChangesBranch: "master", VCSChangesHash: "aaa"}
+		_, err := pipeline.InsertPipelineBuild(db, proj, pip, app, []sdk.Parameter{}, []sdk.Parameter{}, env, -1, trigger)
+		test.NoError(t, err)
+
+		filter := sdk.PathFilter{Include: []string{"api/**"}}
+		client := &changedFilesClient{files: []string{"api/main.go"}}
+
+		// Changes since the last build of the branch
+		assert.Equal(t, "", application.PathFilterSkipReason(db, client, app, pip.ID, filter, "master", "bbb"))
+		client.files = []string{"ui/index.html"}
+		assert.Equal(t, "no file changed since aaa matches the paths filter", application.PathFilterSkipReason(db, client, app, pip.ID, filter, "master", "bbb"))
+
+		// Without previous build on the branch, or when building the same commit again, only the commit is checked
+		assert.Equal(t, "no file changed by bbb matches the paths filter", application.PathFilterSkipReason(db, client, app, pip.ID, filter, "feat", "bbb"))
+		assert.Equal(t, "no file changed by aaa matches the paths filter", application.PathFilterSkipReason(db, client, app, pip.ID, filter, "master", "aaa"))
+		assert.Equal(t, []string{"aaa", "aaa", "", ""}, client.since)
+
+		// Builds are triggered when changed files can't be computed
+		client.err = fmt.Errorf("repository unavailable")
+		assert.Equal(t, "", application.PathFilterSkipReason(db, client, app, pip.ID, filter, "master", "bbb"))
+
+		// Nothing is checked without filter
+		client.since = nil
+		assert.Equal(t, "", application.PathFilterSkipReason(db, client, app, pip.ID, sdk.PathFilter{}, "master", "bbb"))
+		assert.Len(t, client.since, 0)
+	})
+}
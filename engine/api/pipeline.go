@@ -15,6 +15,7 @@ import (
 	"github.com/ovh/cds/engine/api/application"
 	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/deployment"
 	"github.com/ovh/cds/engine/api/environment"
 	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/api/permission"
@@ -119,6 +120,7 @@ func rollbackPipelineHandler(w http.ResponseWriter, r *http.Request, db *gorp.Db
 
 	trigger := pbs[1].Trigger
 	trigger.TriggeredBy = c.User
+	trigger.FreezeOverride = request.FreezeOverride
 
 	newPb, err := queue.RunPipeline(tx, projectKey, app, pipelineName, env.Name, pbs[1].Parameters, pbs[1].Version, trigger, c.User)
 	if err != nil {
@@ -374,6 +376,7 @@ func runPipelineHandlerFunc(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 		ManualTrigger:       true,
 		TriggeredBy:         c.User,
 		ParentPipelineBuild: parentPipelineBuild,
+		FreezeOverride:      request.FreezeOverride,
 	}
 	if parentPipelineBuild != nil {
 		trigger.VCSChangesAuthor = parentPipelineBuild.Trigger.VCSChangesAuthor
@@ -867,9 +870,67 @@ func getPipelineHistoryHandler(w http.ResponseWriter, r *http.Request, db *gorp.
 		return
 	}
 
+	for i := range pbs {
+		pbs[i].Coverage, err = pipeline.LoadCoverage(db, pbs[i].ID, false)
+		if err != nil {
+			log.Warning("getPipelineHistoryHandler> cannot load coverage of build %d: %s\n", pbs[i].ID, err)
+			WriteError(w, r, err)
+			return
+		}
+		pbs[i].Verifications, err = deployment.LoadBuildVerifications(db, pbs[i].ID)
+		if err != nil {
+			log.Warning("getPipelineHistoryHandler> cannot load deployment verifications of build %d: %s\n", pbs[i].ID, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	WriteJSON(w, r, pbs, http.StatusOK)
 }
 
+func getSkippedBuildsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	pipelineName := vars["permPipelineKey"]
+	appName := vars["permApplicationName"]
+
+	limit := 20
+	if l := r.FormValue("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	p, err := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
+	if err != nil {
+		if err != sdk.ErrPipelineNotFound {
+			log.Warning("getSkippedBuildsHandler> Cannot load pipeline %s: %s\n", pipelineName, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	a, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		if err != sdk.ErrApplicationNotFound {
+			log.Warning("getSkippedBuildsHandler> Cannot load application %s: %s\n", appName, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	skipped, err := pipeline.LoadSkippedBuilds(db, a.ID, p.ID, limit)
+	if err != nil {
+		log.Warning("getSkippedBuildsHandler> Cannot load skipped builds of %s/%s: %s\n", appName, pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, skipped, http.StatusOK)
+}
+
 func deletePipeline(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
 	// Get pipeline and action name in URL
 	vars := mux.Vars(r)
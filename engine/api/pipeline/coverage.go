@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const coverageFields = `pipeline_build_id, build_number, branch, format, lines, lines_covered, branches, branches_covered, files, created`
+
+// InsertCoverage inserts or replaces coverage summary of a pipeline build in database
+func InsertCoverage(db gorp.SqlExecutor, pb *sdk.PipelineBuild, c *sdk.Coverage) error {
+	files, err := json.Marshal(c.Files)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`DELETE FROM pipeline_build_coverage WHERE pipeline_build_id = $1`, pb.ID); err != nil {
+		return err
+	}
+
+	c.PipelineBuildID = pb.ID
+	c.BuildNumber = pb.BuildNumber
+	c.Branch = pb.Trigger.VCSChangesBranch
+
+	query := `INSERT INTO pipeline_build_coverage (pipeline_build_id, application_id, pipeline_id, environment_id, build_number, branch, format, lines, lines_covered, branches, branches_covered, files)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING created`
+	return db.QueryRow(query, pb.ID, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, c.BuildNumber, c.Branch, c.Format,
+		c.Lines, c.LinesCovered, c.Branches, c.BranchesCovered, string(files)).Scan(&c.Created)
+}
+
+// LoadCoverage retrieves coverage summary of a pipeline build with its delta since previous build on the same branch.
+// It returns nil if there is no coverage for this build
+func LoadCoverage(db gorp.SqlExecutor, pbID int64, withFiles bool) (*sdk.Coverage, error) {
+	query := `SELECT ` + coverageFields + `, application_id, pipeline_id, environment_id FROM pipeline_build_coverage WHERE pipeline_build_id = $1`
+	var appID, pipID, envID int64
+	var files sql.NullString
+	c := &sdk.Coverage{}
+	err := db.QueryRow(query, pbID).Scan(&c.PipelineBuildID, &c.BuildNumber, &c.Branch, &c.Format, &c.Lines, &c.LinesCovered,
+		&c.Branches, &c.BranchesCovered, &files, &c.Created, &appID, &pipID, &envID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if withFiles && files.Valid {
+		if err := json.Unmarshal([]byte(files.String), &c.Files); err != nil {
+			return nil, err
+		}
+	}
+
+	previous, err := loadPreviousCoverage(db, appID, pipID, envID, c.Branch, c.BuildNumber)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		delta := c.Percent() - previous.Percent()
+		c.Delta = &delta
+	}
+	return c, nil
+}
+
+func loadPreviousCoverage(db gorp.SqlExecutor, appID, pipID, envID int64, branch string, buildNumber int64) (*sdk.Coverage, error) {
+	query := `SELECT lines, lines_covered FROM pipeline_build_coverage
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND branch = $4 AND build_number < $5
+		ORDER BY build_number DESC LIMIT 1`
+	c := &sdk.Coverage{}
+	err := db.QueryRow(query, appID, pipID, envID, branch, buildNumber).Scan(&c.Lines, &c.LinesCovered)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadApplicationCoverageHistory retrieves the last coverage summaries of an application, optionally filtered by branch.
+// Delta are computed between successive builds of the same pipeline, environment and branch
+func LoadApplicationCoverageHistory(db gorp.SqlExecutor, appID int64, branch string, limit int) ([]sdk.Coverage, error) {
+	query := `SELECT ` + coverageFields + `, pipeline_id, environment_id FROM pipeline_build_coverage
+		WHERE application_id = $1 AND ($2 = '' OR branch = $2)
+		ORDER BY created DESC LIMIT $3`
+	rows, err := db.Query(query, appID, branch, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct {
+		pipID, envID int64
+		branch       string
+	}
+	var keys []key
+	cs := []sdk.Coverage{}
+	for rows.Next() {
+		var c sdk.Coverage
+		var k key
+		var files sql.NullString
+		if err := rows.Scan(&c.PipelineBuildID, &c.BuildNumber, &c.Branch, &c.Format, &c.Lines, &c.LinesCovered,
+			&c.Branches, &c.BranchesCovered, &files, &c.Created, &k.pipID, &k.envID); err != nil {
+			return nil, err
+		}
+		k.branch = c.Branch
+		keys = append(keys, k)
+		cs = append(cs, c)
+	}
+
+	// History is sorted from the newest to the oldest
+	for i := range cs {
+		for j := i + 1; j < len(cs); j++ {
+			if keys[j] == keys[i] {
+				delta := cs[i].Percent() - cs[j].Percent()
+				cs[i].Delta = &delta
+				break
+			}
+		}
+	}
+	return cs, nil
+}
+
+// DeleteCoverage removes from database coverage of a pipeline build
+func DeleteCoverage(db gorp.SqlExecutor, pbID int64) error {
+	_, err := db.Exec(`DELETE FROM pipeline_build_coverage WHERE pipeline_build_id = $1`, pbID)
+	return err
+}
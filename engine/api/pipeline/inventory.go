@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadDeployments loads the last successful deployment of each application pipeline on each environment of a project
+func LoadDeployments(db gorp.SqlExecutor, projectKey string) ([]sdk.Deployment, error) {
+	query := `
+		SELECT DISTINCT ON (pb.application_id, pb.pipeline_id, pb.environment_id)
+			pb.application_id, application.name, pipeline.name, pb.environment_id, environment.name,
+			pb.build_number, pb.version, pb.vcs_changes_branch, pb.vcs_changes_hash, "user".username, pb.done
+		FROM pipeline_build pb
+		JOIN application ON application.id = pb.application_id
+		JOIN pipeline ON pipeline.id = pb.pipeline_id
+		JOIN environment ON environment.id = pb.environment_id
+		JOIN project ON project.id = application.project_id
+		LEFT JOIN "user" ON "user".id = pb.triggered_by
+		WHERE project.projectkey = $1 AND pipeline.type = $2 AND pb.status = $3
+		ORDER BY pb.application_id, pb.pipeline_id, pb.environment_id, pb.done DESC
+	`
+	rows, err := db.Query(query, projectKey, string(sdk.DeploymentPipeline), sdk.StatusSuccess.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deployments := []sdk.Deployment{}
+	for rows.Next() {
+		var d sdk.Deployment
+		var branch, hash, username sql.NullString
+		if err := rows.Scan(&d.ApplicationID, &d.Application, &d.Pipeline, &d.EnvironmentID, &d.Environment,
+			&d.BuildNumber, &d.Version, &branch, &hash, &username, &d.Done); err != nil {
+			return nil, err
+		}
+		d.Branch = branch.String
+		d.Hash = hash.String
+		d.Deployer = username.String
+		deployments = append(deployments, d)
+	}
+	return deployments, nil
+}
+
+// Inventory groups deployments by environment, sorted by environment then application and pipeline names
+func Inventory(deployments []sdk.Deployment) []sdk.EnvironmentInventory {
+	sorted := make([]sdk.Deployment, len(deployments))
+	copy(sorted, deployments)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Environment != sorted[j].Environment {
+			return sorted[i].Environment < sorted[j].Environment
+		}
+		if sorted[i].Application != sorted[j].Application {
+			return sorted[i].Application < sorted[j].Application
+		}
+		return sorted[i].Pipeline < sorted[j].Pipeline
+	})
+
+	inventory := []sdk.EnvironmentInventory{}
+	for _, d := range sorted {
+		if len(inventory) == 0 || inventory[len(inventory)-1].Environment != d.Environment {
+			inventory = append(inventory, sdk.EnvironmentInventory{Environment: d.Environment})
+		}
+		inv := &inventory[len(inventory)-1]
+		inv.Deployments = append(inv.Deployments, d)
+	}
+	return inventory
+}
+
+// Drift compares deployments of each application pipeline between environments from and to.
+// Deployments drift when they do not run the same commit, or the same version when commits are unknown.
+func Drift(deployments []sdk.Deployment, from, to string) []sdk.ApplicationDrift {
+	type key struct{ app, pip string }
+	drifts := map[key]*sdk.ApplicationDrift{}
+	keys := []key{}
+
+	for i := range deployments {
+		d := &deployments[i]
+		if d.Environment != from && d.Environment != to {
+			continue
+		}
+		k := key{d.Application, d.Pipeline}
+		ad, ok := drifts[k]
+		if !ok {
+			ad = &sdk.ApplicationDrift{Application: d.Application, Pipeline: d.Pipeline}
+			drifts[k] = ad
+			keys = append(keys, k)
+		}
+		if d.Environment == from {
+			ad.From = d
+		} else {
+			ad.To = d
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].app != keys[j].app {
+			return keys[i].app < keys[j].app
+		}
+		return keys[i].pip < keys[j].pip
+	})
+
+	res := make([]sdk.ApplicationDrift, 0, len(keys))
+	for _, k := range keys {
+		ad := drifts[k]
+		ad.Drift = deploymentsDiffer(ad.From, ad.To)
+		res = append(res, *ad)
+	}
+	return res
+}
+
+func deploymentsDiffer(a, b *sdk.Deployment) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	if a.Hash != "" && b.Hash != "" {
+		return a.Hash != b.Hash
+	}
+	return a.Version != b.Version
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestInventoryAndDrift(t *testing.T) {
+	deployments := []sdk.Deployment{
+		{Application: "api", Pipeline: "deploy", Environment: "production", Version: 10, Hash: "aaa"},
+		{Application: "api", Pipeline: "deploy", Environment: "preprod", Version: 12, Hash: "ccc"},
+		{Application: "front", Pipeline: "deploy", Environment: "preprod", Version: 5, Hash: "fff"},
+		{Application: "front", Pipeline: "deploy", Environment: "production", Version: 5, Hash: "fff"},
+		{Application: "worker", Pipeline: "deploy", Environment: "preprod", Version: 3},
+		{Application: "batch", Pipeline: "deploy", Environment: "qa", Version: 1},
+	}
+
+	inventory := Inventory(deployments)
+	assert.Len(t, inventory, 3)
+	assert.Equal(t, "preprod", inventory[0].Environment)
+	assert.Len(t, inventory[0].Deployments, 3)
+	assert.Equal(t, "api", inventory[0].Deployments[0].Application)
+	assert.Equal(t, "production", inventory[1].Environment)
+	assert.Equal(t, "qa", inventory[2].Environment)
+
+	drifts := Drift(deployments, "preprod", "production")
+	assert.Len(t, drifts, 3)
+
+	assert.Equal(t, "api", drifts[0].Application)
+	assert.True(t, drifts[0].Drift)
+	assert.Equal(t, int64(12), drifts[0].From.Version)
+	assert.Equal(t, int64(10), drifts[0].To.Version)
+
+	assert.Equal(t, "front", drifts[1].Application)
+	assert.False(t, drifts[1].Drift)
+
+	assert.Equal(t, "worker", drifts[2].Application)
+	assert.True(t, drifts[2].Drift)
+	assert.Nil(t, drifts[2].To)
+}
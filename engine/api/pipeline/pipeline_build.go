@@ -10,8 +10,10 @@ import (
 	"github.com/go-gorp/gorp"
 	"github.com/lib/pq"
 
+	"github.com/ovh/cds/engine/api/approval"
 	"github.com/ovh/cds/engine/api/artifact"
 	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/deployment"
 	"github.com/ovh/cds/engine/api/event"
 	"github.com/ovh/cds/engine/api/repositoriesmanager"
 	"github.com/ovh/cds/engine/api/stats"
@@ -43,6 +45,8 @@ type PipelineBuildDbResult struct {
 	ParentPipelineBuildID sql.NullInt64  `db:"parent_pipeline_build"`
 	Username              sql.NullString `db:"username"`
 	ScheduledTrigger      bool           `db:"scheduled_trigger"`
+	ProjectKey            string         `db:"projectKey"`
+	StopReason            sql.NullString `db:"stop_reason"`
 }
 
 const (
@@ -57,9 +61,12 @@ const (
 			pb.vcs_changes_branch as vcs_branch, pb.vcs_changes_hash as vcs_hash, pb.vcs_changes_author as vcs_author,
 			pb.parent_pipeline_build_id as parent_pipeline_build,
 			"user".username as username,
-			pb.scheduled_trigger as scheduled_trigger
+			pb.scheduled_trigger as scheduled_trigger,
+			project.projectkey as projectKey,
+			pb.stop_reason as stop_reason
 		FROM pipeline_build pb
 		JOIN application ON application.id = pb.application_id
+		JOIN project ON project.id = application.project_id
 		JOIN pipeline ON pipeline.id = pb.pipeline_id
 		JOIN environment ON environment.id = pb.environment_id
 		LEFT JOIN "user" ON "user".id = pb.triggered_by
@@ -280,12 +287,14 @@ func scanPipelineBuild(pbResult PipelineBuildDbResult) (*sdk.PipelineBuild, erro
 	pb := sdk.PipelineBuild{
 		ID: pbResult.ID,
 		Application: sdk.Application{
-			ID:   pbResult.ApplicationID,
-			Name: pbResult.ApplicatioName,
+			ID:         pbResult.ApplicationID,
+			Name:       pbResult.ApplicatioName,
+			ProjectKey: pbResult.ProjectKey,
 		},
 		Pipeline: sdk.Pipeline{
-			ID:   pbResult.PipelineID,
-			Name: pbResult.PipelineName,
+			ID:         pbResult.PipelineID,
+			Name:       pbResult.PipelineName,
+			ProjectKey: pbResult.ProjectKey,
 		},
 		Environment: sdk.Environment{
 			ID:   pbResult.EnvironmentID,
@@ -312,6 +321,9 @@ func scanPipelineBuild(pbResult PipelineBuildDbResult) (*sdk.PipelineBuild, erro
 	if pbResult.VCSChangesAuthor.Valid {
 		pb.Trigger.VCSChangesAuthor = pbResult.VCSChangesAuthor.String
 	}
+	if pbResult.StopReason.Valid {
+		pb.StopReason = pbResult.StopReason.String
+	}
 	if pbResult.VCSChangesBranch.Valid {
 		pb.Trigger.VCSChangesBranch = pbResult.VCSChangesBranch.String
 	}
@@ -420,6 +432,22 @@ func DeletePipelineBuildByID(db gorp.SqlExecutor, pbID int64) error {
 		return errDeleteLog
 	}
 
+	if err := DeleteCoverage(db, pbID); err != nil {
+		return err
+	}
+
+	if err := DeleteTestCases(db, pbID); err != nil {
+		return err
+	}
+
+	if err := approval.DeleteBuildApprovals(db, pbID); err != nil {
+		return err
+	}
+
+	if err := deployment.DeleteBuildVerifications(db, pbID); err != nil {
+		return err
+	}
+
 	query := `
 		DELETE FROM pipeline_build
 		WHERE id = $1
@@ -555,7 +583,19 @@ func InsertPipelineBuild(tx gorp.SqlExecutor, project *sdk.Project, p *sdk.Pipel
 	sdk.AddParameter(&params, "cds.application", sdk.StringParameter, app.Name)
 	sdk.AddParameter(&params, "cds.environment", sdk.StringParameter, env.Name)
 	sdk.AddParameter(&params, "cds.buildNumber", sdk.StringParameter, strconv.FormatInt(pb.BuildNumber, 10))
-	sdk.AddParameter(&params, "cds.version", sdk.StringParameter, strconv.FormatInt(pb.Version, 10))
+	// cds.version is the version of the build, or the version of the tag of a release build with VersionFromTag.
+	// pb.Version stays the build version in both cases: it is the one shown in the history and given to the child pipelines.
+	cdsVersion := strconv.FormatInt(pb.Version, 10)
+	if trigger.TagVersion != "" {
+		cdsVersion = trigger.TagVersion
+	}
+	for i := 0; i < len(params); i++ {
+		if params[i].Name == "cds.version" {
+			params = append(params[:i], params[i+1:]...)
+			i--
+		}
+	}
+	sdk.AddParameter(&params, "cds.version", sdk.StringParameter, cdsVersion)
 
 	if client != nil {
 		repo, err := client.RepoByFullname(app.RepositoryFullname)
@@ -1205,3 +1245,9 @@ func RestartPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild) error {
 
 	return nil
 }
+
+// SelectPipelineBuildForUpdate locks the pipeline build row until the end of the transaction
+func SelectPipelineBuildForUpdate(db gorp.SqlExecutor, pbID int64) error {
+	var id int64
+	return db.QueryRow(`SELECT id FROM pipeline_build WHERE id = $1 FOR UPDATE`, pbID).Scan(&id)
+}
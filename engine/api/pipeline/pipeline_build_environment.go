@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// CountRunningEnvironmentBuilds counts pipeline builds holding the given environment
+func CountRunningEnvironmentBuilds(db gorp.SqlExecutor, envID int64) (int, error) {
+	var count int
+	query := `SELECT count(id) FROM pipeline_build WHERE environment_id = $1 AND status IN ($2, $3)`
+	err := db.QueryRow(query, envID, sdk.StatusBuilding.String(), sdk.StatusWaitingApproval.String()).Scan(&count)
+	return count, err
+}
+
+// LoadQueuedEnvironmentIDs returns environments having queued pipeline builds
+func LoadQueuedEnvironmentIDs(db gorp.SqlExecutor) ([]int64, error) {
+	rows, err := db.Query(`SELECT DISTINCT environment_id FROM pipeline_build WHERE status = $1`, sdk.StatusWaiting.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// LoadEnvironmentBuilds loads pipeline builds of an environment with the given status, oldest first
+func LoadEnvironmentBuilds(db gorp.SqlExecutor, envID int64, status ...sdk.Status) ([]sdk.PipelineBuild, error) {
+	pbs := []sdk.PipelineBuild{}
+	for _, s := range status {
+		whereCondition := `
+			WHERE pb.environment_id = $1 AND pb.status = $2
+			ORDER by pb.id ASC
+		`
+		query := fmt.Sprintf("%s %s", selectPipelineBuild, whereCondition)
+		var rows []PipelineBuildDbResult
+		if _, err := db.Select(&rows, query, envID, s.String()); err != nil {
+			return nil, err
+		}
+
+		for _, r := range rows {
+			pb, errScan := scanPipelineBuild(r)
+			if errScan != nil {
+				return nil, errScan
+			}
+			pbs = append(pbs, *pb)
+		}
+	}
+	return pbs, nil
+}
+
+// QueuePipelineBuild puts a pipeline build on hold until its environment is released
+func QueuePipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild) error {
+	return UpdatePipelineBuildStatusAndStage(db, pb, sdk.StatusWaiting)
+}
+
+// StartQueuedPipelineBuild starts a pipeline build which was waiting for its environment
+func StartQueuedPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild) error {
+	pb.Start = time.Now()
+	if _, err := db.Exec(`UPDATE pipeline_build SET start = $1 WHERE id = $2`, pb.Start, pb.ID); err != nil {
+		return err
+	}
+	return UpdatePipelineBuildStatusAndStage(db, pb, sdk.StatusBuilding)
+}
+
+// CancelQueuedPipelineBuild skips a queued or waiting approval pipeline build superseded by a newer version
+func CancelQueuedPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild) error {
+	if _, err := db.Exec(`UPDATE pipeline_build SET done = $1 WHERE id = $2`, time.Now(), pb.ID); err != nil {
+		return err
+	}
+	return UpdatePipelineBuildStatusAndStage(db, pb, sdk.StatusSkipped)
+}
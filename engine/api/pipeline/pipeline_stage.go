@@ -25,7 +25,7 @@ var (
 // LoadStage Get a stage from its ID and pipeline ID
 func LoadStage(db gorp.SqlExecutor, pipelineID int64, stageID int64) (*sdk.Stage, error) {
 	query := `
-		SELECT pipeline_stage.id, pipeline_stage.pipeline_id, pipeline_stage.name, pipeline_stage.build_order, pipeline_stage.enabled, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
+		SELECT pipeline_stage.id, pipeline_stage.pipeline_id, pipeline_stage.name, pipeline_stage.build_order, pipeline_stage.enabled, pipeline_stage.approval, pipeline_stage.progressive, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
 		FROM pipeline_stage
 		LEFT OUTER JOIN pipeline_stage_prerequisite ON pipeline_stage_prerequisite.pipeline_stage_id = pipeline_stage.id
 		WHERE pipeline_stage.pipeline_id = $1 
@@ -45,8 +45,10 @@ func LoadStage(db gorp.SqlExecutor, pipelineID int64, stageID int64) (*sdk.Stage
 	defer rows.Close()
 
 	for rows.Next() {
-		var parameter, expectedValue sql.NullString
-		rows.Scan(&stage.ID, &stage.PipelineID, &stage.Name, &stage.BuildOrder, &stage.Enabled, &parameter, &expectedValue)
+		var approval, progressive, parameter, expectedValue sql.NullString
+		rows.Scan(&stage.ID, &stage.PipelineID, &stage.Name, &stage.BuildOrder, &stage.Enabled, &approval, &progressive, &parameter, &expectedValue)
+		stage.Approval = unmarshalApprovalGate(approval)
+		stage.Progressive = unmarshalProgressiveDeployment(progressive)
 		if parameter.Valid && expectedValue.Valid {
 			p := sdk.Prerequisite{
 				Parameter:     parameter.String,
@@ -62,9 +64,17 @@ func LoadStage(db gorp.SqlExecutor, pipelineID int64, stageID int64) (*sdk.Stage
 // InsertStage insert given stage into given database
 func InsertStage(db gorp.SqlExecutor, s *sdk.Stage) error {
 	s.Enabled = true
-	query := `INSERT INTO "pipeline_stage" (pipeline_id, name, build_order, enabled) VALUES($1,$2,$3,$4) RETURNING id`
+	query := `INSERT INTO "pipeline_stage" (pipeline_id, name, build_order, enabled, approval, progressive) VALUES($1,$2,$3,$4,$5,$6) RETURNING id`
 
-	if err := db.QueryRow(query, s.PipelineID, s.Name, s.BuildOrder, true).Scan(&s.ID); err != nil {
+	approval, err := marshalApprovalGate(s.Approval)
+	if err != nil {
+		return err
+	}
+	progressive, err := marshalProgressiveDeployment(s.Progressive)
+	if err != nil {
+		return err
+	}
+	if err := db.QueryRow(query, s.PipelineID, s.Name, s.BuildOrder, true, approval, progressive).Scan(&s.ID); err != nil {
 		return err
 	}
 	return InsertStagePrequisites(db, s)
@@ -96,7 +106,7 @@ func LoadStages(db gorp.SqlExecutor, pipelineID int64) ([]sdk.Stage, error) {
 	var stages []sdk.Stage
 
 	query := `
-		SELECT pipeline_stage.id, pipeline_stage.name, pipeline_stage.enabled, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
+		SELECT pipeline_stage.id, pipeline_stage.name, pipeline_stage.enabled, pipeline_stage.approval, pipeline_stage.progressive, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
 		FROM pipeline_stage
 		LEFT OUTER JOIN pipeline_stage_prerequisite ON pipeline_stage_prerequisite.pipeline_stage_id = pipeline_stage.id
 	 	WHERE pipeline_id = $1 
@@ -114,8 +124,8 @@ func LoadStages(db gorp.SqlExecutor, pipelineID int64) ([]sdk.Stage, error) {
 	for rows.Next() {
 		var id int64
 		var enabled bool
-		var name, parameter, expectedValue sql.NullString
-		err = rows.Scan(&id, &name, &enabled, &parameter, &expectedValue)
+		var name, approval, progressive, parameter, expectedValue sql.NullString
+		err = rows.Scan(&id, &name, &enabled, &approval, &progressive, &parameter, &expectedValue)
 		if err != nil {
 			return stages, err
 		}
@@ -123,9 +133,11 @@ func LoadStages(db gorp.SqlExecutor, pipelineID int64) ([]sdk.Stage, error) {
 		var stageData = mapStages[id]
 		if stageData == nil {
 			stageData = &sdk.Stage{
-				ID:      id,
-				Name:    name.String,
-				Enabled: enabled,
+				ID:          id,
+				Name:        name.String,
+				Enabled:     enabled,
+				Approval:    unmarshalApprovalGate(approval),
+				Progressive: unmarshalProgressiveDeployment(progressive),
 			}
 			mapStages[id] = stageData
 		}
@@ -154,13 +166,13 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 
 	query := `
 	SELECT  pipeline_stage_R.id as stage_id, pipeline_stage_R.pipeline_id, pipeline_stage_R.name, pipeline_stage_R.last_modified, 
-			pipeline_stage_R.build_order, pipeline_stage_R.enabled, pipeline_stage_R.parameter, 
+			pipeline_stage_R.build_order, pipeline_stage_R.enabled, pipeline_stage_R.approval, pipeline_stage_R.progressive, pipeline_stage_R.parameter, 
 			pipeline_stage_R.expected_value, pipeline_action_R.id as pipeline_action_id, pipeline_action_R.action_id, pipeline_action_R.action_last_modified,
 			pipeline_action_R.action_args, pipeline_action_R.action_enabled
 	FROM (
 		SELECT  pipeline_stage.id, pipeline_stage.pipeline_id, 
 				pipeline_stage.name, pipeline_stage.last_modified ,pipeline_stage.build_order, 
-				pipeline_stage.enabled, 
+				pipeline_stage.enabled, pipeline_stage.approval, pipeline_stage.progressive,
 				pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
 		FROM pipeline_stage
 		LEFT OUTER JOIN pipeline_stage_prerequisite ON pipeline_stage.id = pipeline_stage_prerequisite.pipeline_stage_id
@@ -192,13 +204,13 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 		var stageBuildOrder int
 		var pipelineActionID, actionID sql.NullInt64
 		var stageName string
-		var stagePrerequisiteParameter, stagePrerequisiteExpectedValue, actionArgs sql.NullString
+		var stageApproval, stageProgressive, stagePrerequisiteParameter, stagePrerequisiteExpectedValue, actionArgs sql.NullString
 		var stageEnabled, actionEnabled sql.NullBool
 		var stageLastModified, actionLastModified pq.NullTime
 
 		err = rows.Scan(
 			&stageID, &pipelineID, &stageName, &stageLastModified,
-			&stageBuildOrder, &stageEnabled, &stagePrerequisiteParameter,
+			&stageBuildOrder, &stageEnabled, &stageApproval, &stageProgressive, &stagePrerequisiteParameter,
 			&stagePrerequisiteExpectedValue, &pipelineActionID, &actionID, &actionLastModified,
 			&actionArgs, &actionEnabled)
 		if err != nil {
@@ -215,6 +227,8 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 				Enabled:      stageEnabled.Bool,
 				BuildOrder:   stageBuildOrder,
 				LastModified: stageLastModified.Time.Unix(),
+				Approval:     unmarshalApprovalGate(stageApproval),
+				Progressive:  unmarshalProgressiveDeployment(stageProgressive),
 			}
 			mapStages[stageID] = stageData
 			stagesPtr = append(stagesPtr, stageData)
@@ -304,8 +318,18 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 
 // UpdateStage update Stage and all its prequisites
 func UpdateStage(db gorp.SqlExecutor, s *sdk.Stage) error {
-	query := `UPDATE pipeline_stage SET name=$1, build_order=$2, enabled=$3 WHERE id=$4`
-	_, err := db.Exec(query, s.Name, s.BuildOrder, s.Enabled, s.ID)
+	approval, err := marshalApprovalGate(s.Approval)
+	if err != nil {
+		return err
+	}
+
+	progressive, err := marshalProgressiveDeployment(s.Progressive)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE pipeline_stage SET name=$1, build_order=$2, enabled=$3, approval=$4, progressive=$5 WHERE id=$6`
+	_, err = db.Exec(query, s.Name, s.BuildOrder, s.Enabled, approval, progressive, s.ID)
 	if err != nil {
 		return err
 	}
@@ -526,3 +550,54 @@ func CheckPrerequisites(s sdk.Stage, pb sdk.PipelineBuild) (bool, error) {
 	}
 	return true, nil
 }
+
+// marshalApprovalGate returns the JSON value of an approval gate, or NULL when there is no gate
+func marshalApprovalGate(g *sdk.ApprovalGate) (interface{}, error) {
+	if g == nil || len(g.Groups) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func unmarshalApprovalGate(data sql.NullString) *sdk.ApprovalGate {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var g sdk.ApprovalGate
+	if err := json.Unmarshal([]byte(data.String), &g); err != nil {
+		log.Warning("unmarshalApprovalGate> cannot unmarshal approval gate: %s\n", err)
+		return nil
+	}
+	if len(g.Groups) == 0 {
+		return nil
+	}
+	return &g
+}
+
+// marshalProgressiveDeployment returns the JSON value of a progressive deployment, or NULL when the stage is a plain one
+func marshalProgressiveDeployment(p *sdk.ProgressiveDeployment) (interface{}, error) {
+	if p == nil || p.Strategy == "" {
+		return nil, nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func unmarshalProgressiveDeployment(data sql.NullString) *sdk.ProgressiveDeployment {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var p sdk.ProgressiveDeployment
+	if err := json.Unmarshal([]byte(data.String), &p); err != nil {
+		log.Warning("unmarshalProgressiveDeployment> cannot unmarshal progressive deployment: %s\n", err)
+		return nil
+	}
+	return &p
+}
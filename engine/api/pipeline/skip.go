@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// Origins of skipped builds
+const (
+	SkippedByHook   = "hook"
+	SkippedByPoller = "poller"
+)
+
+// InsertSkippedBuild records why a commit received by a hook or a poller did not trigger a build.
+// Records are kept 30 days, see SkippedBuildsCleaner.
+func InsertSkippedBuild(db gorp.SqlExecutor, appID, pipID int64, trigger sdk.PipelineBuildTrigger, origin, reason string) error {
+	query := `INSERT INTO skipped_build (application_id, pipeline_id, branch, hash, author, origin, reason, skipped)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, current_timestamp)`
+	_, err := db.Exec(query, appID, pipID, trigger.VCSChangesBranch, trigger.VCSChangesHash, trigger.VCSChangesAuthor, origin, reason)
+	return err
+}
+
+// PurgeSkippedBuilds deletes the skipped builds recorded before the given date
+func PurgeSkippedBuilds(db gorp.SqlExecutor, before time.Time) (int64, error) {
+	res, err := db.Exec(`DELETE FROM skipped_build WHERE skipped < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SkippedBuildsCleaner purges the skipped builds older than 30 days every hour
+func SkippedBuildsCleaner() {
+	for {
+		db := database.DBMap(database.DB())
+		if db != nil {
+			if _, err := PurgeSkippedBuilds(db, time.Now().Add(-30*24*time.Hour)); err != nil {
+				log.Warning("SkippedBuildsCleaner> Cannot purge skipped builds: %s\n", err)
+			}
+		}
+		time.Sleep(1 * time.Hour)
+	}
+}
+
+// LoadSkippedBuilds loads the last skipped builds of an application pipeline
+func LoadSkippedBuilds(db gorp.SqlExecutor, appID, pipID int64, limit int) ([]sdk.SkippedBuild, error) {
+	query := `SELECT id, branch, hash, author, origin, reason, skipped
+		FROM skipped_build
+		WHERE application_id = $1 AND pipeline_id = $2
+		ORDER BY skipped DESC
+		LIMIT $3`
+
+	rows, err := db.Query(query, appID, pipID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skipped := []sdk.SkippedBuild{}
+	for rows.Next() {
+		s := sdk.SkippedBuild{ApplicationID: appID, PipelineID: pipID}
+		if err := rows.Scan(&s.ID, &s.Branch, &s.Hash, &s.Author, &s.Origin, &s.Reason, &s.Skipped); err != nil {
+			return nil, err
+		}
+		skipped = append(skipped, s)
+	}
+
+	return skipped, nil
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadSupersededPipelineBuilds loads the building, waiting or waiting approval pipeline builds of the same branch started before the given one
+func LoadSupersededPipelineBuilds(db gorp.SqlExecutor, pb *sdk.PipelineBuild) ([]sdk.PipelineBuild, error) {
+	whereCondition := `
+		WHERE pb.application_id = $1 AND pb.pipeline_id = $2 AND pb.environment_id = $3
+		AND pb.vcs_changes_branch = $4 AND pb.id < $5
+		AND pb.status IN ($6, $7, $8)
+		ORDER BY pb.id
+	`
+	query := fmt.Sprintf("%s %s", selectPipelineBuild, whereCondition)
+	var rows []PipelineBuildDbResult
+	if _, err := db.Select(&rows, query, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, pb.Trigger.VCSChangesBranch, pb.ID,
+		sdk.StatusBuilding.String(), sdk.StatusWaiting.String(), sdk.StatusWaitingApproval.String()); err != nil {
+		return nil, err
+	}
+
+	pbs := make([]sdk.PipelineBuild, 0, len(rows))
+	for _, r := range rows {
+		old, err := scanPipelineBuild(r)
+		if err != nil {
+			return nil, err
+		}
+		pbs = append(pbs, *old)
+	}
+	return pbs, nil
+}
+
+// SupersedePipelineBuild stops an older pipeline build replaced by a newer build of the same branch
+func SupersedePipelineBuild(db gorp.SqlExecutor, old *sdk.PipelineBuild, by *sdk.PipelineBuild) error {
+	reason := fmt.Sprintf("superseded by #%d", by.BuildNumber)
+	if _, err := db.Exec(`UPDATE pipeline_build SET stop_reason = $1 WHERE id = $2`, reason, old.ID); err != nil {
+		return err
+	}
+	old.StopReason = reason
+
+	// Queued on its environment or paused on an approval gate, nothing is running
+	if old.Status == sdk.StatusWaiting || old.Status == sdk.StatusWaitingApproval {
+		return CancelQueuedPipelineBuild(db, old)
+	}
+
+	pbJobs, err := GetPipelineBuildJobByPipelineBuildID(db, old.ID)
+	if err != nil {
+		return err
+	}
+	for _, pbJob := range pbJobs {
+		if pbJob.Status != sdk.StatusBuilding.String() && pbJob.Status != sdk.StatusWaiting.String() {
+			continue
+		}
+		if err := InsertLog(db, pbJob.ID, "SYSTEM", fmt.Sprintf("Stopped (Reason: %s)\n", reason), old.ID); err != nil {
+			log.Warning("SupersedePipelineBuild> Cannot insert log for job %d: %s\n", pbJob.ID, err)
+		}
+	}
+
+	return StopPipelineBuild(db, old.ID)
+}
@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"sort"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// TestCaseRun is the result of a single test case in a pipeline build
+type TestCaseRun struct {
+	PipelineID    int64
+	Pipeline      string
+	EnvironmentID int64
+	Environment   string
+	Branch        string
+	BuildNumber   int64
+	Suite         string
+	Name          string
+	Status        string
+}
+
+// InsertTestCases indexes each test case reported by a job of a pipeline build so they can be analyzed across builds.
+// Test cases of the reported suites replace the ones indexed before, as test suites are merged in the build results.
+func InsertTestCases(db gorp.SqlExecutor, pb *sdk.PipelineBuild, pbJobID int64, tests sdk.Tests) error {
+	query := `INSERT INTO pipeline_build_test_case (pipeline_build_id, pipeline_build_job_id, application_id, pipeline_id, environment_id, build_number, branch, suite, name, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	for _, s := range tests.TestSuites {
+		if _, err := db.Exec(`DELETE FROM pipeline_build_test_case WHERE pipeline_build_id = $1 AND suite = $2`, pb.ID, s.Name); err != nil {
+			return err
+		}
+		for _, t := range s.Tests {
+			if _, err := db.Exec(query, pb.ID, pbJobID, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, pb.BuildNumber,
+				pb.Trigger.VCSChangesBranch, s.Name, t.Name, t.Status()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadJobFailedTestCases loads the failed test cases reported by a job, only their suite and name are set
+func LoadJobFailedTestCases(db gorp.SqlExecutor, pbJobID int64) ([]TestCaseRun, error) {
+	query := `SELECT suite, name FROM pipeline_build_test_case WHERE pipeline_build_job_id = $1 AND status = $2 ORDER BY id`
+	rows, err := db.Query(query, pbJobID, sdk.TestStatusFail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []TestCaseRun
+	for rows.Next() {
+		var r TestCaseRun
+		if err := rows.Scan(&r.Suite, &r.Name); err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+// LoadApplicationTestCaseRuns loads test case results of the last builds of an application, optionally filtered by branch
+func LoadApplicationTestCaseRuns(db gorp.SqlExecutor, appID int64, branch string, nbBuilds int) ([]TestCaseRun, error) {
+	query := `SELECT tc.pipeline_id, pipeline.name, tc.environment_id, environment.name, tc.branch, tc.build_number, tc.suite, tc.name, tc.status
+		FROM pipeline_build_test_case tc
+		JOIN pipeline ON pipeline.id = tc.pipeline_id
+		JOIN environment ON environment.id = tc.environment_id
+		WHERE tc.application_id = $1 AND ($2 = '' OR tc.branch = $2)
+		AND tc.pipeline_build_id IN (
+			SELECT id FROM pipeline_build WHERE application_id = $1 ORDER BY id DESC LIMIT $3
+		)
+		ORDER BY tc.build_number ASC`
+	rows, err := db.Query(query, appID, branch, nbBuilds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []TestCaseRun
+	for rows.Next() {
+		var r TestCaseRun
+		if err := rows.Scan(&r.PipelineID, &r.Pipeline, &r.EnvironmentID, &r.Environment, &r.Branch, &r.BuildNumber, &r.Suite, &r.Name, &r.Status); err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+// ComputeFlakyTests computes failure rate and flip-flops of each test case on each branch.
+// A test is flaky when it both failed and succeeded on a branch and its status changed at least minFlips times.
+// Runs must be sorted by build number.
+func ComputeFlakyTests(runs []TestCaseRun, minFlips int) []sdk.FlakyTest {
+	type key struct {
+		pipelineID, envID   int64
+		branch, suite, name string
+	}
+	type state struct {
+		test       sdk.FlakyTest
+		lastStatus string
+	}
+
+	var keys []key
+	states := map[key]*state{}
+	for _, r := range runs {
+		if r.Status == sdk.TestStatusSkipped {
+			continue
+		}
+		k := key{r.PipelineID, r.EnvironmentID, r.Branch, r.Suite, r.Name}
+		s, ok := states[k]
+		if !ok {
+			s = &state{test: sdk.FlakyTest{
+				PipelineID:    r.PipelineID,
+				Pipeline:      r.Pipeline,
+				EnvironmentID: r.EnvironmentID,
+				Environment:   r.Environment,
+				Branch:        r.Branch,
+				Suite:         r.Suite,
+				Name:          r.Name,
+			}}
+			states[k] = s
+			keys = append(keys, k)
+		}
+
+		s.test.Runs++
+		if r.Status == sdk.TestStatusFail {
+			s.test.Failures++
+			s.test.LastFailure = r.BuildNumber
+		}
+		if s.lastStatus != "" && s.lastStatus != r.Status {
+			s.test.Flips++
+		}
+		s.lastStatus = r.Status
+	}
+
+	flaky := []sdk.FlakyTest{}
+	for _, k := range keys {
+		t := states[k].test
+		if t.Failures == 0 || t.Failures == t.Runs || t.Flips < minFlips {
+			continue
+		}
+		t.FailureRate = float64(t.Failures) / float64(t.Runs)
+		flaky = append(flaky, t)
+	}
+
+	sort.SliceStable(flaky, func(i, j int) bool {
+		return flaky[i].Flips > flaky[j].Flips
+	})
+	return flaky
+}
+
+// LoadFlakyTests loads test case results of the last builds of an application and returns the flaky ones
+func LoadFlakyTests(db gorp.SqlExecutor, appID int64, branch string, nbBuilds, minFlips int) ([]sdk.FlakyTest, error) {
+	runs, err := LoadApplicationTestCaseRuns(db, appID, branch, nbBuilds)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeFlakyTests(runs, minFlips), nil
+}
+
+// IsFlaky returns true if the test case is in the flaky list of the pipeline, environment and branch
+func IsFlaky(flaky []sdk.FlakyTest, pipelineID, envID int64, branch, suite, name string) bool {
+	for _, f := range flaky {
+		if f.PipelineID == pipelineID && f.EnvironmentID == envID && f.Branch == branch && f.Suite == suite && f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteTestCases removes indexed test cases of a pipeline build
+func DeleteTestCases(db gorp.SqlExecutor, pbID int64) error {
+	_, err := db.Exec(`DELETE FROM pipeline_build_test_case WHERE pipeline_build_id = $1`, pbID)
+	return err
+}
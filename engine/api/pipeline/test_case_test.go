@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestComputeFlakyTests(t *testing.T) {
+	run := func(bn int64, branch, name, status string) TestCaseRun {
+		return TestCaseRun{PipelineID: 1, Pipeline: "build", EnvironmentID: sdk.DefaultEnv.ID, Environment: sdk.DefaultEnv.Name, Branch: branch, BuildNumber: bn, Suite: "suite", Name: name, Status: status}
+	}
+
+	runs := []TestCaseRun{
+		run(1, "master", "TestFlaky", sdk.TestStatusSuccess),
+		run(1, "master", "TestBroken", sdk.TestStatusFail),
+		run(1, "master", "TestOK", sdk.TestStatusSuccess),
+		run(2, "master", "TestFlaky", sdk.TestStatusFail),
+		run(2, "master", "TestBroken", sdk.TestStatusFail),
+		run(2, "master", "TestOK", sdk.TestStatusSuccess),
+		run(3, "master", "TestFlaky", sdk.TestStatusSkipped),
+		run(4, "master", "TestFlaky", sdk.TestStatusSuccess),
+		run(4, "master", "TestBroken", sdk.TestStatusFail),
+		// Fixed once on another branch: one flip only
+		run(5, "feat", "TestFlaky", sdk.TestStatusFail),
+		run(6, "feat", "TestFlaky", sdk.TestStatusSuccess),
+	}
+
+	flaky := ComputeFlakyTests(runs, 2)
+	if len(flaky) != 1 {
+		t.Fatalf("expected 1 flaky test, got %+v", flaky)
+	}
+
+	f := flaky[0]
+	if f.Branch != "master" || f.Name != "TestFlaky" {
+		t.Fatalf("unexpected flaky test %+v", f)
+	}
+	if f.Runs != 3 || f.Failures != 1 || f.Flips != 2 || f.LastFailure != 2 {
+		t.Fatalf("unexpected stats %+v", f)
+	}
+
+	envID := sdk.DefaultEnv.ID
+	if !IsFlaky(flaky, 1, envID, "master", "suite", "TestFlaky") {
+		t.Fatalf("TestFlaky should be flaky on master")
+	}
+	if IsFlaky(flaky, 1, envID, "feat", "suite", "TestFlaky") || IsFlaky(flaky, 1, envID, "master", "suite", "TestBroken") {
+		t.Fatalf("only TestFlaky on master should be flaky")
+	}
+	if IsFlaky(flaky, 2, envID, "master", "suite", "TestFlaky") || IsFlaky(flaky, 1, envID+1, "master", "suite", "TestFlaky") {
+		t.Fatalf("TestFlaky should only be flaky in its pipeline and environment")
+	}
+
+	if flaky := ComputeFlakyTests(runs, 1); len(flaky) != 2 {
+		t.Fatalf("expected 2 flaky tests with one flip, got %+v", flaky)
+	}
+}
@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadWorkflowRunRootID returns the id of the root pipeline build of the workflow run the given pipeline build belongs to
+func LoadWorkflowRunRootID(db gorp.SqlExecutor, pbID int64) (int64, error) {
+	query := `
+		WITH RECURSIVE ancestors(id, parent) AS (
+			SELECT id, parent_pipeline_build_id FROM pipeline_build WHERE id = $1
+			UNION
+			SELECT pb.id, pb.parent_pipeline_build_id FROM pipeline_build pb
+			JOIN ancestors ON pb.id = ancestors.parent
+		)
+		SELECT id FROM ancestors WHERE parent IS NULL OR parent NOT IN (SELECT id FROM pipeline_build)`
+	var rootID int64
+	if err := db.QueryRow(query, pbID).Scan(&rootID); err != nil {
+		return 0, err
+	}
+	return rootID, nil
+}
+
+// LoadWorkflowRun loads every pipeline build spawned from the given root pipeline build
+func LoadWorkflowRun(db gorp.SqlExecutor, rootID int64) (*sdk.WorkflowRun, error) {
+	whereCondition := `
+		WHERE pb.id IN (
+			WITH RECURSIVE run(id) AS (
+				SELECT id FROM pipeline_build WHERE id = $1
+				UNION
+				SELECT child.id FROM pipeline_build child
+				JOIN run ON child.parent_pipeline_build_id = run.id
+			)
+			SELECT id FROM run
+		)
+		ORDER BY pb.id
+	`
+	query := fmt.Sprintf("%s %s", selectPipelineBuild, whereCondition)
+	var rows []PipelineBuildDbResult
+	if _, err := db.Select(&rows, query, rootID); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, sdk.ErrNoPipelineBuild
+	}
+
+	pbs := make([]sdk.PipelineBuild, 0, len(rows))
+	parents := make(map[int64]int64, len(rows))
+	for _, r := range rows {
+		pb, err := scanPipelineBuild(r)
+		if err != nil {
+			return nil, err
+		}
+		pbs = append(pbs, *pb)
+		if r.ParentPipelineBuildID.Valid {
+			parents[pb.ID] = r.ParentPipelineBuildID.Int64
+		}
+	}
+
+	run := WorkflowRun(rootID, pbs, parents, time.Now())
+	return &run, nil
+}
+
+// WorkflowRun builds the execution graph of the given pipeline builds: parents gives
+// the pipeline build that triggered each of them
+func WorkflowRun(rootID int64, pbs []sdk.PipelineBuild, parents map[int64]int64, now time.Time) sdk.WorkflowRun {
+	run := sdk.WorkflowRun{
+		RootID: rootID,
+		Nodes:  []sdk.WorkflowRunNode{},
+		Edges:  []sdk.WorkflowRunEdge{},
+	}
+
+	ids := make(map[int64]bool, len(pbs))
+	for _, pb := range pbs {
+		ids[pb.ID] = true
+	}
+
+	running, failed := false, false
+	for _, pb := range pbs {
+		n := sdk.WorkflowRunNode{
+			ID:            pb.ID,
+			Project:       pb.Pipeline.ProjectKey,
+			ApplicationID: pb.Application.ID,
+			Application:   pb.Application.Name,
+			Pipeline:      pb.Pipeline.Name,
+			Environment:   pb.Environment.Name,
+			BuildNumber:   pb.BuildNumber,
+			Version:       pb.Version,
+			Branch:        pb.Trigger.VCSChangesBranch,
+			Hash:          pb.Trigger.VCSChangesHash,
+			Status:        pb.Status,
+			Start:         pb.Start,
+			Done:          pb.Done,
+		}
+		end := pb.Done
+		if end.IsZero() || end.Before(pb.Start) {
+			end = now
+		}
+		n.Duration = int64(end.Sub(pb.Start).Seconds())
+
+		if parent, ok := parents[pb.ID]; ok && pb.ID != rootID && ids[parent] {
+			n.ParentID = parent
+			run.Edges = append(run.Edges, sdk.WorkflowRunEdge{From: parent, To: pb.ID})
+		}
+
+		switch pb.Status {
+		case sdk.StatusFail:
+			failed = true
+		case sdk.StatusSuccess, sdk.StatusSkipped, sdk.StatusDisabled:
+		default:
+			running = true
+		}
+
+		if run.Start.IsZero() || pb.Start.Before(run.Start) {
+			run.Start = pb.Start
+		}
+		if pb.Done.After(run.Done) {
+			run.Done = pb.Done
+		}
+		run.Nodes = append(run.Nodes, n)
+	}
+
+	sort.SliceStable(run.Nodes, func(i, j int) bool {
+		return run.Nodes[i].Start.Before(run.Nodes[j].Start)
+	})
+
+	switch {
+	case running:
+		run.Status = sdk.StatusBuilding
+		run.Done = time.Time{}
+	case failed:
+		run.Status = sdk.StatusFail
+	default:
+		run.Status = sdk.StatusSuccess
+	}
+
+	end := run.Done
+	if end.IsZero() {
+		end = now
+	}
+	if !run.Start.IsZero() {
+		run.Duration = int64(end.Sub(run.Start).Seconds())
+	}
+	return run
+}
@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func workflowBuild(id int64, app string, status sdk.Status, start, done time.Time) sdk.PipelineBuild {
+	pb := sdk.PipelineBuild{ID: id, Status: status, Start: start, Done: done}
+	pb.Application.Name = app
+	pb.Pipeline.Name = "build"
+	return pb
+}
+
+func TestWorkflowRun(t *testing.T) {
+	now := time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC)
+	pbs := []sdk.PipelineBuild{
+		workflowBuild(1, "api", sdk.StatusSuccess, now.Add(-10*time.Minute), now.Add(-8*time.Minute)),
+		workflowBuild(2, "front", sdk.StatusSuccess, now.Add(-8*time.Minute), now.Add(-5*time.Minute)),
+		workflowBuild(3, "worker", sdk.StatusFail, now.Add(-7*time.Minute), now.Add(-6*time.Minute)),
+	}
+	parents := map[int64]int64{2: 1, 3: 1}
+
+	run := WorkflowRun(1, pbs, parents, now)
+	assert.Equal(t, sdk.StatusFail, run.Status)
+	assert.Equal(t, int64(300), run.Duration)
+	assert.Len(t, run.Nodes, 3)
+	assert.Len(t, run.Edges, 2)
+	assert.Equal(t, int64(120), run.Nodes[0].Duration)
+	children := run.Children(1)
+	if assert.Len(t, children, 2) {
+		assert.Equal(t, "front", children[0].Application)
+		assert.Equal(t, "worker", children[1].Application)
+	}
+	assert.Len(t, run.Children(2), 0)
+
+	// A running build keeps the whole run running
+	pbs = append(pbs, workflowBuild(4, "deploy", sdk.StatusBuilding, now.Add(-2*time.Minute), time.Time{}))
+	parents[4] = 2
+	// Parent outside of the run is ignored
+	parents[1] = 42
+
+	run = WorkflowRun(1, pbs, parents, now)
+	assert.Equal(t, sdk.StatusBuilding, run.Status)
+	assert.True(t, run.Done.IsZero())
+	assert.Equal(t, int64(600), run.Duration)
+	assert.Len(t, run.Edges, 3)
+	assert.Equal(t, int64(0), run.Nodes[0].ParentID)
+	assert.Equal(t, int64(120), run.Nodes[3].Duration)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/pipeline"
+	test "github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_supersedePipelineBuilds(t *testing.T) {
+	testApplicationPipelineNotifBoilerPlate(t, func(t *testing.T, db *gorp.DbMap, proj *sdk.Project, pip *sdk.Pipeline, app *sdk.Application, env *sdk.Environment) {
+		newBuild := func(branch string, status sdk.Status) *sdk.PipelineBuild {
+			trigger := sdk.PipelineBuildTrigger{VCSChangesBranch: branch}
+			pb, err := pipeline.InsertPipelineBuild(db, proj, pip, app, []sdk.Parameter{}, []sdk.Parameter{}, env, -1, trigger)
+			test.NoError(t, err)
+			test.NoError(t, pipeline.UpdatePipelineBuildStatusAndStage(db, pb, status))
+			return pb
+		}
+
+		building := newBuild("master", sdk.StatusBuilding)
+		waiting := newBuild("master", sdk.StatusWaiting)
+		waitingApproval := newBuild("master", sdk.StatusWaitingApproval)
+		newBuild("master", sdk.StatusSuccess)
+		newBuild("feat", sdk.StatusBuilding)
+		latest := newBuild("master", sdk.StatusBuilding)
+
+		olds, err := pipeline.LoadSupersededPipelineBuilds(db, latest)
+		test.NoError(t, err)
+		ids := []int64{}
+		for _, old := range olds {
+			ids = append(ids, old.ID)
+		}
+		assert.Equal(t, []int64{building.ID, waiting.ID, waitingApproval.ID}, ids)
+
+		test.NoError(t, pipeline.SupersedePipelineBuild(db, &olds[2], latest))
+		pb, err := pipeline.LoadPipelineBuildByID(db, waitingApproval.ID)
+		test.NoError(t, err)
+		assert.Equal(t, sdk.StatusSkipped, pb.Status)
+		assert.Equal(t, fmt.Sprintf("superseded by #%d", latest.BuildNumber), pb.StopReason)
+
+		// Builds which are done are not superseded again
+		olds, err = pipeline.LoadSupersededPipelineBuilds(db, latest)
+		test.NoError(t, err)
+		assert.Len(t, olds, 2)
+	})
+}
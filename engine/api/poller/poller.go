@@ -1,6 +1,9 @@
 package poller
 
 import (
+	"database/sql"
+	"encoding/json"
+
 	"github.com/go-gorp/gorp"
 
 	"github.com/ovh/cds/engine/api/application"
@@ -13,11 +16,15 @@ import (
 //InsertPoller insert or update a new poller in DB
 func InsertPoller(db database.Executer, poller *sdk.RepositoryPoller) error {
 	query := `
-        INSERT INTO poller (application_id, pipeline_id, name, enabled, date_creation)
-        VALUES ($1, $2, $3, $4, now())
+        INSERT INTO poller (application_id, pipeline_id, name, enabled, date_creation, paths)
+        VALUES ($1, $2, $3, $4, now(), $5)
 		RETURNING application_id, pipeline_id
     `
-	if _, err := db.Exec(query, poller.Application.ID, poller.Pipeline.ID, poller.Name, poller.Enabled); err != nil {
+	paths, err := json.Marshal(poller.Paths)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(query, poller.Application.ID, poller.Pipeline.ID, poller.Name, poller.Enabled, string(paths)); err != nil {
 		log.Warning("InsertPoller> Error :%s", err)
 		return err
 	}
@@ -52,11 +59,15 @@ func DeleteAllPollers(db database.Executer, appID int64) error {
 func UpdatePoller(db database.Executer, poller *sdk.RepositoryPoller) error {
 	query := `
         UPDATE  poller 
-        SET enabled = $3, name = $4
+        SET enabled = $3, name = $4, paths = $5
         WHERE application_id = $1
         AND pipeline_id  = $2
     `
-	if _, err := db.Exec(query, poller.Application.ID, poller.Pipeline.ID, poller.Enabled, poller.Name); err != nil {
+	paths, err := json.Marshal(poller.Paths)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(query, poller.Application.ID, poller.Pipeline.ID, poller.Enabled, poller.Name, string(paths)); err != nil {
 		log.Warning("UpdatePoller> Error :%s", err)
 		return err
 	}
@@ -66,7 +77,7 @@ func UpdatePoller(db database.Executer, poller *sdk.RepositoryPoller) error {
 //LoadEnabledPollers load all RepositoryPoller
 func LoadEnabledPollers(db gorp.SqlExecutor) ([]sdk.RepositoryPoller, error) {
 	query := `
-        SELECT application_id, pipeline_id, name, enabled, date_creation
+        SELECT application_id, pipeline_id, name, enabled, date_creation, paths
         FROM poller
         WHERE enabled = true
     `
@@ -76,7 +87,7 @@ func LoadEnabledPollers(db gorp.SqlExecutor) ([]sdk.RepositoryPoller, error) {
 //LoadEnabledPollersByProject load all RepositoryPoller for a project
 func LoadEnabledPollersByProject(db gorp.SqlExecutor, projKey string) ([]sdk.RepositoryPoller, error) {
 	query := `
-        SELECT poller.application_id, poller.pipeline_id, poller.name, poller.enabled, poller.date_creation
+        SELECT poller.application_id, poller.pipeline_id, poller.name, poller.enabled, poller.date_creation, poller.paths
         FROM poller, application, project
         WHERE poller.application_id = application.id
 		AND application.project_id = project.id
@@ -89,7 +100,7 @@ func LoadEnabledPollersByProject(db gorp.SqlExecutor, projKey string) ([]sdk.Rep
 //LoadPollersByApplication loads all pollers for an application
 func LoadPollersByApplication(db gorp.SqlExecutor, applicationID int64) ([]sdk.RepositoryPoller, error) {
 	query := `
-        SELECT application_id, pipeline_id, name, enabled, date_creation
+        SELECT application_id, pipeline_id, name, enabled, date_creation, paths
         FROM poller
         WHERE application_id = $1
     `
@@ -99,7 +110,7 @@ func LoadPollersByApplication(db gorp.SqlExecutor, applicationID int64) ([]sdk.R
 //LoadPollerByApplicationAndPipeline loads all pollers for an application/pipeline
 func LoadPollerByApplicationAndPipeline(db gorp.SqlExecutor, applicationID, pipelineID int64) (*sdk.RepositoryPoller, error) {
 	query := `
-        SELECT application_id, pipeline_id, name, enabled, date_creation
+        SELECT application_id, pipeline_id, name, enabled, date_creation, paths
         FROM poller
         WHERE application_id = $1
 		AND pipeline_id = $2
@@ -127,10 +138,17 @@ func loadPollersByQUery(db gorp.SqlExecutor, query string, args ...interface{})
 	for rows.Next() {
 		var applicationID, pipelineID int64
 		poller := sdk.RepositoryPoller{}
-		if err := rows.Scan(&applicationID, &pipelineID, &poller.Name, &poller.Enabled, &poller.DateCreation); err != nil {
+		var paths sql.NullString
+		if err := rows.Scan(&applicationID, &pipelineID, &poller.Name, &poller.Enabled, &poller.DateCreation, &paths); err != nil {
 			log.Warning("loadPollersByQuery> error scanning poller : %s", err)
 			return nil, err
 		}
+		if paths.Valid && paths.String != "" {
+			if err := json.Unmarshal([]byte(paths.String), &poller.Paths); err != nil {
+				log.Warning("loadPollersByQuery> error reading paths of poller : %s", err)
+				return nil, err
+			}
+		}
 		app, err := application.LoadApplicationByID(db, applicationID)
 		if err != nil {
 			log.Warning("loadPollersByQuery> error loading application %d : %s", applicationID, err)
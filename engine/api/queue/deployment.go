@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/deployment"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// deploymentSlot returns the target of a progressive deployment stage: canary, or the idle blue/green slot
+func deploymentSlot(db gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild) (string, error) {
+	if stage.Progressive.Strategy == sdk.DeploymentBlueGreen {
+		return deployment.NextSlot(db, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID)
+	}
+	return sdk.DeploymentCanary, nil
+}
+
+// deploymentParameters returns {{.cds.deploy.*}} parameters of a stage: the strategy, weight and slot
+// to deploy on a progressive deployment stage, or the promoted slot on stages following it
+func deploymentParameters(db gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild) ([]sdk.Parameter, error) {
+	params := []sdk.Parameter{}
+
+	if stage.Progressive != nil {
+		slot, err := deploymentSlot(db, stage, pb)
+		if err != nil {
+			return nil, err
+		}
+		sdk.AddParameter(&params, "cds.deploy.strategy", sdk.StringParameter, stage.Progressive.Strategy)
+		sdk.AddParameter(&params, "cds.deploy.slot", sdk.StringParameter, slot)
+		if stage.Progressive.Strategy == sdk.DeploymentCanary {
+			sdk.AddParameter(&params, "cds.deploy.weight", sdk.StringParameter, fmt.Sprintf("%d", stage.Progressive.Weight))
+		}
+		return params, nil
+	}
+
+	v, err := deployment.LoadLastVerification(db, pb.ID)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil && v.Status == sdk.VerificationPromoted {
+		sdk.AddParameter(&params, "cds.deploy.strategy", sdk.StringParameter, v.Strategy)
+		sdk.AddParameter(&params, "cds.deploy.slot", sdk.StringParameter, v.Slot)
+		sdk.AddParameter(&params, "cds.deploy.promoted", sdk.BooleanParameter, "true")
+	}
+	return params, nil
+}
+
+// startVerification records the start of the verification window of a deployed progressive stage
+func startVerification(db gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild) error {
+	slot, err := deploymentSlot(db, stage, pb)
+	if err != nil {
+		return err
+	}
+	if _, err := deployment.InsertVerification(db, &pb, stage, slot); err != nil {
+		return err
+	}
+	stage.Status = sdk.StatusChecking
+	return nil
+}
+
+// verifyStage decides from the health checks recorded by HealthChecks to promote a progressive stage, roll it back or keep verifying
+func verifyStage(db gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild) (string, *sdk.DeploymentVerification, error) {
+	v, err := deployment.LoadLastVerification(db, pb.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if v == nil || v.StageID != stage.ID || v.Status != sdk.VerificationRunning {
+		return "", nil, fmt.Errorf("no running verification for stage %d", stage.ID)
+	}
+
+	now := time.Now()
+	decision := deployment.Evaluate(v, stage.Progressive, now)
+	switch decision {
+	case deployment.DecisionPromote:
+		v.Status = sdk.VerificationPromoted
+		v.Ended = &now
+	case deployment.DecisionRollback:
+		v.Status = sdk.VerificationRolledBack
+		v.Ended = &now
+		v.Reason = fmt.Sprintf("%d failed health checks out of %d (%d allowed), last: %s", v.Failures, v.Checks, stage.Progressive.MaxFailures, v.LastResult)
+	default:
+		return decision, v, nil
+	}
+
+	if err := deployment.EndVerification(db, v); err != nil {
+		return "", nil, err
+	}
+	return decision, v, nil
+}
+
+// HealthChecks is a goroutine running the due health checks of progressive deployments.
+// Checks are run outside of the queue transactions, RunActions only reads their results.
+func HealthChecks() {
+	// If this goroutine exits, then it's a crash
+	defer log.Fatalf("Goroutine of queue.HealthChecks exited - Exit CDS Engine")
+
+	for {
+		time.Sleep(5 * time.Second)
+
+		db := database.DBMap(database.DB())
+		if db == nil {
+			continue
+		}
+
+		verifications, err := deployment.LoadRunningVerifications(db)
+		if err != nil {
+			log.Warning("queue.HealthChecks> Cannot load running verifications: %s\n", err)
+			continue
+		}
+		for i := range verifications {
+			if err := healthCheck(db, &verifications[i]); err != nil {
+				log.Warning("queue.HealthChecks> Cannot check verification %d: %s\n", verifications[i].ID, err)
+			}
+		}
+	}
+}
+
+// healthCheck runs the health check of a running verification if it is due, and records its result
+func healthCheck(db gorp.SqlExecutor, v *sdk.DeploymentVerification) error {
+	pb, err := pipeline.LoadPipelineBuildByID(db, v.PipelineBuildID)
+	if err != nil {
+		return err
+	}
+	var stage *sdk.Stage
+	for i := range pb.Stages {
+		if pb.Stages[i].ID == v.StageID {
+			stage = &pb.Stages[i]
+		}
+	}
+	if stage == nil || stage.Progressive == nil {
+		return fmt.Errorf("no progressive deployment on stage %d", v.StageID)
+	}
+
+	now := time.Now()
+	if !deployment.Due(v, stage.Progressive, now) {
+		return nil
+	}
+	claimed, err := deployment.ClaimCheck(db, v, now)
+	if err != nil || !claimed {
+		return err
+	}
+
+	errCheck := deployment.Check(stage.Progressive.HealthCheck, pb)
+	if errCheck != nil {
+		log.Notice("queue.healthCheck> health check failed on stage %s of %s/%s/%s[%s] #%d: %s\n", stage.Name, pb.Application.ProjectKey,
+			pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber, errCheck)
+	}
+	return deployment.RecordCheck(db, v.ID, errCheck)
+}
+
+// rollbackDeployment runs again the last successful build of the pipeline on the environment,
+// and records the rollback build in the verification
+func rollbackDeployment(db gorp.SqlExecutor, pb sdk.PipelineBuild, v *sdk.DeploymentVerification) error {
+	pbs, err := pipeline.LoadPipelineBuildsByApplicationAndPipeline(db, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, 1, string(sdk.StatusSuccess), "")
+	if err != nil {
+		return err
+	}
+	if len(pbs) == 0 {
+		v.Reason += "; no previous success to roll back to"
+		return deployment.UpdateVerification(db, v)
+	}
+
+	app, err := application.LoadApplicationByName(db, pb.Application.ProjectKey, pb.Application.Name, application.WithClearPassword())
+	if err != nil {
+		return err
+	}
+
+	trigger := pbs[0].Trigger
+	trigger.ManualTrigger = false
+	trigger.TriggeredBy = pb.Trigger.TriggeredBy
+
+	newPb, err := RunPipeline(db, pb.Application.ProjectKey, app, pb.Pipeline.Name, pb.Environment.Name, pbs[0].Parameters, pbs[0].Version, trigger, &sdk.User{Admin: true})
+	if err != nil {
+		v.Reason += fmt.Sprintf("; rollback to #%d not started: %s", pbs[0].BuildNumber, err)
+		return deployment.UpdateVerification(db, v)
+	}
+
+	log.Info("rollbackDeployment> %s/%s/%s[%s] #%d rolled back to #%d with build #%d: %s\n", pb.Application.ProjectKey, pb.Application.Name,
+		pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber, pbs[0].BuildNumber, newPb.BuildNumber, v.Reason)
+	v.RollbackBuildNumber = newPb.BuildNumber
+	v.Reason += fmt.Sprintf("; rolled back to #%d", pbs[0].BuildNumber)
+	return deployment.UpdateVerification(db, v)
+}
@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/sanity"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// acquireEnvironment checks that a deployment can start on the environment.
+// It returns true if the deployment has to be queued because another one is running.
+func acquireEnvironment(db gorp.SqlExecutor, projectID int64, app *sdk.Application, pip *sdk.Pipeline, env *sdk.Environment, freezeOverride string) (bool, error) {
+	if env.ID == sdk.DefaultEnv.ID {
+		return false, nil
+	}
+
+	if err := environment.SelectEnvironmentForUpdate(db, env.ID); err != nil {
+		return false, err
+	}
+
+	if err := checkFreezeWindows(db, projectID, app, pip, env, freezeOverride); err != nil {
+		return false, err
+	}
+
+	lock, err := environment.LoadLock(db, env.ID)
+	if err != nil {
+		return false, err
+	}
+	if lock != nil {
+		log.Notice("acquireEnvironment> Environment %s is locked by %s: %s\n", env.Name, lock.Username, lock.Reason)
+		return false, sdk.ErrEnvironmentLocked
+	}
+
+	running, err := pipeline.CountRunningEnvironmentBuilds(db, env.ID)
+	if err != nil {
+		return false, err
+	}
+	return running > 0, nil
+}
+
+// checkFreezeWindows refuses deployments during a freeze window of the environment, unless a valid override is supplied
+func checkFreezeWindows(db gorp.SqlExecutor, projectID int64, app *sdk.Application, pip *sdk.Pipeline, env *sdk.Environment, freezeOverride string) error {
+	windows, err := environment.LoadFreezeWindows(db, env.ID)
+	if err != nil {
+		return err
+	}
+
+	w, until := environment.ActiveFreezeWindow(windows, time.Now())
+	if w == nil {
+		return sanity.DeleteFreezeWarnings(db, app.ID, pip.ID, env.ID)
+	}
+
+	ok, err := environment.IsValidFreezeOverride(db, env.ID, freezeOverride)
+	if err != nil {
+		return err
+	}
+	if ok {
+		log.Notice("checkFreezeWindows> Freeze window '%s' on environment %s overridden for %s/%s\n", w.Reason, env.Name, app.Name, pip.Name)
+		return nil
+	}
+
+	log.Notice("checkFreezeWindows> Deployment of %s/%s on environment %s refused: freeze window '%s' until %s\n", app.Name, pip.Name, env.Name, w.Reason, until)
+	// The caller transaction is rolled back on error, record the warning outside of it
+	if err := sanity.InsertFreezeWarning(database.DBMap(database.DB()), projectID, app, pip, env, w, until); err != nil {
+		log.Warning("checkFreezeWindows> Cannot insert freeze warning: %s\n", err)
+	}
+	return sdk.ErrEnvironmentFrozen
+}
+
+// RunQueuedDeployments starts queued pipeline builds on environments released by previous deployments.
+// Queued builds superseded by a newer version of the same application pipeline are cancelled.
+func RunQueuedDeployments(db *gorp.DbMap) {
+	envIDs, err := pipeline.LoadQueuedEnvironmentIDs(db)
+	if err != nil {
+		log.Warning("RunQueuedDeployments> Cannot load queued environments: %s\n", err)
+		return
+	}
+
+	for _, envID := range envIDs {
+		if err := runQueuedDeployment(db, envID); err != nil {
+			log.Warning("RunQueuedDeployments> Cannot start queued deployment on environment %d: %s\n", envID, err)
+		}
+	}
+}
+
+func runQueuedDeployment(db *gorp.DbMap, envID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := environment.SelectEnvironmentForUpdate(tx, envID); err != nil {
+		return err
+	}
+
+	lock, err := environment.LoadLock(tx, envID)
+	if err != nil {
+		return err
+	}
+	if lock != nil {
+		return nil
+	}
+
+	running, err := pipeline.CountRunningEnvironmentBuilds(tx, envID)
+	if err != nil {
+		return err
+	}
+	if running > 0 {
+		return nil
+	}
+
+	queued, err := pipeline.LoadEnvironmentBuilds(tx, envID, sdk.StatusWaiting)
+	if err != nil {
+		return err
+	}
+
+	next, superseded := selectQueuedDeployment(queued)
+	for _, pb := range superseded {
+		log.Info("runQueuedDeployment> Cancel %s/%s[%s] #%d superseded by a newer version\n", pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, pb.BuildNumber)
+		if err := pipeline.CancelQueuedPipelineBuild(tx, pb); err != nil {
+			return err
+		}
+	}
+
+	if next != nil {
+		log.Info("runQueuedDeployment> Start %s/%s[%s] #%d\n", next.Application.Name, next.Pipeline.Name, next.Environment.Name, next.BuildNumber)
+		if err := pipeline.StartQueuedPipelineBuild(tx, next); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// selectQueuedDeployment returns the oldest queued build to start, and queued builds
+// superseded by a newer version of the same application pipeline
+func selectQueuedDeployment(queued []sdk.PipelineBuild) (*sdk.PipelineBuild, []*sdk.PipelineBuild) {
+	type appPipeline struct {
+		appID, pipID int64
+	}
+	latest := map[appPipeline]int64{}
+	for _, pb := range queued {
+		k := appPipeline{pb.Application.ID, pb.Pipeline.ID}
+		if pb.Version > latest[k] {
+			latest[k] = pb.Version
+		}
+	}
+
+	var next *sdk.PipelineBuild
+	var superseded []*sdk.PipelineBuild
+	for i := range queued {
+		pb := &queued[i]
+		if pb.Version < latest[appPipeline{pb.Application.ID, pb.Pipeline.ID}] {
+			superseded = append(superseded, pb)
+			continue
+		}
+		if next == nil {
+			next = pb
+		}
+	}
+	return next, superseded
+}
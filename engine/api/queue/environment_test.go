@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func queuedBuild(id, appID, pipID, version int64) sdk.PipelineBuild {
+	return sdk.PipelineBuild{
+		ID:          id,
+		Version:     version,
+		Application: sdk.Application{ID: appID},
+		Pipeline:    sdk.Pipeline{ID: pipID},
+	}
+}
+
+func TestSelectQueuedDeployment(t *testing.T) {
+	queued := []sdk.PipelineBuild{
+		queuedBuild(1, 1, 1, 10),
+		queuedBuild(2, 2, 1, 3),
+		queuedBuild(3, 1, 1, 12),
+		queuedBuild(4, 1, 2, 1),
+	}
+
+	next, superseded := selectQueuedDeployment(queued)
+	if next == nil || next.ID != 2 {
+		t.Fatalf("expected build 2 to start, got %v", next)
+	}
+	if len(superseded) != 1 || superseded[0].ID != 1 {
+		t.Fatalf("expected build 1 to be superseded, got %v", superseded)
+	}
+
+	next, superseded = selectQueuedDeployment(nil)
+	if next != nil || len(superseded) != 0 {
+		t.Fatalf("expected nothing to start on an empty queue")
+	}
+}
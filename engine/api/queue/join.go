@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/trigger"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// pipelineBuildJoins records a successful pipeline build on the fan-in triggers waiting for it,
+// and runs the destination pipeline of those whose sources have all succeeded on the same key
+func pipelineBuildJoins(tx gorp.SqlExecutor, pb sdk.PipelineBuild) {
+	joins, err := trigger.LoadJoinsAsSource(tx, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID)
+	if err != nil {
+		log.Warning("pipelineBuildJoins> Cannot load fan-in triggers for %s-%s-%s[%s]: %s\n", pb.Pipeline.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.Environment.Name, err)
+		return
+	}
+
+	now := time.Now()
+	for _, j := range joins {
+		source := trigger.JoinSource(j, pb)
+		if source == nil {
+			continue
+		}
+		key := trigger.JoinKey(j, pb)
+		if key == "" {
+			log.Notice("pipelineBuildJoins> No %s on pb %d for fan-in trigger %d\n", j.MatchOn, pb.ID, j.ID)
+			continue
+		}
+
+		if err := trigger.DeleteExpiredJoinArrivals(tx, j, now); err != nil {
+			log.Warning("pipelineBuildJoins> Cannot delete expired builds of fan-in trigger %d: %s\n", j.ID, err)
+			continue
+		}
+
+		a := sdk.TriggerJoinArrival{
+			SourceID:        source.ID,
+			Key:             key,
+			PipelineBuildID: pb.ID,
+			BuildNumber:     pb.BuildNumber,
+			Version:         pb.Version,
+			Hash:            pb.Trigger.VCSChangesHash,
+			Arrived:         now,
+		}
+		if err := trigger.InsertJoinArrival(tx, j.ID, &a); err != nil {
+			log.Warning("pipelineBuildJoins> Cannot record pb %d on fan-in trigger %d: %s\n", pb.ID, j.ID, err)
+			continue
+		}
+
+		arrivals, err := trigger.LoadJoinArrivals(tx, j.ID)
+		if err != nil {
+			log.Warning("pipelineBuildJoins> Cannot load builds of fan-in trigger %d: %s\n", j.ID, err)
+			continue
+		}
+
+		for _, p := range trigger.PendingJoins(j, arrivals, now) {
+			if p.Key != key || len(p.Missing) > 0 {
+				continue
+			}
+
+			log.Info("pipelineBuildJoins> All %d sources of fan-in trigger %d succeeded on %s %s\n", len(j.Sources), j.ID, j.MatchOn, key)
+			t := sdk.PipelineTrigger{
+				SrcProject:      sdk.Project{Key: pb.Pipeline.ProjectKey},
+				SrcApplication:  pb.Application,
+				SrcPipeline:     pb.Pipeline,
+				SrcEnvironment:  pb.Environment,
+				DestProject:     j.DestProject,
+				DestApplication: j.DestApplication,
+				DestPipeline:    j.DestPipeline,
+				DestEnvironment: j.DestEnvironment,
+				Parameters:      j.Parameters,
+			}
+			t.Parameters, _ = trigger.ProcessTriggerParameters(t, pb.Parameters)
+			if err := RunTrigger(tx, &t, pb); err != nil {
+				log.Warning("pipelineBuildJoins> Cannot run pipeline on project %s, application %s, pipeline %s, env %s: %s\n", t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name, t.DestEnvironment.Name, err)
+				break
+			}
+			if err := trigger.DeleteJoinArrivals(tx, j.ID, key); err != nil {
+				log.Warning("pipelineBuildJoins> Cannot delete builds of fan-in trigger %d: %s\n", j.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/go-gorp/gorp"
+	"github.com/spf13/viper"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/artifact"
+	"github.com/ovh/cds/engine/api/event"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// commentPullRequest publishes the summary of a finished pull request build, when the application pipeline asks for it
+func commentPullRequest(db gorp.SqlExecutor, pb sdk.PipelineBuild) {
+	id, _ := strconv.ParseInt(sdk.ParameterValue(pb.Parameters, "git.pr.number"), 10, 64)
+	if id == 0 || pb.Application.RepositoriesManager == nil {
+		return
+	}
+
+	opts, err := application.LoadPipelineOptions(db, pb.Application.ID, pb.Pipeline.ID)
+	if err != nil {
+		log.Warning("commentPullRequest> Cannot load options of %s/%s: %s\n", pb.Application.Name, pb.Pipeline.Name, err)
+		return
+	}
+	if !opts.CommentPullRequests {
+		return
+	}
+
+	tests, err := pipeline.LoadTestResults(db, pb.ID)
+	if err != nil {
+		log.Warning("commentPullRequest> Cannot load tests of pipeline build %d: %s\n", pb.ID, err)
+	} else {
+		pb.Tests = &tests
+	}
+
+	if pb.Coverage, err = pipeline.LoadCoverage(db, pb.ID, false); err != nil {
+		log.Warning("commentPullRequest> Cannot load coverage of pipeline build %d: %s\n", pb.ID, err)
+	}
+
+	if pb.Artifacts, err = artifact.LoadArtifactsByBuildNumber(db, pb.Pipeline.ID, pb.Application.ID, pb.BuildNumber, pb.Environment.ID); err != nil {
+		log.Warning("commentPullRequest> Cannot load artifacts of pipeline build %d: %s\n", pb.ID, err)
+	}
+
+	event.PublishPullRequestComment(&pb, id, pullRequestSummary(pb, viper.GetString("base_url"), viper.GetString("api_url")))
+}
+
+// pullRequestSummary returns the markdown summary of a pull request build: status, tests, coverage and artifacts links
+func pullRequestSummary(pb sdk.PipelineBuild, baseURL, apiURL string) string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "**CDS %s/%s #%d: %s**\n\n", pb.Application.Name, pb.Pipeline.Name, pb.BuildNumber, pb.Status)
+	fmt.Fprintf(&buf, "[Build details](%s/#/project/%s/application/%s/pipeline/%s/build/%d?env=%s)\n",
+		baseURL, pb.Pipeline.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.BuildNumber, url.QueryEscape(pb.Environment.Name))
+
+	if pb.Tests != nil && pb.Tests.Total > 0 {
+		fmt.Fprintf(&buf, "\nTests: %d passed, %d failed, %d skipped (%d total)\n", pb.Tests.TotalOK, pb.Tests.TotalKO, pb.Tests.TotalSkipped, pb.Tests.Total)
+	}
+
+	if pb.Coverage != nil && pb.Coverage.Lines > 0 {
+		fmt.Fprintf(&buf, "\nCoverage: %.1f%% of lines", pb.Coverage.Percent())
+		if pb.Coverage.Delta != nil {
+			fmt.Fprintf(&buf, " (%+.1f%%)", *pb.Coverage.Delta)
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(pb.Artifacts) > 0 {
+		buf.WriteString("\nArtifacts:\n")
+		for _, a := range pb.Artifacts {
+			fmt.Fprintf(&buf, "- [%s](%s/artifact/%s)\n", a.Name, apiURL, a.DownloadHash)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestPullRequestSummary(t *testing.T) {
+	delta := -2.5
+	pb := sdk.PipelineBuild{
+		BuildNumber: 12,
+		Status:      sdk.StatusFail,
+		Pipeline:    sdk.Pipeline{Name: "build", ProjectKey: "KEY"},
+		Application: sdk.Application{Name: "app"},
+		Environment: sdk.DefaultEnv,
+		Tests:       &sdk.Tests{Total: 10, TotalOK: 7, TotalKO: 2, TotalSkipped: 1},
+		Coverage:    &sdk.Coverage{Lines: 200, LinesCovered: 150, Delta: &delta},
+		Artifacts:   []sdk.Artifact{{Name: "app.tar.gz", DownloadHash: "abcdef"}},
+	}
+
+	s := pullRequestSummary(pb, "https://cds.local", "https://api.cds.local")
+	for _, expected := range []string{
+		"**CDS app/build #12: Fail**",
+		"(https://cds.local/#/project/KEY/application/app/pipeline/build/build/12?env=NoEnv)",
+		"Tests: 7 passed, 2 failed, 1 skipped (10 total)",
+		"Coverage: 75.0% of lines (-2.5%)",
+		"- [app.tar.gz](https://api.cds.local/artifact/abcdef)",
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("summary should contain %q:\n%s", expected, s)
+		}
+	}
+
+	// Builds without tests, coverage nor artifacts only link the build
+	pb.Tests, pb.Coverage, pb.Artifacts = &sdk.Tests{}, nil, nil
+	s = pullRequestSummary(pb, "https://cds.local", "https://api.cds.local")
+	if strings.Contains(s, "Tests") || strings.Contains(s, "Coverage") || strings.Contains(s, "Artifacts") {
+		t.Errorf("summary should only link the build:\n%s", s)
+	}
+}
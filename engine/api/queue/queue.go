@@ -10,13 +10,16 @@ import (
 
 	"github.com/ovh/cds/engine/api/action"
 	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/approval"
 
 	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/deployment"
 	"github.com/ovh/cds/engine/api/environment"
 	"github.com/ovh/cds/engine/api/event"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/repositoriesmanager"
 	"github.com/ovh/cds/engine/api/trigger"
 	"github.com/ovh/cds/engine/log"
 	"github.com/ovh/cds/sdk"
@@ -51,6 +54,8 @@ func Pipelines() {
 			for i := range pipelines {
 				RunActions(db, pipelines[i])
 			}
+
+			RunQueuedDeployments(db)
 		}
 	}
 }
@@ -82,6 +87,7 @@ func RunActions(db *gorp.DbMap, pb sdk.PipelineBuild) {
 	}
 
 	pbNewStatus := sdk.StatusBuilding
+	var rollback *sdk.DeploymentVerification
 
 	// OH! AN EMPTY PIPELINE
 	if len(pb.Stages) == 0 {
@@ -94,6 +100,17 @@ func RunActions(db *gorp.DbMap, pb sdk.PipelineBuild) {
 		stage := &pb.Stages[stageIndex]
 
 		if stage.Status == sdk.StatusWaiting {
+			if stage.Approval != nil && stage.Enabled {
+				approved, err := checkStageApproval(tx, stage, pb)
+				if err != nil {
+					log.Warning("queue.RunActions> Cannot check approval on stage %s(%d) of pipeline build %d: %s\n", stage.Name, stage.ID, pb.ID, err)
+					return
+				}
+				if !approved {
+					pbNewStatus = sdk.StatusWaitingApproval
+					break
+				}
+			}
 			if err := addJobsToQueue(tx, stage, pb); err != nil {
 				log.Warning("queue.RunActions> Cannot add job to queue: %s", err)
 				return
@@ -119,6 +136,14 @@ func RunActions(db *gorp.DbMap, pb sdk.PipelineBuild) {
 					pbNewStatus = sdk.StatusFail
 					break
 				}
+				// Verify the progressive deployment before promoting the stage
+				if stage.Progressive != nil && stage.Status == sdk.StatusSuccess {
+					if err := startVerification(tx, stage, pb); err != nil {
+						log.Warning("queue.RunActions> Cannot start verification on stage %s(%d) of pipeline build %d: %s\n", stage.Name, stage.ID, pb.ID, err)
+						return
+					}
+					break
+				}
 				if stageIndex == len(pb.Stages)-1 {
 					pbNewStatus = sdk.StatusSuccess
 					break
@@ -130,15 +155,53 @@ func RunActions(db *gorp.DbMap, pb sdk.PipelineBuild) {
 				}
 			}
 		}
+
+		if stage.Status == sdk.StatusChecking {
+			decision, v, err := verifyStage(tx, stage, pb)
+			if err != nil {
+				log.Warning("queue.RunActions> Cannot verify stage %s(%d) of pipeline build %d: %s\n", stage.Name, stage.ID, pb.ID, err)
+				return
+			}
+
+			if decision == deployment.DecisionRollback {
+				stage.Status = sdk.StatusFail
+				pbNewStatus = sdk.StatusFail
+				if stage.Progressive.AutoRollback {
+					rollback = v
+				}
+				break
+			}
+			if decision != deployment.DecisionPromote {
+				break
+			}
+			stage.Status = sdk.StatusSuccess
+			if stageIndex == len(pb.Stages)-1 {
+				pbNewStatus = sdk.StatusSuccess
+				break
+			}
+			pb.Stages[stageIndex+1].Status = sdk.StatusWaiting
+			continue
+		}
 	}
 
 	if err := pipeline.UpdatePipelineBuildStatusAndStage(tx, &pb, pbNewStatus); err != nil {
 		log.Warning("RunActions> Cannot update UpdatePipelineBuildStatusAndStage on pb %d: %s\n", pb.ID, err)
 	}
 
-	// If pipeline build succeed, run trigger
-	if pb.Status == sdk.StatusSuccess {
+	// If pipeline build ended, run triggers: only conditional triggers may run after a failure
+	if pb.Status == sdk.StatusSuccess || pb.Status == sdk.StatusFail {
 		pipelineBuildEnd(tx, pb)
+		commentPullRequest(tx, pb)
+	}
+	if pb.Status == sdk.StatusSuccess {
+		pipelineBuildJoins(tx, pb)
+	}
+
+	// If a progressive deployment failed its verification, redeploy the last successful build
+	if rollback != nil {
+		if err := rollbackDeployment(tx, pb, rollback); err != nil {
+			log.Warning("RunActions> Cannot rollback pb %d: %s\n", pb.ID, err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -146,6 +209,26 @@ func RunActions(db *gorp.DbMap, pb sdk.PipelineBuild) {
 	}
 }
 
+// checkStageApproval returns true if the stage has been approved since the pipeline build started.
+// Otherwise it records a new approval request, notifies the designated groups and pauses the stage.
+func checkStageApproval(tx gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild) (bool, error) {
+	last, err := approval.LoadLastStageApproval(tx, pb.ID, stage.ID)
+	if err != nil {
+		return false, err
+	}
+	if last != nil && last.Status == sdk.ApprovalApproved && last.Decided != nil && !last.Decided.Before(pb.Start) {
+		return true, nil
+	}
+
+	a, err := approval.InsertStageApproval(tx, &pb, stage)
+	if err != nil {
+		return false, err
+	}
+	approval.Notify(tx, &pb, a)
+	stage.Status = sdk.StatusWaitingApproval
+	return false, nil
+}
+
 func addJobsToQueue(tx gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild) error {
 	//Check stage prerequisites
 	prerequisitesOK, err := pipeline.CheckPrerequisites(*stage, pb)
@@ -155,12 +238,19 @@ func addJobsToQueue(tx gorp.SqlExecutor, stage *sdk.Stage, pb sdk.PipelineBuild)
 	}
 	stage.Status = sdk.StatusBuilding
 
+	deployParams, err := deploymentParameters(tx, stage, pb)
+	if err != nil {
+		log.Warning("addJobsToQueue> Cannot compute deployment parameters on stage %s(%d) of pipeline build %d: %s\n", stage.Name, stage.ID, pb.ID, err)
+		return err
+	}
+
 	for _, job := range stage.Jobs {
 		pbJobParams, errParam := getPipelineBuildJobParameters(tx, job, pb)
 		if errParam != nil {
 			log.Warning("addJobsToQueue> Cannot get action build parameters for pipeline build %d: %s\n", pb.ID, err)
 			return errParam
 		}
+		pbJobParams = append(pbJobParams, deployParams...)
 		pbJob := sdk.PipelineBuildJob{
 			PipelineBuildID: pb.ID,
 			Parameters:      pbJobParams,
@@ -265,14 +355,30 @@ func pipelineBuildEnd(tx gorp.SqlExecutor, pb sdk.PipelineBuild) {
 		return
 	}
 
-	if len(triggers) > 0 {
-		log.Debug("(v%d) Loaded %d potential triggers for  %s[%s]", pb.Version, len(triggers), pb.Pipeline.Name, pb.Environment.Name)
+	if len(triggers) == 0 {
+		return
 	}
-	for _, t := range triggers {
+	log.Debug("(v%d) Loaded %d potential triggers for  %s[%s]", pb.Version, len(triggers), pb.Pipeline.Name, pb.Environment.Name)
+
+	ctx := triggerConditionContext(tx, pb)
+	for i := range triggers {
+		t := &triggers[i]
+
+		// Check condition
+		ctx.Parameters = t.Parameters
+		conditionOK, err := trigger.CheckTriggerCondition(*t, ctx)
+		if err != nil {
+			log.Warning("pipelineBuildEnd> Cannot evaluate condition '%s' of trigger %d: %s\n", t.Condition, t.ID, err)
+			continue
+		}
+		if !conditionOK {
+			log.Debug("Condition not met for trigger %s/%s/%s[%s] -> %s/%s/%s[%s]\n", t.SrcProject.Key, t.SrcApplication.Name, t.SrcPipeline.Name, t.SrcEnvironment.Name, t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name, t.DestEnvironment.Name)
+			continue
+		}
 
 		// Check prerequisites
 		log.Debug("Checking %d prerequisites for trigger %s/%s/%s -> %s/%s/%s\n", len(t.Prerequisites), t.SrcProject.Key, t.SrcApplication.Name, t.SrcPipeline.Name, t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name)
-		prereqOK, err := trigger.CheckPrerequisites(t, pb)
+		prereqOK, err := trigger.CheckPrerequisites(*t, pb)
 		if err != nil {
 			log.Warning("pipelineScheduler> Cannot check trigger prereq: %s\n", err)
 			continue
@@ -282,39 +388,118 @@ func pipelineBuildEnd(tx gorp.SqlExecutor, pb sdk.PipelineBuild) {
 			continue
 		}
 
-		parameters := t.Parameters
-		// Add parent build info
-		parentParams, err := ParentBuildInfos(&pb)
-		if err != nil {
-			log.Warning("pipelineBuildEnd> Cannot create parent build infos: %s\n", err)
+		// Wait for a manual approval before running the destination pipeline
+		if t.Approval != nil {
+			a, err := approval.InsertTriggerApproval(tx, &pb, t)
+			if err != nil {
+				log.Warning("pipelineBuildEnd> Cannot insert approval request for trigger %d: %s\n", t.ID, err)
+				continue
+			}
+			approval.Notify(tx, &pb, a)
 			continue
 		}
-		parameters = append(parameters, parentParams...)
 
-		// Start build
-		app, err := application.LoadApplicationByName(tx, t.DestProject.Key, t.DestApplication.Name, application.WithClearPassword())
-		if err != nil {
-			log.Warning("pipelineBuildEnd> Cannot load destination application: %s\n", err)
+		if err := RunTrigger(tx, t, pb); err != nil {
+			log.Warning("pipelineScheduler> Cannot run pipeline on project %s, application %s, pipeline %s, env %s: %s\n", t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name, t.DestEnvironment.Name, err)
 			continue
 		}
+	}
+}
 
-		log.Info("Prerequisites OK for trigger %s/%s/%s-%s -> %s/%s/%s-%s (version %d)\n", t.SrcProject.Key, t.SrcApplication.Name, t.SrcPipeline.Name, t.SrcEnvironment.Name, t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name, t.DestEnvironment.Name, pb.Version)
+// triggerConditionContext returns the data trigger conditions are evaluated against.
+// Test results are only loaded when a condition uses tests.*, and changed files are only fetched
+// from the repositories manager when a condition uses changed()
+func triggerConditionContext(tx gorp.SqlExecutor, pb sdk.PipelineBuild) trigger.ConditionContext {
+	var files []string
+	var filesLoaded bool
+	var tests *sdk.Tests
+	return trigger.ConditionContext{
+		Build: pb,
+		Now:   time.Now(),
+		Tests: func() (*sdk.Tests, error) {
+			if tests != nil {
+				return tests, nil
+			}
+			t, err := pipeline.LoadTestResults(tx, pb.ID)
+			if err != nil {
+				return nil, err
+			}
+			tests = &t
+			return tests, nil
+		},
+		ChangedFiles: func() ([]string, error) {
+			if filesLoaded {
+				return files, nil
+			}
+			var err error
+			files, err = changedFiles(tx, pb)
+			if err != nil {
+				return nil, err
+			}
+			filesLoaded = true
+			return files, nil
+		},
+	}
+}
 
-		trigger := sdk.PipelineBuildTrigger{
-			ManualTrigger:       false,
-			TriggeredBy:         pb.Trigger.TriggeredBy,
-			ParentPipelineBuild: &pb,
-			VCSChangesAuthor:    pb.Trigger.VCSChangesAuthor,
-			VCSChangesBranch:    pb.Trigger.VCSChangesBranch,
-			VCSChangesHash:      pb.Trigger.VCSChangesHash,
-		}
+// changedFiles returns the files changed between the previous build on the same branch and the given build
+func changedFiles(tx gorp.SqlExecutor, pb sdk.PipelineBuild) ([]string, error) {
+	app, err := application.LoadApplicationByName(tx, pb.Application.ProjectKey, pb.Application.Name)
+	if err != nil {
+		return nil, err
+	}
+	if app.RepositoriesManager == nil || app.RepositoryFullname == "" {
+		return nil, sdk.ErrNoReposManagerClientAuth
+	}
 
-		_, err = RunPipeline(tx, t.DestProject.Key, app, t.DestPipeline.Name, t.DestEnvironment.Name, parameters, pb.Version, trigger, &sdk.User{Admin: true})
-		if err != nil {
-			log.Warning("pipelineScheduler> Cannot run pipeline on project %s, application %s, pipeline %s, env %s: %s\n", t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name, t.DestEnvironment.Name, err)
-			continue
-		}
+	cur, prev, err := pipeline.CurrentAndPreviousPipelineBuildNumberAndHash(tx, pb.BuildNumber, pb.Pipeline.ID, pb.Application.ID, pb.Environment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cur == nil || cur.Hash == "" {
+		return nil, nil
+	}
+	var since string
+	if prev != nil {
+		since = prev.Hash
+	}
+
+	client, err := repositoriesmanager.AuthorizedClient(tx, pb.Application.ProjectKey, app.RepositoriesManager.Name)
+	if err != nil {
+		return nil, sdk.ErrNoReposManagerClientAuth
 	}
+	return client.ChangedFiles(app.RepositoryFullname, since, cur.Hash)
+}
+
+// RunTrigger runs the destination pipeline of a trigger after the end of the given pipeline build
+func RunTrigger(tx gorp.SqlExecutor, t *sdk.PipelineTrigger, pb sdk.PipelineBuild) error {
+	parameters := t.Parameters
+	// Add parent build info
+	parentParams, err := ParentBuildInfos(&pb)
+	if err != nil {
+		return fmt.Errorf("cannot create parent build infos: %s", err)
+	}
+	parameters = append(parameters, parentParams...)
+
+	// Start build
+	app, err := application.LoadApplicationByName(tx, t.DestProject.Key, t.DestApplication.Name, application.WithClearPassword())
+	if err != nil {
+		return fmt.Errorf("cannot load destination application: %s", err)
+	}
+
+	log.Info("Prerequisites OK for trigger %s/%s/%s-%s -> %s/%s/%s-%s (version %d)\n", t.SrcProject.Key, t.SrcApplication.Name, t.SrcPipeline.Name, t.SrcEnvironment.Name, t.DestProject.Key, t.DestApplication.Name, t.DestPipeline.Name, t.DestEnvironment.Name, pb.Version)
+
+	trigger := sdk.PipelineBuildTrigger{
+		ManualTrigger:       false,
+		TriggeredBy:         pb.Trigger.TriggeredBy,
+		ParentPipelineBuild: &pb,
+		VCSChangesAuthor:    pb.Trigger.VCSChangesAuthor,
+		VCSChangesBranch:    pb.Trigger.VCSChangesBranch,
+		VCSChangesHash:      pb.Trigger.VCSChangesHash,
+	}
+
+	_, err = RunPipeline(tx, t.DestProject.Key, app, t.DestPipeline.Name, t.DestEnvironment.Name, parameters, pb.Version, trigger, &sdk.User{Admin: true})
+	return err
 }
 
 // ParentBuildInfos fetch parent build data and injects them as {{.cds.parent.*}} parameters
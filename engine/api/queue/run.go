@@ -4,6 +4,7 @@ import (
 	"github.com/go-gorp/gorp"
 
 	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/environment"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/project"
@@ -65,11 +66,60 @@ func RunPipeline(db gorp.SqlExecutor, projectKey string, app *sdk.Application, p
 		env = &sdk.DefaultEnv
 	}
 
+	// Only one deployment at a time on an environment
+	queued, err := acquireEnvironment(db, projectData.ID, app, p, env, trigger.FreezeOverride)
+	if err != nil {
+		log.Warning("scheduler.Run> Cannot acquire environment %s: %s\n", env.Name, err)
+		return nil, err
+	}
+
 	pb, err := pipeline.InsertPipelineBuild(db, projectData, p, app, applicationPipelineParams, params, env, version, trigger)
 	if err != nil {
 		log.Warning("scheduler.Run> Cannot start pipeline %s: %s\n", pipelineName, err)
 		return nil, err
 	}
 
+	if queued {
+		log.Info("scheduler.Run> Environment %s is busy, %s/%s #%d is queued\n", env.Name, app.Name, pipelineName, pb.BuildNumber)
+		if err := pipeline.QueuePipelineBuild(db, pb); err != nil {
+			log.Warning("scheduler.Run> Cannot queue pipeline build %d: %s\n", pb.ID, err)
+			return nil, err
+		}
+	}
+
+	if trigger.VCSChangesBranch != "" {
+		cancelSupersededBuilds(db, pb)
+	}
+
 	return pb, nil
 }
+
+// cancelSupersededBuilds stops the older builds of the same branch when the application pipeline asks for it
+func cancelSupersededBuilds(db gorp.SqlExecutor, pb *sdk.PipelineBuild) {
+	opts, err := application.LoadPipelineOptions(db, pb.Application.ID, pb.Pipeline.ID)
+	if err != nil {
+		log.Warning("cancelSupersededBuilds> Cannot load options of %s/%s: %s\n", pb.Application.Name, pb.Pipeline.Name, err)
+		return
+	}
+	if !opts.CancelSuperseded {
+		return
+	}
+
+	olds, err := pipeline.LoadSupersededPipelineBuilds(db, pb)
+	if err != nil {
+		log.Warning("cancelSupersededBuilds> Cannot load builds superseded by %d: %s\n", pb.ID, err)
+		return
+	}
+
+	for i := range olds {
+		old := &olds[i]
+		log.Info("cancelSupersededBuilds> %s/%s #%d on %s superseded by #%d\n", pb.Application.Name, pb.Pipeline.Name, old.BuildNumber, pb.Trigger.VCSChangesBranch, pb.BuildNumber)
+		if err := pipeline.SupersedePipelineBuild(db, old, pb); err != nil {
+			log.Warning("cancelSupersededBuilds> Cannot stop pipeline build %d: %s\n", old.ID, err)
+		}
+	}
+
+	if len(olds) > 0 {
+		cache.DeleteAll(cache.Key("application", pb.Application.ProjectKey, "builds", "*"))
+	}
+}
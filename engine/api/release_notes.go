@@ -0,0 +1,286 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/artifact"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/releasenotes"
+	"github.com/ovh/cds/engine/api/repositoriesmanager"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// releaseNotes generates release notes of an application between two commits of a branch
+func releaseNotes(db gorp.SqlExecutor, projectKey string, app *sdk.Application, from, to, fromHash, toHash, branch, groupBy, issuePattern string) (*sdk.ReleaseNotes, error) {
+	commits := []sdk.VCSCommit{}
+	if fromHash != toHash && toHash != "" {
+		if app.RepositoriesManager == nil || app.RepositoryFullname == "" {
+			return nil, sdk.ErrNoReposManagerClientAuth
+		}
+
+		client, err := repositoriesmanager.AuthorizedClient(db, projectKey, app.RepositoriesManager.Name)
+		if err != nil {
+			log.Warning("releaseNotes> Cannot get client: %s\n", err)
+			return nil, sdk.ErrNoReposManagerClientAuth
+		}
+
+		commits, err = client.Commits(app.RepositoryFullname, branch, fromHash, toHash)
+		if err != nil {
+			log.Warning("releaseNotes> Cannot get commits: %s\n", err)
+			return nil, err
+		}
+	}
+
+	rn, err := releasenotes.Generate(commits, groupBy, issuePattern)
+	if err != nil {
+		return nil, err
+	}
+	rn.Application = app.Name
+	rn.From = from
+	rn.To = to
+	rn.FromHash = fromHash
+	rn.ToHash = toHash
+	rn.Markdown = releasenotes.Markdown(rn)
+	return rn, nil
+}
+
+func writeReleaseNotes(w http.ResponseWriter, r *http.Request, rn *sdk.ReleaseNotes) {
+	if r.FormValue("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(rn.Markdown))
+		return
+	}
+	WriteJSON(w, r, rn, http.StatusOK)
+}
+
+func getApplicationReleaseNotesHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+
+	if err := r.ParseForm(); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	groupBy := r.Form.Get("groupBy")
+	issuePattern := r.Form.Get("issuePattern")
+	pipName := r.Form.Get("pipeline")
+
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getApplicationReleaseNotesHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	// Between the deployments of two environments
+	if fromEnv, toEnv := r.Form.Get("fromEnv"), r.Form.Get("toEnv"); fromEnv != "" || toEnv != "" {
+		if fromEnv == "" || toEnv == "" {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+
+		deployments, err := loadReadableDeployments(db, projectKey, c.User)
+		if err != nil {
+			log.Warning("getApplicationReleaseNotesHandler> Cannot load deployments of project %s: %s\n", projectKey, err)
+			WriteError(w, r, err)
+			return
+		}
+
+		var from, to *sdk.Deployment
+		for i := range deployments {
+			d := &deployments[i]
+			if d.ApplicationID != app.ID || (pipName != "" && d.Pipeline != pipName) {
+				continue
+			}
+			if d.Environment == fromEnv && (from == nil || d.Done.After(from.Done)) {
+				from = d
+			}
+			if d.Environment == toEnv && (to == nil || d.Done.After(to.Done)) {
+				to = d
+			}
+		}
+		if from == nil || to == nil {
+			log.Warning("getApplicationReleaseNotesHandler> Application %s is not deployed on both %s and %s\n", appName, fromEnv, toEnv)
+			WriteError(w, r, sdk.ErrNoPipelineBuild)
+			return
+		}
+
+		rn, err := releaseNotes(db, projectKey, app, fromEnv, toEnv, from.Hash, to.Hash, to.Branch, groupBy, issuePattern)
+		if err != nil {
+			WriteError(w, r, err)
+			return
+		}
+		writeReleaseNotes(w, r, rn)
+		return
+	}
+
+	// Between two builds of a pipeline
+	fromBuild, errFrom := strconv.ParseInt(r.Form.Get("fromBuild"), 10, 64)
+	toBuild, errTo := strconv.ParseInt(r.Form.Get("toBuild"), 10, 64)
+	if errFrom != nil || errTo != nil || pipName == "" {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	pip, err := pipeline.LoadPipeline(db, projectKey, pipName, false)
+	if err != nil {
+		log.Warning("getApplicationReleaseNotesHandler> Cannot load pipeline %s: %s\n", pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	env := &sdk.DefaultEnv
+	if envName := r.Form.Get("envName"); envName != "" && envName != sdk.DefaultEnv.Name {
+		env, err = environment.LoadEnvironmentByName(db, projectKey, envName)
+		if err != nil {
+			log.Warning("getApplicationReleaseNotesHandler> Cannot load environment %s: %s\n", envName, err)
+			WriteError(w, r, err)
+			return
+		}
+		if !permission.AccessToEnvironment(env.ID, c.User, permission.PermissionRead) {
+			WriteError(w, r, sdk.ErrForbidden)
+			return
+		}
+	}
+
+	from, err := pipeline.LoadPipelineBuildByApplicationPipelineEnvBuildNumber(db, app.ID, pip.ID, env.ID, fromBuild)
+	if err != nil {
+		log.Warning("getApplicationReleaseNotesHandler> Cannot load build #%d: %s\n", fromBuild, err)
+		WriteError(w, r, sdk.ErrNoPipelineBuild)
+		return
+	}
+	to, err := pipeline.LoadPipelineBuildByApplicationPipelineEnvBuildNumber(db, app.ID, pip.ID, env.ID, toBuild)
+	if err != nil {
+		log.Warning("getApplicationReleaseNotesHandler> Cannot load build #%d: %s\n", toBuild, err)
+		WriteError(w, r, sdk.ErrNoPipelineBuild)
+		return
+	}
+
+	rn, err := releaseNotes(db, projectKey, app, fmt.Sprintf("#%d", fromBuild), fmt.Sprintf("#%d", toBuild),
+		from.Trigger.VCSChangesHash, to.Trigger.VCSChangesHash, to.Trigger.VCSChangesBranch, groupBy, issuePattern)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeReleaseNotes(w, r, rn)
+}
+
+func generatePipelineBuildReleaseNotesHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+
+	var request sdk.ReleaseNotesRequest
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &request); err != nil {
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	pb, err := loadRequestPipelineBuild(db, r, c, permission.PermissionReadExecute)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	app, err := application.LoadApplicationByName(db, projectKey, pb.Application.Name)
+	if err != nil {
+		log.Warning("generatePipelineBuildReleaseNotesHandler> Cannot load application %s: %s\n", pb.Application.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cur, prev, err := pipeline.CurrentAndPreviousPipelineBuildNumberAndHash(db, pb.BuildNumber, pb.Pipeline.ID, pb.Application.ID, pb.Environment.ID)
+	if err != nil {
+		log.Warning("generatePipelineBuildReleaseNotesHandler> Cannot load previous build of #%d: %s\n", pb.BuildNumber, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	from, fromHash := "", ""
+	if prev != nil {
+		from = fmt.Sprintf("#%d", prev.BuildNumber)
+		fromHash = prev.Hash
+	}
+	rn, err := releaseNotes(db, projectKey, app, from, fmt.Sprintf("#%d", cur.BuildNumber), fromHash, cur.Hash, cur.Branch, request.GroupBy, request.IssuePattern)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	if request.Artifact {
+		if err := saveReleaseNotesArtifact(db, pb, rn, request.ArtifactTag); err != nil {
+			log.Warning("generatePipelineBuildReleaseNotesHandler> Cannot save release notes artifact of #%d: %s\n", pb.BuildNumber, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
+	if request.Tag != "" {
+		if app.RepositoriesManager == nil || app.RepositoryFullname == "" {
+			WriteError(w, r, sdk.ErrNoReposManagerClientAuth)
+			return
+		}
+		client, err := repositoriesmanager.AuthorizedClient(db, projectKey, app.RepositoriesManager.Name)
+		if err != nil {
+			log.Warning("generatePipelineBuildReleaseNotesHandler> Cannot get client: %s\n", err)
+			WriteError(w, r, sdk.ErrNoReposManagerClientAuth)
+			return
+		}
+		if err := client.CreateRelease(app.RepositoryFullname, request.Tag, cur.Hash, fmt.Sprintf("%s %s", app.Name, request.Tag), rn.Markdown); err != nil {
+			log.Warning("generatePipelineBuildReleaseNotesHandler> Cannot publish release notes on tag %s: %s\n", request.Tag, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
+	WriteJSON(w, r, rn, http.StatusOK)
+}
+
+// saveReleaseNotesArtifact attaches markdown release notes to a pipeline build as RELEASE_NOTES.md
+func saveReleaseNotesArtifact(db *gorp.DbMap, pb *sdk.PipelineBuild, rn *sdk.ReleaseNotes, tag string) error {
+	if tag == "" {
+		tag = fmt.Sprintf("%d", pb.Version)
+	}
+
+	hash, err := generateHash()
+	if err != nil {
+		return err
+	}
+
+	art := sdk.Artifact{
+		Name:         "RELEASE_NOTES.md",
+		Project:      pb.Application.ProjectKey,
+		Pipeline:     pb.Pipeline.Name,
+		Application:  pb.Application.Name,
+		Tag:          tag,
+		Environment:  pb.Environment.Name,
+		BuildNumber:  int(pb.BuildNumber),
+		DownloadHash: hash,
+		Size:         int64(len(rn.Markdown)),
+		Perm:         0644,
+		MD5sum:       fmt.Sprintf("%x", md5.Sum([]byte(rn.Markdown))),
+	}
+	return artifact.SaveFile(db, &pb.Pipeline, &pb.Application, art, ioutil.NopCloser(strings.NewReader(rn.Markdown)), &pb.Environment)
+}
@@ -0,0 +1,226 @@
+package releasenotes
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// DefaultIssuePattern matches JIRA like issue keys (PROJ-123) and GitHub like references (#123)
+const DefaultIssuePattern = `[A-Z][A-Z0-9]+-[0-9]+|#[0-9]+`
+
+const (
+	breakingKey = "breaking"
+	otherKey    = "other"
+)
+
+// conventionalCommit parses "type(scope)!: subject"
+var conventionalCommit = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// sectionTitles lists conventional commit types in the order of release notes sections
+var sectionTitles = []struct{ key, title string }{
+	{breakingKey, "Breaking changes"},
+	{"feat", "Features"},
+	{"fix", "Bug fixes"},
+	{"perf", "Performance improvements"},
+	{"revert", "Reverts"},
+	{"refactor", "Code refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build system"},
+	{"ci", "Continuous integration"},
+	{"style", "Styles"},
+	{"chore", "Chores"},
+	{otherKey, "Other changes"},
+}
+
+// Parse parses a commit message as a conventional commit and extracts its issue keys
+func Parse(c sdk.VCSCommit, issues *regexp.Regexp) sdk.ReleaseNotesEntry {
+	lines := strings.Split(strings.TrimSpace(c.Message), "\n")
+	e := sdk.ReleaseNotesEntry{
+		Hash:    c.Hash,
+		Subject: strings.TrimSpace(lines[0]),
+		Author:  c.Author.Name,
+		URL:     c.URL,
+	}
+	if c.Author.DisplayName != "" {
+		e.Author = c.Author.DisplayName
+	}
+
+	if m := conventionalCommit.FindStringSubmatch(e.Subject); m != nil {
+		e.Type = strings.ToLower(m[1])
+		e.Scope = m[2]
+		e.Breaking = m[3] == "!"
+		e.Subject = m[4]
+	}
+	for _, l := range lines[1:] {
+		if strings.HasPrefix(l, "BREAKING CHANGE") || strings.HasPrefix(l, "BREAKING-CHANGE") {
+			e.Breaking = true
+		}
+	}
+
+	if issues != nil {
+		seen := map[string]bool{}
+		for _, k := range issues.FindAllString(c.Message, -1) {
+			if !seen[k] {
+				seen[k] = true
+				e.Issues = append(e.Issues, k)
+			}
+		}
+	}
+	return e
+}
+
+// Generate aggregates commits in release notes, grouped by conventional commit type or by issue key.
+// Commits are expected newest first, as returned by repositories managers.
+func Generate(commits []sdk.VCSCommit, groupBy, issuePattern string) (*sdk.ReleaseNotes, error) {
+	if groupBy == "" {
+		groupBy = sdk.ReleaseNotesByType
+	}
+	if groupBy != sdk.ReleaseNotesByType && groupBy != sdk.ReleaseNotesByIssue {
+		return nil, sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("unknown grouping %s", groupBy))
+	}
+	if issuePattern == "" {
+		issuePattern = DefaultIssuePattern
+	}
+	issues, err := regexp.Compile(issuePattern)
+	if err != nil {
+		return nil, sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("invalid issue pattern: %s", err))
+	}
+
+	rn := &sdk.ReleaseNotes{GroupBy: groupBy, Sections: []sdk.ReleaseNotesSection{}, Issues: []string{}}
+	sections := map[string]*sdk.ReleaseNotesSection{}
+	var keys []string
+	allIssues := map[string]bool{}
+
+	add := func(key, title string, e sdk.ReleaseNotesEntry) {
+		s, ok := sections[key]
+		if !ok {
+			s = &sdk.ReleaseNotesSection{Key: key, Title: title}
+			sections[key] = s
+			keys = append(keys, key)
+		}
+		s.Entries = append(s.Entries, e)
+	}
+
+	for _, c := range commits {
+		e := Parse(c, issues)
+		// Merge commits do not bring anything to release notes
+		if strings.HasPrefix(e.Subject, "Merge ") && e.Type == "" {
+			continue
+		}
+		for _, k := range e.Issues {
+			allIssues[k] = true
+		}
+
+		if groupBy == sdk.ReleaseNotesByIssue {
+			if len(e.Issues) == 0 {
+				add(otherKey, "Other changes", e)
+			}
+			for _, k := range e.Issues {
+				add(k, k, e)
+			}
+			continue
+		}
+
+		key := otherKey
+		if e.Breaking {
+			key = breakingKey
+		} else if e.Type != "" {
+			for _, t := range sectionTitles {
+				if t.key == e.Type {
+					key = e.Type
+				}
+			}
+		}
+		add(key, sectionTitle(key), e)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		oi, oj := sectionOrder(keys[i], groupBy), sectionOrder(keys[j], groupBy)
+		if oi != oj {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		rn.Sections = append(rn.Sections, *sections[k])
+	}
+
+	for k := range allIssues {
+		rn.Issues = append(rn.Issues, k)
+	}
+	sort.Strings(rn.Issues)
+	return rn, nil
+}
+
+func sectionTitle(key string) string {
+	for _, t := range sectionTitles {
+		if t.key == key {
+			return t.title
+		}
+	}
+	return key
+}
+
+func sectionOrder(key, groupBy string) int {
+	if groupBy == sdk.ReleaseNotesByIssue {
+		if key == otherKey {
+			return 1
+		}
+		return 0
+	}
+	for i, t := range sectionTitles {
+		if t.key == key {
+			return i
+		}
+	}
+	return len(sectionTitles)
+}
+
+func shortHash(h string) string {
+	if len(h) > 7 {
+		return h[:7]
+	}
+	return h
+}
+
+// Markdown renders release notes
+func Markdown(rn *sdk.ReleaseNotes) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s %s...%s\n", rn.Application, rn.From, rn.To)
+
+	if len(rn.Sections) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	for _, s := range rn.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Title)
+		for _, e := range s.Entries {
+			b.WriteString("- ")
+			if e.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", e.Scope)
+			}
+			b.WriteString(e.Subject)
+			if e.URL != "" {
+				fmt.Fprintf(&b, " ([%s](%s))", shortHash(e.Hash), e.URL)
+			} else if e.Hash != "" {
+				fmt.Fprintf(&b, " (%s)", shortHash(e.Hash))
+			}
+			if e.Author != "" {
+				fmt.Fprintf(&b, " - %s", e.Author)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(rn.Issues) > 0 && rn.GroupBy != sdk.ReleaseNotesByIssue {
+		fmt.Fprintf(&b, "\n## Issues\n\n%s\n", strings.Join(rn.Issues, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package releasenotes
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func commit(hash, message string) sdk.VCSCommit {
+	return sdk.VCSCommit{Hash: hash, Message: message, Author: sdk.VCSAuthor{Name: "jdoe"}}
+}
+
+var commits = []sdk.VCSCommit{
+	commit("a1", "feat(api): add inventory endpoint\n\nCloses CDS-12"),
+	commit("a2", "fix: handle empty pipelines (#42)"),
+	commit("a3", "Merge branch 'feature' into master"),
+	commit("a4", "feat!: remove deprecated routes CDS-13"),
+	commit("a5", "update README"),
+	commit("a6", "fix(worker): retry on timeout\n\nBREAKING CHANGE: timeout flag renamed, refs CDS-12"),
+}
+
+func TestParse(t *testing.T) {
+	issues := regexp.MustCompile(DefaultIssuePattern)
+
+	e := Parse(commits[0], issues)
+	assert.Equal(t, "feat", e.Type)
+	assert.Equal(t, "api", e.Scope)
+	assert.Equal(t, "add inventory endpoint", e.Subject)
+	assert.Equal(t, []string{"CDS-12"}, e.Issues)
+	assert.False(t, e.Breaking)
+
+	e = Parse(commits[3], issues)
+	assert.True(t, e.Breaking)
+	assert.Equal(t, "remove deprecated routes CDS-13", e.Subject)
+
+	e = Parse(commits[4], issues)
+	assert.Equal(t, "", e.Type)
+	assert.Equal(t, "update README", e.Subject)
+}
+
+func TestGenerateByType(t *testing.T) {
+	rn, err := Generate(commits, sdk.ReleaseNotesByType, "")
+	assert.NoError(t, err)
+
+	var keys []string
+	for _, s := range rn.Sections {
+		keys = append(keys, s.Key)
+	}
+	assert.Equal(t, []string{"breaking", "feat", "fix", "other"}, keys)
+	assert.Len(t, rn.Sections[0].Entries, 2)
+	assert.Equal(t, []string{"#42", "CDS-12", "CDS-13"}, rn.Issues)
+
+	rn.Application = "api"
+	rn.From = "#10"
+	rn.To = "#12"
+	md := Markdown(rn)
+	assert.True(t, strings.HasPrefix(md, "# api #10...#12\n"))
+	assert.Contains(t, md, "## Features\n\n- **api:** add inventory endpoint (a1) - jdoe\n")
+	assert.Contains(t, md, "## Issues\n\n#42, CDS-12, CDS-13\n")
+	assert.NotContains(t, md, "Merge branch")
+}
+
+func TestGenerateByIssue(t *testing.T) {
+	rn, err := Generate(commits, sdk.ReleaseNotesByIssue, `CDS-[0-9]+`)
+	assert.NoError(t, err)
+
+	var keys []string
+	for _, s := range rn.Sections {
+		keys = append(keys, s.Key)
+	}
+	assert.Equal(t, []string{"CDS-12", "CDS-13", "other"}, keys)
+	assert.Len(t, rn.Sections[0].Entries, 2)
+	assert.Len(t, rn.Sections[2].Entries, 2)
+
+	_, err = Generate(commits, "author", "")
+	assert.Error(t, err)
+	_, err = Generate(commits, sdk.ReleaseNotesByIssue, "[")
+	assert.Error(t, err)
+}
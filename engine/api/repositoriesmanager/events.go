@@ -30,10 +30,16 @@ func ReceiveEvents() {
 func processEvent(db gorp.SqlExecutor, event sdk.Event) error {
 	log.Debug("repositoriesmanager>processEvent> receive: type:%s all: %+v", event.EventType, event)
 
-	if event.EventType != fmt.Sprintf("%T", sdk.EventPipelineBuild{}) {
-		return nil
+	switch event.EventType {
+	case fmt.Sprintf("%T", sdk.EventPipelineBuild{}):
+		return processPipelineBuildEvent(db, event)
+	case fmt.Sprintf("%T", sdk.EventPullRequestComment{}):
+		return processPullRequestCommentEvent(db, event)
 	}
+	return nil
+}
 
+func processPipelineBuildEvent(db gorp.SqlExecutor, event sdk.Event) error {
 	var eventpb sdk.EventPipelineBuild
 	if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
 		log.Critical("Error during consumption: %s", err)
@@ -59,3 +65,25 @@ func processEvent(db gorp.SqlExecutor, event sdk.Event) error {
 
 	return nil
 }
+
+func processPullRequestCommentEvent(db gorp.SqlExecutor, event sdk.Event) error {
+	var eventpr sdk.EventPullRequestComment
+	if err := mapstructure.Decode(event.Payload, &eventpr); err != nil {
+		log.Critical("Error during consumption: %s", err)
+		return err
+	}
+
+	if eventpr.RepositoryManagerName == "" || eventpr.PullRequestID == 0 {
+		return nil
+	}
+
+	c, erra := AuthorizedClient(db, eventpr.ProjectKey, eventpr.RepositoryManagerName)
+	if erra != nil {
+		return fmt.Errorf("repositoriesmanager>processEvent> AuthorizedClient (%s, %s) > err:%s", eventpr.ProjectKey, eventpr.RepositoryManagerName, erra)
+	}
+
+	if err := c.PullRequestComment(eventpr.RepositoryFullname, eventpr.PullRequestID, eventpr.Body); err != nil {
+		return fmt.Errorf("repositoriesmanager>processEvent> PullRequestComment on %s #%d > err:%s", eventpr.RepositoryFullname, eventpr.PullRequestID, err)
+	}
+	return nil
+}
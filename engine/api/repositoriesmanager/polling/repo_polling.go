@@ -285,12 +285,16 @@ func triggerPipelines(db *gorp.DbMap, projectKey string, rm *sdk.RepositoriesMan
 			return "Error", err
 		}
 
+		ref := event.Branch.DisplayID
+		if event.Tag != "" {
+			ref = "tag " + event.Tag
+		}
 		if ok {
-			log.Debug("Polling.triggerPipelines> Triggered %s/%s/%s", projectKey, poller.Application.RepositoryFullname, event.Branch)
-			status = fmt.Sprintf("%s Pipeline %s triggered on %s (%s)", status, poller.Pipeline.Name, event.Branch.DisplayID, event.Commit.Hash)
+			log.Debug("Polling.triggerPipelines> Triggered %s/%s/%s", projectKey, poller.Application.RepositoryFullname, ref)
+			status = fmt.Sprintf("%s Pipeline %s triggered on %s (%s)", status, poller.Pipeline.Name, ref, event.Commit.Hash)
 		} else {
-			log.Info("Polling.triggerPipelines> Did not trigger %s/%s/%s\n", projectKey, poller.Application.RepositoryFullname, event.Branch.ID)
-			status = fmt.Sprintf("%s Pipeline %s skipped on %s (%s)", status, poller.Pipeline.Name, event.Branch.DisplayID, event.Commit.Hash)
+			log.Info("Polling.triggerPipelines> Did not trigger %s/%s/%s\n", projectKey, poller.Application.RepositoryFullname, ref)
+			status = fmt.Sprintf("%s Pipeline %s skipped on %s (%s)", status, poller.Pipeline.Name, ref, event.Commit.Hash)
 		}
 	}
 
@@ -325,6 +329,12 @@ func TriggerPipeline(tx gorp.SqlExecutor, rm *sdk.RepositoriesManager, poller *s
 		Name:  "git.project",
 		Value: strings.Split(poller.Application.RepositoryFullname, "/")[0],
 	})
+	if e.Tag != "" {
+		args = append(args, sdk.Parameter{
+			Name:  "git.tag",
+			Value: e.Tag,
+		})
+	}
 	repo, _ := client.RepoByFullname(poller.Application.RepositoryFullname)
 	if repo.SSHCloneURL != "" {
 		args = append(args, sdk.Parameter{
@@ -352,6 +362,17 @@ func TriggerPipeline(tx gorp.SqlExecutor, rm *sdk.RepositoriesManager, poller *s
 		VCSChangesAuthor: e.Commit.Author.DisplayName,
 	}
 
+	// Release pipelines are only triggered by tags
+	tagArgs, reason, err := application.RefTriggerArgs(tx, poller.Application.ID, poller.Pipeline.ID, e.Tag, &trigger)
+	if err != nil {
+		return false, err
+	}
+	if reason != "" {
+		log.Debug("polling> Skipping build of %s/%s for commit %s by %s: %s\n", projectData.Key, poller.Application.Name, trigger.VCSChangesHash, trigger.VCSChangesAuthor, reason)
+		return false, pipeline.InsertSkippedBuild(tx, poller.Application.ID, poller.Pipeline.ID, trigger, pipeline.SkippedByPoller, reason)
+	}
+	args = append(args, tagArgs...)
+
 	// Get commit message to check if we have to skip the build
 	match, err := regexp.Match(".*\\[ci skip\\].*|.*\\[cd skip\\].*", []byte(e.Commit.Message))
 	if err != nil {
@@ -359,7 +380,7 @@ func TriggerPipeline(tx gorp.SqlExecutor, rm *sdk.RepositoriesManager, poller *s
 	}
 	if match {
 		log.Debug("polling> Skipping build of %s/%s for commit %s by %s\n", projectData.Key, poller.Application.Name, trigger.VCSChangesHash, trigger.VCSChangesAuthor)
-		return false, nil
+		return false, pipeline.InsertSkippedBuild(tx, poller.Application.ID, poller.Pipeline.ID, trigger, pipeline.SkippedByPoller, "commit message asks to skip the build")
 	}
 
 	if b, err := pipeline.BuildExists(tx, poller.Application.ID, poller.Pipeline.ID, sdk.DefaultEnv.ID, &trigger); err != nil || b {
@@ -369,6 +390,12 @@ func TriggerPipeline(tx gorp.SqlExecutor, rm *sdk.RepositoriesManager, poller *s
 		return false, nil
 	}
 
+	// Only build the application if the files it cares about have changed
+	if reason := application.PathFilterSkipReason(tx, client, &poller.Application, poller.Pipeline.ID, poller.Paths, e.Branch.ID, e.Commit.Hash); reason != "" {
+		log.Debug("polling> Skipping build of %s/%s for commit %s by %s: %s\n", projectData.Key, poller.Application.Name, trigger.VCSChangesHash, trigger.VCSChangesAuthor, reason)
+		return false, pipeline.InsertSkippedBuild(tx, poller.Application.ID, poller.Pipeline.ID, trigger, pipeline.SkippedByPoller, reason)
+	}
+
 	_, err = pipeline.InsertPipelineBuild(tx, projectData, &poller.Pipeline, &poller.Application, applicationPipelineArgs, args, &sdk.DefaultEnv, 0, trigger)
 	if err != nil {
 		return false, err
@@ -0,0 +1,338 @@
+package repogit
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+//commitFormat is the git log format parsed by parseCommits
+const commitFormat = "--format=%H%x00%an%x00%ae%x00%at%x00%ct%x00%B%x1e"
+
+// GitClient is a plain git wrapper for CDS RepositoriesManagerClient interface
+type GitClient struct {
+	URL              string
+	PrivateKey       string
+	KnownHosts       string
+	MirrorsDirectory string
+}
+
+// Repos cannot be listed without provider API, repositories are attached by fullname
+func (g *GitClient) Repos() ([]sdk.VCSRepo, error) {
+	return []sdk.VCSRepo{}, nil
+}
+
+// RepoByFullname checks the repository is reachable with the deploy key
+func (g *GitClient) RepoByFullname(fullname string) (sdk.VCSRepo, error) {
+	if _, _, err := g.lsRemote(fullname); err != nil {
+		log.Warning("GitClient.RepoByFullname> Cannot reach %s: %s\n", fullname, err)
+		return sdk.VCSRepo{}, sdk.ErrRepoNotFound
+	}
+
+	u := cloneURL(g.URL, fullname)
+	repo := sdk.VCSRepo{
+		ID:       fullname,
+		Name:     strings.TrimSuffix(path.Base(fullname), ".git"),
+		Slug:     strings.TrimSuffix(path.Base(fullname), ".git"),
+		Fullname: fullname,
+		URL:      u,
+	}
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		repo.HTTPCloneURL = u
+	} else {
+		repo.SSHCloneURL = u
+	}
+	return repo, nil
+}
+
+// Branches returns list of branches for a repo, with git ls-remote
+func (g *GitClient) Branches(fullname string) ([]sdk.VCSBranch, error) {
+	heads, head, err := g.lsRemote(fullname)
+	if err != nil {
+		log.Warning("GitClient.Branches> Error %s\n", err)
+		return nil, err
+	}
+
+	branches := make([]sdk.VCSBranch, 0, len(heads))
+	for ref, hash := range heads {
+		name := strings.TrimPrefix(ref, "refs/heads/")
+		branches = append(branches, sdk.VCSBranch{
+			ID:           name,
+			DisplayID:    name,
+			LatestCommit: hash,
+			Default:      ref == head,
+		})
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].ID < branches[j].ID })
+	return branches, nil
+}
+
+// Branch returns only detail of a branch
+func (g *GitClient) Branch(fullname, branch string) (sdk.VCSBranch, error) {
+	branches, err := g.Branches(fullname)
+	if err != nil {
+		return sdk.VCSBranch{}, err
+	}
+	for _, b := range branches {
+		if b.DisplayID == branch {
+			return b, nil
+		}
+	}
+	return sdk.VCSBranch{}, sdk.ErrNoBranch
+}
+
+// Commits returns the commits of a branch after the commit SHA since, up to the commit SHA until (or the head of the branch)
+func (g *GitClient) Commits(repo, branch, since, until string) ([]sdk.VCSCommit, error) {
+	rev := "refs/heads/" + branch
+	if until != "" {
+		if err := checkHash(until); err != nil {
+			return nil, err
+		}
+		rev = until
+	} else if err := checkRefName("heads", branch); err != nil {
+		return nil, err
+	}
+	if since != "" {
+		if err := checkHash(since); err != nil {
+			return nil, err
+		}
+		rev = since + ".." + rev
+	}
+
+	dir, err := g.fetch(repo)
+	if err != nil {
+		log.Warning("GitClient.Commits> Error %s\n", err)
+		return nil, err
+	}
+
+	out, err := g.git(dir, "log", commitFormat, "--end-of-options", rev, "--")
+	if err != nil {
+		log.Warning("GitClient.Commits> Error %s\n", err)
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+// Commit retrieves a specific commit
+func (g *GitClient) Commit(repo, hash string) (sdk.VCSCommit, error) {
+	if err := checkHash(hash); err != nil {
+		return sdk.VCSCommit{}, err
+	}
+
+	dir, err := g.fetch(repo)
+	if err != nil {
+		log.Warning("GitClient.Commit> Error %s\n", err)
+		return sdk.VCSCommit{}, err
+	}
+
+	out, err := g.git(dir, "log", "-1", commitFormat, "--end-of-options", hash, "--")
+	if err != nil {
+		log.Warning("GitClient.Commit> Error %s\n", err)
+		return sdk.VCSCommit{}, err
+	}
+	commits := parseCommits(out)
+	if len(commits) == 0 {
+		return sdk.VCSCommit{}, fmt.Errorf("commit %s not found in %s", hash, repo)
+	}
+	return commits[0], nil
+}
+
+// parseCommits parses the output of git log with commitFormat
+func parseCommits(out string) []sdk.VCSCommit {
+	commits := []sdk.VCSCommit{}
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		authorTime, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, sdk.VCSCommit{
+			Hash:      fields[0],
+			Message:   strings.TrimSpace(fields[5]),
+			Timestamp: authorTime * 1000,
+			Author: sdk.VCSAuthor{
+				Name:        fields[1],
+				DisplayName: fields[1],
+				Email:       fields[2],
+			},
+		})
+	}
+	return commits
+}
+
+// ChangedFiles returns the files changed between two commits, or by the until commit if since is empty
+func (g *GitClient) ChangedFiles(repo, since, until string) ([]string, error) {
+	if err := checkHash(until); err != nil {
+		return nil, err
+	}
+	if since != "" {
+		if err := checkHash(since); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := g.fetch(repo)
+	if err != nil {
+		log.Warning("GitClient.ChangedFiles> Error %s\n", err)
+		return nil, err
+	}
+
+	var out string
+	if since == "" {
+		out, err = g.git(dir, "diff-tree", "--root", "--no-commit-id", "--name-only", "-r", "--end-of-options", until)
+	} else {
+		out, err = g.git(dir, "diff", "--name-only", "--end-of-options", since, until, "--")
+	}
+	if err != nil {
+		log.Warning("GitClient.ChangedFiles> Error %s\n", err)
+		return nil, err
+	}
+
+	files := []string{}
+	for _, f := range strings.Split(strings.TrimSpace(out), "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// CreateHook is not supported without provider API
+func (g *GitClient) CreateHook(repo, url string) error {
+	return fmt.Errorf("Not supported on git")
+}
+
+// DeleteHook is not supported without provider API
+func (g *GitClient) DeleteHook(repo, url string) error {
+	return fmt.Errorf("Not supported on git")
+}
+
+// PushEvents fetches the mirror and returns the head of each branch committed after the reference date,
+// and the tags created after it.
+// Pollers skip the heads already built.
+func (g *GitClient) PushEvents(fullname string, dateRef time.Time) ([]sdk.VCSPushEvent, time.Duration, error) {
+	interval := time.Duration(60.0)
+
+	dir, err := g.fetch(fullname)
+	if err != nil {
+		log.Warning("GitClient.PushEvents> Error %s\n", err)
+		return nil, 0.0, err
+	}
+
+	out, err := g.git(dir, "for-each-ref", "--format=%(refname)%00%(objectname)%00%(committerdate:raw)", "refs/heads/")
+	if err != nil {
+		log.Warning("GitClient.PushEvents> Error %s\n", err)
+		return nil, 0.0, err
+	}
+
+	head, _ := g.git(dir, "symbolic-ref", "HEAD")
+	head = strings.TrimSpace(head)
+
+	res := []sdk.VCSPushEvent{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		//committerdate:raw is "<unix timestamp> <timezone>"
+		ts, err := strconv.ParseInt(strings.Fields(fields[2])[0], 10, 64)
+		if err != nil || !time.Unix(ts, 0).After(dateRef) {
+			continue
+		}
+
+		commit, err := g.Commit(fullname, fields[1])
+		if err != nil {
+			return nil, 0.0, err
+		}
+		name := strings.TrimPrefix(fields[0], "refs/heads/")
+		res = append(res, sdk.VCSPushEvent{
+			Branch: sdk.VCSBranch{
+				ID:           name,
+				DisplayID:    name,
+				LatestCommit: fields[1],
+				Default:      fields[0] == head,
+			},
+			Commit: commit,
+		})
+	}
+
+	//Annotated tags are peeled to their commit, creatordate is the date of the tag or of the commit of a lightweight tag
+	out, err = g.git(dir, "for-each-ref", "--format=%(refname)%00%(objectname)%00%(*objectname)%00%(creatordate:raw)", "refs/tags/")
+	if err != nil {
+		log.Warning("GitClient.PushEvents> Error %s\n", err)
+		return nil, 0.0, err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		ts, err := strconv.ParseInt(strings.Fields(fields[3])[0], 10, 64)
+		if err != nil || !time.Unix(ts, 0).After(dateRef) {
+			continue
+		}
+
+		hash := fields[1]
+		if fields[2] != "" {
+			hash = fields[2]
+		}
+		commit, err := g.Commit(fullname, hash)
+		if err != nil {
+			return nil, 0.0, err
+		}
+		res = append(res, sdk.VCSPushEvent{
+			Tag:    strings.TrimPrefix(fields[0], "refs/tags/"),
+			Commit: commit,
+		})
+	}
+
+	return res, interval, nil
+}
+
+// SetStatus does nothing, a plain git server has no commit status
+func (g *GitClient) SetStatus(event sdk.Event) error {
+	return nil
+}
+
+// PullRequestComment is not supported, a plain git server has no pull request
+func (g *GitClient) PullRequestComment(repo string, id int64, body string) error {
+	return fmt.Errorf("Not supported on git")
+}
+
+// CreateRelease pushes an annotated tag on the given commit, the deploy key must have write access
+func (g *GitClient) CreateRelease(repo, tag, hash, title, body string) error {
+	if err := checkRefName("tags", tag); err != nil {
+		return err
+	}
+	if err := checkHash(hash); err != nil {
+		return err
+	}
+
+	dir, err := g.fetch(repo)
+	if err != nil {
+		return err
+	}
+
+	l := mirrorLock(dir)
+	l.Lock()
+	defer l.Unlock()
+
+	message := title
+	if body != "" {
+		message += "\n\n" + body
+	}
+	if _, err := g.git(dir, "-c", "user.name=CDS", "-c", "user.email=cds@localhost", "tag", "-a", "-m", message, "--end-of-options", tag, hash); err != nil {
+		return err
+	}
+	if _, err := g.git(dir, "push", "--quiet", "--end-of-options", cloneURL(g.URL, repo), "refs/tags/"+tag); err != nil {
+		g.git(dir, "tag", "-d", "--end-of-options", tag)
+		return err
+	}
+	return nil
+}
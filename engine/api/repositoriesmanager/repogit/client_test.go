@@ -0,0 +1,213 @@
+package repogit
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// upstream is a local git repository standing for a remote git server
+type upstream struct {
+	t    *testing.T
+	root string
+	dir  string
+}
+
+func newUpstream(t *testing.T) *upstream {
+	root, err := ioutil.TempDir("", "repogit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &upstream{t: t, root: root, dir: filepath.Join(root, "group", "repo")}
+	if err := os.MkdirAll(u.dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	u.git("init", "--quiet")
+	u.git("symbolic-ref", "HEAD", "refs/heads/master")
+	return u
+}
+
+func (u *upstream) git(args ...string) string {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=John", "-c", "user.email=john@localhost"}, args...)...)
+	cmd.Dir = u.dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		u.t.Fatalf("git %s: %s %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (u *upstream) commit(file, message string) string {
+	if err := ioutil.WriteFile(filepath.Join(u.dir, file), []byte(message), 0600); err != nil {
+		u.t.Fatal(err)
+	}
+	u.git("add", file)
+	u.git("commit", "--quiet", "-m", message)
+	return u.git("rev-parse", "HEAD")
+}
+
+func (u *upstream) client() *GitClient {
+	return &GitClient{URL: u.root, MirrorsDirectory: filepath.Join(u.root, "mirrors")}
+}
+
+func TestBranches(t *testing.T) {
+	u := newUpstream(t)
+	defer os.RemoveAll(u.root)
+
+	c1 := u.commit("a.go", "first")
+	u.git("checkout", "--quiet", "-b", "feat/x")
+	c2 := u.commit("b.go", "second")
+	u.git("checkout", "--quiet", "master")
+
+	client := u.client()
+	repo, err := client.RepoByFullname("group/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", repo.Name)
+	assert.Equal(t, filepath.Join(u.root, "group/repo"), repo.SSHCloneURL)
+
+	_, err = client.RepoByFullname("group/unknown")
+	assert.Equal(t, sdk.ErrRepoNotFound, err)
+
+	branches, err := client.Branches("group/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.VCSBranch{
+		{ID: "feat/x", DisplayID: "feat/x", LatestCommit: c2},
+		{ID: "master", DisplayID: "master", LatestCommit: c1, Default: true},
+	}, branches)
+
+	_, err = client.Branch("group/repo", "unknown")
+	assert.Equal(t, sdk.ErrNoBranch, err)
+}
+
+func TestCommits(t *testing.T) {
+	u := newUpstream(t)
+	defer os.RemoveAll(u.root)
+
+	c1 := u.commit("a.go", "first")
+	c2 := u.commit("b.go", "second\n\nwith a body")
+	client := u.client()
+
+	commits, err := client.Commits("group/repo", "master", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+
+	//New commits are fetched in the existing mirror
+	c3 := u.commit("a.go", "third")
+	commits, err = client.Commits("group/repo", "master", c1, "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, c3, commits[0].Hash)
+	assert.Equal(t, c2, commits[1].Hash)
+	assert.Equal(t, "second\n\nwith a body", commits[1].Message)
+	assert.Equal(t, "John", commits[1].Author.Name)
+	assert.Equal(t, "john@localhost", commits[1].Author.Email)
+
+	commit, err := client.Commit("group/repo", c1)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", commit.Message)
+
+	files, err := client.ChangedFiles("group/repo", c1, c3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "b.go"}, files)
+
+	files, err = client.ChangedFiles("group/repo", "", c1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.go"}, files)
+}
+
+func TestPushEvents(t *testing.T) {
+	u := newUpstream(t)
+	defer os.RemoveAll(u.root)
+
+	u.commit("a.go", "first")
+	client := u.client()
+
+	events, _, err := client.PushEvents("group/repo", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+
+	u.git("checkout", "--quiet", "-b", "feat/x")
+	c2 := u.commit("b.go", "second")
+	events, interval, err := client.PushEvents("group/repo", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(60), interval)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "feat/x", events[0].Branch.ID)
+	assert.Equal(t, c2, events[0].Commit.Hash)
+	assert.Equal(t, "second", events[0].Commit.Message)
+	assert.Equal(t, "master", events[1].Branch.ID)
+	assert.True(t, events[1].Branch.Default)
+
+	u.git("tag", "-a", "v1.0.0", "-m", "release", c2)
+	u.git("tag", "nightly", c2)
+	events, _, err = client.PushEvents("group/repo", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+	assert.Equal(t, "nightly", events[2].Tag)
+	assert.Equal(t, "v1.0.0", events[3].Tag)
+	assert.Equal(t, c2, events[3].Commit.Hash)
+}
+
+func TestCreateRelease(t *testing.T) {
+	u := newUpstream(t)
+	defer os.RemoveAll(u.root)
+
+	c1 := u.commit("a.go", "first")
+	assert.NoError(t, u.client().CreateRelease("group/repo", "v1.0.0", c1, "Release 1.0.0", "notes"))
+	assert.Equal(t, c1, u.git("rev-list", "-n", "1", "v1.0.0"))
+	assert.Equal(t, "Release 1.0.0\n\nnotes", u.git("tag", "-l", "--format=%(contents)", "v1.0.0"))
+}
+
+func TestInvalidRevisions(t *testing.T) {
+	u := newUpstream(t)
+	defer os.RemoveAll(u.root)
+
+	c1 := u.commit("a.go", "first")
+	client := u.client()
+	output := filepath.Join(u.root, "output")
+
+	//Revisions and names coming from hooks are never taken as git options
+	_, err := client.ChangedFiles("group/repo", "", "--output="+output)
+	assert.Error(t, err)
+	_, err = client.ChangedFiles("group/repo", "--output="+output, c1)
+	assert.Error(t, err)
+	_, err = client.Commit("group/repo", "--output="+output)
+	assert.Error(t, err)
+	_, err = client.Commits("group/repo", "master", "HEAD~1", "")
+	assert.Error(t, err)
+	_, err = client.Commits("group/repo", "master..feat", "", "")
+	assert.Error(t, err)
+	assert.Error(t, client.CreateRelease("group/repo", "--delete", c1, "Release", ""))
+	assert.Error(t, client.CreateRelease("group/repo", "v1..2", c1, "Release", ""))
+	assert.Error(t, client.CreateRelease("group/repo", "v1.0.0", "-d", "Release", ""))
+
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAuthorize(t *testing.T) {
+	consumer := New("ssh://git@localhost:2222/", "/tmp/key", "/tmp/known_hosts", "/tmp/mirrors", "http://cds/repositories_manager/oauth2/callback")
+	token, url, err := consumer.AuthorizeRedirect()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://cds/repositories_manager/oauth2/callback?code="+token+"&state="+token, url)
+
+	accessToken, secret, err := consumer.AuthorizeToken(token, token)
+	assert.NoError(t, err)
+	assert.Equal(t, token, accessToken)
+	assert.Equal(t, token, secret)
+
+	client, err := consumer.GetAuthorized(accessToken, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, &GitClient{URL: "ssh://git@localhost:2222/", PrivateKey: "/tmp/key", KnownHosts: "/tmp/known_hosts", MirrorsDirectory: "/tmp/mirrors"}, client)
+	assert.Contains(t, client.(*GitClient).env(), "GIT_SSH_COMMAND=ssh -i /tmp/key -o IdentitiesOnly=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile=/tmp/known_hosts")
+	assert.Equal(t, "ssh://git@localhost:2222/group/repo", cloneURL("ssh://git@localhost:2222/", "group/repo"))
+	assert.Equal(t, "git@localhost:group/repo", cloneURL("git@localhost:", "group/repo"))
+}
@@ -0,0 +1,92 @@
+package repogit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+//GitConsumer is a repositories manager working on any git server, from clone urls and a deploy key
+type GitConsumer struct {
+	URL                      string `json:"url"`
+	PrivateKey               string `json:"private_key"`
+	KnownHosts               string `json:"known_hosts"`
+	MirrorsDirectory         string `json:"mirrors_directory"`
+	AuthorizationCallbackURL string `json:"-"`
+}
+
+//New creates a new GitConsumer. URL is the base of the clone urls: ssh://git@host:port/, git@host: or https://host/.
+//The host key of the git server is checked against the knownHosts file
+func New(URL, privateKey, knownHosts, mirrorsDirectory, AuthorizationCallbackURL string) *GitConsumer {
+	return &GitConsumer{
+		URL:                      URL,
+		PrivateKey:               privateKey,
+		KnownHosts:               knownHosts,
+		MirrorsDirectory:         mirrorsDirectory,
+		AuthorizationCallbackURL: AuthorizationCallbackURL,
+	}
+}
+
+//Data returns a serilized version of specific data
+func (g *GitConsumer) Data() string {
+	b, _ := json.Marshal(g)
+	return string(b)
+}
+
+//AuthorizeRedirect returns the request token and directly the callback url:
+//there is no user authorization on a plain git server, the deploy key is used for every project
+func (g *GitConsumer) AuthorizeRedirect() (string, string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		log.Critical("GitConsumer.AuthorizeRedirect> rand.Read failed: %s\n", err)
+		return "", "", err
+	}
+	requestToken := hex.EncodeToString(bs)
+
+	val := url.Values{}
+	val.Add("state", requestToken)
+	val.Add("code", requestToken)
+	return requestToken, fmt.Sprintf("%s?%s", g.AuthorizationCallbackURL, val.Encode()), nil
+}
+
+//AuthorizeToken returns the token stored for the project, there is no secret to exchange
+func (g *GitConsumer) AuthorizeToken(token, verifier string) (string, string, error) {
+	if token == "" {
+		return "", "", fmt.Errorf("GitConsumer.AuthorizeToken> missing token")
+	}
+	return token, token, nil
+}
+
+//GetAuthorized returns a client running git with the deploy key
+func (g *GitConsumer) GetAuthorized(accessToken, accessTokenSecret string) (sdk.RepositoriesManagerClient, error) {
+	return &GitClient{
+		URL:              g.URL,
+		PrivateKey:       g.PrivateKey,
+		KnownHosts:       g.KnownHosts,
+		MirrorsDirectory: g.MirrorsDirectory,
+	}, nil
+}
+
+//HooksSupported returns false: there is no API to create hooks on a plain git server
+func (g *GitConsumer) HooksSupported() bool {
+	return false
+}
+
+//PollingSupported returns true, polling is done by fetching a local mirror
+func (g *GitConsumer) PollingSupported() bool {
+	return true
+}
+
+//cloneURL returns the clone url of the given repository fullname
+func cloneURL(base, fullname string) string {
+	if strings.HasSuffix(base, "/") || strings.HasSuffix(base, ":") {
+		return base + fullname
+	}
+	return base + "/" + fullname
+}
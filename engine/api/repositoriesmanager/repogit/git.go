@@ -0,0 +1,135 @@
+package repogit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ovh/cds/engine/log"
+)
+
+var (
+	mirrorsMutex = sync.Mutex{}
+	mirrorsLocks = map[string]*sync.Mutex{}
+	hashPattern  = regexp.MustCompile("^[0-9a-fA-F]{4,40}$")
+)
+
+//checkHash rejects anything but a commit hash, revisions come from hooks and must never be taken as git options
+func checkHash(hash string) error {
+	if !hashPattern.MatchString(hash) {
+		return fmt.Errorf("invalid commit hash %q", hash)
+	}
+	return nil
+}
+
+//checkRefName rejects invalid branch or tag names, such as options or revision ranges
+func checkRefName(kind, name string) error {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	if err := exec.Command("git", "check-ref-format", "refs/"+kind+"/"+name).Run(); err != nil {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+//mirrorLock returns the lock of a mirror directory, mirrors are shared by all the clients
+func mirrorLock(dir string) *sync.Mutex {
+	mirrorsMutex.Lock()
+	defer mirrorsMutex.Unlock()
+	l, ok := mirrorsLocks[dir]
+	if !ok {
+		l = &sync.Mutex{}
+		mirrorsLocks[dir] = l
+	}
+	return l
+}
+
+//env returns the git environment. The deploy key gives access to every repository:
+//the git server host key is always checked, against the known hosts file if set
+func (g *GitClient) env() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if g.PrivateKey != "" {
+		ssh := fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=yes", g.PrivateKey)
+		if g.KnownHosts != "" {
+			ssh += fmt.Sprintf(" -o UserKnownHostsFile=%s", g.KnownHosts)
+		}
+		env = append(env, "GIT_SSH_COMMAND="+ssh)
+	}
+	return env
+}
+
+//git runs git in dir and returns its standard output
+func (g *GitClient) git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = g.env()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s (%s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+//mirrorDir returns the directory of the bare mirror of a repository
+func (g *GitClient) mirrorDir(fullname string) string {
+	name := strings.Replace(strings.Trim(fullname, "/"), "/", "_", -1)
+	return filepath.Join(g.MirrorsDirectory, name+".git")
+}
+
+//fetch creates or updates the bare mirror of a repository and returns its directory
+func (g *GitClient) fetch(fullname string) (string, error) {
+	dir := g.mirrorDir(fullname)
+	l := mirrorLock(dir)
+	l.Lock()
+	defer l.Unlock()
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(g.MirrorsDirectory, 0700); err != nil {
+			return "", err
+		}
+		log.Info("GitClient.fetch> Creating mirror of %s in %s\n", fullname, dir)
+		if _, err := g.git(g.MirrorsDirectory, "clone", "--mirror", "--quiet", cloneURL(g.URL, fullname), dir); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		return dir, nil
+	}
+
+	if _, err := g.git(dir, "fetch", "--prune", "--quiet", "origin"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+//lsRemote returns the heads of a repository by ref name, and the ref HEAD points to
+func (g *GitClient) lsRemote(fullname string) (map[string]string, string, error) {
+	out, err := g.git("", "ls-remote", "--symref", cloneURL(g.URL, fullname), "HEAD", "refs/heads/*")
+	if err != nil {
+		return nil, "", err
+	}
+
+	heads := map[string]string{}
+	var head string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "ref: ") {
+			head = strings.TrimPrefix(fields[0], "ref: ")
+			continue
+		}
+		if strings.HasPrefix(fields[1], "refs/heads/") {
+			heads[fields[1]] = fields[0]
+		}
+	}
+	return heads, head, nil
+}
@@ -9,6 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+
 	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/log"
 	"github.com/ovh/cds/sdk"
@@ -425,10 +428,10 @@ func (g *GithubClient) PushEvents(fullname string, dateRef time.Time) ([]sdk.VCS
 				cache.SetWithTTL(cache.Key("reposmanager", "github", "events", g.OAuthToken, nextPage), nextEvents, 61*60)
 			}
 
-			//Check here only events after the reference date and only of type PushEvent
+			//Check here only events after the reference date and only of type PushEvent, or CreateEvent of a tag
 			nextEventsAfterDateRef := []Event{}
 			for _, e := range nextEvents {
-				if e.CreatedAt.After(dateRef) && e.Type == "PushEvent" {
+				if e.CreatedAt.After(dateRef) && (e.Type == "PushEvent" || (e.Type == "CreateEvent" && e.Payload.RefType == "tag")) {
 					nextEventsAfterDateRef = append(nextEventsAfterDateRef, e)
 				}
 			}
@@ -450,8 +453,21 @@ func (g *GithubClient) PushEvents(fullname string, dateRef time.Time) ([]sdk.VCS
 	}
 
 	lastCommitPerBranch := map[string]sdk.VCSCommit{}
+	res := []sdk.VCSPushEvent{}
 	for _, e := range events {
-		if e.Type == "PushEvent" {
+		//Tags are created at once, their event does not list any commit
+		if e.Type == "CreateEvent" {
+			commit, err := g.Commit(fullname, e.Payload.Ref)
+			if err != nil {
+				return nil, 0.0, fmt.Errorf("Unable to find tag %s in %s : %s", e.Payload.Ref, fullname, err)
+			}
+			res = append(res, sdk.VCSPushEvent{
+				Tag:    e.Payload.Ref,
+				Commit: commit,
+			})
+			continue
+		}
+		if e.Type == "PushEvent" && strings.HasPrefix(e.Payload.Ref, "refs/heads/") {
 			branch := strings.Replace(e.Payload.Ref, "refs/heads/", "", 1)
 			for _, c := range e.Payload.Commits {
 				commit := sdk.VCSCommit{
@@ -475,7 +491,6 @@ func (g *GithubClient) PushEvents(fullname string, dateRef time.Time) ([]sdk.VCS
 		}
 	}
 
-	res := []sdk.VCSPushEvent{}
 	for b, c := range lastCommitPerBranch {
 		branch, err := g.Branch(fullname, b)
 		if err != nil {
@@ -490,7 +505,126 @@ func (g *GithubClient) PushEvents(fullname string, dateRef time.Time) ([]sdk.VCS
 	return res, interval, nil
 }
 
-//SetStatus set build status on github
+// SetStatus sends the status of a pipeline build on its commit
+// https://developer.github.com/v3/repos/statuses/#create-a-status
 func (g *GithubClient) SetStatus(event sdk.Event) error {
-	return fmt.Errorf("Not yet implemented on github")
+	var eventpb sdk.EventPipelineBuild
+	if event.EventType != fmt.Sprintf("%T", sdk.EventPipelineBuild{}) {
+		return nil
+	}
+
+	if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
+		log.Warning("GithubClient.SetStatus> Error during consumption: %s", err)
+		return err
+	}
+
+	key := fmt.Sprintf("%s-%s-%s", eventpb.ProjectKey, eventpb.ApplicationName, eventpb.PipelineName)
+	if eventpb.EnvironmentName != "" && eventpb.EnvironmentName != sdk.DefaultEnv.Name {
+		key += "-" + eventpb.EnvironmentName
+	}
+
+	status := CommitStatus{
+		State:       getGithubStateFromStatus(eventpb.Status),
+		Context:     key,
+		Description: fmt.Sprintf("CDS build #%d %s", eventpb.BuildNumber, strings.ToLower(eventpb.Status.String())),
+		TargetURL: fmt.Sprintf("%s/#/project/%s/application/%s/pipeline/%s/build/%d?env=%s",
+			viper.GetString("base_url"),
+			eventpb.ProjectKey,
+			eventpb.ApplicationName,
+			eventpb.PipelineName,
+			eventpb.BuildNumber,
+			url.QueryEscape(eventpb.EnvironmentName),
+		),
+	}
+
+	log.Debug("GithubClient.SetStatus> hash:%s status:%+v", eventpb.Hash, status)
+	if _, _, err := g.post("/repos/"+eventpb.RepositoryFullname+"/statuses/"+eventpb.Hash, status); err != nil {
+		return fmt.Errorf("SetStatus> err on github: %s", err)
+	}
+	return nil
+}
+
+func getGithubStateFromStatus(status sdk.Status) string {
+	switch status {
+	case sdk.StatusSuccess, sdk.StatusDisabled, sdk.StatusSkipped:
+		return "success"
+	case sdk.StatusWaiting, sdk.StatusBuilding, sdk.StatusChecking, sdk.StatusWaitingApproval:
+		return "pending"
+	case sdk.StatusFail:
+		return "failure"
+	default:
+		return "error"
+	}
+}
+
+// PullRequestComment posts a comment on a pull request
+// https://developer.github.com/v3/issues/comments/#create-a-comment
+func (g *GithubClient) PullRequestComment(repo string, id int64, body string) error {
+	comment := struct {
+		Body string `json:"body"`
+	}{
+		Body: body,
+	}
+
+	if _, _, err := g.post(fmt.Sprintf("/repos/%s/issues/%d/comments", repo, id), comment); err != nil {
+		log.Warning("GithubClient.PullRequestComment> Error %s", err)
+		return err
+	}
+	return nil
+}
+
+// ChangedFiles returns the files changed between two commits, or by the until commit if since is empty
+// https://developer.github.com/v3/repos/commits/#compare-two-commits
+func (g *GithubClient) ChangedFiles(repo, since, until string) ([]string, error) {
+	url := "/repos/" + repo + "/commits/" + until
+	if since != "" {
+		url = "/repos/" + repo + "/compare/" + since + "..." + until
+	}
+	status, body, _, err := g.get(url)
+	if err != nil {
+		log.Warning("GithubClient.ChangedFiles> Error %s", err)
+		return nil, err
+	}
+	if status >= 400 {
+		return nil, sdk.NewError(sdk.ErrRepoNotFound, ErrorAPI(body))
+	}
+	c := Comparison{}
+
+	//Github may return 304 status because we are using conditionnal request with ETag based headers
+	if status == http.StatusNotModified {
+		cache.Get(cache.Key("reposmanager", "github", "files", g.OAuthToken, url), &c)
+	} else {
+		if err := json.Unmarshal(body, &c); err != nil {
+			log.Warning("GithubClient.ChangedFiles> Unable to parse github comparison: %s", err)
+			return nil, err
+		}
+		cache.SetWithTTL(cache.Key("reposmanager", "github", "files", g.OAuthToken, url), c, 61*60)
+	}
+
+	files := make([]string, len(c.Files))
+	for i := range c.Files {
+		files[i] = c.Files[i].Filename
+	}
+	return files, nil
+}
+
+// CreateRelease creates a release, and its tag on the given commit if it does not exist
+// https://developer.github.com/v3/repos/releases/#create-a-release
+func (g *GithubClient) CreateRelease(repo, tag, hash, title, body string) error {
+	release := struct {
+		TagName         string `json:"tag_name"`
+		TargetCommitish string `json:"target_commitish,omitempty"`
+		Name            string `json:"name"`
+		Body            string `json:"body"`
+	}{
+		TagName:         tag,
+		TargetCommitish: hash,
+		Name:            title,
+		Body:            body,
+	}
+
+	if _, _, err := g.post("/repos/"+repo+"/releases", release); err != nil {
+		return err
+	}
+	return nil
 }
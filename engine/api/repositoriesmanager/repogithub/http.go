@@ -1,6 +1,7 @@
 package repogithub
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -182,3 +183,46 @@ func (c *GithubClient) get(path string, opts ...getArgFunc) (int, []byte, http.H
 	return res.StatusCode, resBody, res.Header, nil
 
 }
+
+func (c *GithubClient) post(path string, in interface{}) (int, []byte, error) {
+	if !strings.HasPrefix(path, APIURL) {
+		path = APIURL + path
+	}
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	req.Header.Set("User-Agent", "CDS-gh_client_id="+c.ClientID)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("token %s", c.OAuthToken))
+
+	log.Debug("Github API>> Request URL %s", req.URL.String())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return res.StatusCode, nil, ErrorUnauthorized
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, err
+	}
+
+	if res.StatusCode >= 400 {
+		return res.StatusCode, resBody, ErrorAPI(resBody)
+	}
+	return res.StatusCode, resBody, nil
+}
@@ -334,6 +334,7 @@ type Event struct {
 		Size         int    `json:"size"`
 		DistinctSize int    `json:"distinct_size"`
 		Ref          string `json:"ref"`
+		RefType      string `json:"ref_type"`
 		Head         string `json:"head"`
 		Before       string `json:"before"`
 		Commits      []struct {
@@ -382,3 +383,22 @@ type RateLimit struct {
 func (r *RateLimit) String() string {
 	return fmt.Sprintf("Limit: %d - Remaining: %d - Reset: %d", r.Rate.Limit, r.Rate.Remaining, r.Rate.Reset)
 }
+
+// CommitFile represents a file changed by a commit or between two commits
+type CommitFile struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"`
+}
+
+// Comparison represents the files changed by a commit or between two commits
+type Comparison struct {
+	Files []CommitFile `json:"files"`
+}
+
+// CommitStatus represents the status of a commit for a context
+type CommitStatus struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+}
@@ -0,0 +1,470 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// GitlabClient is a gitlab wrapper for CDS RepositoriesManagerClient interface
+type GitlabClient struct {
+	URL        string
+	OAuthToken string
+}
+
+// Repos list projects the authenticated user is a member of
+// https://docs.gitlab.com/ce/api/projects.html#list-all-projects
+func (g *GitlabClient) Repos() ([]sdk.VCSRepo, error) {
+	var projects []Project
+	if err := g.getAll("/projects?membership=true&simple=true", func(body []byte) error {
+		page := []Project{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		projects = append(projects, page...)
+		return nil
+	}); err != nil {
+		log.Warning("GitlabClient.Repos> Error %s", err)
+		return nil, err
+	}
+
+	repos := make([]sdk.VCSRepo, 0, len(projects))
+	for _, p := range projects {
+		repos = append(repos, vcsRepo(p))
+	}
+	return repos, nil
+}
+
+// RepoByFullname Get only one repo
+// https://docs.gitlab.com/ce/api/projects.html#get-single-project
+func (g *GitlabClient) RepoByFullname(fullname string) (sdk.VCSRepo, error) {
+	p, err := g.project(fullname)
+	if err != nil {
+		return sdk.VCSRepo{}, err
+	}
+	return vcsRepo(p), nil
+}
+
+func (g *GitlabClient) project(fullname string) (Project, error) {
+	p := Project{}
+	status, body, _, err := g.do(http.MethodGet, projectPath(fullname), nil)
+	if err != nil {
+		log.Warning("GitlabClient.project> Error %s", err)
+		if status == http.StatusNotFound {
+			return p, sdk.ErrRepoNotFound
+		}
+		return p, err
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		log.Warning("GitlabClient.project> Unable to parse gitlab project: %s", err)
+		return p, err
+	}
+	return p, nil
+}
+
+func vcsRepo(p Project) sdk.VCSRepo {
+	return sdk.VCSRepo{
+		ID:           strconv.Itoa(p.ID),
+		Name:         p.Name,
+		Slug:         p.Path,
+		Fullname:     p.PathWithNamespace,
+		URL:          p.WebURL,
+		HTTPCloneURL: p.HTTPURLToRepo,
+		SSHCloneURL:  p.SSHURLToRepo,
+	}
+}
+
+// Branches returns list of branches for a repo
+// https://docs.gitlab.com/ce/api/branches.html#list-repository-branches
+func (g *GitlabClient) Branches(fullname string) ([]sdk.VCSBranch, error) {
+	p, err := g.project(fullname)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	if err := g.getAll(projectPath(fullname)+"/repository/branches", func(body []byte) error {
+		page := []Branch{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		branches = append(branches, page...)
+		return nil
+	}); err != nil {
+		log.Warning("GitlabClient.Branches> Error %s", err)
+		return nil, err
+	}
+
+	res := make([]sdk.VCSBranch, 0, len(branches))
+	for _, b := range branches {
+		res = append(res, sdk.VCSBranch{
+			ID:           b.Name,
+			DisplayID:    b.Name,
+			LatestCommit: b.Commit.ID,
+			Default:      b.Name == p.DefaultBranch,
+		})
+	}
+	return res, nil
+}
+
+// Branch returns only detail of a branch
+// https://docs.gitlab.com/ce/api/branches.html#get-single-repository-branch
+func (g *GitlabClient) Branch(fullname, branch string) (sdk.VCSBranch, error) {
+	p, err := g.project(fullname)
+	if err != nil {
+		return sdk.VCSBranch{}, err
+	}
+
+	status, body, _, err := g.do(http.MethodGet, projectPath(fullname)+"/repository/branches/"+url.QueryEscape(branch), nil)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return sdk.VCSBranch{}, sdk.ErrNoBranch
+		}
+		log.Warning("GitlabClient.Branch> Error %s", err)
+		return sdk.VCSBranch{}, err
+	}
+
+	b := Branch{}
+	if err := json.Unmarshal(body, &b); err != nil {
+		log.Warning("GitlabClient.Branch> Unable to parse gitlab branch: %s", err)
+		return sdk.VCSBranch{}, err
+	}
+	return sdk.VCSBranch{
+		ID:           b.Name,
+		DisplayID:    b.Name,
+		LatestCommit: b.Commit.ID,
+		Default:      b.Name == p.DefaultBranch,
+	}, nil
+}
+
+// Commits returns the commits of a branch after the commit SHA since, up to the commit SHA until (or the head of the branch)
+// https://docs.gitlab.com/ce/api/commits.html#list-repository-commits
+func (g *GitlabClient) Commits(repo, branch, since, until string) ([]sdk.VCSCommit, error) {
+	log.Debug("GitlabClient.Commits> Looking for commits on repo %s since = %s until = %s", repo, since, until)
+
+	ref := until
+	if ref == "" {
+		ref = branch
+	}
+
+	var commits []Commit
+	found := false
+	path := projectPath(repo) + "/repository/commits?ref_name=" + url.QueryEscape(ref)
+	if err := g.getAll(path, func(body []byte) error {
+		if found {
+			return nil
+		}
+		page := []Commit{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		//Commits are listed from the newest, stop on the since commit
+		for _, c := range page {
+			if since != "" && c.ID == since {
+				found = true
+				break
+			}
+			commits = append(commits, c)
+		}
+		return nil
+	}); err != nil {
+		log.Warning("GitlabClient.Commits> Error %s", err)
+		return nil, err
+	}
+
+	res := make([]sdk.VCSCommit, 0, len(commits))
+	for _, c := range commits {
+		res = append(res, vcsCommit(c))
+	}
+	return res, nil
+}
+
+// Commit retrieves a specific commit
+// https://docs.gitlab.com/ce/api/commits.html#get-a-single-commit
+func (g *GitlabClient) Commit(repo, hash string) (sdk.VCSCommit, error) {
+	_, body, _, err := g.do(http.MethodGet, projectPath(repo)+"/repository/commits/"+hash, nil)
+	if err != nil {
+		log.Warning("GitlabClient.Commit> Error %s", err)
+		return sdk.VCSCommit{}, err
+	}
+
+	c := Commit{}
+	if err := json.Unmarshal(body, &c); err != nil {
+		log.Warning("GitlabClient.Commit> Unable to parse gitlab commit: %s", err)
+		return sdk.VCSCommit{}, err
+	}
+	return vcsCommit(c), nil
+}
+
+func vcsCommit(c Commit) sdk.VCSCommit {
+	return sdk.VCSCommit{
+		Hash:      c.ID,
+		Message:   c.Message,
+		Timestamp: c.AuthoredDate.Unix() * 1000,
+		URL:       c.WebURL,
+		Author: sdk.VCSAuthor{
+			Name:        c.AuthorName,
+			DisplayName: c.AuthorName,
+			Email:       c.AuthorEmail,
+		},
+	}
+}
+
+// ChangedFiles returns the files changed between two commits, or by the until commit if since is empty
+// https://docs.gitlab.com/ce/api/repositories.html#compare-branches-tags-or-commits
+func (g *GitlabClient) ChangedFiles(repo, since, until string) ([]string, error) {
+	var diffs []Diff
+	if since == "" {
+		_, body, _, err := g.do(http.MethodGet, projectPath(repo)+"/repository/commits/"+until+"/diff", nil)
+		if err != nil {
+			log.Warning("GitlabClient.ChangedFiles> Error %s", err)
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &diffs); err != nil {
+			log.Warning("GitlabClient.ChangedFiles> Unable to parse gitlab diff: %s", err)
+			return nil, err
+		}
+	} else {
+		_, body, _, err := g.do(http.MethodGet, projectPath(repo)+"/repository/compare?from="+since+"&to="+until, nil)
+		if err != nil {
+			log.Warning("GitlabClient.ChangedFiles> Error %s", err)
+			return nil, err
+		}
+		c := Comparison{}
+		if err := json.Unmarshal(body, &c); err != nil {
+			log.Warning("GitlabClient.ChangedFiles> Unable to parse gitlab comparison: %s", err)
+			return nil, err
+		}
+		diffs = c.Diffs
+	}
+
+	files := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		if d.DeletedFile {
+			files = append(files, d.OldPath)
+			continue
+		}
+		files = append(files, d.NewPath)
+	}
+	return files, nil
+}
+
+// CreateHook enables push and tag push events on the given url
+// https://docs.gitlab.com/ce/api/projects.html#add-project-hook
+func (g *GitlabClient) CreateHook(repo, url string) error {
+	h := Hook{
+		URL:                   url,
+		PushEvents:            true,
+		TagPushEvents:         true,
+		EnableSSLVerification: true,
+	}
+	if _, _, _, err := g.do(http.MethodPost, projectPath(repo)+"/hooks", h); err != nil {
+		log.Warning("GitlabClient.CreateHook> Error %s", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteHook removes the hooks of the project calling the given url
+// https://docs.gitlab.com/ce/api/projects.html#delete-project-hook
+func (g *GitlabClient) DeleteHook(repo, url string) error {
+	var hooks []Hook
+	if err := g.getAll(projectPath(repo)+"/hooks", func(body []byte) error {
+		page := []Hook{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		hooks = append(hooks, page...)
+		return nil
+	}); err != nil {
+		log.Warning("GitlabClient.DeleteHook> Error %s", err)
+		return err
+	}
+
+	for _, h := range hooks {
+		if h.URL != url {
+			continue
+		}
+		if _, _, _, err := g.do(http.MethodDelete, fmt.Sprintf("%s/hooks/%d", projectPath(repo), h.ID), nil); err != nil {
+			log.Warning("GitlabClient.DeleteHook> Error %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// PushEvents returns the last commit of each branch and the tags pushed after the reference date
+// https://docs.gitlab.com/ce/api/events.html#list-a-project-s-visible-events
+func (g *GitlabClient) PushEvents(fullname string, dateRef time.Time) ([]sdk.VCSPushEvent, time.Duration, error) {
+	log.Debug("GitlabClient.PushEvents> loading events for %s after %v", fullname, dateRef)
+	interval := time.Duration(60.0)
+
+	//after is a date, filter on the exact time afterwards
+	path := projectPath(fullname) + "/events?action=pushed&after=" + dateRef.AddDate(0, 0, -1).Format("2006-01-02")
+	var events []Event
+	if err := g.getAll(path, func(body []byte) error {
+		page := []Event{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		for _, e := range page {
+			if e.CreatedAt.After(dateRef) {
+				events = append(events, e)
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Warning("GitlabClient.PushEvents> Error %s", err)
+		return nil, 0.0, err
+	}
+
+	lastEventPerBranch := map[string]Event{}
+	res := []sdk.VCSPushEvent{}
+	for _, e := range events {
+		if e.PushData.Action == "removed" || e.PushData.CommitTo == "" {
+			continue
+		}
+		switch e.PushData.RefType {
+		case "branch":
+			l, ok := lastEventPerBranch[e.PushData.Ref]
+			if !ok || l.CreatedAt.Before(e.CreatedAt) {
+				lastEventPerBranch[e.PushData.Ref] = e
+			}
+		case "tag":
+			res = append(res, sdk.VCSPushEvent{
+				Tag:    e.PushData.Ref,
+				Commit: pushEventCommit(e),
+			})
+		}
+	}
+
+	for b, e := range lastEventPerBranch {
+		branch, err := g.Branch(fullname, b)
+		if err != nil {
+			return nil, 0.0, fmt.Errorf("Unable to find branch %s in %s : %s", b, fullname, err)
+		}
+		res = append(res, sdk.VCSPushEvent{
+			Branch: branch,
+			Commit: pushEventCommit(e),
+		})
+	}
+
+	return res, interval, nil
+}
+
+//pushEventCommit returns the commit pushed by an event
+func pushEventCommit(e Event) sdk.VCSCommit {
+	return sdk.VCSCommit{
+		Hash:      e.PushData.CommitTo,
+		Message:   e.PushData.CommitTitle,
+		Timestamp: e.CreatedAt.Unix() * 1000,
+		Author: sdk.VCSAuthor{
+			Name:        e.Author.Username,
+			DisplayName: e.Author.Name,
+			Avatar:      e.Author.AvatarURL,
+		},
+	}
+}
+
+// SetStatus sends the status of a pipeline build on its commit
+// https://docs.gitlab.com/ce/api/commits.html#post-the-build-status-to-a-commit
+func (g *GitlabClient) SetStatus(event sdk.Event) error {
+	var eventpb sdk.EventPipelineBuild
+	if event.EventType != fmt.Sprintf("%T", sdk.EventPipelineBuild{}) {
+		return nil
+	}
+
+	if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
+		log.Warning("GitlabClient.SetStatus> Error during consumption: %s", err)
+		return err
+	}
+
+	key := fmt.Sprintf("%s-%s-%s", eventpb.ProjectKey, eventpb.ApplicationName, eventpb.PipelineName)
+	if eventpb.EnvironmentName != "" && eventpb.EnvironmentName != sdk.DefaultEnv.Name {
+		key += "-" + eventpb.EnvironmentName
+	}
+
+	status := CommitStatus{
+		State:       getGitlabStateFromStatus(eventpb.Status),
+		Ref:         eventpb.BranchName,
+		Name:        key,
+		Description: fmt.Sprintf("CDS build #%d %s", eventpb.BuildNumber, strings.ToLower(eventpb.Status.String())),
+		TargetURL: fmt.Sprintf("%s/#/project/%s/application/%s/pipeline/%s/build/%d?env=%s",
+			viper.GetString("base_url"),
+			eventpb.ProjectKey,
+			eventpb.ApplicationName,
+			eventpb.PipelineName,
+			eventpb.BuildNumber,
+			url.QueryEscape(eventpb.EnvironmentName),
+		),
+	}
+
+	log.Debug("GitlabClient.SetStatus> hash:%s status:%+v", eventpb.Hash, status)
+	if _, _, _, err := g.do(http.MethodPost, projectPath(eventpb.RepositoryFullname)+"/statuses/"+eventpb.Hash, status); err != nil {
+		return fmt.Errorf("SetStatus> err on gitlab: %s", err)
+	}
+	return nil
+}
+
+func getGitlabStateFromStatus(status sdk.Status) string {
+	switch status {
+	case sdk.StatusSuccess:
+		return "success"
+	case sdk.StatusWaiting:
+		return "pending"
+	case sdk.StatusBuilding:
+		return "running"
+	case sdk.StatusDisabled, sdk.StatusSkipped:
+		return "canceled"
+	default:
+		return "failed"
+	}
+}
+
+// CreateRelease creates a tag on the given commit with the release notes
+// https://docs.gitlab.com/ce/api/tags.html#create-a-new-tag
+func (g *GitlabClient) CreateRelease(repo, tag, hash, title, body string) error {
+	t := struct {
+		TagName            string `json:"tag_name"`
+		Ref                string `json:"ref"`
+		Message            string `json:"message"`
+		ReleaseDescription string `json:"release_description"`
+	}{
+		TagName:            tag,
+		Ref:                hash,
+		Message:            title,
+		ReleaseDescription: body,
+	}
+
+	if _, _, _, err := g.do(http.MethodPost, projectPath(repo)+"/repository/tags", t); err != nil {
+		log.Warning("GitlabClient.CreateRelease> Error %s", err)
+		return err
+	}
+	return nil
+}
+
+// PullRequestComment posts a note on a merge request, given its internal id
+// https://docs.gitlab.com/ce/api/notes.html#create-new-merge-request-note
+func (g *GitlabClient) PullRequestComment(repo string, id int64, body string) error {
+	note := struct {
+		Body string `json:"body"`
+	}{
+		Body: body,
+	}
+
+	if _, _, _, err := g.do(http.MethodPost, fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(repo), id), note); err != nil {
+		log.Warning("GitlabClient.PullRequestComment> Error %s", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// gitlabStandIn serves canned responses of the gitlab API v4 for the project group/repo
+type gitlabStandIn struct {
+	t        *testing.T
+	requests []string
+	bodies   map[string]string
+}
+
+func (s *gitlabStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := r.Method + " " + r.URL.EscapedPath()
+	s.requests = append(s.requests, call)
+
+	if r.URL.Path != "/oauth/token" && r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"401 Unauthorized"}`))
+		return
+	}
+
+	if r.Body != nil {
+		b, _ := ioutil.ReadAll(r.Body)
+		s.bodies[call] = string(b)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch call {
+	case "POST /oauth/token":
+		form, _ := url.ParseQuery(s.bodies[call])
+		assert.Equal(s.t, "authorization_code", form.Get("grant_type"))
+		assert.Equal(s.t, "the-code", form.Get("code"))
+		assert.Equal(s.t, "secret", form.Get("client_secret"))
+		w.Write([]byte(`{"access_token":"token","token_type":"bearer","scope":"api"}`))
+	case "GET /api/v4/projects":
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			w.Write([]byte(`[{"id":1,"name":"repo","path":"repo","path_with_namespace":"group/repo","web_url":"https://gitlab/group/repo","http_url_to_repo":"https://gitlab/group/repo.git","ssh_url_to_repo":"git@gitlab:group/repo.git","default_branch":"master"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id":2,"name":"other","path":"other","path_with_namespace":"group/other","default_branch":"master"}]`))
+	case "GET /api/v4/projects/group%2Frepo":
+		w.Write([]byte(`{"id":1,"name":"repo","path":"repo","path_with_namespace":"group/repo","default_branch":"master"}`))
+	case "GET /api/v4/projects/group%2Funknown":
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"404 Project Not Found"}`))
+	case "GET /api/v4/projects/group%2Frepo/repository/branches":
+		w.Write([]byte(`[{"name":"master","commit":{"id":"aaa"}},{"name":"feat/x","commit":{"id":"bbb"}}]`))
+	case "GET /api/v4/projects/group%2Frepo/repository/branches/feat%2Fx":
+		w.Write([]byte(`{"name":"feat/x","commit":{"id":"bbb"}}`))
+	case "GET /api/v4/projects/group%2Frepo/repository/commits":
+		assert.Equal(s.t, "feat/x", r.URL.Query().Get("ref_name"))
+		w.Write([]byte(`[
+			{"id":"c3","message":"third","author_name":"John","author_email":"john@localhost","authored_date":"2017-01-03T10:00:00Z"},
+			{"id":"c2","message":"second","author_name":"John","author_email":"john@localhost","authored_date":"2017-01-02T10:00:00Z"},
+			{"id":"c1","message":"first","author_name":"John","author_email":"john@localhost","authored_date":"2017-01-01T10:00:00Z"}
+		]`))
+	case "GET /api/v4/projects/group%2Frepo/repository/compare":
+		assert.Equal(s.t, "c1", r.URL.Query().Get("from"))
+		assert.Equal(s.t, "c3", r.URL.Query().Get("to"))
+		w.Write([]byte(`{"commits":[],"diffs":[{"old_path":"a.go","new_path":"a.go"},{"old_path":"old.go","new_path":"old.go","deleted_file":true},{"old_path":"b.go","new_path":"c.go","renamed_file":true}]}`))
+	case "POST /api/v4/projects/group%2Frepo/hooks":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":12}`))
+	case "GET /api/v4/projects/group%2Frepo/hooks":
+		w.Write([]byte(`[{"id":11,"url":"http://other"},{"id":12,"url":"http://cds/hook?uid=1"}]`))
+	case "DELETE /api/v4/projects/group%2Frepo/hooks/12":
+		w.WriteHeader(http.StatusNoContent)
+	case "GET /api/v4/projects/group%2Frepo/events":
+		assert.Equal(s.t, "pushed", r.URL.Query().Get("action"))
+		w.Write([]byte(`[
+			{"action_name":"pushed to","created_at":"2017-01-03T10:00:00Z","author":{"username":"john","name":"John"},"push_data":{"action":"pushed","ref_type":"branch","ref":"feat/x","commit_to":"c3","commit_title":"third"}},
+			{"action_name":"pushed to","created_at":"2017-01-02T10:00:00Z","author":{"username":"john","name":"John"},"push_data":{"action":"pushed","ref_type":"branch","ref":"feat/x","commit_to":"c2","commit_title":"second"}},
+			{"action_name":"pushed new","created_at":"2017-01-02T11:00:00Z","author":{"username":"john","name":"John"},"push_data":{"action":"created","ref_type":"tag","ref":"v1.0.0","commit_to":"c2"}},
+			{"action_name":"pushed to","created_at":"2016-12-31T10:00:00Z","author":{"username":"john","name":"John"},"push_data":{"action":"pushed","ref_type":"branch","ref":"master","commit_to":"c0"}}
+		]`))
+	case "POST /api/v4/projects/group%2Frepo/merge_requests/7/notes":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	case "POST /api/v4/projects/group%2Frepo/statuses/c3":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	default:
+		s.t.Errorf("unexpected call %s", call)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newStandIn(t *testing.T) (*gitlabStandIn, *httptest.Server, *GitlabClient) {
+	s := &gitlabStandIn{t: t, bodies: map[string]string{}}
+	srv := httptest.NewServer(s)
+	return s, srv, &GitlabClient{URL: srv.URL, OAuthToken: "token"}
+}
+
+func TestAuthorize(t *testing.T) {
+	_, srv, _ := newStandIn(t)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "repogitlab")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "gitlab.clientSecret")
+	assert.NoError(t, ioutil.WriteFile(secret, []byte("secret\n"), 0600))
+
+	consumer := New(srv.URL+"/", "id", secret, "http://cds/callback")
+	state, redirect, err := consumer.AuthorizeRedirect()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, state)
+
+	u, err := url.Parse(redirect)
+	assert.NoError(t, err)
+	assert.Equal(t, "/oauth/authorize", u.Path)
+	assert.Equal(t, "id", u.Query().Get("client_id"))
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, state, u.Query().Get("state"))
+	assert.Equal(t, "http://cds/callback", u.Query().Get("redirect_uri"))
+
+	token, tokenSecret, err := consumer.AuthorizeToken(state, "the-code")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+	assert.Equal(t, state, tokenSecret)
+
+	client, err := consumer.GetAuthorized(token, tokenSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, &GitlabClient{URL: srv.URL, OAuthToken: "token"}, client)
+}
+
+func TestRepos(t *testing.T) {
+	_, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	repos, err := client.Repos()
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, sdk.VCSRepo{
+		ID:           "1",
+		Name:         "repo",
+		Slug:         "repo",
+		Fullname:     "group/repo",
+		URL:          "https://gitlab/group/repo",
+		HTTPCloneURL: "https://gitlab/group/repo.git",
+		SSHCloneURL:  "git@gitlab:group/repo.git",
+	}, repos[0])
+	assert.Equal(t, "group/other", repos[1].Fullname)
+
+	_, err = client.RepoByFullname("group/unknown")
+	assert.Equal(t, sdk.ErrRepoNotFound, err)
+
+	client.OAuthToken = "wrong"
+	_, err = client.Repos()
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.(Error).Status)
+}
+
+func TestBranches(t *testing.T) {
+	_, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	branches, err := client.Branches("group/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.VCSBranch{
+		{ID: "master", DisplayID: "master", LatestCommit: "aaa", Default: true},
+		{ID: "feat/x", DisplayID: "feat/x", LatestCommit: "bbb"},
+	}, branches)
+
+	branch, err := client.Branch("group/repo", "feat/x")
+	assert.NoError(t, err)
+	assert.Equal(t, "bbb", branch.LatestCommit)
+	assert.False(t, branch.Default)
+}
+
+func TestCommits(t *testing.T) {
+	_, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	commits, err := client.Commits("group/repo", "feat/x", "c1", "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "c3", commits[0].Hash)
+	assert.Equal(t, "c2", commits[1].Hash)
+	assert.Equal(t, "john@localhost", commits[0].Author.Email)
+	assert.Equal(t, time.Date(2017, 1, 3, 10, 0, 0, 0, time.UTC).Unix()*1000, commits[0].Timestamp)
+
+	commits, err = client.Commits("group/repo", "feat/x", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 3)
+
+	files, err := client.ChangedFiles("group/repo", "c1", "c3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "old.go", "c.go"}, files)
+}
+
+func TestHooks(t *testing.T) {
+	s, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	assert.NoError(t, client.CreateHook("group/repo", "http://cds/hook?uid=1"))
+	h := Hook{}
+	assert.NoError(t, json.Unmarshal([]byte(s.bodies["POST /api/v4/projects/group%2Frepo/hooks"]), &h))
+	assert.Equal(t, "http://cds/hook?uid=1", h.URL)
+	assert.True(t, h.PushEvents)
+	assert.True(t, h.TagPushEvents)
+
+	assert.NoError(t, client.DeleteHook("group/repo", "http://cds/hook?uid=1"))
+	assert.Contains(t, s.requests, "DELETE /api/v4/projects/group%2Frepo/hooks/12")
+	assert.NotContains(t, s.requests, "DELETE /api/v4/projects/group%2Frepo/hooks/11")
+}
+
+func TestPushEvents(t *testing.T) {
+	_, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	events, interval, err := client.PushEvents("group/repo", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(60), interval)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "v1.0.0", events[0].Tag)
+	assert.Equal(t, "c2", events[0].Commit.Hash)
+	assert.Equal(t, "feat/x", events[1].Branch.DisplayID)
+	assert.Equal(t, "c3", events[1].Commit.Hash)
+	assert.Equal(t, "john", events[1].Commit.Author.Name)
+}
+
+func TestSetStatus(t *testing.T) {
+	s, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	event := sdk.Event{
+		EventType: "sdk.EventPipelineBuild",
+		Payload: map[string]interface{}{
+			"ProjectKey":         "KEY",
+			"ApplicationName":    "app",
+			"PipelineName":       "deploy",
+			"EnvironmentName":    sdk.DefaultEnv.Name,
+			"BuildNumber":        4,
+			"BranchName":         "feat/x",
+			"Hash":               "c3",
+			"RepositoryFullname": "group/repo",
+			"Status":             sdk.StatusBuilding,
+		},
+	}
+	assert.NoError(t, client.SetStatus(event))
+
+	status := CommitStatus{}
+	assert.NoError(t, json.Unmarshal([]byte(s.bodies["POST /api/v4/projects/group%2Frepo/statuses/c3"]), &status))
+	assert.Equal(t, "running", status.State)
+	assert.Equal(t, "KEY-app-deploy", status.Name)
+	assert.Equal(t, "feat/x", status.Ref)
+	assert.Contains(t, status.TargetURL, "/#/project/KEY/application/app/pipeline/deploy/build/4")
+
+	//Other events are ignored
+	assert.NoError(t, client.SetStatus(sdk.Event{EventType: "sdk.EventJob"}))
+}
+
+func TestPullRequestComment(t *testing.T) {
+	s, srv, client := newStandIn(t)
+	defer srv.Close()
+
+	assert.NoError(t, client.PullRequestComment("group/repo", 7, "**CDS app/build #4: Success**"))
+	assert.Equal(t, `{"body":"**CDS app/build #4: Success**"}`, s.bodies["POST /api/v4/projects/group%2Frepo/merge_requests/7/notes"])
+}
@@ -0,0 +1,37 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//Error wraps gitlab error format
+type Error struct {
+	Status  int    `json:"-"`
+	ID      string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("(gitlab_%d) %s", e.Status, e.Message)
+}
+
+func (e Error) String() string {
+	return e.Error()
+}
+
+//ErrorAPI creates a new error from a gitlab API response.
+//Gitlab returns either {"message": "..."}, {"message": {"field": ["..."]}} or {"error": "..."}
+func ErrorAPI(status int, body []byte) Error {
+	res := map[string]interface{}{}
+	json.Unmarshal(body, &res)
+	e := Error{Status: status}
+	if id, ok := res["error"].(string); ok {
+		e.ID = id
+		e.Message = id
+	}
+	if msg, ok := res["message"]; ok {
+		e.Message = fmt.Sprintf("%v", msg)
+	}
+	return e
+}
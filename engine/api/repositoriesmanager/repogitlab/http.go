@@ -0,0 +1,111 @@
+package repogitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/facebookgo/httpcontrol"
+
+	"github.com/ovh/cds/engine/log"
+)
+
+//APIPath is the path of the gitlab API v4
+const APIPath = "/api/v4"
+
+var httpClient = &http.Client{
+	Transport: &httpcontrol.Transport{
+		RequestTimeout: time.Second * 30,
+		MaxTries:       5,
+	},
+}
+
+func postForm(path string, data url.Values) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(data.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	return res.StatusCode, body, err
+}
+
+//projectPath returns the API path of a project given its fullname
+func projectPath(fullname string) string {
+	return "/projects/" + url.QueryEscape(fullname)
+}
+
+func (c *GitlabClient) do(method, path string, in interface{}) (int, []byte, http.Header, error) {
+	if !strings.HasPrefix(path, "http") {
+		path = c.URL + APIPath + path
+	}
+
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.OAuthToken)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	log.Debug("Gitlab API>> %s %s", method, path)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, nil, err
+	}
+
+	if res.StatusCode >= 400 {
+		return res.StatusCode, resBody, res.Header, ErrorAPI(res.StatusCode, resBody)
+	}
+	return res.StatusCode, resBody, res.Header, nil
+}
+
+//getAll follows the pagination of a gitlab list and unmarshal every page with the given function
+func (c *GitlabClient) getAll(path string, page func([]byte) error) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	next := "1"
+	for next != "" {
+		_, body, headers, err := c.do(http.MethodGet, path+sep+"per_page=100&page="+next, nil)
+		if err != nil {
+			return err
+		}
+		if err := page(body); err != nil {
+			return err
+		}
+		next = headers.Get("X-Next-Page")
+	}
+	return nil
+}
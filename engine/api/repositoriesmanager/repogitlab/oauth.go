@@ -0,0 +1,152 @@
+package repogitlab
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+//Gitlab var
+var (
+	RequestedScope = []string{"api"} //https://docs.gitlab.com/ce/integration/oauth_provider.html
+)
+
+func generateHash() (string, error) {
+	bs := make([]byte, 64)
+	if _, err := rand.Read(bs); err != nil {
+		log.Critical("generateHash: rand.Read failed: %s\n", err)
+		return "", err
+	}
+	return hex.EncodeToString(bs), nil
+}
+
+//GitlabConsumer embeds a gitlab oauth2 consumer
+type GitlabConsumer struct {
+	URL                      string `json:"url"`
+	ClientID                 string `json:"client-id"`
+	ClientSecret             string `json:"client-secret"`
+	AuthorizationCallbackURL string `json:"-"`
+	WithHooks                bool   `json:"with-hooks"`
+	WithPolling              bool   `json:"with-polling"`
+}
+
+//New creates a new GitlabConsumer
+func New(URL, ClientID, ClientSecret, AuthorizationCallbackURL string) *GitlabConsumer {
+	return &GitlabConsumer{
+		URL:                      strings.TrimSuffix(URL, "/"),
+		ClientID:                 ClientID,
+		ClientSecret:             ClientSecret,
+		AuthorizationCallbackURL: AuthorizationCallbackURL,
+	}
+}
+
+func (g *GitlabConsumer) getClientSecretValue() ([]byte, error) {
+	b, err := ioutil.ReadFile(g.ClientSecret)
+	if err != nil {
+		log.Critical("GitlabConsumer> Unable to read client secret value %s : %s", g.ClientSecret, err)
+		return nil, err
+	}
+	b = bytes.Replace(b, []byte{'\n'}, []byte{}, -1)
+	return b, err
+}
+
+//Data returns a serilized version of specific data
+func (g *GitlabConsumer) Data() string {
+	b, _ := json.Marshal(g)
+	return string(b)
+}
+
+//AuthorizeRedirect returns the request token, the Authorize URL
+//doc: https://docs.gitlab.com/ce/api/oauth2.html#web-application-flow
+func (g *GitlabConsumer) AuthorizeRedirect() (string, string, error) {
+	requestToken, err := generateHash()
+	if err != nil {
+		return "", "", err
+	}
+
+	val := url.Values{}
+	val.Add("client_id", g.ClientID)
+	val.Add("redirect_uri", g.AuthorizationCallbackURL)
+	val.Add("response_type", "code")
+	val.Add("scope", strings.Join(RequestedScope, " "))
+	val.Add("state", requestToken)
+
+	authorizeURL := fmt.Sprintf("%s/oauth/authorize?%s", g.URL, val.Encode())
+
+	return requestToken, authorizeURL, nil
+}
+
+//AuthorizeToken returns the authorized token (and its secret)
+//from the request token and the verifier got on authorize url
+func (g *GitlabConsumer) AuthorizeToken(state, code string) (string, string, error) {
+	log.Debug("AuthorizeToken> Gitlab send code %s for state %s", code, state)
+
+	secret, err := g.getClientSecretValue()
+	if err != nil {
+		return "", "", err
+	}
+
+	params := url.Values{}
+	params.Add("client_id", g.ClientID)
+	params.Add("client_secret", string(secret))
+	params.Add("code", code)
+	params.Add("grant_type", "authorization_code")
+	params.Add("redirect_uri", g.AuthorizationCallbackURL)
+
+	status, res, err := postForm(g.URL+"/oauth/token", params)
+	if err != nil {
+		return "", "", err
+	}
+
+	if status >= 400 {
+		return "", "", fmt.Errorf("Gitlab error (%d) %s ", status, string(res))
+	}
+
+	glResponse := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		Scope       string `json:"scope"`
+	}{}
+	if err := json.Unmarshal(res, &glResponse); err != nil {
+		return "", "", fmt.Errorf("Unable to parse gitlab response (%d) %s ", status, string(res))
+	}
+	if glResponse.AccessToken == "" {
+		return "", "", fmt.Errorf("No access token in gitlab response (%d) %s ", status, string(res))
+	}
+
+	return glResponse.AccessToken, state, nil
+}
+
+//keep client in memory
+var instancesAuthorizedClient = map[string]sdk.RepositoriesManagerClient{}
+
+//GetAuthorized returns an authorized client
+func (g *GitlabConsumer) GetAuthorized(accessToken, accessTokenSecret string) (sdk.RepositoriesManagerClient, error) {
+	c := instancesAuthorizedClient[g.URL+accessToken]
+	if c == nil {
+		c = &GitlabClient{
+			URL:        g.URL,
+			OAuthToken: accessToken,
+		}
+		instancesAuthorizedClient[g.URL+accessToken] = c
+	}
+	return c, nil
+}
+
+//HooksSupported returns true if the driver technically support hook
+func (g *GitlabConsumer) HooksSupported() bool {
+	return true
+}
+
+//PollingSupported returns true if the driver technically support polling
+func (g *GitlabConsumer) PollingSupported() bool {
+	return true
+}
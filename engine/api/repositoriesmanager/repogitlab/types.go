@@ -0,0 +1,107 @@
+package repogitlab
+
+import "time"
+
+// Project represents a gitlab project
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	SSHURLToRepo      string `json:"ssh_url_to_repo"`
+	DefaultBranch     string `json:"default_branch"`
+	Namespace         struct {
+		Path     string `json:"path"`
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+// Branch represents a gitlab branch
+type Branch struct {
+	Name   string `json:"name"`
+	Commit Commit `json:"commit"`
+}
+
+// Commit represents a gitlab commit
+type Commit struct {
+	ID           string    `json:"id"`
+	ShortID      string    `json:"short_id"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	AuthorName   string    `json:"author_name"`
+	AuthorEmail  string    `json:"author_email"`
+	AuthoredDate time.Time `json:"authored_date"`
+	WebURL       string    `json:"web_url"`
+}
+
+// Diff represents a file changed by a commit or between two commits
+type Diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// Comparison represents the result of the comparison of two commits
+type Comparison struct {
+	Commits []Commit `json:"commits"`
+	Diffs   []Diff   `json:"diffs"`
+}
+
+// Hook represents a gitlab project hook
+type Hook struct {
+	ID                    int    `json:"id,omitempty"`
+	URL                   string `json:"url"`
+	PushEvents            bool   `json:"push_events"`
+	TagPushEvents         bool   `json:"tag_push_events"`
+	EnableSSLVerification bool   `json:"enable_ssl_verification"`
+}
+
+// User represents a gitlab user as given in events
+type User struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// Event represents a gitlab project event
+type Event struct {
+	ActionName string    `json:"action_name"`
+	CreatedAt  time.Time `json:"created_at"`
+	Author     User      `json:"author"`
+	PushData   struct {
+		CommitCount int    `json:"commit_count"`
+		Action      string `json:"action"`
+		RefType     string `json:"ref_type"`
+		CommitFrom  string `json:"commit_from"`
+		CommitTo    string `json:"commit_to"`
+		Ref         string `json:"ref"`
+		CommitTitle string `json:"commit_title"`
+	} `json:"push_data"`
+}
+
+// CommitStatus represents the status of a commit
+type CommitStatus struct {
+	State       string `json:"state"`
+	Ref         string `json:"ref,omitempty"`
+	Name        string `json:"name"`
+	TargetURL   string `json:"target_url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PushHook represents the payload sent by gitlab push hooks
+// https://docs.gitlab.com/ce/user/project/integrations/webhooks.html#push-events
+type PushHook struct {
+	ObjectKind   string `json:"object_kind"`
+	Ref          string `json:"ref"`
+	Before       string `json:"before"`
+	After        string `json:"after"`
+	UserUsername string `json:"user_username"`
+}
+
+// NullSHA is the hash sent by gitlab as before of a created branch, or as after of a deleted branch
+const NullSHA = "0000000000000000000000000000000000000000"
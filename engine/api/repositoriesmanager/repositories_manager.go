@@ -11,7 +11,9 @@ import (
 
 	"github.com/go-gorp/gorp"
 	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogit"
 	"github.com/ovh/cds/engine/api/repositoriesmanager/repogithub"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogitlab"
 	"github.com/ovh/cds/engine/api/repositoriesmanager/repostash"
 	"github.com/ovh/cds/engine/api/secret/secretbackend"
 	"github.com/ovh/cds/engine/log"
@@ -176,6 +178,69 @@ func New(t sdk.RepositoriesManagerType, id int64, name, URL string, args map[str
 			PollingSupported: *withPolling && github.PollingSupported(),
 		}
 
+		return &rm, nil
+	case sdk.Gitlab:
+		var gitlab *repogitlab.GitlabConsumer
+		//Check if it isn't comming from the DB
+		if id == 0 || consumerData == "" {
+			//Check args
+			if args["client-id"] == "" || args["client-secret"] == "" {
+				return nil, fmt.Errorf("client-id args and client-secret are mandatory to connect to gitlab : %v", args)
+			}
+			gitlab = repogitlab.New(URL, args["client-id"], args["client-secret"], apiURL+"/repositories_manager/oauth2/callback")
+			gitlab.WithHooks = gitlab.HooksSupported()
+			gitlab.WithPolling = gitlab.PollingSupported()
+			if b, err := strconv.ParseBool(args["with-hooks"]); err == nil {
+				gitlab.WithHooks = b
+			}
+			if b, err := strconv.ParseBool(args["with-polling"]); err == nil {
+				gitlab.WithPolling = b
+			}
+		} else {
+			//It's coming from the database, we just have to unmarshal data from the DB to get consumerData
+			//Hooks and polling stay enabled when they are not set in DB
+			gitlab = repogitlab.New(URL, "", "", apiURL+"/repositories_manager/oauth2/callback")
+			gitlab.WithHooks, gitlab.WithPolling = true, true
+			if err := json.Unmarshal([]byte(consumerData), gitlab); err != nil {
+				log.Warning("New> Error %s", err)
+				return nil, err
+			}
+		}
+
+		rm := sdk.RepositoriesManager{
+			ID:               id,
+			Consumer:         gitlab,
+			Name:             name,
+			URL:              gitlab.URL,
+			Type:             sdk.Gitlab,
+			HooksSupported:   gitlab.WithHooks && gitlab.HooksSupported(),
+			PollingSupported: gitlab.WithPolling && gitlab.PollingSupported(),
+		}
+		return &rm, nil
+	case sdk.Git:
+		var git *repogit.GitConsumer
+		//Check if it isn't comming from the DB
+		if id == 0 || consumerData == "" {
+			//The deploy key is written from the secret backend at init, mirrors go in the keys directory by default
+			git = repogit.New(URL, args["key"], args["known-hosts"], args["mirrors-directory"], apiURL+"/repositories_manager/oauth2/callback")
+		} else {
+			//It's coming from the database, we just have to unmarshal data from the DB to get consumerData
+			git = repogit.New(URL, "", "", "", apiURL+"/repositories_manager/oauth2/callback")
+			if err := json.Unmarshal([]byte(consumerData), git); err != nil {
+				log.Warning("New> Error %s", err)
+				return nil, err
+			}
+		}
+
+		rm := sdk.RepositoriesManager{
+			ID:               id,
+			Consumer:         git,
+			Name:             name,
+			URL:              URL,
+			Type:             sdk.Git,
+			HooksSupported:   git.HooksSupported(),
+			PollingSupported: git.PollingSupported(),
+		}
 		return &rm, nil
 	}
 	return nil, fmt.Errorf("Unknown type %s. Cannot instanciate repositories manager t=%s id=%d name=%s url=%s args=%s consumerData=%s", t, t, id, name, URL, args, consumerData)
@@ -220,5 +285,59 @@ func initRepositoriesManager(db gorp.SqlExecutor, rm *sdk.RepositoriesManager, d
 		}
 		return nil
 	}
+
+	if rm.Type == sdk.Gitlab {
+		clientSecret := secrets["client-secret"]
+		if clientSecret == "" {
+			return fmt.Errorf("Cannot init %s. Missing client secret", rm.Name)
+		}
+		path := filepath.Join(directory, fmt.Sprintf("%s.%s", rm.Name, "clientSecret"))
+		log.Notice("RepositoriesManager> Writing gitlab client secret %s", path)
+		if err := ioutil.WriteFile(path, []byte(clientSecret), 0600); err != nil {
+			log.Warning("RepositoriesManager> Unable to write gitlab client secret %s : %s", path, err)
+			return err
+		}
+		gl := rm.Consumer.(*repogitlab.GitlabConsumer)
+		gl.ClientSecret = path
+		if err := Update(db, rm); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if rm.Type == sdk.Git {
+		privateKey := secrets["privatekey"]
+		if privateKey == "" {
+			return fmt.Errorf("Cannot init %s. Missing deploy key", rm.Name)
+		}
+		path := filepath.Join(directory, fmt.Sprintf("%s.%s", rm.Name, "privateKey"))
+		log.Notice("RepositoriesManager> Writing git deploy key %s", path)
+		if err := ioutil.WriteFile(path, []byte(privateKey), 0600); err != nil {
+			log.Warning("RepositoriesManager> Unable to write git deploy key %s : %s", path, err)
+			return err
+		}
+		git := rm.Consumer.(*repogit.GitConsumer)
+		git.PrivateKey = path
+		//The git server host keys come from the known-hosts option, or from the knownhosts secret
+		if knownHosts := secrets["knownhosts"]; knownHosts != "" {
+			path := filepath.Join(directory, fmt.Sprintf("%s.%s", rm.Name, "knownHosts"))
+			log.Notice("RepositoriesManager> Writing git known hosts %s", path)
+			if err := ioutil.WriteFile(path, []byte(knownHosts), 0600); err != nil {
+				log.Warning("RepositoriesManager> Unable to write git known hosts %s : %s", path, err)
+				return err
+			}
+			git.KnownHosts = path
+		}
+		if git.KnownHosts == "" {
+			return fmt.Errorf("Cannot init %s. Missing known hosts", rm.Name)
+		}
+		if git.MirrorsDirectory == "" {
+			git.MirrorsDirectory = filepath.Join(directory, fmt.Sprintf("%s.%s", rm.Name, "mirrors"))
+		}
+		if err := Update(db, rm); err != nil {
+			return err
+		}
+		return nil
+	}
 	return fmt.Errorf("Unsupported repositories manager : %s: %s", rm.Name, rm.Type)
 }
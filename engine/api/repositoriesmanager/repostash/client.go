@@ -377,6 +377,16 @@ func (s *StashClient) SetStatus(event sdk.Event) error {
 	return nil
 }
 
+// ChangedFiles is not supported by the stash client library
+func (s *StashClient) ChangedFiles(repo, since, until string) ([]string, error) {
+	return nil, fmt.Errorf("Not supported on stash")
+}
+
+// CreateRelease is not supported by Bitbucket Server which has no releases
+func (s *StashClient) CreateRelease(repo, tag, hash, title, body string) error {
+	return fmt.Errorf("Not supported on stash")
+}
+
 func getBitbucketStateFromStatus(status sdk.Status) string {
 	switch status {
 	case sdk.StatusSuccess:
@@ -391,3 +401,8 @@ func getBitbucketStateFromStatus(status sdk.Status) string {
 		return failed
 	}
 }
+
+// PullRequestComment is not supported by the stash client library
+func (s *StashClient) PullRequestComment(repo string, id int64, body string) error {
+	return fmt.Errorf("Not supported on stash")
+}
@@ -141,8 +141,8 @@ func (r *Router) Handle(uri string, handlers ...RouterConfigParam) {
 		// Authorization ?
 		w.Header().Add("Access-Control-Allow-Origin", "*")
 		w.Header().Add("Access-Control-Allow-Methods", "GET,OPTIONS,PUT,POST,DELETE")
-		w.Header().Add("Access-Control-Allow-Headers", "Accept, Origin, Referer, User-Agent, Content-Type, Authorization, Session-Token, Last-Event-Id")
-		w.Header().Add("Access-Control-Expose-Headers", "Accept, Origin, Referer, User-Agent, Content-Type, Authorization, Session-Token, Last-Event-Id")
+		w.Header().Add("Access-Control-Allow-Headers", "Accept, Origin, Referer, User-Agent, Content-Type, Authorization, Session-Token, Access-Token, Last-Event-Id")
+		w.Header().Add("Access-Control-Expose-Headers", "Accept, Origin, Referer, User-Agent, Content-Type, Authorization, Session-Token, Access-Token, Last-Event-Id")
 
 		c := &context.Context{}
 
@@ -167,6 +167,11 @@ func (r *Router) Handle(uri string, handlers ...RouterConfigParam) {
 				WriteError(w, req, sdk.ErrUnauthorized)
 				return
 			}
+			if c.AccessToken != nil && !checkAccessTokenScope(rc, req, c) {
+				log.Warning("Access token %d of %s denied on %s %s\n", c.AccessToken.ID, c.User.Username, req.Method, req.URL)
+				WriteError(w, req, sdk.ErrForbidden)
+				return
+			}
 		}
 
 		permissionOk := true
@@ -328,6 +333,9 @@ func (r *Router) checkAuthentication(db *gorp.DbMap, headers http.Header, c *con
 	case sdk.HatcheryAgent:
 		return r.checkHatcheryAuth(db, headers, c)
 	default:
+		if token := headers.Get(sdk.AccessTokenHeader); token != "" {
+			return auth.CheckAccessToken(db, token, c)
+		}
 		return r.checkAuthHeader(db, headers, c)
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/go-gorp/gorp"
 
@@ -34,6 +35,7 @@ const (
 	IncompatibleMemoryAndModelRequirements
 	GitURLWithoutLinkedRepository
 	GitURLWithoutKey
+	EnvironmentFrozen
 )
 
 var messageAmericanEnglish = map[int64]string{
@@ -50,6 +52,7 @@ var messageAmericanEnglish = map[int64]string{
 	IncompatibleMemoryAndModelRequirements:  `Action {{index . "ActionName"}}{{if index . "PipelineName"}} in pipeline {{index . "ProjectKey"}}/{{index . "PipelineName"}}{{end}}: Model {{index . "ModelName"}} cannot handle memory requirement`,
 	GitURLWithoutLinkedRepository:           `Action {{index . "ActionName"}}{{if index . "PipelineName"}} in pipeline {{index . "ProjectKey"}}/{{index . "PipelineName"}}{{end}} is used but one one more applications are linked to any repository. Git clone will failed`,
 	GitURLWithoutKey:                        `Action {{index . "ActionName"}}{{if index . "PipelineName"}} in pipeline {{index . "ProjectKey"}}/{{index . "PipelineName"}}{{end}} is used but no ssh key were found. Git clone will failed`,
+	EnvironmentFrozen:                       `Deployment of {{index . "AppName"}}/{{index . "PipelineName"}} on environment {{index . "EnvName"}} was refused: freeze window '{{index . "Reason"}}' until {{index . "Until"}}`,
 }
 
 func processWarning(w *sdk.Warning, acceptedlanguage string) error {
@@ -296,6 +299,35 @@ func InsertActionWarnings(tx gorp.SqlExecutor, projectID, pipelineID int64, acti
 	return nil
 }
 
+// InsertFreezeWarning records a warning on a deployment refused because of a freeze window
+func InsertFreezeWarning(db gorp.SqlExecutor, projectID int64, app *sdk.Application, pip *sdk.Pipeline, env *sdk.Environment, w *sdk.FreezeWindow, until time.Time) error {
+	if err := DeleteFreezeWarnings(db, app.ID, pip.ID, env.ID); err != nil {
+		return err
+	}
+
+	mParam, err := json.Marshal(map[string]string{
+		"AppName":      app.Name,
+		"PipelineName": pip.Name,
+		"EnvName":      env.Name,
+		"Reason":       w.Reason,
+		"Until":        until.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO warning (project_id, app_id, pip_id, env_id, warning_id, message_param) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = db.Exec(query, projectID, app.ID, pip.ID, env.ID, EnvironmentFrozen, string(mParam))
+	return err
+}
+
+// DeleteFreezeWarnings removes freeze warnings of an application pipeline on an environment
+func DeleteFreezeWarnings(db gorp.SqlExecutor, appID, pipID, envID int64) error {
+	query := `DELETE FROM warning WHERE warning_id = $1 AND app_id = $2 AND pip_id = $3 AND env_id = $4`
+	_, err := db.Exec(query, EnvironmentFrozen, appID, pipID, envID)
+	return err
+}
+
 // CheckProjectPipelines checks all pipelines in project
 func CheckProjectPipelines(db *gorp.DbMap, project *sdk.Project) error {
 
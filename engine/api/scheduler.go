@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/go-gorp/gorp"
-	"github.com/gorhill/cronexpr"
 	"github.com/gorilla/mux"
 
 	"github.com/ovh/cds/engine/api/application"
@@ -166,8 +167,8 @@ func addSchedulerApplicationPipelineHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	//Parsing cronexpr
-	if _, err := cronexpr.Parse(s.Crontab); err != nil {
+	//Parsing cronexpr and timezone
+	if err := scheduler.Check(s); err != nil {
 		WriteError(w, r, sdk.NewError(sdk.ErrWrongRequest, err))
 		return
 	}
@@ -228,8 +229,8 @@ func updateSchedulerApplicationPipelineHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	//Parsing cronexpr
-	if _, err := cronexpr.Parse(s.Crontab); err != nil {
+	//Parsing cronexpr and timezone
+	if err := scheduler.Check(s); err != nil {
 		log.Warning("updateSchedulerApplicationPipelineHandler> %s", err)
 		WriteError(w, r, sdk.NewError(sdk.ErrWrongRequest, err))
 		return
@@ -268,6 +269,9 @@ func updateSchedulerApplicationPipelineHandler(w http.ResponseWriter, r *http.Re
 	sOld.Crontab = s.Crontab
 	sOld.Disabled = s.Disabled
 	sOld.Args = s.Args
+	sOld.Timezone = s.Timezone
+	sOld.Jitter = s.Jitter
+	sOld.SkipIfRunning = s.SkipIfRunning
 
 	if env != nil {
 		sOld.EnvironmentID = env.ID
@@ -305,3 +309,74 @@ func deleteSchedulerApplicationPipelineHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 }
+
+func getSchedulerApplicationPipelineNextHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	count := 10
+	if countS := r.FormValue("count"); countS != "" {
+		var err error
+		count, err = strconv.Atoi(countS)
+		if err != nil || count <= 0 || count > 100 {
+			log.Warning("getSchedulerApplicationPipelineNextHandler> Invalid count %s\n", countS)
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	app, errA := application.LoadApplicationByName(db, key, appName)
+	if errA != nil {
+		log.Warning("getSchedulerApplicationPipelineNextHandler> Cannot load application %s for project %s from db: %s\n", appName, key, errA)
+		WriteError(w, r, errA)
+		return
+	}
+
+	pip, errP := pipeline.LoadPipeline(db, key, pipelineName, false)
+	if errP != nil {
+		log.Warning("getSchedulerApplicationPipelineNextHandler> Cannot load pipeline %s: %s\n", pipelineName, errP)
+		WriteError(w, r, errP)
+		return
+	}
+
+	schedulers, err := scheduler.GetByApplicationPipeline(db, app, pip)
+	if err != nil {
+		log.Warning("getSchedulerApplicationPipelineNextHandler> Cannot load pipeline schedulers: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	planned := []sdk.PipelineSchedulerPlannedExecution{}
+	for _, s := range schedulers {
+		if s.Disabled {
+			continue
+		}
+		dates, err := scheduler.NextDates(&s, now, count)
+		if err != nil {
+			log.Warning("getSchedulerApplicationPipelineNextHandler> Cannot compute next executions of scheduler %d: %s\n", s.ID, err)
+			continue
+		}
+		for _, d := range dates {
+			planned = append(planned, sdk.PipelineSchedulerPlannedExecution{
+				PipelineSchedulerID: s.ID,
+				EnvironmentName:     s.EnvironmentName,
+				Crontab:             s.Crontab,
+				Timezone:            s.Timezone,
+				Jitter:              s.Jitter,
+				Date:                d,
+			})
+		}
+	}
+
+	sort.SliceStable(planned, func(i, j int) bool {
+		return planned[i].Date.Before(planned[j].Date)
+	})
+	if len(planned) > count {
+		planned = planned[:count]
+	}
+
+	WriteJSON(w, r, planned, http.StatusOK)
+}
@@ -182,6 +182,27 @@ func LoadNextExecution(db gorp.SqlExecutor, id int64) (*sdk.PipelineSchedulerExe
 	return &ps, nil
 }
 
+//IsLastExecutionRunning checks if the pipeline build of the last execution of a scheduler is still running
+func IsLastExecutionRunning(db gorp.SqlExecutor, s *sdk.PipelineScheduler) (bool, error) {
+	exec := database.PipelineSchedulerExecution{}
+	if err := db.SelectOne(&exec, "select * from pipeline_scheduler_execution where pipeline_scheduler_id = $1 and executed = true and skipped = false order by execution_planned_date desc limit 1", s.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		log.Warning("IsLastExecutionRunning> Unable to load pipeline scheduler execution : %T %s", err, err)
+		return false, err
+	}
+
+	query := `select count(1) from pipeline_build
+	where application_id = $1 and pipeline_id = $2 and environment_id = $3 and version = $4
+	and scheduled_trigger = true and status in ($5, $6)`
+	n, err := db.SelectInt(query, s.ApplicationID, s.PipelineID, s.EnvironmentID, exec.PipelineBuildVersion, sdk.StatusBuilding.String(), sdk.StatusWaiting.String())
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 //LoadPastExecutions loads all pipeline execution executed prior date 't'
 func LoadPastExecutions(db gorp.SqlExecutor, id int64) ([]sdk.PipelineSchedulerExecution, error) {
 	as := []database.PipelineSchedulerExecution{}
@@ -90,6 +90,22 @@ func executerProcess(db gorp.SqlExecutor, e *sdk.PipelineSchedulerExecution) err
 		return err
 	}
 
+	//Skip the execution while the previous one is still running
+	if s.SkipIfRunning {
+		running, err := IsLastExecutionRunning(db, s)
+		if err != nil {
+			return err
+		}
+		if running {
+			log.Info("executerProcess> Skipping execution %d of scheduler %d: previous execution still running", e.ID, s.ID)
+			t := time.Now()
+			e.ExecutionDate = &t
+			e.Executed = true
+			e.Skipped = true
+			return UpdateExecution(db, e)
+		}
+	}
+
 	//Create a new pipeline build
 	pb, err := queue.RunPipeline(db, app.ProjectKey, app, pip.Name, env.Name, s.Args, -1, sdk.PipelineBuildTrigger{
 		ManualTrigger:    false,
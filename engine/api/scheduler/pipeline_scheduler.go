@@ -1,6 +1,9 @@
 package scheduler
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/gorhill/cronexpr"
 	"github.com/ovh/cds/sdk"
 )
@@ -25,3 +28,70 @@ func New(app *sdk.Application, pip *sdk.Pipeline, env *sdk.Environment, cron str
 		Args:          args,
 	}, nil
 }
+
+//Check checks the crontab, timezone and jitter of a pipeline scheduler
+func Check(s *sdk.PipelineScheduler) error {
+	if _, err := cronexpr.Parse(s.Crontab); err != nil {
+		return err
+	}
+	if _, err := Location(s); err != nil {
+		return fmt.Errorf("invalid timezone %s: %s", s.Timezone, err)
+	}
+	if s.Jitter < 0 {
+		return fmt.Errorf("invalid jitter %d: must be positive", s.Jitter)
+	}
+	if s.Jitter > 0 {
+		interval, err := minInterval(s)
+		if err != nil {
+			return err
+		}
+		if time.Duration(s.Jitter)*time.Second >= interval {
+			return fmt.Errorf("invalid jitter %d: must be shorter than %s between two executions", s.Jitter, interval)
+		}
+	}
+	return nil
+}
+
+//minInterval returns the shortest interval between two of the next dates matching the crontab
+func minInterval(s *sdk.PipelineScheduler) (time.Duration, error) {
+	dates, err := NextDates(s, time.Now(), 500)
+	if err != nil {
+		return 0, err
+	}
+	var interval time.Duration
+	for i := 1; i < len(dates); i++ {
+		if dates[i].IsZero() {
+			break
+		}
+		if d := dates[i].Sub(dates[i-1]); interval == 0 || d < interval {
+			interval = d
+		}
+	}
+	if interval == 0 {
+		return 0, fmt.Errorf("crontab %s does not match several dates", s.Crontab)
+	}
+	return interval, nil
+}
+
+//Location returns the timezone in which the crontab is evaluated, server time by default
+func Location(s *sdk.PipelineScheduler) (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+//NextDates returns the n next dates matching the crontab after from, jitter excluded
+func NextDates(s *sdk.PipelineScheduler, from time.Time, n int) ([]time.Time, error) {
+	cronExpr, err := cronexpr.Parse(s.Crontab)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := Location(s)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := cronExpr.NextN(from.In(loc), uint(n))
+	return dates, nil
+}
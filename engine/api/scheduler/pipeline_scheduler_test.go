@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(&sdk.PipelineScheduler{Crontab: "0 0 * * *"}))
+	assert.NoError(t, Check(&sdk.PipelineScheduler{Crontab: "0 0 * * *", Timezone: "Europe/Paris", Jitter: 300}))
+	assert.Error(t, Check(&sdk.PipelineScheduler{Crontab: "foo"}))
+	assert.Error(t, Check(&sdk.PipelineScheduler{Crontab: "0 0 * * *", Timezone: "Mars/Olympus"}))
+	assert.Error(t, Check(&sdk.PipelineScheduler{Crontab: "0 0 * * *", Jitter: -1}))
+
+	// Jitter must be shorter than the shortest interval between two executions
+	assert.NoError(t, Check(&sdk.PipelineScheduler{Crontab: "*/10 * * * *", Jitter: 599}))
+	assert.Error(t, Check(&sdk.PipelineScheduler{Crontab: "*/10 * * * *", Jitter: 600}))
+	assert.Error(t, Check(&sdk.PipelineScheduler{Crontab: "0 9,10 * * *", Jitter: 3600}))
+}
+
+func TestNextFrom(t *testing.T) {
+	s := &sdk.PipelineScheduler{Crontab: "0 * * * *", Timezone: "UTC", Jitter: 3000}
+	cron := time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC)
+	planned := cron.Add(45 * time.Minute)
+	executed := planned.Add(10 * time.Second)
+	exec := &sdk.PipelineSchedulerExecution{ExecutionPlannedDate: planned, ExecutionDate: &executed, Executed: true}
+
+	// The next execution is planned after the next crontab date, not after the jittered date
+	from := nextFrom(s, exec)
+	assert.True(t, from.Equal(planned))
+	dates, err := NextDates(s, from, 1)
+	assert.NoError(t, err)
+	assert.True(t, dates[0].Equal(cron.Add(time.Hour)), "got %s", dates[0])
+
+	// Crontab dates missed by a late execution are skipped
+	late := cron.Add(3 * time.Hour)
+	exec.ExecutionDate = &late
+	assert.True(t, nextFrom(s, exec).Equal(late.Add(-3000*time.Second)))
+
+	s.Jitter = 0
+	assert.True(t, nextFrom(s, exec).Equal(late))
+}
+
+func TestNextDates(t *testing.T) {
+	from := time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	s := &sdk.PipelineScheduler{Crontab: "0 0 * * *", Timezone: "UTC"}
+	dates, err := NextDates(s, from, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, dates, 3) {
+		assert.True(t, dates[0].Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, dates[2].Equal(time.Date(2017, 3, 7, 0, 0, 0, 0, time.UTC)))
+	}
+
+	// Midnight in New York is 5:00 UTC in winter
+	s.Timezone = "America/New_York"
+	dates, err = NextDates(s, from, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, dates, 1) {
+		assert.True(t, dates[0].Equal(time.Date(2017, 3, 5, 5, 0, 0, 0, time.UTC)), "got %s", dates[0])
+	}
+}
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/go-gorp/gorp"
@@ -94,15 +95,25 @@ func Next(db gorp.SqlExecutor, s *sdk.PipelineScheduler) (*sdk.PipelineScheduler
 	if exec == nil {
 		t := time.Now()
 		exec = &sdk.PipelineSchedulerExecution{
-			Executed:      true,
-			ExecutionDate: &t,
+			Executed:             true,
+			ExecutionPlannedDate: t,
+			ExecutionDate:        &t,
 		}
 	}
 
 	if !exec.Executed {
 		return nil, fmt.Errorf("Last execution %d not ran", s.ID)
 	}
-	nextTime := cronExpr.Next(*exec.ExecutionDate)
+	loc, err := Location(s)
+	if err != nil {
+		log.Warning("scheduler.Next> Unable to load timezone %s for ID %d : %s", s.Timezone, s.ID, err)
+		return nil, err
+	}
+	nextTime := cronExpr.Next(nextFrom(s, exec).In(loc))
+	//Spread executions planned at the same time
+	if s.Jitter > 0 {
+		nextTime = nextTime.Add(time.Duration(rand.Int63n(s.Jitter)) * time.Second)
+	}
 	e := &sdk.PipelineSchedulerExecution{
 		ExecutionPlannedDate: nextTime,
 		PipelineSchedulerID:  s.ID,
@@ -111,6 +122,20 @@ func Next(db gorp.SqlExecutor, s *sdk.PipelineScheduler) (*sdk.PipelineScheduler
 	return e, nil
 }
 
+//nextFrom returns the date after which the next execution is planned. The planned date of the last execution
+//is its crontab date delayed by less than the jitter, so it is still before the next crontab date.
+//Executions which ran late, longer than the jitter, don't plan again the crontab dates they missed.
+func nextFrom(s *sdk.PipelineScheduler, exec *sdk.PipelineSchedulerExecution) time.Time {
+	from := exec.ExecutionPlannedDate
+	if exec.ExecutionDate != nil {
+		late := exec.ExecutionDate.Add(-time.Duration(s.Jitter) * time.Second)
+		if late.After(from) {
+			from = late
+		}
+	}
+	return from
+}
+
 // Status returns Event status
 func Status() string {
 	if schedulerStatus != "OK" {
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/deployment"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/log"
 	"github.com/ovh/cds/sdk"
@@ -44,6 +45,12 @@ func addStageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *
 		return
 	}
 
+	if err := checkStageProgressive(stageData, pipelineData); err != nil {
+		log.Warning("addStageHandler> Invalid progressive deployment: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	if err := pipeline.LoadPipelineStage(db, pipelineData); err != nil {
 		log.Warning("addStageHandler> Cannot load pipeline stages: %s", err)
 		WriteError(w, r, err)
@@ -235,6 +242,12 @@ func updateStageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap,
 		return
 	}
 
+	if err := checkStageProgressive(stageData, pipelineData); err != nil {
+		log.Warning("updateStageHandler> Invalid progressive deployment: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	// check if stage exist
 	s, err := pipeline.LoadStage(db, pipelineData.ID, stageData.ID)
 	if err != nil {
@@ -353,3 +366,14 @@ func deleteStageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap,
 
 	WriteJSON(w, r, pipelineData, http.StatusOK)
 }
+
+// checkStageProgressive checks that progressive deployment is only set on valid stages of deployment pipelines
+func checkStageProgressive(s *sdk.Stage, p *sdk.Pipeline) error {
+	if s.Progressive == nil {
+		return nil
+	}
+	if p.Type != sdk.DeploymentPipeline {
+		return sdk.ErrInvalidProgressiveDeployment
+	}
+	return deployment.Validate(s.Progressive)
+}
@@ -43,7 +43,6 @@ func init() {
 		flag.String("sslMode", "disable", "ssl mode")
 
 		log.SetLevel(log.DebugLevel)
-		flag.Parse()
 	}
 }
 
@@ -51,6 +50,10 @@ type bootstrap func(db *sql.DB) error
 
 // SetupPG setup PG DB for test
 func SetupPG(t *testing.T, bootstrapFunc ...bootstrap) *gorp.DbMap {
+	//Flags are parsed by the test binary, after the testing flags are registered
+	if !flag.Parsed() {
+		flag.Parse()
+	}
 	DBDriver = flag.Lookup("dbDriver").Value.String()
 	dbUser = flag.Lookup("dbUser").Value.String()
 	dbPassword = flag.Lookup("dbPassword").Value.String()
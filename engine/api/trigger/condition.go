@@ -0,0 +1,639 @@
+package trigger
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// ConditionContext holds the data a trigger condition is evaluated against
+type ConditionContext struct {
+	Build      sdk.PipelineBuild
+	Parameters []sdk.Parameter
+	Now        time.Time
+	// ChangedFiles returns the paths changed since the previous build, it is only called by changed()
+	ChangedFiles func() ([]string, error)
+	// Tests returns the test results of the build when they are not loaded in Build, it is only called by tests.* variables
+	Tests func() (*sdk.Tests, error)
+}
+
+// Condition is a parsed trigger condition
+type Condition struct {
+	source string
+	root   conditionNode
+}
+
+// conditionFunctions lists the functions usable in conditions with their minimal and maximal number of arguments (-1: no limit)
+var conditionFunctions = map[string][2]int{
+	"glob":     {2, 2},
+	"match":    {2, 2},
+	"contains": {2, 2},
+	"changed":  {1, -1},
+}
+
+// CheckCondition validates the syntax of a trigger condition. An empty condition is valid.
+func CheckCondition(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	_, err := ParseCondition(s)
+	return err
+}
+
+// ParseCondition parses a boolean expression such as:
+//
+//	glob(branch, "release/*") && tests.ko == 0 && (hour >= 8 || weekday == "saturday")
+func ParseCondition(s string) (*Condition, error) {
+	tokens, err := tokenizeCondition(s)
+	if err != nil {
+		return nil, sdk.NewError(sdk.ErrInvalidTriggerCondition, err)
+	}
+	p := &conditionParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, sdk.NewError(sdk.ErrInvalidTriggerCondition, err)
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, sdk.NewError(sdk.ErrInvalidTriggerCondition, fmt.Errorf("unexpected '%s' at position %d", t.value, t.pos))
+	}
+	return &Condition{source: s, root: root}, nil
+}
+
+// String returns the source of the condition
+func (c *Condition) String() string {
+	return c.source
+}
+
+// References returns true if the condition uses the given variable
+func (c *Condition) References(name string) bool {
+	return c.root.references(name)
+}
+
+// Eval evaluates the condition, it returns true if the trigger must run
+func (c *Condition) Eval(ctx ConditionContext) (bool, error) {
+	v, err := c.root.eval(&ctx)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// CheckTriggerCondition evaluates the trigger condition against the ending pipeline build.
+// A trigger without condition only runs after a successful build, as well as a trigger
+// whose condition does not look at the status of the build.
+func CheckTriggerCondition(t sdk.PipelineTrigger, ctx ConditionContext) (bool, error) {
+	if strings.TrimSpace(t.Condition) == "" {
+		return ctx.Build.Status == sdk.StatusSuccess, nil
+	}
+	c, err := ParseCondition(t.Condition)
+	if err != nil {
+		return false, err
+	}
+	if ctx.Build.Status != sdk.StatusSuccess && !c.References("status") {
+		return false, nil
+	}
+	return c.Eval(ctx)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type conditionToken struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+var conditionOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!"}
+
+func tokenizeCondition(s string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, conditionToken{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, conditionToken{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, conditionToken{kind: tokenComma, value: ",", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			var b bytes.Buffer
+			closed := false
+			for i < len(s) {
+				if s[i] == '\\' && i+1 < len(s) {
+					b.WriteByte(s[i+1])
+					i += 2
+					continue
+				}
+				if s[i] == c {
+					closed = true
+					i++
+					break
+				}
+				b.WriteByte(s[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenString, value: b.String(), pos: start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			if _, err := strconv.ParseFloat(s[start:i], 64); err != nil {
+				return nil, fmt.Errorf("invalid number '%s' at position %d", s[start:i], start)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenNumber, value: s[start:i], pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(s) && (isIdentStart(s[i]) || s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: tokenIdent, value: s[start:i], pos: start})
+		default:
+			found := false
+			for _, op := range conditionOperators {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, conditionToken{kind: tokenOperator, value: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+			}
+		}
+	}
+	tokens = append(tokens, conditionToken{kind: tokenEOF, value: "end of condition", pos: len(s)})
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type conditionParser struct {
+	tokens []conditionToken
+	cur    int
+}
+
+func (p *conditionParser) peek() conditionToken {
+	return p.tokens[p.cur]
+}
+
+func (p *conditionParser) next() conditionToken {
+	t := p.tokens[p.cur]
+	if t.kind != tokenEOF {
+		p.cur++
+	}
+	return t
+}
+
+func (p *conditionParser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.value == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *conditionParser) parseOr() (conditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseNot() (conditionNode, error) {
+	if p.isOperator("!") {
+		p.next()
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: n}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("==", "!=", "<", "<=", ">", ">=", "=~", "!~") {
+		return left, nil
+	}
+	op := p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op.value == "=~" || op.value == "!~" {
+		if lit, ok := right.(*literalNode); ok {
+			if _, err := regexp.Compile(toString(lit.value)); err != nil {
+				return nil, fmt.Errorf("invalid regexp at position %d: %s", op.pos, err)
+			}
+		}
+	}
+	return &comparisonNode{op: op.value, left: left, right: right}, nil
+}
+
+func (p *conditionParser) parsePrimary() (conditionNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalNode{value: t.value}, nil
+	case tokenNumber:
+		f, _ := strconv.ParseFloat(t.value, 64)
+		return &literalNode{value: f}, nil
+	case tokenLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at position %d", c.pos)
+		}
+		return n, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		return &variableNode{name: t.value}, nil
+	}
+	return nil, fmt.Errorf("unexpected '%s' at position %d", t.value, t.pos)
+}
+
+func (p *conditionParser) parseCall(name conditionToken) (conditionNode, error) {
+	arity, ok := conditionFunctions[name.value]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s' at position %d", name.value, name.pos)
+	}
+	p.next() // (
+
+	call := &callNode{name: name.value}
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if c := p.next(); c.kind != tokenRParen {
+		return nil, fmt.Errorf("expected ')' at position %d", c.pos)
+	}
+
+	if len(call.args) < arity[0] || (arity[1] >= 0 && len(call.args) > arity[1]) {
+		return nil, fmt.Errorf("wrong number of arguments for %s at position %d", name.value, name.pos)
+	}
+	if call.name == "match" {
+		if lit, ok := call.args[1].(*literalNode); ok {
+			if _, err := regexp.Compile(toString(lit.value)); err != nil {
+				return nil, fmt.Errorf("invalid regexp at position %d: %s", name.pos, err)
+			}
+		}
+	}
+	return call, nil
+}
+
+type conditionNode interface {
+	eval(ctx *ConditionContext) (interface{}, error)
+	references(name string) bool
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(ctx *ConditionContext) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *literalNode) references(name string) bool {
+	return false
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(ctx *ConditionContext) (interface{}, error) {
+	pb := ctx.Build
+	switch n.name {
+	case "status":
+		return pb.Status.String(), nil
+	case "branch":
+		return pb.Trigger.VCSChangesBranch, nil
+	case "hash":
+		return pb.Trigger.VCSChangesHash, nil
+	case "author":
+		return pb.Trigger.VCSChangesAuthor, nil
+	case "application":
+		return pb.Application.Name, nil
+	case "pipeline":
+		return pb.Pipeline.Name, nil
+	case "environment":
+		return pb.Environment.Name, nil
+	case "build_number":
+		return float64(pb.BuildNumber), nil
+	case "version":
+		return float64(pb.Version), nil
+	case "hour":
+		return float64(ctx.Now.Hour()), nil
+	case "minute":
+		return float64(ctx.Now.Minute()), nil
+	case "weekday":
+		return strings.ToLower(ctx.Now.Weekday().String()), nil
+	case "tests.total", "tests.ok", "tests.ko", "tests.skipped":
+		tests := pb.Tests
+		if tests == nil && ctx.Tests != nil {
+			var err error
+			if tests, err = ctx.Tests(); err != nil {
+				return nil, err
+			}
+		}
+		if tests == nil {
+			return float64(0), nil
+		}
+		switch n.name {
+		case "tests.total":
+			return float64(tests.Total), nil
+		case "tests.ok":
+			return float64(tests.TotalOK), nil
+		case "tests.ko":
+			return float64(tests.TotalKO), nil
+		}
+		return float64(tests.TotalSkipped), nil
+	}
+
+	for _, p := range ctx.Parameters {
+		if p.Name == n.name {
+			return p.Value, nil
+		}
+	}
+	for _, p := range pb.Parameters {
+		if p.Name == n.name {
+			return p.Value, nil
+		}
+	}
+	// Unknown parameters are empty
+	return "", nil
+}
+
+func (n *variableNode) references(name string) bool {
+	return n.name == name
+}
+
+type notNode struct {
+	node conditionNode
+}
+
+func (n *notNode) eval(ctx *ConditionContext) (interface{}, error) {
+	v, err := n.node.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+func (n *notNode) references(name string) bool {
+	return n.node.references(name)
+}
+
+type logicalNode struct {
+	op          string
+	left, right conditionNode
+}
+
+func (n *logicalNode) eval(ctx *ConditionContext) (interface{}, error) {
+	l, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" && !truthy(l) {
+		return false, nil
+	}
+	if n.op == "||" && truthy(l) {
+		return true, nil
+	}
+	r, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(r), nil
+}
+
+func (n *logicalNode) references(name string) bool {
+	return n.left.references(name) || n.right.references(name)
+}
+
+type comparisonNode struct {
+	op          string
+	left, right conditionNode
+}
+
+func (n *comparisonNode) eval(ctx *ConditionContext) (interface{}, error) {
+	l, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "=~", "!~":
+		ok, err := regexp.MatchString(toString(r), toString(l))
+		if err != nil {
+			return nil, err
+		}
+		return ok == (n.op == "=~"), nil
+	case "==", "!=":
+		lf, lok := toNumber(l)
+		rf, rok := toNumber(r)
+		equal := toString(l) == toString(r)
+		if lok && rok {
+			equal = lf == rf
+		}
+		return equal == (n.op == "=="), nil
+	}
+
+	lf, lok := toNumber(l)
+	rf, rok := toNumber(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot compare '%s' %s '%s': not numbers", toString(l), n.op, toString(r))
+	}
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	}
+	return lf >= rf, nil
+}
+
+func (n *comparisonNode) references(name string) bool {
+	return n.left.references(name) || n.right.references(name)
+}
+
+type callNode struct {
+	name string
+	args []conditionNode
+}
+
+func (n *callNode) eval(ctx *ConditionContext) (interface{}, error) {
+	args := make([]string, len(n.args))
+	for i := range n.args {
+		v, err := n.args[i].eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = toString(v)
+	}
+
+	switch n.name {
+	case "glob":
+		return sdk.MatchGlob(args[1], args[0]), nil
+	case "match":
+		return regexp.MatchString(args[1], args[0])
+	case "contains":
+		return strings.Contains(args[0], args[1]), nil
+	case "changed":
+		if ctx.ChangedFiles == nil {
+			return nil, fmt.Errorf("changed files are not available")
+		}
+		files, err := ctx.ChangedFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			for _, pattern := range args {
+				if sdk.MatchPath(pattern, f) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+	return nil, fmt.Errorf("unknown function '%s'", n.name)
+}
+
+func (n *callNode) references(name string) bool {
+	if n.name == name {
+		return true
+	}
+	for _, a := range n.args {
+		if a.references(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != "" && t != "false" && t != "0"
+	}
+	return false
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	return ""
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,179 @@
+package trigger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func conditionContext() ConditionContext {
+	pb := sdk.PipelineBuild{
+		BuildNumber: 42,
+		Version:     12,
+		Status:      sdk.StatusSuccess,
+		Parameters: []sdk.Parameter{
+			{Name: "git.branch", Value: "release/1.2"},
+			{Name: "cds.parent.application", Value: "front"},
+		},
+		Tests: &sdk.Tests{Total: 10, TotalOK: 9, TotalKO: 1},
+		Trigger: sdk.PipelineBuildTrigger{
+			VCSChangesBranch: "release/1.2",
+			VCSChangesHash:   "abcdef",
+			VCSChangesAuthor: "jdoe",
+		},
+	}
+	pb.Application.Name = "api"
+	pb.Environment.Name = "production"
+
+	return ConditionContext{
+		Build:      pb,
+		Parameters: []sdk.Parameter{{Name: "target", Value: "eu"}},
+		Now:        time.Date(2017, 3, 4, 14, 30, 0, 0, time.UTC),
+		ChangedFiles: func() ([]string, error) {
+			return []string{"api/main.go", "docs/index.md"}, nil
+		},
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	valids := []string{
+		`status == "Success"`,
+		`glob(branch, "release/*") && tests.ko == 0`,
+		`!(hour < 8 || hour >= 19) && weekday != 'sunday'`,
+		`git.branch =~ "^release/[0-9.]+$"`,
+		`changed("api/**", "lib/**")`,
+		`true`,
+	}
+	for _, s := range valids {
+		_, err := ParseCondition(s)
+		assert.NoError(t, err, s)
+	}
+
+	invalids := []string{
+		`status ==`,
+		`status == "Success`,
+		`(branch == "master"`,
+		`unknown(branch)`,
+		`glob(branch)`,
+		`branch =~ "["`,
+		`match(branch, "(")`,
+		`branch == "master" branch`,
+		`changed()`,
+		`branch = "master"`,
+	}
+	for _, s := range invalids {
+		_, err := ParseCondition(s)
+		assert.Error(t, err, s)
+	}
+
+	assert.NoError(t, CheckCondition(""))
+	assert.Error(t, CheckCondition("&&"))
+}
+
+func TestConditionEval(t *testing.T) {
+	tests := map[string]bool{
+		`status == "Success"`:                               true,
+		`glob(branch, "release/*")`:                         true,
+		`glob(branch, "feature/*")`:                         false,
+		`git.branch =~ "^release/"`:                         true,
+		`git.branch !~ "^release/"`:                         false,
+		`tests.ko == 0`:                                     false,
+		`tests.total >= 10 && tests.ok > 8`:                 true,
+		`hour >= 8 && hour < 19`:                            true,
+		`weekday == "saturday"`:                             true,
+		`build_number == 42 && version == "12"`:             true,
+		`target == "eu" && cds.parent.application != "api"`: true,
+		`unknown.parameter == ""`:                           true,
+		`changed("api/**")`:                                 true,
+		`changed("*.md")`:                                   false,
+		`changed("**/*.md")`:                                true,
+		`contains(author, "doe") || false`:                  true,
+		`!match(environment, "^prod")`:                      false,
+	}
+	for s, expected := range tests {
+		c, err := ParseCondition(s)
+		if !assert.NoError(t, err, s) {
+			continue
+		}
+		ok, err := c.Eval(conditionContext())
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, ok, s)
+	}
+
+	c, err := ParseCondition(`branch > 3`)
+	assert.NoError(t, err)
+	_, err = c.Eval(conditionContext())
+	assert.Error(t, err)
+}
+
+func TestConditionLazyChangedFiles(t *testing.T) {
+	ctx := conditionContext()
+	calls := 0
+	ctx.ChangedFiles = func() ([]string, error) {
+		calls++
+		return nil, fmt.Errorf("not available")
+	}
+
+	c, err := ParseCondition(`branch == "master" && changed("api/**")`)
+	assert.NoError(t, err)
+	ok, err := c.Eval(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0, calls)
+
+	c, err = ParseCondition(`changed("api/**")`)
+	assert.NoError(t, err)
+	_, err = c.Eval(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestConditionLazyTests(t *testing.T) {
+	ctx := conditionContext()
+	ctx.Build.Tests = nil
+	calls := 0
+	ctx.Tests = func() (*sdk.Tests, error) {
+		calls++
+		return &sdk.Tests{Total: 3, TotalKO: 2}, nil
+	}
+
+	c, err := ParseCondition(`branch == "master" && tests.ko == 0`)
+	assert.NoError(t, err)
+	ok, err := c.Eval(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0, calls)
+
+	c, err = ParseCondition(`tests.ko == 2`)
+	assert.NoError(t, err)
+	ok, err = c.Eval(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCheckTriggerCondition(t *testing.T) {
+	ctx := conditionContext()
+	ctx.Build.Status = sdk.StatusFail
+
+	ok, err := CheckTriggerCondition(sdk.PipelineTrigger{}, ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = CheckTriggerCondition(sdk.PipelineTrigger{Condition: `glob(branch, "release/*")`}, ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok, "a condition without status only runs after a success")
+
+	ok, err = CheckTriggerCondition(sdk.PipelineTrigger{Condition: `status == "Fail"`}, ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ctx.Build.Status = sdk.StatusSuccess
+	ok, err = CheckTriggerCondition(sdk.PipelineTrigger{}, ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,428 @@
+package trigger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// DefaultJoinWaitWindow is the time a fan-in trigger waits for all its sources, in seconds
+const DefaultJoinWaitWindow = 3600
+
+// CheckJoin validates a fan-in trigger and sets its default values
+func CheckJoin(j *sdk.TriggerJoin) error {
+	if j.DestEnvironment.ID == 0 {
+		j.DestEnvironment = sdk.DefaultEnv
+	}
+	if j.MatchOn == "" {
+		j.MatchOn = sdk.JoinMatchOnHash
+	}
+	if j.MatchOn != sdk.JoinMatchOnHash && j.MatchOn != sdk.JoinMatchOnVersion {
+		return sdk.NewError(sdk.ErrInvalidTriggerJoin, fmt.Errorf("match_on must be %s or %s", sdk.JoinMatchOnHash, sdk.JoinMatchOnVersion))
+	}
+	if j.WaitWindow == 0 {
+		j.WaitWindow = DefaultJoinWaitWindow
+	}
+	if j.WaitWindow < 0 {
+		return sdk.NewError(sdk.ErrInvalidTriggerJoin, fmt.Errorf("wait_window must be positive"))
+	}
+	if len(j.Sources) < 2 {
+		return sdk.ErrInvalidTriggerJoin
+	}
+
+	for i := range j.Sources {
+		s := &j.Sources[i]
+		if s.Environment.ID == 0 {
+			s.Environment = sdk.DefaultEnv
+		}
+		if s.Application.ID == j.DestApplication.ID && s.Pipeline.ID == j.DestPipeline.ID && s.Environment.ID == j.DestEnvironment.ID {
+			return sdk.ErrInvalidTriggerJoin
+		}
+		for k := 0; k < i; k++ {
+			o := j.Sources[k]
+			if s.Application.ID == o.Application.ID && s.Pipeline.ID == o.Pipeline.ID && s.Environment.ID == o.Environment.ID {
+				return sdk.NewError(sdk.ErrInvalidTriggerJoin, fmt.Errorf("source %s/%s is duplicated", s.Application.Name, s.Pipeline.Name))
+			}
+		}
+	}
+
+	for _, p := range j.Parameters {
+		if string(p.Type) == string(sdk.SecretVariable) {
+			return sdk.ErrNoDirectSecretUse
+		}
+	}
+	return nil
+}
+
+// InsertJoin adds a new fan-in trigger in database
+func InsertJoin(db gorp.SqlExecutor, j *sdk.TriggerJoin) error {
+	if err := CheckJoin(j); err != nil {
+		return err
+	}
+
+	// Check we are not creating an infinite loop from any source
+	for _, s := range j.Sources {
+		t := sdk.PipelineTrigger{
+			SrcApplication:  s.Application,
+			SrcPipeline:     s.Pipeline,
+			SrcEnvironment:  s.Environment,
+			DestApplication: j.DestApplication,
+			DestPipeline:    j.DestPipeline,
+			DestEnvironment: j.DestEnvironment,
+		}
+		if err := isTriggerLoopFree(db, &t, []parent{parent{AppID: s.Application.ID, PipID: s.Pipeline.ID, EnvID: s.Environment.ID}}); err != nil {
+			log.Warning("InsertJoin> Infinite trigger loop found from %s/%s[%s]\n", s.Application.Name, s.Pipeline.Name, s.Environment.Name)
+			return err
+		}
+	}
+
+	params, err := json.Marshal(j.Parameters)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO pipeline_trigger_join (dest_application_id, dest_pipeline_id, dest_environment_id, match_on, wait_window, parameters)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := db.QueryRow(query, j.DestApplication.ID, j.DestPipeline.ID, j.DestEnvironment.ID, j.MatchOn, j.WaitWindow, string(params)).Scan(&j.ID); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO pipeline_trigger_join_source (join_id, application_id, pipeline_id, environment_id) VALUES ($1, $2, $3, $4) RETURNING id`
+	for i := range j.Sources {
+		s := &j.Sources[i]
+		if err := db.QueryRow(query, j.ID, s.Application.ID, s.Pipeline.ID, s.Environment.ID).Scan(&s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteJoin removes a fan-in trigger and its pending builds from database
+func DeleteJoin(db database.Executer, joinID int64) error {
+	if _, err := db.Exec(`DELETE FROM pipeline_trigger_join_arrival WHERE join_id = $1`, joinID); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DELETE FROM pipeline_trigger_join_source WHERE join_id = $1`, joinID); err != nil {
+		return err
+	}
+	_, err := db.Exec(`DELETE FROM pipeline_trigger_join WHERE id = $1`, joinID)
+	return err
+}
+
+// deleteJoins removes the fan-in triggers returned by the given query
+func deleteJoins(db gorp.SqlExecutor, query string, args ...interface{}) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := DeleteJoin(db, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePipelineJoins removes from database all fan-in triggers where given pipeline is present
+func DeletePipelineJoins(db gorp.SqlExecutor, pipelineID int64) error {
+	query := `SELECT id FROM pipeline_trigger_join WHERE dest_pipeline_id = $1
+	UNION SELECT join_id FROM pipeline_trigger_join_source WHERE pipeline_id = $1`
+	return deleteJoins(db, query, pipelineID)
+}
+
+// DeleteApplicationJoins removes from database all fan-in triggers where given application is present
+func DeleteApplicationJoins(db gorp.SqlExecutor, appID int64) error {
+	query := `SELECT id FROM pipeline_trigger_join WHERE dest_application_id = $1
+	UNION SELECT join_id FROM pipeline_trigger_join_source WHERE application_id = $1`
+	return deleteJoins(db, query, appID)
+}
+
+// DeleteApplicationPipelineJoins removes from database all fan-in triggers where given pipeline of an application is present
+func DeleteApplicationPipelineJoins(db gorp.SqlExecutor, proj, app, pip string) error {
+	query := `SELECT pipeline_trigger_join.id FROM pipeline_trigger_join
+	JOIN pipeline ON pipeline.id = dest_pipeline_id
+	JOIN application ON application.id = dest_application_id
+	JOIN project ON project.id = application.project_id
+	WHERE pipeline.name = $1 AND application.name = $2 AND project.projectkey = $3
+	UNION
+	SELECT join_id FROM pipeline_trigger_join_source
+	JOIN pipeline ON pipeline.id = pipeline_id
+	JOIN application ON application.id = application_id
+	JOIN project ON project.id = application.project_id
+	WHERE pipeline.name = $1 AND application.name = $2 AND project.projectkey = $3`
+	return deleteJoins(db, query, pip, app, proj)
+}
+
+const joinQuery = `
+	SELECT pipeline_trigger_join.id,
+	dest_application_id, dest_app.name,
+	dest_pipeline_id, dest_pip.name, dest_pip.type,
+	dest_environment_id, dest_env.name,
+	dest_project.id, dest_project.projectkey, dest_project.name,
+	match_on, wait_window, parameters
+	FROM pipeline_trigger_join
+	JOIN pipeline AS dest_pip ON dest_pip.id = dest_pipeline_id
+	JOIN application AS dest_app ON dest_app.id = dest_application_id
+	JOIN project AS dest_project ON dest_project.id = dest_app.project_id
+	JOIN environment AS dest_env ON dest_env.id = dest_environment_id
+	WHERE %s
+	ORDER BY pipeline_trigger_join.id
+	%s`
+
+// LoadJoin retrieves a fan-in trigger with its sources and pending builds
+func LoadJoin(db gorp.SqlExecutor, joinID int64) (*sdk.TriggerJoin, error) {
+	joins, err := loadJoins(db, "pipeline_trigger_join.id = $1", "", joinID)
+	if err != nil {
+		return nil, err
+	}
+	if len(joins) == 0 {
+		return nil, sdk.ErrNotFound
+	}
+	return &joins[0], nil
+}
+
+// LoadJoinsByDestination retrieves the fan-in triggers of a destination pipeline with their pending builds
+func LoadJoinsByDestination(db gorp.SqlExecutor, appID, pipelineID int64) ([]sdk.TriggerJoin, error) {
+	return loadJoins(db, "dest_application_id = $1 AND dest_pipeline_id = $2", "", appID, pipelineID)
+}
+
+// LoadJoinsByApplication retrieves the fan-in triggers where the given application is the destination or a source
+func LoadJoinsByApplication(db gorp.SqlExecutor, appID int64) ([]sdk.TriggerJoin, error) {
+	clause := `dest_application_id = $1 OR pipeline_trigger_join.id IN (
+		SELECT join_id FROM pipeline_trigger_join_source WHERE application_id = $1
+	)`
+	return loadJoins(db, clause, "", appID)
+}
+
+// LoadJoinsAsSource retrieves and locks the fan-in triggers waiting for the given pipeline
+func LoadJoinsAsSource(db gorp.SqlExecutor, appID, pipelineID, envID int64) ([]sdk.TriggerJoin, error) {
+	clause := `pipeline_trigger_join.id IN (
+		SELECT join_id FROM pipeline_trigger_join_source WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3
+	)`
+	return loadJoins(db, clause, "FOR UPDATE OF pipeline_trigger_join", appID, pipelineID, envID)
+}
+
+func loadJoins(db gorp.SqlExecutor, clause, lock string, args ...interface{}) ([]sdk.TriggerJoin, error) {
+	rows, err := db.Query(fmt.Sprintf(joinQuery, clause, lock), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	joins := []sdk.TriggerJoin{}
+	for rows.Next() {
+		var j sdk.TriggerJoin
+		var destPipType string
+		var params sql.NullString
+		if err := rows.Scan(&j.ID,
+			&j.DestApplication.ID, &j.DestApplication.Name,
+			&j.DestPipeline.ID, &j.DestPipeline.Name, &destPipType,
+			&j.DestEnvironment.ID, &j.DestEnvironment.Name,
+			&j.DestProject.ID, &j.DestProject.Key, &j.DestProject.Name,
+			&j.MatchOn, &j.WaitWindow, &params); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		j.DestPipeline.Type = sdk.PipelineTypeFromString(destPipType)
+		if params.Valid {
+			if err := json.Unmarshal([]byte(params.String), &j.Parameters); err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		joins = append(joins, j)
+	}
+	rows.Close()
+
+	now := time.Now()
+	for i := range joins {
+		joins[i].Sources, err = loadJoinSources(db, joins[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		arrivals, err := LoadJoinArrivals(db, joins[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		joins[i].Pending = PendingJoins(joins[i], arrivals, now)
+	}
+	return joins, nil
+}
+
+func loadJoinSources(db gorp.SqlExecutor, joinID int64) ([]sdk.TriggerJoinSource, error) {
+	query := `SELECT pipeline_trigger_join_source.id,
+	application_id, application.name,
+	pipeline_id, pipeline.name, pipeline.type,
+	environment_id, environment.name
+	FROM pipeline_trigger_join_source
+	JOIN application ON application.id = application_id
+	JOIN pipeline ON pipeline.id = pipeline_id
+	JOIN environment ON environment.id = environment_id
+	WHERE join_id = $1
+	ORDER BY pipeline_trigger_join_source.id`
+	rows, err := db.Query(query, joinID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []sdk.TriggerJoinSource
+	for rows.Next() {
+		var s sdk.TriggerJoinSource
+		var pipType string
+		if err := rows.Scan(&s.ID,
+			&s.Application.ID, &s.Application.Name,
+			&s.Pipeline.ID, &s.Pipeline.Name, &pipType,
+			&s.Environment.ID, &s.Environment.Name); err != nil {
+			return nil, err
+		}
+		s.Pipeline.Type = sdk.PipelineTypeFromString(pipType)
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// LoadJoinArrivals retrieves the successful source builds recorded for a fan-in trigger
+func LoadJoinArrivals(db gorp.SqlExecutor, joinID int64) ([]sdk.TriggerJoinArrival, error) {
+	query := `SELECT source_id, join_key, pipeline_build_id, build_number, version, hash, arrived
+	FROM pipeline_trigger_join_arrival WHERE join_id = $1 ORDER BY arrived`
+	rows, err := db.Query(query, joinID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var arrivals []sdk.TriggerJoinArrival
+	for rows.Next() {
+		var a sdk.TriggerJoinArrival
+		if err := rows.Scan(&a.SourceID, &a.Key, &a.PipelineBuildID, &a.BuildNumber, &a.Version, &a.Hash, &a.Arrived); err != nil {
+			return nil, err
+		}
+		arrivals = append(arrivals, a)
+	}
+	return arrivals, nil
+}
+
+// InsertJoinArrival records the successful build of a source of a fan-in trigger, replacing a previous build on the same key
+func InsertJoinArrival(db gorp.SqlExecutor, joinID int64, a *sdk.TriggerJoinArrival) error {
+	query := `DELETE FROM pipeline_trigger_join_arrival WHERE join_id = $1 AND source_id = $2 AND join_key = $3`
+	if _, err := db.Exec(query, joinID, a.SourceID, a.Key); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO pipeline_trigger_join_arrival (join_id, source_id, join_key, pipeline_build_id, build_number, version, hash, arrived)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := db.Exec(query, joinID, a.SourceID, a.Key, a.PipelineBuildID, a.BuildNumber, a.Version, a.Hash, a.Arrived)
+	return err
+}
+
+// DeleteJoinArrivals removes the recorded source builds of a fan-in trigger for the given key
+func DeleteJoinArrivals(db gorp.SqlExecutor, joinID int64, key string) error {
+	_, err := db.Exec(`DELETE FROM pipeline_trigger_join_arrival WHERE join_id = $1 AND join_key = $2`, joinID, key)
+	return err
+}
+
+// DeleteExpiredJoinArrivals removes the recorded source builds whose wait window is over
+func DeleteExpiredJoinArrivals(db gorp.SqlExecutor, j sdk.TriggerJoin, now time.Time) error {
+	query := `DELETE FROM pipeline_trigger_join_arrival WHERE join_id = $1 AND join_key IN (
+		SELECT join_key FROM pipeline_trigger_join_arrival WHERE join_id = $1 GROUP BY join_key HAVING min(arrived) < $2
+	)`
+	_, err := db.Exec(query, j.ID, now.Add(-time.Duration(j.WaitWindow)*time.Second))
+	return err
+}
+
+// JoinSource returns the source of the fan-in trigger matching the given pipeline build
+func JoinSource(j sdk.TriggerJoin, pb sdk.PipelineBuild) *sdk.TriggerJoinSource {
+	for i := range j.Sources {
+		s := &j.Sources[i]
+		if s.Application.ID == pb.Application.ID && s.Pipeline.ID == pb.Pipeline.ID && s.Environment.ID == pb.Environment.ID {
+			return s
+		}
+	}
+	return nil
+}
+
+// JoinKey returns the value source builds of a fan-in trigger must share: their git hash or their version
+func JoinKey(j sdk.TriggerJoin, pb sdk.PipelineBuild) string {
+	if j.MatchOn == sdk.JoinMatchOnVersion {
+		return fmt.Sprintf("%d", pb.Version)
+	}
+	return pb.Trigger.VCSChangesHash
+}
+
+// PendingJoins groups the recorded source builds of a fan-in trigger by key, ignoring expired ones
+func PendingJoins(j sdk.TriggerJoin, arrivals []sdk.TriggerJoinArrival, now time.Time) []sdk.TriggerJoinPending {
+	window := time.Duration(j.WaitWindow) * time.Second
+	byKey := map[string]*sdk.TriggerJoinPending{}
+	var keys []string
+
+	for _, a := range arrivals {
+		p, ok := byKey[a.Key]
+		if !ok {
+			p = &sdk.TriggerJoinPending{Key: a.Key, Started: a.Arrived}
+			byKey[a.Key] = p
+			keys = append(keys, a.Key)
+		}
+		if a.Arrived.Before(p.Started) {
+			p.Started = a.Arrived
+		}
+
+		replaced := false
+		for i := range p.Arrived {
+			if p.Arrived[i].SourceID == a.SourceID {
+				if a.Arrived.After(p.Arrived[i].Arrived) {
+					p.Arrived[i] = a
+				}
+				replaced = true
+			}
+		}
+		if !replaced {
+			p.Arrived = append(p.Arrived, a)
+		}
+	}
+
+	pending := []sdk.TriggerJoinPending{}
+	for _, k := range keys {
+		p := byKey[k]
+		p.Expires = p.Started.Add(window)
+		if p.Expires.Before(now) {
+			continue
+		}
+		for _, s := range j.Sources {
+			found := false
+			for _, a := range p.Arrived {
+				if a.SourceID == s.ID {
+					found = true
+					break
+				}
+			}
+			if !found {
+				p.Missing = append(p.Missing, s)
+			}
+		}
+		pending = append(pending, *p)
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Started.Before(pending[j].Started)
+	})
+	return pending
+}
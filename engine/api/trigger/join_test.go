@@ -0,0 +1,115 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func joinSource(id, appID, pipID int64) sdk.TriggerJoinSource {
+	s := sdk.TriggerJoinSource{ID: id}
+	s.Application.ID = appID
+	s.Pipeline.ID = pipID
+	return s
+}
+
+func testJoin() sdk.TriggerJoin {
+	j := sdk.TriggerJoin{
+		ID:      1,
+		Sources: []sdk.TriggerJoinSource{joinSource(10, 1, 1), joinSource(11, 2, 1), joinSource(12, 3, 2)},
+	}
+	j.DestApplication.ID = 4
+	j.DestPipeline.ID = 3
+	return j
+}
+
+func TestCheckJoin(t *testing.T) {
+	j := testJoin()
+	assert.NoError(t, CheckJoin(&j))
+	assert.Equal(t, sdk.JoinMatchOnHash, j.MatchOn)
+	assert.Equal(t, int64(DefaultJoinWaitWindow), j.WaitWindow)
+	assert.Equal(t, sdk.DefaultEnv.ID, j.DestEnvironment.ID)
+	assert.Equal(t, sdk.DefaultEnv.ID, j.Sources[0].Environment.ID)
+
+	j = testJoin()
+	j.Sources = j.Sources[:1]
+	assert.Error(t, CheckJoin(&j), "a single source")
+
+	j = testJoin()
+	j.Sources[2] = joinSource(12, 1, 1)
+	assert.Error(t, CheckJoin(&j), "duplicated source")
+
+	j = testJoin()
+	j.Sources[2] = joinSource(12, 4, 3)
+	assert.Error(t, CheckJoin(&j), "destination as source")
+
+	j = testJoin()
+	j.MatchOn = "branch"
+	assert.Error(t, CheckJoin(&j))
+
+	j = testJoin()
+	j.WaitWindow = -1
+	assert.Error(t, CheckJoin(&j))
+}
+
+func TestJoinKey(t *testing.T) {
+	j := testJoin()
+	pb := sdk.PipelineBuild{Version: 12}
+	pb.Trigger.VCSChangesHash = "abcdef"
+
+	j.MatchOn = sdk.JoinMatchOnHash
+	assert.Equal(t, "abcdef", JoinKey(j, pb))
+	j.MatchOn = sdk.JoinMatchOnVersion
+	assert.Equal(t, "12", JoinKey(j, pb))
+
+	pb.Application.ID = 2
+	pb.Pipeline.ID = 1
+	pb.Environment = sdk.DefaultEnv
+	assert.NoError(t, CheckJoin(&j))
+	s := JoinSource(j, pb)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, int64(11), s.ID)
+	}
+	pb.Pipeline.ID = 2
+	assert.Nil(t, JoinSource(j, pb))
+}
+
+func TestPendingJoins(t *testing.T) {
+	j := testJoin()
+	j.WaitWindow = 600
+	now := time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	arrivals := []sdk.TriggerJoinArrival{
+		// expired
+		{SourceID: 10, Key: "aaa", BuildNumber: 1, Arrived: now.Add(-20 * time.Minute)},
+		// complete
+		{SourceID: 10, Key: "bbb", BuildNumber: 2, Arrived: now.Add(-5 * time.Minute)},
+		{SourceID: 11, Key: "bbb", BuildNumber: 3, Arrived: now.Add(-4 * time.Minute)},
+		{SourceID: 11, Key: "bbb", BuildNumber: 4, Arrived: now.Add(-3 * time.Minute)},
+		{SourceID: 12, Key: "bbb", BuildNumber: 5, Arrived: now.Add(-2 * time.Minute)},
+		// waiting
+		{SourceID: 12, Key: "ccc", BuildNumber: 6, Arrived: now.Add(-1 * time.Minute)},
+	}
+
+	pending := PendingJoins(j, arrivals, now)
+	if !assert.Len(t, pending, 2) {
+		return
+	}
+
+	assert.Equal(t, "bbb", pending[0].Key)
+	assert.Len(t, pending[0].Arrived, 3)
+	assert.Len(t, pending[0].Missing, 0)
+	assert.Equal(t, now.Add(-5*time.Minute), pending[0].Started)
+	assert.Equal(t, now.Add(5*time.Minute), pending[0].Expires)
+	assert.Equal(t, int64(4), pending[0].Arrived[1].BuildNumber, "the last build of a source is kept")
+
+	assert.Equal(t, "ccc", pending[1].Key)
+	assert.Len(t, pending[1].Arrived, 1)
+	if assert.Len(t, pending[1].Missing, 2) {
+		assert.Equal(t, int64(10), pending[1].Missing[0].ID)
+		assert.Equal(t, int64(11), pending[1].Missing[1].ID)
+	}
+}
@@ -2,6 +2,7 @@ package trigger
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -27,7 +28,7 @@ func InsertTriggerParameter(db database.Executer, triggerID int64, p sdk.Paramet
 // InsertTrigger adds a new trigger in database
 func InsertTrigger(tx gorp.SqlExecutor, t *sdk.PipelineTrigger) error {
 	query := `INSERT INTO pipeline_trigger (src_application_id, src_pipeline_id, src_environment_id,
-	dest_application_id, dest_pipeline_id, dest_environment_id, manual) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	dest_application_id, dest_pipeline_id, dest_environment_id, manual, approval, condition) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
 
 	var srcEnvID sql.NullInt64
 	if t.SrcEnvironment.ID != 0 {
@@ -57,9 +58,19 @@ func InsertTrigger(tx gorp.SqlExecutor, t *sdk.PipelineTrigger) error {
 		return err
 	}
 
+	approval, err := marshalApprovalGate(t.Approval)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckCondition(t.Condition); err != nil {
+		log.Warning("InsertTrigger> Invalid condition '%s': %s\n", t.Condition, err)
+		return err
+	}
+
 	// Insert trigger
 	err = tx.QueryRow(query, t.SrcApplication.ID, t.SrcPipeline.ID, srcEnvID,
-		t.DestApplication.ID, t.DestPipeline.ID, dstEnvID, t.Manual).Scan(&t.ID)
+		t.DestApplication.ID, t.DestPipeline.ID, dstEnvID, t.Manual, approval, t.Condition).Scan(&t.ID)
 	if err != nil {
 		return err
 	}
@@ -162,13 +173,23 @@ func UpdateTrigger(db gorp.SqlExecutor, t *sdk.PipelineTrigger) error {
 		return err
 	}
 
+	approval, err := marshalApprovalGate(t.Approval)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckCondition(t.Condition); err != nil {
+		log.Warning("UpdateTrigger> Invalid condition '%s': %s\n", t.Condition, err)
+		return err
+	}
+
 	// Update trigger
 	query := `UPDATE pipeline_trigger SET 
 	src_application_id = $1, src_pipeline_id = $2, src_environment_id = $3,
 	dest_application_id = $4, dest_pipeline_id = $5, dest_environment_id = $6,
-	manual = $7
-	WHERE id = $8`
-	if _, err := db.Exec(query, t.SrcApplication.ID, t.SrcPipeline.ID, srcEnvID, t.DestApplication.ID, t.DestPipeline.ID, destEnvID, t.Manual, t.ID); err != nil {
+	manual = $7, approval = $8, condition = $9
+	WHERE id = $10`
+	if _, err := db.Exec(query, t.SrcApplication.ID, t.SrcPipeline.ID, srcEnvID, t.DestApplication.ID, t.DestPipeline.ID, destEnvID, t.Manual, approval, t.Condition, t.ID); err != nil {
 		return err
 	}
 
@@ -210,7 +231,7 @@ func LoadTriggersAsSource(db gorp.SqlExecutor, appID, pipelineID, envID int64) (
 	dest_pipeline_id, dest_pip.name, dest_pip.type,
 	dest_environment_id, dest_env.name,
 	dest_project.id, dest_project.projectkey, dest_project.name,
-	manual
+	manual, pipeline_trigger.approval, pipeline_trigger.condition
 	FROM pipeline_trigger
 	JOIN pipeline as src_pip ON src_pip.id = src_pipeline_id
 	JOIN application AS src_app ON src_app.id = src_application_id
@@ -276,7 +297,7 @@ func LoadAutomaticTriggersAsSource(db gorp.SqlExecutor, appID, pipelineID, envID
 	dest_pipeline_id, dest_pip.name, dest_pip.type,
 	dest_environment_id, dest_env.name,
 	dest_project.id, dest_project.projectkey, dest_project.name,
-	manual
+	manual, pipeline_trigger.approval, pipeline_trigger.condition
 	FROM pipeline_trigger
 	JOIN pipeline as src_pip ON src_pip.id = src_pipeline_id
 	JOIN application AS src_app ON src_app.id = src_application_id
@@ -341,7 +362,7 @@ func LoadTriggersByAppAndPipeline(db gorp.SqlExecutor, appID int64, pipID int64)
 	dest_pipeline_id, dest_pip.name, dest_pip.type,
 	dest_environment_id, dest_env.name,
 	dest_project.id, dest_project.projectkey, dest_project.name,
-	manual
+	manual, pipeline_trigger.approval, pipeline_trigger.condition
 	FROM pipeline_trigger
 	JOIN pipeline as src_pip ON src_pip.id = src_pipeline_id
 	JOIN application AS src_app ON src_app.id = src_application_id
@@ -395,7 +416,7 @@ func LoadTriggerByApp(db gorp.SqlExecutor, appID int64) ([]sdk.PipelineTrigger,
 	dest_pipeline_id, dest_pip.name, dest_pip.type,
 	dest_environment_id, dest_env.name,
 	dest_project.id, dest_project.projectkey, dest_project.name,
-	manual
+	manual, pipeline_trigger.approval, pipeline_trigger.condition
 	FROM pipeline_trigger
 	JOIN pipeline as src_pip ON src_pip.id = src_pipeline_id
 	JOIN application AS src_app ON src_app.id = src_application_id
@@ -449,7 +470,7 @@ func LoadTrigger(db gorp.SqlExecutor, triggerID int64) (*sdk.PipelineTrigger, er
 	dest_pipeline_id, dest_pip.name, dest_pip.type,
 	dest_environment_id, dest_env.name,
 	dest_project.id, dest_project.projectkey, dest_project.name,
-	manual
+	manual, pipeline_trigger.approval, pipeline_trigger.condition
 	FROM pipeline_trigger
 	JOIN pipeline as src_pip ON src_pip.id = src_pipeline_id
 	JOIN application AS src_app ON src_app.id = src_application_id
@@ -490,7 +511,7 @@ func LoadTriggers(db gorp.SqlExecutor, appID, pipelineID, envID int64) ([]sdk.Pi
 	dest_pipeline_id, dest_pip.name, dest_pip.type,
 	dest_environment_id, dest_env.name,
 	dest_project.id, dest_project.projectkey, dest_project.name,
-	manual
+	manual, pipeline_trigger.approval, pipeline_trigger.condition
 	FROM pipeline_trigger
 	JOIN pipeline as src_pip ON src_pip.id = src_pipeline_id
 	JOIN application AS src_app ON src_app.id = src_application_id
@@ -533,7 +554,7 @@ func LoadTriggers(db gorp.SqlExecutor, appID, pipelineID, envID int64) ([]sdk.Pi
 
 func loadTrigger(db gorp.SqlExecutor, s database.Scanner, subqueries bool) (sdk.PipelineTrigger, error) {
 	var t sdk.PipelineTrigger
-	var srcEnvName, destEnvName sql.NullString
+	var srcEnvName, destEnvName, approval, condition sql.NullString
 	var srcEnvID, destEnvID sql.NullInt64
 
 	var srcPipType, destPipType string
@@ -546,12 +567,26 @@ func loadTrigger(db gorp.SqlExecutor, s database.Scanner, subqueries bool) (sdk.
 		&t.DestPipeline.ID, &t.DestPipeline.Name, &destPipType,
 		&destEnvID, &destEnvName,
 		&t.DestProject.ID, &t.DestProject.Key, &t.DestProject.Name,
-		&t.Manual,
+		&t.Manual, &approval, &condition,
 	)
 	if err != nil {
 		return t, err
 	}
 
+	if approval.Valid && approval.String != "" {
+		var g sdk.ApprovalGate
+		if err := json.Unmarshal([]byte(approval.String), &g); err != nil {
+			return t, err
+		}
+		if len(g.Groups) > 0 {
+			t.Approval = &g
+		}
+	}
+
+	if condition.Valid {
+		t.Condition = condition.String
+	}
+
 	t.SrcPipeline.Type = sdk.PipelineTypeFromString(srcPipType)
 	t.DestPipeline.Type = sdk.PipelineTypeFromString(destPipType)
 	// Handle nullable envirnoments
@@ -690,7 +725,8 @@ func DeleteApplicationPipelineTriggers(db gorp.SqlExecutor, proj, app, pip strin
 		return err
 	}
 
-	return nil
+	// Delete fan-in triggers
+	return DeleteApplicationPipelineJoins(db, proj, app, pip)
 }
 
 // DeletePipelineTriggers removes from database all triggers where given pipeline is present
@@ -721,7 +757,8 @@ func DeletePipelineTriggers(db gorp.SqlExecutor, pipelineID int64) error {
 		return err
 	}
 
-	return nil
+	// Delete fan-in triggers
+	return DeletePipelineJoins(db, pipelineID)
 }
 
 // DeleteApplicationTriggers removes from database all triggers where given application is present
@@ -752,7 +789,8 @@ func DeleteApplicationTriggers(db gorp.SqlExecutor, appID int64) error {
 		return err
 	}
 
-	return nil
+	// Delete fan-in triggers
+	return DeleteApplicationJoins(db, appID)
 }
 
 // DeleteTrigger removes from database given trigger
@@ -902,3 +940,15 @@ func Exists(db gorp.SqlExecutor, applicationSource, pipelineSource, EnvSource, a
 	}
 	return n == 1, nil
 }
+
+// marshalApprovalGate returns the JSON value of an approval gate, or NULL when there is no gate
+func marshalApprovalGate(g *sdk.ApprovalGate) (interface{}, error) {
+	if g == nil || len(g.Groups) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
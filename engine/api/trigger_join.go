@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/trigger"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// loadJoinSource loads the ids of a fan-in trigger source and checks the user can trigger from it
+func loadJoinSource(db gorp.SqlExecutor, projectKey string, s *sdk.TriggerJoinSource, u *sdk.User) error {
+	if s.Application.ID == 0 {
+		a, err := application.LoadApplicationByName(db, projectKey, s.Application.Name)
+		if err != nil {
+			return err
+		}
+		s.Application.ID = a.ID
+	}
+	if !permission.AccessToApplication(s.Application.ID, u, permission.PermissionReadWriteExecute) {
+		return sdk.ErrForbidden
+	}
+
+	if s.Pipeline.ID == 0 {
+		p, err := pipeline.LoadPipeline(db, projectKey, s.Pipeline.Name, false)
+		if err != nil {
+			return err
+		}
+		s.Pipeline.ID = p.ID
+	}
+	if !permission.AccessToPipeline(sdk.DefaultEnv.ID, s.Pipeline.ID, u, permission.PermissionReadWriteExecute) {
+		return sdk.ErrForbidden
+	}
+
+	if s.Environment.ID == 0 && s.Environment.Name != "" && s.Environment.Name != sdk.DefaultEnv.Name {
+		e, err := environment.LoadEnvironmentByName(db, projectKey, s.Environment.Name)
+		if err != nil {
+			return err
+		}
+		s.Environment.ID = e.ID
+	} else if s.Environment.ID == 0 {
+		s.Environment = sdk.DefaultEnv
+	}
+	if s.Environment.ID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(s.Environment.ID, u, permission.PermissionReadWriteExecute) {
+		return sdk.ErrForbidden
+	}
+	return nil
+}
+
+func getTriggerJoinsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getTriggerJoinsHandler> Cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	pip, err := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
+	if err != nil {
+		log.Warning("getTriggerJoinsHandler> Cannot load pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	joins, err := trigger.LoadJoinsByDestination(db, app.ID, pip.ID)
+	if err != nil {
+		log.Warning("getTriggerJoinsHandler> Cannot load fan-in triggers: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, joins, http.StatusOK)
+}
+
+func addTriggerJoinHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("addTriggerJoinHandler> cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var j sdk.TriggerJoin
+	if err := json.Unmarshal(data, &j); err != nil {
+		log.Warning("addTriggerJoinHandler> cannot unmarshal body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	// The destination is given by the route
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("addTriggerJoinHandler> cannot load application %s: %s\n", appName, err)
+		WriteError(w, r, err)
+		return
+	}
+	j.DestApplication = *app
+
+	pip, err := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
+	if err != nil {
+		log.Warning("addTriggerJoinHandler> cannot load pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+	j.DestPipeline = *pip
+
+	if j.DestEnvironment.Name != "" && j.DestEnvironment.Name != sdk.DefaultEnv.Name {
+		e, err := environment.LoadEnvironmentByName(db, projectKey, j.DestEnvironment.Name)
+		if err != nil {
+			log.Warning("addTriggerJoinHandler> cannot load dst environment: %s\n", err)
+			WriteError(w, r, err)
+			return
+		}
+		j.DestEnvironment = *e
+	} else {
+		j.DestEnvironment = sdk.DefaultEnv
+	}
+	if j.DestEnvironment.ID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(j.DestEnvironment.ID, c.User, permission.PermissionReadWriteExecute) {
+		log.Warning("addTriggerJoinHandler> No enought right on this environment %s\n", j.DestEnvironment.Name)
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	for i := range j.Sources {
+		if err := loadJoinSource(db, projectKey, &j.Sources[i], c.User); err != nil {
+			log.Warning("addTriggerJoinHandler> cannot load source %s/%s: %s\n", j.Sources[i].Application.Name, j.Sources[i].Pipeline.Name, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := trigger.InsertJoin(tx, &j); err != nil {
+		log.Warning("addTriggerJoinHandler> cannot insert fan-in trigger: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := application.UpdateLastModified(tx, app); err != nil {
+		log.Warning("addTriggerJoinHandler> cannot update last modified date on application: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, j, http.StatusCreated)
+}
+
+func deleteTriggerJoinHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	joinID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Warning("deleteTriggerJoinHandler> invalid id (%s)\n", err)
+		WriteError(w, r, sdk.ErrInvalidID)
+		return
+	}
+
+	j, err := trigger.LoadJoin(db, joinID)
+	if err != nil {
+		log.Warning("deleteTriggerJoinHandler> Cannot load fan-in trigger %d: %s\n", joinID, err)
+		WriteError(w, r, err)
+		return
+	}
+	if j.DestProject.Key != projectKey || j.DestApplication.Name != appName || j.DestPipeline.Name != pipelineName {
+		WriteError(w, r, sdk.ErrNotFound)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := trigger.DeleteJoin(tx, joinID); err != nil {
+		log.Warning("deleteTriggerJoinHandler> cannot delete fan-in trigger: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := application.UpdateLastModified(tx, &j.DestApplication); err != nil {
+		log.Warning("deleteTriggerJoinHandler> cannot update last modified date on application: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
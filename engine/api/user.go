@@ -174,8 +174,8 @@ func GetUsers(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context
 
 // AddUser creates a new user and generate verification email
 func AddUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
-	//returns forbidden if LDAP mode is activated
-	if _, ldap := router.authDriver.(*auth.LDAPClient); ldap {
+	//returns forbidden if LDAP or OIDC mode is activated
+	if _, local := router.authDriver.(*auth.LocalClient); !local {
 		WriteError(w, r, sdk.ErrForbidden)
 		return
 	}
@@ -266,8 +266,8 @@ func AddUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.
 
 // ResetUser deletes auth secret, generates new ones and send them via email
 func ResetUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
-	//returns forbidden if LDAP mode is activated
-	if _, ldap := router.authDriver.(*auth.LDAPClient); ldap {
+	//returns forbidden if LDAP or OIDC mode is activated
+	if _, local := router.authDriver.(*auth.LocalClient); !local {
 		WriteError(w, r, sdk.ErrForbidden)
 		return
 	}
@@ -324,11 +324,14 @@ func ResetUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *contex
 	WriteJSON(w, r, userDb, http.StatusCreated)
 }
 
-//AuthModeHandler returns the auth mode : local ok ldap
+//AuthModeHandler returns the auth mode : local, ldap or oidc
 func AuthModeHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
 	mode := "local"
-	if _, ldap := router.authDriver.(*auth.LDAPClient); ldap {
+	switch router.authDriver.(type) {
+	case *auth.LDAPClient:
 		mode = "ldap"
+	case *auth.OIDCClient:
+		mode = "oidc"
 	}
 	res := map[string]string{
 		"auth_mode": mode,
@@ -338,8 +341,8 @@ func AuthModeHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *
 
 // ConfirmUser verify token send via email and mark user as verified
 func ConfirmUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
-	//returns forbidden if LDAP mode is activated
-	if _, ldap := router.authDriver.(*auth.LDAPClient); ldap {
+	//returns forbidden if LDAP or OIDC mode is activated
+	if _, local := router.authDriver.(*auth.LocalClient); !local {
 		WriteError(w, r, sdk.ErrForbidden)
 		return
 	}
@@ -0,0 +1,119 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// GenerateAccessToken returns a new personal access token and the hash stored in database
+func GenerateAccessToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := "cds_" + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAccessToken(token), nil
+}
+
+// HashAccessToken returns the hash of a personal access token, only hashes are stored
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// InsertAccessToken stores a new personal access token of a user
+func InsertAccessToken(db gorp.SqlExecutor, userID int64, t *sdk.AccessToken, hash string) error {
+	projects, err := json.Marshal(t.Projects)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO user_access_token (user_id, name, hash, scope, projects, created, expire)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	return db.QueryRow(query, userID, t.Name, hash, t.Scope, string(projects), t.Created, t.Expire).Scan(&t.ID)
+}
+
+const accessTokenColumns = `id, name, scope, projects, created, expire, last_used, revoked`
+
+func scanAccessToken(scan func(...interface{}) error, extra ...interface{}) (sdk.AccessToken, error) {
+	var t sdk.AccessToken
+	var projects sql.NullString
+	var expire, lastUsed, revoked *time.Time
+	dest := append([]interface{}{&t.ID, &t.Name, &t.Scope, &projects, &t.Created, &expire, &lastUsed, &revoked}, extra...)
+	if err := scan(dest...); err != nil {
+		return t, err
+	}
+	t.Expire, t.LastUsed, t.Revoked = expire, lastUsed, revoked
+	if projects.Valid && projects.String != "" {
+		if err := json.Unmarshal([]byte(projects.String), &t.Projects); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// LoadAccessTokens loads the personal access tokens of a user, including the revoked and expired ones
+func LoadAccessTokens(db gorp.SqlExecutor, userID int64) ([]sdk.AccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM user_access_token WHERE user_id = $1 ORDER BY id DESC`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []sdk.AccessToken{}
+	for rows.Next() {
+		t, err := scanAccessToken(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// LoadAccessTokenByHash loads a personal access token and the ID of its user
+func LoadAccessTokenByHash(db gorp.SqlExecutor, hash string) (*sdk.AccessToken, int64, error) {
+	query := `SELECT ` + accessTokenColumns + `, user_id FROM user_access_token WHERE hash = $1`
+	var userID int64
+	t, err := scanAccessToken(db.QueryRow(query, hash).Scan, &userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, sdk.ErrUnauthorized
+		}
+		return nil, 0, err
+	}
+	return &t, userID, nil
+}
+
+// UpdateAccessTokenLastUsed records the last time a personal access token has been used
+func UpdateAccessTokenLastUsed(db gorp.SqlExecutor, id int64, lastUsed time.Time) error {
+	_, err := db.Exec(`UPDATE user_access_token SET last_used = $2 WHERE id = $1`, id, lastUsed)
+	return err
+}
+
+// RevokeAccessToken revokes a personal access token of a user
+func RevokeAccessToken(db gorp.SqlExecutor, userID, id int64) error {
+	query := `UPDATE user_access_token SET revoked = current_timestamp WHERE id = $1 AND user_id = $2 AND revoked IS NULL`
+	res, err := db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sdk.ErrNotFound
+	}
+	return nil
+}
@@ -140,6 +140,24 @@ func LoadUserAndAuth(db gorp.SqlExecutor, name string) (*sdk.User, error) {
 	return u, nil
 }
 
+// LoadUserAndAuthByExternalID Load user with auth information from the id given by an external identity provider
+func LoadUserAndAuthByExternalID(db gorp.SqlExecutor, externalID string) (*sdk.User, error) {
+	query := `SELECT username FROM "user" WHERE external_id = $1`
+
+	var username string
+	if err := db.QueryRow(query, externalID).Scan(&username); err != nil {
+		return nil, err
+	}
+	return LoadUserAndAuth(db, username)
+}
+
+// UpdateUserExternalID links the user to its id on an external identity provider
+func UpdateUserExternalID(db gorp.SqlExecutor, userID int64, externalID string) error {
+	query := `UPDATE "user" SET external_id=$1 WHERE id=$2`
+	_, err := db.Exec(query, externalID, userID)
+	return err
+}
+
 // FindUserIDByName retrieves only user ID in database
 func FindUserIDByName(db gorp.SqlExecutor, name string) (int64, error) {
 	query := `SELECT id FROM "user" WHERE username = $1`
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/hook"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func receiveGithubHookHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	receiveWebhook(w, r, db, hook.WebhookGithub, r.Header.Get("X-GitHub-Event"), r.Header.Get("X-GitHub-Delivery"))
+}
+
+func receiveBitbucketHookHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	receiveWebhook(w, r, db, hook.WebhookBitbucket, r.Header.Get("X-Event-Key"), r.Header.Get("X-Request-Id"))
+}
+
+func receiveGitlabHookHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *context.Context) {
+	receiveWebhook(w, r, db, hook.WebhookGitlab, r.Header.Get("X-Gitlab-Event"), r.Header.Get("X-Gitlab-Event-UUID"))
+}
+
+// receiveWebhook checks the signature of a native webhook, ignores deliveries already received,
+// and processes its push, tag and pull request events as the stash hooks
+func receiveWebhook(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, provider, event, deliveryID string) {
+	uid := mux.Vars(r)["uid"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if db == nil {
+		WriteError(w, r, sdk.ErrServiceUnavailable)
+		return
+	}
+
+	h, err := hook.LoadHookByUID(db, uid)
+	if err != nil {
+		log.Warning("receiveWebhook> Cannot load hook %s: %s\n", uid, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	//Gitlab sends the secret token instead of a signature
+	var errCheck error
+	if provider == hook.WebhookGitlab {
+		errCheck = hook.CheckToken(h.Secret, r.Header.Get("X-Gitlab-Token"))
+	} else {
+		signature := r.Header.Get("X-Hub-Signature-256")
+		if signature == "" {
+			signature = r.Header.Get("X-Hub-Signature")
+		}
+		errCheck = hook.CheckSignature(h.Secret, data, signature)
+	}
+	if errCheck != nil {
+		log.Warning("receiveWebhook> Invalid %s signature for hook %d on %s/%s\n", provider, h.ID, h.Project, h.Repository)
+		WriteError(w, r, errCheck)
+		return
+	}
+
+	if !h.Enabled {
+		WriteJSON(w, r, map[string]string{"status": "disabled"}, http.StatusOK)
+		return
+	}
+
+	events, err := hook.ParseWebhookEvent(provider, event, data)
+	if err != nil {
+		log.Warning("receiveWebhook> Cannot parse %s %s event: %s\n", provider, event, err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	for _, e := range events {
+		if !strings.EqualFold(e.Project, h.Project) || !strings.EqualFold(e.Repository, h.Repository) {
+			log.Warning("receiveWebhook> Event on %s/%s received by hook %d on %s/%s\n", e.Project, e.Repository, h.ID, h.Project, h.Repository)
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+	}
+
+	if deliveryID != "" {
+		first, err := hook.InsertDelivery(db, h.ID, deliveryID, event)
+		if err != nil {
+			log.Warning("receiveWebhook> Cannot insert delivery %s: %s\n", deliveryID, err)
+			WriteError(w, r, err)
+			return
+		}
+		if !first {
+			log.Notice("receiveWebhook> Delivery %s already received for hook %d\n", deliveryID, h.ID)
+			WriteJSON(w, r, map[string]string{"status": "duplicate"}, http.StatusOK)
+			return
+		}
+	}
+
+	//Once the delivery is recorded, a retry of the provider is a duplicate: every event is processed,
+	//failures are recovered and kept in the hook deliveries to be replayed
+	var errProcess error
+	for _, e := range events {
+		rh, ok := receivedHookFromEvent(r, data, h, e)
+		if !ok {
+			continue
+		}
+		if err := processHook(rh); err != nil {
+			log.Warning("receiveWebhook> Cannot process %s event on %s for hook %d: %s\n", event, e.Branch, h.ID, err)
+			hook.Recovery(rh, err)
+			if errProcess == nil {
+				errProcess = err
+			}
+		}
+	}
+
+	if errProcess != nil {
+		WriteError(w, r, errProcess)
+		return
+	}
+	WriteJSON(w, r, map[string]string{"status": "processed"}, http.StatusOK)
+}
+
+// receivedHookFromEvent returns the hook to process for a webhook event, false if the event does not trigger anything
+func receivedHookFromEvent(r *http.Request, data []byte, h sdk.Hook, e hook.WebhookEvent) (hook.ReceivedHook, bool) {
+	rh := hook.ReceivedHook{
+		URL:        *r.URL,
+		Data:       data,
+		ProjectKey: h.Project,
+		Repository: h.Repository,
+		Branch:     e.Branch,
+		Hash:       e.Hash,
+		Author:     e.Author,
+		Message:    e.Change,
+		UID:        h.UID,
+	}
+
+	switch e.Type {
+	case hook.WebhookTag:
+		//Deleting a tag does not remove any build
+		if e.Change == "DELETE" {
+			return rh, false
+		}
+		rh.Args = append(rh.Args, sdk.Parameter{Name: "git.tag", Type: sdk.StringParameter, Value: e.Tag})
+	case hook.WebhookPullRequest:
+		//Branches of forks cannot be cloned from the repository
+		if e.PullRequest.Fork {
+			log.Notice("receiveWebhook> Skipping pull request %d from a fork on %s/%s\n", e.PullRequest.ID, h.Project, h.Repository)
+			return rh, false
+		}
+		rh.Args = append(rh.Args, e.PullRequest.Args()...)
+	}
+	return rh, true
+}
@@ -76,6 +76,119 @@ Parse given file to extract Unit Test results.`
 		return err
 	}
 
+	// ----------------------------------- Git Clone ---------------------------
+	gitclone := sdk.NewAction(sdk.GitCloneAction)
+	gitclone.Type = sdk.BuiltinAction
+	gitclone.Description = `CDS Builtin Action.
+Clone the repository attached to the application.
+SSH url are cloned with the ssh key of the
+project, application or environment, http url
+can use user and password.
+
+After the clone, git.hash, git.branch, git.author
+and git.message are exported as build variables.
+
+On pull request builds, the target branch is
+merged to build the result of the merge.`
+	gitclone.Requirement("git", sdk.BinaryRequirement, "git")
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "url",
+		Value:       "{{.git.url}}",
+		Description: "Git clone URL (default: url of the repository attached to the application)",
+		Type:        sdk.StringParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "branch",
+		Value:       "{{.git.branch}}",
+		Description: "Branch to checkout",
+		Type:        sdk.StringParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "commit",
+		Value:       "{{.git.hash}}",
+		Description: "Commit to checkout (optional)",
+		Type:        sdk.StringParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "merge",
+		Value:       "{{.git.pr.target}}",
+		Description: "Branch merged after the checkout (default: target branch of the pull request, if any)",
+		Type:        sdk.StringParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "directory",
+		Value:       "{{.cds.application}}",
+		Description: "Target directory of the clone",
+		Type:        sdk.StringParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "depth",
+		Value:       "50",
+		Description: "Create a shallow clone with a history truncated to this number of commits. 0 to clone all history",
+		Type:        sdk.NumberParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "submodules",
+		Value:       "false",
+		Description: "Initialize and update submodules",
+		Type:        sdk.BooleanParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "lfs",
+		Value:       "false",
+		Description: "Fetch Git LFS objects (git-lfs must be installed on the worker)",
+		Type:        sdk.BooleanParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "user",
+		Value:       "",
+		Description: "User used for http clone (optional)",
+		Type:        sdk.StringParameter})
+	gitclone.Parameter(sdk.Parameter{
+		Name:        "password",
+		Value:       "",
+		Description: "Password used for http clone (optional)",
+		Type:        sdk.StringParameter})
+	if err := checkBuiltinAction(db, gitclone); err != nil {
+		return err
+	}
+
+	// ----------------------------------- Coverage ---------------------------
+	coverage := sdk.NewAction(sdk.CoverageAction)
+	coverage.Type = sdk.BuiltinAction
+	coverage.Description = `CDS Builtin Action.
+Parse given files to extract code coverage.
+Supported formats are Cobertura, LCOV and Go coverprofile.`
+	coverage.Parameter(sdk.Parameter{
+		Name:        "path",
+		Description: `Path to coverage report files.`,
+		Type:        sdk.StringParameter})
+	coverage.Parameter(sdk.Parameter{
+		Name:        "format",
+		Value:       "auto",
+		Description: `Format of the reports: auto, cobertura, lcov or gocover.`,
+		Type:        sdk.StringParameter})
+	coverage.Parameter(sdk.Parameter{
+		Name:        "minimum",
+		Value:       "",
+		Description: `Fail if line coverage percentage is below this value (optional).`,
+		Type:        sdk.StringParameter})
+	if err := checkBuiltinAction(db, coverage); err != nil {
+		return err
+	}
+
+	// ----------------------------------- Test Report ---------------------------
+	testreport := sdk.NewAction(sdk.TestReportAction)
+	testreport.Type = sdk.BuiltinAction
+	testreport.Description = `CDS Builtin Action.
+Parse given files to extract Unit Test results.
+Supported formats are JUnit, TAP, xUnit.net,
+go test -json and TRX.`
+	testreport.Parameter(sdk.Parameter{
+		Name:        "path",
+		Description: `Path to test report files.`,
+		Type:        sdk.StringParameter})
+	testreport.Parameter(sdk.Parameter{
+		Name:        "format",
+		Value:       "auto",
+		Description: `Format of the reports: auto, junit, tap, xunit, gotest or trx.`,
+		Type:        sdk.StringParameter})
+	if err := checkBuiltinAction(db, testreport); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_build_coverage" (
+    pipeline_build_id BIGINT PRIMARY KEY,
+    application_id BIGINT,
+    pipeline_id BIGINT,
+    environment_id BIGINT,
+    build_number BIGINT,
+    branch TEXT,
+    format TEXT,
+    lines INT,
+    lines_covered INT,
+    branches INT,
+    branches_covered INT,
+    files JSONB,
+    created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP
+);
+select create_index('pipeline_build_coverage','IDX_PIPELINE_BUILD_COVERAGE_APPLICATION', 'application_id,branch');
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_build_coverage;
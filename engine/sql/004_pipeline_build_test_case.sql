@@ -0,0 +1,24 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_build_test_case" (
+    id BIGSERIAL PRIMARY KEY,
+    pipeline_build_id BIGINT,
+    application_id BIGINT,
+    pipeline_id BIGINT,
+    environment_id BIGINT,
+    build_number BIGINT,
+    branch TEXT,
+    suite TEXT,
+    name TEXT,
+    status TEXT,
+    created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP
+);
+select create_index('pipeline_build_test_case','IDX_PIPELINE_BUILD_TEST_CASE_PIPELINE_BUILD', 'pipeline_build_id');
+select create_index('pipeline_build_test_case','IDX_PIPELINE_BUILD_TEST_CASE_APPLICATION', 'application_id,branch');
+
+ALTER TABLE application_pipeline ADD COLUMN options JSONB;
+ALTER TABLE pipeline_build_job ADD COLUMN retry INT DEFAULT 0;
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_build_test_case;
+ALTER TABLE application_pipeline DROP COLUMN options;
+ALTER TABLE pipeline_build_job DROP COLUMN retry;
@@ -0,0 +1,29 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_build_approval" (
+    id BIGSERIAL PRIMARY KEY,
+    pipeline_build_id BIGINT,
+    application_id BIGINT,
+    pipeline_id BIGINT,
+    environment_id BIGINT,
+    build_number BIGINT,
+    stage_id BIGINT DEFAULT 0,
+    stage_name TEXT DEFAULT '',
+    trigger_id BIGINT DEFAULT 0,
+    groups JSONB,
+    message TEXT DEFAULT '',
+    status TEXT,
+    requested TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP,
+    decided TIMESTAMP WITH TIME ZONE,
+    user_id BIGINT,
+    username TEXT DEFAULT '',
+    comment TEXT DEFAULT ''
+);
+select create_index('pipeline_build_approval','IDX_PIPELINE_BUILD_APPROVAL_PIPELINE_BUILD', 'pipeline_build_id');
+
+ALTER TABLE pipeline_stage ADD COLUMN approval JSONB;
+ALTER TABLE pipeline_trigger ADD COLUMN approval JSONB;
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_build_approval;
+ALTER TABLE pipeline_stage DROP COLUMN approval;
+ALTER TABLE pipeline_trigger DROP COLUMN approval;
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "environment_lock" (
+    environment_id BIGINT PRIMARY KEY,
+    reason TEXT DEFAULT '',
+    user_id BIGINT,
+    username TEXT DEFAULT '',
+    locked TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP
+);
+select create_index('pipeline_build','IDX_PIPELINE_BUILD_ENVIRONMENT_STATUS', 'environment_id,status');
+
+-- +migrate Down
+DROP TABLE IF EXISTS environment_lock;
+DROP INDEX IF EXISTS IDX_PIPELINE_BUILD_ENVIRONMENT_STATUS;
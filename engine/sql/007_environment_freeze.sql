@@ -0,0 +1,27 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "environment_freeze_window" (
+    id BIGSERIAL PRIMARY KEY,
+    environment_id BIGINT,
+    reason TEXT DEFAULT '',
+    timezone TEXT DEFAULT '',
+    cron TEXT DEFAULT '',
+    duration TEXT DEFAULT '',
+    start_date TEXT DEFAULT '',
+    end_date TEXT DEFAULT ''
+);
+select create_index('environment_freeze_window','IDX_ENVIRONMENT_FREEZE_WINDOW_ENVIRONMENT', 'environment_id');
+
+CREATE TABLE IF NOT EXISTS "environment_freeze_override" (
+    id BIGSERIAL PRIMARY KEY,
+    environment_id BIGINT,
+    token TEXT,
+    reason TEXT DEFAULT '',
+    user_id BIGINT,
+    username TEXT DEFAULT '',
+    expires TIMESTAMP WITH TIME ZONE
+);
+select create_index('environment_freeze_override','IDX_ENVIRONMENT_FREEZE_OVERRIDE_ENVIRONMENT', 'environment_id');
+
+-- +migrate Down
+DROP TABLE IF EXISTS environment_freeze_window;
+DROP TABLE IF EXISTS environment_freeze_override;
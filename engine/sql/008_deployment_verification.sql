@@ -0,0 +1,30 @@
+-- +migrate Up
+ALTER TABLE pipeline_stage ADD COLUMN progressive JSONB;
+
+CREATE TABLE IF NOT EXISTS "pipeline_build_verification" (
+    id BIGSERIAL PRIMARY KEY,
+    pipeline_build_id BIGINT,
+    application_id BIGINT,
+    pipeline_id BIGINT,
+    environment_id BIGINT,
+    build_number BIGINT,
+    stage_id BIGINT,
+    stage_name TEXT DEFAULT '',
+    strategy TEXT,
+    slot TEXT DEFAULT '',
+    status TEXT,
+    started TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP,
+    last_check TIMESTAMP WITH TIME ZONE,
+    ended TIMESTAMP WITH TIME ZONE,
+    checks INT DEFAULT 0,
+    failures INT DEFAULT 0,
+    last_result TEXT DEFAULT '',
+    reason TEXT DEFAULT '',
+    rollback_build_number BIGINT DEFAULT 0
+);
+select create_index('pipeline_build_verification','IDX_PIPELINE_BUILD_VERIFICATION_PIPELINE_BUILD', 'pipeline_build_id');
+select create_index('pipeline_build_verification','IDX_PIPELINE_BUILD_VERIFICATION_APP_PIP_ENV', 'application_id,pipeline_id,environment_id');
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_build_verification;
+ALTER TABLE pipeline_stage DROP COLUMN progressive;
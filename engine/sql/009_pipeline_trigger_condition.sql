@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE pipeline_trigger ADD COLUMN condition TEXT DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE pipeline_trigger DROP COLUMN condition;
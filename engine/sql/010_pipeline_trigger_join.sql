@@ -0,0 +1,38 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_trigger_join" (
+    id BIGSERIAL PRIMARY KEY,
+    dest_application_id BIGINT,
+    dest_pipeline_id BIGINT,
+    dest_environment_id BIGINT,
+    match_on TEXT DEFAULT 'hash',
+    wait_window BIGINT DEFAULT 3600,
+    parameters JSONB
+);
+select create_index('pipeline_trigger_join','IDX_PIPELINE_TRIGGER_JOIN_DEST', 'dest_application_id,dest_pipeline_id');
+
+CREATE TABLE IF NOT EXISTS "pipeline_trigger_join_source" (
+    id BIGSERIAL PRIMARY KEY,
+    join_id BIGINT,
+    application_id BIGINT,
+    pipeline_id BIGINT,
+    environment_id BIGINT
+);
+select create_index('pipeline_trigger_join_source','IDX_PIPELINE_TRIGGER_JOIN_SOURCE', 'application_id,pipeline_id,environment_id');
+
+CREATE TABLE IF NOT EXISTS "pipeline_trigger_join_arrival" (
+    id BIGSERIAL PRIMARY KEY,
+    join_id BIGINT,
+    source_id BIGINT,
+    join_key TEXT,
+    pipeline_build_id BIGINT,
+    build_number BIGINT,
+    version BIGINT,
+    hash TEXT DEFAULT '',
+    arrived TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP
+);
+select create_index('pipeline_trigger_join_arrival','IDX_PIPELINE_TRIGGER_JOIN_ARRIVAL', 'join_id,join_key');
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_trigger_join_arrival;
+DROP TABLE IF EXISTS pipeline_trigger_join_source;
+DROP TABLE IF EXISTS pipeline_trigger_join;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE pipeline_build ADD COLUMN stop_reason TEXT;
+
+-- +migrate Down
+ALTER TABLE pipeline_build DROP COLUMN stop_reason;
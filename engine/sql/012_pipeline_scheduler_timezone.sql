@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE pipeline_scheduler ADD COLUMN timezone TEXT DEFAULT '';
+ALTER TABLE pipeline_scheduler ADD COLUMN jitter BIGINT DEFAULT 0;
+ALTER TABLE pipeline_scheduler ADD COLUMN skip_if_running BOOLEAN DEFAULT FALSE;
+ALTER TABLE pipeline_scheduler_execution ADD COLUMN skipped BOOLEAN DEFAULT FALSE;
+
+-- +migrate Down
+ALTER TABLE pipeline_scheduler DROP COLUMN timezone;
+ALTER TABLE pipeline_scheduler DROP COLUMN jitter;
+ALTER TABLE pipeline_scheduler DROP COLUMN skip_if_running;
+ALTER TABLE pipeline_scheduler_execution DROP COLUMN skipped;
@@ -0,0 +1,10 @@
+-- +migrate Up
+ALTER TABLE hook ADD COLUMN secret TEXT;
+UPDATE hook SET secret = md5(random()::text || uid) || md5(random()::text || id::text);
+select create_index('hook','IDX_HOOK_UID','uid');
+CREATE TABLE IF NOT EXISTS "hook_delivery" (hook_id BIGINT NOT NULL REFERENCES hook(id) ON DELETE CASCADE, delivery_id TEXT NOT NULL, event TEXT, received TIMESTAMP WITH TIME ZONE, PRIMARY KEY(hook_id, delivery_id));
+
+-- +migrate Down
+DROP TABLE hook_delivery;
+DROP INDEX IF EXISTS idx_hook_uid;
+ALTER TABLE hook DROP COLUMN secret;
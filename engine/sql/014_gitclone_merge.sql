@@ -0,0 +1,8 @@
+-- +migrate Up
+INSERT INTO action_parameter (action_id, name, type, value, description)
+SELECT action.id, 'merge', 'string', '{{.git.pr.target}}', 'Branch merged after the checkout (default: target branch of the pull request, if any)'
+FROM action WHERE action.name = 'GitClone' AND action.type = 'Builtin'
+AND NOT EXISTS (SELECT 1 FROM action_parameter WHERE action_parameter.action_id = action.id AND action_parameter.name = 'merge');
+
+-- +migrate Down
+DELETE FROM action_parameter WHERE name = 'merge' AND action_id IN (SELECT id FROM action WHERE name = 'GitClone' AND type = 'Builtin');
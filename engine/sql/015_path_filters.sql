@@ -0,0 +1,10 @@
+-- +migrate Up
+ALTER TABLE hook ADD COLUMN paths JSONB;
+ALTER TABLE poller ADD COLUMN paths JSONB;
+CREATE TABLE IF NOT EXISTS "skipped_build" (id BIGSERIAL PRIMARY KEY, application_id BIGINT NOT NULL REFERENCES application(id) ON DELETE CASCADE, pipeline_id BIGINT NOT NULL REFERENCES pipeline(id) ON DELETE CASCADE, branch TEXT, hash TEXT, author TEXT, origin TEXT, reason TEXT, skipped TIMESTAMP WITH TIME ZONE);
+select create_index('skipped_build','IDX_SKIPPED_BUILD_APPLICATION_PIPELINE','application_id,pipeline_id');
+
+-- +migrate Down
+DROP TABLE skipped_build;
+ALTER TABLE poller DROP COLUMN paths;
+ALTER TABLE hook DROP COLUMN paths;
@@ -0,0 +1,31 @@
+-- +migrate Up
+ALTER TABLE received_hook ADD COLUMN hook_id BIGINT REFERENCES hook(id) ON DELETE CASCADE;
+ALTER TABLE received_hook ADD COLUMN uid TEXT;
+ALTER TABLE received_hook ADD COLUMN branch TEXT;
+ALTER TABLE received_hook ADD COLUMN hash TEXT;
+ALTER TABLE received_hook ADD COLUMN author TEXT;
+ALTER TABLE received_hook ADD COLUMN message TEXT;
+ALTER TABLE received_hook ADD COLUMN args JSONB;
+ALTER TABLE received_hook ADD COLUMN received TIMESTAMP WITH TIME ZONE;
+ALTER TABLE received_hook ADD COLUMN status TEXT;
+ALTER TABLE received_hook ADD COLUMN build_number BIGINT;
+ALTER TABLE received_hook ADD COLUMN reason TEXT;
+ALTER TABLE received_hook ADD COLUMN replay_of BIGINT;
+select create_index('received_hook','IDX_RECEIVED_HOOK_HOOK_ID','hook_id');
+select create_index('received_hook','IDX_RECEIVED_HOOK_RECEIVED','received');
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_received_hook_received;
+DROP INDEX IF EXISTS idx_received_hook_hook_id;
+ALTER TABLE received_hook DROP COLUMN replay_of;
+ALTER TABLE received_hook DROP COLUMN reason;
+ALTER TABLE received_hook DROP COLUMN build_number;
+ALTER TABLE received_hook DROP COLUMN status;
+ALTER TABLE received_hook DROP COLUMN received;
+ALTER TABLE received_hook DROP COLUMN args;
+ALTER TABLE received_hook DROP COLUMN message;
+ALTER TABLE received_hook DROP COLUMN author;
+ALTER TABLE received_hook DROP COLUMN hash;
+ALTER TABLE received_hook DROP COLUMN branch;
+ALTER TABLE received_hook DROP COLUMN uid;
+ALTER TABLE received_hook DROP COLUMN hook_id;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE group_user ADD COLUMN origin TEXT DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE group_user DROP COLUMN origin;
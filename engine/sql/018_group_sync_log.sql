@@ -0,0 +1,6 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS group_sync_log (id BIGSERIAL PRIMARY KEY, origin TEXT, trigger TEXT, dry_run BOOLEAN, started TIMESTAMP WITH TIME ZONE, changes JSONB, error TEXT);
+select create_index('group_sync_log','IDX_GROUP_SYNC_LOG_STARTED','started');
+
+-- +migrate Down
+DROP TABLE group_sync_log;
@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "user_access_token" (id BIGSERIAL PRIMARY KEY, user_id BIGINT NOT NULL REFERENCES "user"(id) ON DELETE CASCADE, name TEXT NOT NULL, hash TEXT NOT NULL, scope TEXT NOT NULL, projects JSONB, created TIMESTAMP WITH TIME ZONE, expire TIMESTAMP WITH TIME ZONE, last_used TIMESTAMP WITH TIME ZONE, revoked TIMESTAMP WITH TIME ZONE);
+select create_unique_index('user_access_token','IDX_USER_ACCESS_TOKEN_HASH','hash');
+select create_index('user_access_token','IDX_USER_ACCESS_TOKEN_USER_ID','user_id');
+
+-- +migrate Down
+DROP TABLE user_access_token;
@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE "user" ADD COLUMN external_id TEXT;
+select create_unique_index('user','IDX_USER_EXTERNAL_ID','external_id');
+
+-- +migrate Down
+ALTER TABLE "user" DROP COLUMN external_id;
@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE pipeline_build_test_case ADD COLUMN pipeline_build_job_id BIGINT;
+select create_index('pipeline_build_test_case','IDX_PIPELINE_BUILD_TEST_CASE_JOB', 'pipeline_build_job_id');
+
+-- +migrate Down
+ALTER TABLE pipeline_build_test_case DROP COLUMN pipeline_build_job_id;
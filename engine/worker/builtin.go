@@ -21,6 +21,12 @@ func runBuiltin(a *sdk.Action, pbJob sdk.PipelineBuildJob) sdk.Result {
 		return runScriptAction(a, pbJob)
 	case sdk.JUnitAction:
 		return runParseJunitTestResultAction(a, pbJob)
+	case sdk.GitCloneAction:
+		return runGitClone(a, pbJob)
+	case sdk.CoverageAction:
+		return runCoverageAction(a, pbJob)
+	case sdk.TestReportAction:
+		return runTestReportAction(a, pbJob)
 	}
 
 	sendLog(pbJob.ID, name, fmt.Sprintf("Unknown builtin step: %s\n", name), pbJob.PipelineBuildID)
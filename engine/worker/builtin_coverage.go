@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func runCoverageAction(a *sdk.Action, pbJob sdk.PipelineBuildJob) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+
+	// Retrieve build info
+	var proj, app, pip, bnS, envName string
+	for _, p := range pbJob.Parameters {
+		switch p.Name {
+		case "cds.pipeline":
+			pip = p.Value
+		case "cds.project":
+			proj = p.Value
+		case "cds.application":
+			app = p.Value
+		case "cds.buildNumber":
+			bnS = p.Value
+		case "cds.environment":
+			envName = p.Value
+		}
+	}
+
+	var path, format, minimum string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "path":
+			path = p.Value
+		case "format":
+			format = strings.ToLower(strings.TrimSpace(p.Value))
+		case "minimum":
+			minimum = strings.TrimSpace(p.Value)
+		}
+	}
+
+	if path == "" {
+		sendLog(pbJob.ID, sdk.CoverageAction, "Coverage parser: path not provided\n", pbJob.PipelineBuildID)
+		return res
+	}
+
+	var threshold float64
+	if minimum != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(strings.TrimSuffix(minimum, "%"), 64)
+		if err != nil {
+			sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage parser: invalid minimum %s\n", minimum), pbJob.PipelineBuildID)
+			return res
+		}
+	}
+
+	files, err := filepath.Glob(path)
+	if err != nil {
+		sendLog(pbJob.ID, sdk.CoverageAction, "Coverage parser: Cannot find requested files, invalid pattern\n", pbJob.PipelineBuildID)
+		return res
+	}
+	if len(files) == 0 {
+		sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage parser: pattern '%s' matched no file\n", path), pbJob.PipelineBuildID)
+		return res
+	}
+
+	var coverage sdk.Coverage
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage parser: cannot read file %s (%s)\n", f, err), pbJob.PipelineBuildID)
+			return res
+		}
+
+		fileFormat := format
+		if fileFormat == "" || fileFormat == "auto" {
+			fileFormat = detectCoverageFormat(data)
+		}
+
+		cfiles, err := parseCoverage(fileFormat, data)
+		if err != nil {
+			sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage parser: cannot interpret file %s (%s)\n", f, err), pbJob.PipelineBuildID)
+			return res
+		}
+		coverage.Format = fileFormat
+		coverage.Add(cfiles...)
+	}
+
+	sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: %.2f%% of lines (%d/%d), %d/%d branches\n",
+		coverage.Percent(), coverage.LinesCovered, coverage.Lines, coverage.BranchesCovered, coverage.Branches), pbJob.PipelineBuildID)
+
+	data, err := json.Marshal(coverage)
+	if err != nil {
+		sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage parser: failed to send coverage details: %s\n", err), pbJob.PipelineBuildID)
+		return res
+	}
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%s/coverage?envName=%s", proj, app, pip, bnS, envName)
+	btes, code, err := sdk.Request("POST", uri, data)
+	if err == nil && code > 300 {
+		err = fmt.Errorf("HTTP %d", code)
+	}
+	if err != nil {
+		sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage parser: failed to send coverage details: %s\n", err), pbJob.PipelineBuildID)
+		return res
+	}
+
+	var summary sdk.Coverage
+	if err := json.Unmarshal(btes, &summary); err == nil && summary.Delta != nil {
+		sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: %+.2f%% since previous build on branch %s\n", *summary.Delta, summary.Branch), pbJob.PipelineBuildID)
+	}
+
+	if minimum != "" && coverage.Percent() < threshold {
+		sendLog(pbJob.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: %.2f%% is below minimum %.2f%%\n", coverage.Percent(), threshold), pbJob.PipelineBuildID)
+		return res
+	}
+
+	res.Status = sdk.StatusSuccess
+	return res
+}
+
+// detectCoverageFormat guesses the format of a coverage report from its content
+func detectCoverageFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return sdk.CoverageFormatGo
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return sdk.CoverageFormatCobertura
+	default:
+		return sdk.CoverageFormatLCOV
+	}
+}
+
+func parseCoverage(format string, data []byte) ([]sdk.CoverageFile, error) {
+	switch format {
+	case sdk.CoverageFormatCobertura:
+		return parseCobertura(data)
+	case sdk.CoverageFormatLCOV:
+		return parseLCOV(data)
+	case sdk.CoverageFormatGo:
+		return parseGoCoverProfile(data)
+	}
+	return nil, fmt.Errorf("unsupported coverage format %s", format)
+}
+
+type coberturaCoverage struct {
+	Packages []struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number            int    `xml:"number,attr"`
+				Hits              int    `xml:"hits,attr"`
+				Branch            bool   `xml:"branch,attr"`
+				ConditionCoverage string `xml:"condition-coverage,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+var conditionCoverageRegexp = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+func parseCobertura(data []byte) ([]sdk.CoverageFile, error) {
+	var c coberturaCoverage
+	if err := xml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	// A file may be split in several classes
+	files := map[string]*sdk.CoverageFile{}
+	lines := map[string]map[int]bool{}
+	for _, p := range c.Packages {
+		for _, cl := range p.Classes {
+			f, ok := files[cl.Filename]
+			if !ok {
+				f = &sdk.CoverageFile{Name: cl.Filename}
+				files[cl.Filename] = f
+				lines[cl.Filename] = map[int]bool{}
+			}
+			for _, l := range cl.Lines {
+				if _, seen := lines[cl.Filename][l.Number]; seen {
+					continue
+				}
+				lines[cl.Filename][l.Number] = l.Hits > 0
+				f.Lines++
+				if l.Hits > 0 {
+					f.LinesCovered++
+				}
+				if l.Branch {
+					if m := conditionCoverageRegexp.FindStringSubmatch(l.ConditionCoverage); m != nil {
+						covered, _ := strconv.Atoi(m[1])
+						total, _ := strconv.Atoi(m[2])
+						f.BranchesCovered += covered
+						f.Branches += total
+					}
+				}
+			}
+		}
+	}
+	return sortedCoverageFiles(files), nil
+}
+
+func parseLCOV(data []byte) ([]sdk.CoverageFile, error) {
+	files := map[string]*sdk.CoverageFile{}
+	var current *sdk.CoverageFile
+	var lf, lh, brf, brh int
+	var hasSummary, hasBranchSummary bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "end_of_record" {
+			if current == nil {
+				return nil, fmt.Errorf("end_of_record without SF")
+			}
+			if hasSummary {
+				current.Lines, current.LinesCovered = lf, lh
+			}
+			if hasBranchSummary {
+				current.Branches, current.BranchesCovered = brf, brh
+			}
+			current = nil
+			continue
+		}
+
+		t := strings.SplitN(line, ":", 2)
+		if len(t) != 2 {
+			continue
+		}
+		if t[0] == "SF" {
+			current = &sdk.CoverageFile{Name: t[1]}
+			files[t[1]] = current
+			lf, lh, brf, brh = 0, 0, 0, 0
+			hasSummary, hasBranchSummary = false, false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		var err error
+		switch t[0] {
+		case "DA":
+			fields := strings.Split(t[1], ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid line %s", line)
+			}
+			current.Lines++
+			if fields[1] != "0" {
+				current.LinesCovered++
+			}
+		case "BRDA":
+			fields := strings.Split(t[1], ",")
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("invalid line %s", line)
+			}
+			current.Branches++
+			if fields[3] != "-" && fields[3] != "0" {
+				current.BranchesCovered++
+			}
+		case "LF":
+			hasSummary = true
+			lf, err = strconv.Atoi(t[1])
+		case "LH":
+			hasSummary = true
+			lh, err = strconv.Atoi(t[1])
+		case "BRF":
+			hasBranchSummary = true
+			brf, err = strconv.Atoi(t[1])
+		case "BRH":
+			hasBranchSummary = true
+			brh, err = strconv.Atoi(t[1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %s", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no source file found")
+	}
+	return sortedCoverageFiles(files), nil
+}
+
+// parseGoCoverProfile parses output of go test -coverprofile.
+// Go coverage is computed on statements, they are reported as lines.
+func parseGoCoverProfile(data []byte) ([]sdk.CoverageFile, error) {
+	type block struct {
+		stmts int
+		hit   bool
+	}
+	blocks := map[string]map[string]*block{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.column,line.column numberOfStatements count
+		i := strings.LastIndex(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid line %s", line)
+		}
+		fields := strings.Fields(line[i+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid line %s", line)
+		}
+		stmts, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %s", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %s", line)
+		}
+
+		name := line[:i]
+		if blocks[name] == nil {
+			blocks[name] = map[string]*block{}
+		}
+		// Merged profiles may contain the same block several times
+		b, ok := blocks[name][fields[0]]
+		if !ok {
+			b = &block{stmts: stmts}
+			blocks[name][fields[0]] = b
+		}
+		b.hit = b.hit || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	files := map[string]*sdk.CoverageFile{}
+	for name, bs := range blocks {
+		f := &sdk.CoverageFile{Name: name}
+		for _, b := range bs {
+			f.Lines += b.stmts
+			if b.hit {
+				f.LinesCovered += b.stmts
+			}
+		}
+		files[name] = f
+	}
+	return sortedCoverageFiles(files), nil
+}
+
+func sortedCoverageFiles(files map[string]*sdk.CoverageFile) []sdk.CoverageFile {
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	res := make([]sdk.CoverageFile, 0, len(files))
+	for _, n := range names {
+		res = append(res, *files[n])
+	}
+	return res
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const coberturaReport = `<?xml version="1.0" ?>
+<coverage line-rate="0.75" branch-rate="0.5" version="1.9">
+	<packages>
+		<package name="app">
+			<classes>
+				<class name="Foo" filename="app/foo.py">
+					<lines>
+						<line number="1" hits="1"/>
+						<line number="2" hits="0"/>
+						<line number="3" hits="2" branch="true" condition-coverage="50% (1/2)"/>
+					</lines>
+				</class>
+				<class name="Bar" filename="app/bar.py">
+					<lines>
+						<line number="1" hits="1"/>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>`
+
+const lcovReport = `TN:
+SF:src/foo.js
+DA:1,1
+DA:2,0
+DA:3,4
+BRDA:3,0,0,1
+BRDA:3,0,1,-
+end_of_record
+SF:src/bar.js
+DA:1,0
+LF:10
+LH:7
+end_of_record
+`
+
+const goReport = `mode: set
+github.com/ovh/cds/sdk/foo.go:10.2,12.3 2 1
+github.com/ovh/cds/sdk/foo.go:14.2,15.3 3 0
+github.com/ovh/cds/sdk/foo.go:14.2,15.3 3 1
+github.com/ovh/cds/sdk/bar.go:1.2,3.3 4 0
+`
+
+func TestDetectCoverageFormat(t *testing.T) {
+	if f := detectCoverageFormat([]byte(coberturaReport)); f != sdk.CoverageFormatCobertura {
+		t.Fatalf("expected cobertura, got %s", f)
+	}
+	if f := detectCoverageFormat([]byte(lcovReport)); f != sdk.CoverageFormatLCOV {
+		t.Fatalf("expected lcov, got %s", f)
+	}
+	if f := detectCoverageFormat([]byte(goReport)); f != sdk.CoverageFormatGo {
+		t.Fatalf("expected gocover, got %s", f)
+	}
+}
+
+func TestParseCobertura(t *testing.T) {
+	files, err := parseCobertura([]byte(coberturaReport))
+	if err != nil {
+		t.Fatalf("parseCobertura should not fail: %s", err)
+	}
+
+	var c sdk.Coverage
+	c.Add(files...)
+	if len(c.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(c.Files))
+	}
+	if c.Lines != 4 || c.LinesCovered != 3 {
+		t.Fatalf("expected 3/4 lines, got %d/%d", c.LinesCovered, c.Lines)
+	}
+	if c.Branches != 2 || c.BranchesCovered != 1 {
+		t.Fatalf("expected 1/2 branches, got %d/%d", c.BranchesCovered, c.Branches)
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	files, err := parseLCOV([]byte(lcovReport))
+	if err != nil {
+		t.Fatalf("parseLCOV should not fail: %s", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	// Files are sorted by name
+	bar, foo := files[0], files[1]
+	if foo.Lines != 3 || foo.LinesCovered != 2 || foo.Branches != 2 || foo.BranchesCovered != 1 {
+		t.Fatalf("unexpected coverage for foo.js: %+v", foo)
+	}
+	// LF and LH take precedence on DA lines
+	if bar.Lines != 10 || bar.LinesCovered != 7 {
+		t.Fatalf("unexpected coverage for bar.js: %+v", bar)
+	}
+}
+
+func TestParseGoCoverProfile(t *testing.T) {
+	files, err := parseGoCoverProfile([]byte(goReport))
+	if err != nil {
+		t.Fatalf("parseGoCoverProfile should not fail: %s", err)
+	}
+
+	var c sdk.Coverage
+	c.Add(files...)
+	if c.Lines != 9 || c.LinesCovered != 5 {
+		t.Fatalf("expected 5/9 statements, got %d/%d", c.LinesCovered, c.Lines)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// gitCloneOpts gathers all the parameters of the GitClone builtin action
+type gitCloneOpts struct {
+	url        string
+	branch     string
+	commit     string
+	merge      string
+	directory  string
+	depth      int
+	submodules bool
+	lfs        bool
+	user       string
+	password   string
+}
+
+func getGitCloneOpts(a *sdk.Action) (gitCloneOpts, error) {
+	var opts gitCloneOpts
+	for _, p := range a.Parameters {
+		v := strings.TrimSpace(p.Value)
+		// Unresolved placeholders means the variable does not exist for this build
+		if strings.HasPrefix(v, "{{.") {
+			v = ""
+		}
+		switch p.Name {
+		case "url":
+			opts.url = v
+		case "branch":
+			opts.branch = v
+		case "commit":
+			opts.commit = v
+		case "merge":
+			opts.merge = v
+		case "directory":
+			opts.directory = v
+		case "depth":
+			if v == "" {
+				continue
+			}
+			d, err := strconv.Atoi(v)
+			if err != nil || d < 0 {
+				return opts, fmt.Errorf("invalid depth %s", v)
+			}
+			opts.depth = d
+		case "submodules":
+			opts.submodules = v == "true"
+		case "lfs":
+			opts.lfs = v == "true"
+		case "user":
+			opts.user = v
+		case "password":
+			opts.password = p.Value
+		}
+	}
+
+	if opts.url == "" {
+		return opts, fmt.Errorf("git url not provided, is the application attached to a repository ?")
+	}
+	return opts, nil
+}
+
+// cloneURL returns the url to clone, with credentials for http urls
+func (opts gitCloneOpts) cloneURL() (string, error) {
+	if opts.user == "" || !strings.HasPrefix(opts.url, "http") {
+		return opts.url, nil
+	}
+	u, err := url.Parse(opts.url)
+	if err != nil {
+		return "", err
+	}
+	if opts.password != "" {
+		u.User = url.UserPassword(opts.user, opts.password)
+	} else {
+		u.User = url.User(opts.user)
+	}
+	return u.String(), nil
+}
+
+// mergeFetchArgs returns the arguments of the git fetch command of the branch to merge,
+// the whole history is needed to find the merge base in shallow clones
+func (opts gitCloneOpts) mergeFetchArgs() []string {
+	args := []string{"fetch"}
+	if opts.depth > 0 {
+		args = append(args, "--unshallow")
+	}
+	return append(args, "origin", opts.merge)
+}
+
+// cloneArgs returns the arguments of the git clone command
+func (opts gitCloneOpts) cloneArgs(cloneURL string) []string {
+	args := []string{"clone"}
+	if opts.depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.depth))
+	}
+	if opts.branch != "" {
+		args = append(args, "--branch", opts.branch)
+	}
+	if opts.submodules {
+		args = append(args, "--recursive")
+		if opts.depth > 0 {
+			args = append(args, "--shallow-submodules")
+		}
+	}
+	args = append(args, cloneURL)
+	if opts.directory != "" {
+		args = append(args, opts.directory)
+	}
+	return args
+}
+
+func runGitClone(a *sdk.Action, pbJob sdk.PipelineBuildJob) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+
+	opts, err := getGitCloneOpts(a)
+	if err != nil {
+		sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("%s\n", err), pbJob.PipelineBuildID)
+		return res
+	}
+
+	cloneURL, err := opts.cloneURL()
+	if err != nil {
+		sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("invalid git url %s: %s\n", opts.url, err), pbJob.PipelineBuildID)
+		return res
+	}
+
+	if err := gitCommand(pbJob, "", opts.cloneArgs(cloneURL)...); err != nil {
+		return res
+	}
+
+	dir := opts.directory
+	if dir == "" {
+		dir = strings.TrimSuffix(opts.url[strings.LastIndexAny(opts.url, "/:")+1:], ".git")
+	}
+
+	if opts.commit != "" {
+		// The commit may be out of the truncated history, so fetch it explicitly
+		if opts.depth > 0 {
+			if err := gitCommand(pbJob, dir, "fetch", "--depth", strconv.Itoa(opts.depth), "origin", opts.commit); err != nil {
+				return res
+			}
+		}
+		if err := gitCommand(pbJob, dir, "checkout", "-q", opts.commit); err != nil {
+			return res
+		}
+	}
+
+	// On pull requests, the result of the merge in the target branch is built
+	if opts.merge != "" {
+		if err := gitCommand(pbJob, dir, opts.mergeFetchArgs()...); err != nil {
+			return res
+		}
+		if err := gitCommand(pbJob, dir, "-c", "user.name=CDS", "-c", "user.email=cds@localhost", "merge", "--no-edit", "FETCH_HEAD"); err != nil {
+			sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("cannot merge %s, conflicts must be resolved in the pull request\n", opts.merge), pbJob.PipelineBuildID)
+			return res
+		}
+	}
+
+	if opts.submodules && (opts.commit != "" || opts.merge != "") {
+		if err := gitCommand(pbJob, dir, "submodule", "update", "--init", "--recursive"); err != nil {
+			return res
+		}
+	}
+
+	if opts.lfs {
+		if err := gitCommand(pbJob, dir, "lfs", "pull"); err != nil {
+			return res
+		}
+	}
+
+	if err := exportGitVariables(pbJob, dir, opts.branch); err != nil {
+		sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("cannot export git variables: %s\n", err), pbJob.PipelineBuildID)
+		return res
+	}
+
+	res.Status = sdk.StatusSuccess
+	return res
+}
+
+// exportGitVariables exports git.hash, git.branch, git.author and git.message
+// of the HEAD of the cloned repository as build variables
+func exportGitVariables(pbJob sdk.PipelineBuildJob, dir, branch string) error {
+	out, err := gitOutput(dir, "log", "-1", "--format=%H%n%an%n%s")
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitN(out, "\n", 3)
+	if len(lines) != 3 {
+		return fmt.Errorf("unexpected git log output: %s", out)
+	}
+
+	if branch == "" {
+		branch, err = gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return err
+		}
+	}
+
+	vars := []sdk.Variable{
+		{Name: "git.hash", Type: sdk.StringVariable, Value: lines[0]},
+		{Name: "git.branch", Type: sdk.StringVariable, Value: branch},
+		{Name: "git.author", Type: sdk.StringVariable, Value: lines[1]},
+		{Name: "git.message", Type: sdk.StringVariable, Value: lines[2]},
+	}
+	for _, v := range vars {
+		sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("%s=%s\n", v.Name, v.Value), pbJob.PipelineBuildID)
+		if err := addBuildVariable(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gitEnv() []string {
+	env := os.Environ()
+	if pkey != "" && gitssh != "" {
+		env = append(env, fmt.Sprintf("%s=%s", pKEY, pkey))
+		env = append(env, fmt.Sprintf("%s=%s", GitSSH, gitssh))
+	}
+	return env
+}
+
+// gitCommand runs git in dir and sends its output in build logs
+func gitCommand(pbJob sdk.PipelineBuildJob, dir string, args ...string) error {
+	sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("git %s\n", hideURLCredentials(strings.Join(args, " "))), pbJob.PipelineBuildID)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = gitEnv()
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			sendLog(pbJob.ID, sdk.GitCloneAction, hideURLCredentials(line)+"\n", pbJob.PipelineBuildID)
+		}
+	}
+	if err != nil {
+		sendLog(pbJob.ID, sdk.GitCloneAction, fmt.Sprintf("%s\n", err), pbJob.PipelineBuildID)
+	}
+	return err
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = gitEnv()
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// hideURLCredentials removes password from http urls
+func hideURLCredentials(s string) string {
+	fields := strings.Fields(s)
+	for i, f := range fields {
+		u, err := url.Parse(f)
+		if err != nil || u.User == nil {
+			continue
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "****")
+			fields[i] = u.String()
+		}
+	}
+	return strings.Join(fields, " ")
+}
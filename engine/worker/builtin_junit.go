@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -14,28 +13,6 @@ func runParseJunitTestResultAction(a *sdk.Action, pbJob sdk.PipelineBuildJob) sd
 	var res sdk.Result
 	res.Status = sdk.StatusFail
 
-	// Retrieve build info
-	var proj, app, pip, bnS, envName string
-	for _, p := range pbJob.Parameters {
-		switch p.Name {
-		case "cds.pipeline":
-			pip = p.Value
-			break
-		case "cds.project":
-			proj = p.Value
-			break
-		case "cds.application":
-			app = p.Value
-			break
-		case "cds.buildNumber":
-			bnS = p.Value
-			break
-		case "cds.environment":
-			envName = p.Value
-			break
-		}
-	}
-
 	var p string
 	for _, a := range a.Parameters {
 		if a.Name == "path" {
@@ -99,19 +76,7 @@ func runParseJunitTestResultAction(a *sdk.Action, pbJob sdk.PipelineBuildJob) sd
 		res.Status = sdk.StatusFail
 	}
 
-	data, err := json.Marshal(v)
-	if err != nil {
-		res.Status = sdk.StatusFail
-		sendLog(pbJob.ID, sdk.JUnitAction, fmt.Sprintf("JUnit parse: failed to send tests details: %s", err), pbJob.PipelineBuildID)
-		return res
-	}
-
-	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%s/test?envName=%s", proj, app, pip, bnS, envName)
-	_, code, err := sdk.Request("POST", uri, data)
-	if err == nil && code > 300 {
-		err = fmt.Errorf("HTTP %d", code)
-	}
-	if err != nil {
+	if err := sendTestResults(pbJob, v); err != nil {
 		res.Status = sdk.StatusFail
 		sendLog(pbJob.ID, sdk.JUnitAction, fmt.Sprintf("JUnit parse: failed to send tests details: %s", err), pbJob.PipelineBuildID)
 		return res
@@ -0,0 +1,610 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func runTestReportAction(a *sdk.Action, pbJob sdk.PipelineBuildJob) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+
+	var path, format string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "path":
+			path = p.Value
+		case "format":
+			format = strings.ToLower(strings.TrimSpace(p.Value))
+		}
+	}
+
+	if path == "" {
+		sendLog(pbJob.ID, sdk.TestReportAction, "Test report parser: path not provided\n", pbJob.PipelineBuildID)
+		return res
+	}
+
+	files, err := filepath.Glob(path)
+	if err != nil {
+		sendLog(pbJob.ID, sdk.TestReportAction, "Test report parser: Cannot find requested files, invalid pattern\n", pbJob.PipelineBuildID)
+		return res
+	}
+
+	var v sdk.Tests
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			sendLog(pbJob.ID, sdk.TestReportAction, fmt.Sprintf("Test report parser: cannot read file %s (%s)\n", f, err), pbJob.PipelineBuildID)
+			return res
+		}
+
+		fileFormat := format
+		if fileFormat == "" || fileFormat == "auto" {
+			fileFormat = detectTestReportFormat(data)
+		}
+
+		suites, err := parseTestReport(fileFormat, f, data)
+		if err != nil {
+			sendLog(pbJob.ID, sdk.TestReportAction, fmt.Sprintf("Test report parser: cannot interpret file %s as %s (%s)\n", f, fileFormat, err), pbJob.PipelineBuildID)
+			return res
+		}
+		v.TestSuites = append(v.TestSuites, suites...)
+	}
+	computeTestsStats(&v)
+
+	res.Status = sdk.StatusSuccess
+	for _, s := range v.TestSuites {
+		if s.Failures+s.Errors > 0 {
+			sendLog(pbJob.ID, sdk.TestReportAction, fmt.Sprintf("Test report parser: %s has %d failed tests\n", s.Name, s.Failures+s.Errors), pbJob.PipelineBuildID)
+			res.Status = sdk.StatusFail
+		}
+	}
+
+	if v.Total == 0 {
+		sendLog(pbJob.ID, sdk.TestReportAction, "Test report parser: No tests\n", pbJob.PipelineBuildID)
+		res.Status = sdk.StatusFail
+	}
+
+	if err := sendTestResults(pbJob, v); err != nil {
+		sendLog(pbJob.ID, sdk.TestReportAction, fmt.Sprintf("Test report parser: failed to send tests details: %s\n", err), pbJob.PipelineBuildID)
+		res.Status = sdk.StatusFail
+	}
+
+	return res
+}
+
+// sendTestResults uploads tests results of the job to the pipeline build
+func sendTestResults(pbJob sdk.PipelineBuildJob, v sdk.Tests) error {
+	var proj, app, pip, bnS, envName string
+	for _, p := range pbJob.Parameters {
+		switch p.Name {
+		case "cds.pipeline":
+			pip = p.Value
+		case "cds.project":
+			proj = p.Value
+		case "cds.application":
+			app = p.Value
+		case "cds.buildNumber":
+			bnS = p.Value
+		case "cds.environment":
+			envName = p.Value
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%s/test?envName=%s&jobID=%d", proj, app, pip, bnS, envName, pbJob.ID)
+	_, code, err := sdk.Request("POST", uri, data)
+	if err == nil && code > 300 {
+		err = fmt.Errorf("HTTP %d", code)
+	}
+	return err
+}
+
+// computeTestsStats updates counters of each suite and global counters from test cases
+func computeTestsStats(v *sdk.Tests) {
+	v.Total, v.TotalOK, v.TotalKO, v.TotalSkipped = 0, 0, 0, 0
+	for i := range v.TestSuites {
+		s := &v.TestSuites[i]
+		s.Total, s.Failures, s.Errors, s.Skip = len(s.Tests), 0, 0, 0
+		for _, t := range s.Tests {
+			switch {
+			case t.Error != "":
+				s.Errors++
+			case t.Failure != "":
+				s.Failures++
+			case t.Skip != nil:
+				s.Skip++
+			}
+		}
+		v.Total += s.Total
+		v.TotalKO += s.Failures + s.Errors
+		v.TotalOK += s.Total - s.Skip - s.Failures - s.Errors
+		v.TotalSkipped += s.Skip
+	}
+}
+
+// detectTestReportFormat guesses the format of a test report from its content
+func detectTestReportFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		switch xmlRootElement(trimmed) {
+		case "TestRun":
+			return sdk.TestFormatTRX
+		case "assemblies", "assembly":
+			return sdk.TestFormatXUnit
+		}
+		return sdk.TestFormatJUnit
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return sdk.TestFormatGoTest
+	default:
+		return sdk.TestFormatTAP
+	}
+}
+
+func xmlRootElement(data []byte) string {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if e, ok := tok.(xml.StartElement); ok {
+			return e.Name.Local
+		}
+	}
+}
+
+func parseTestReport(format, filename string, data []byte) ([]sdk.TestSuite, error) {
+	switch format {
+	case sdk.TestFormatJUnit:
+		return parseJUnit(data)
+	case sdk.TestFormatTAP:
+		name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		return parseTAP(name, data)
+	case sdk.TestFormatXUnit:
+		return parseXUnit(data)
+	case sdk.TestFormatGoTest:
+		return parseGoTestJSON(data)
+	case sdk.TestFormatTRX:
+		return parseTRX(data)
+	}
+	return nil, fmt.Errorf("unsupported test report format %s", format)
+}
+
+func parseJUnit(data []byte) ([]sdk.TestSuite, error) {
+	var v sdk.Tests
+	if err := xml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if len(v.TestSuites) > 0 {
+		return v.TestSuites, nil
+	}
+
+	// Is it nosetests format ?
+	if s, ok := parseNoseTests(data); ok {
+		return []sdk.TestSuite{s}, nil
+	}
+	return nil, nil
+}
+
+var (
+	tapTestRegexp      = regexp.MustCompile(`^(not ok|ok)\b\s*(\d+)?\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(.*))?$`)
+	tapDurationRegexp  = regexp.MustCompile(`(?m)^\s*duration_ms:\s*([0-9.]+)\s*$`)
+	tapDirectiveRegexp = regexp.MustCompile(`(?i)^(skip|todo)\S*\s*(.*)$`)
+)
+
+// parseTAP parses Test Anything Protocol output, diagnostics and YAML blocks are attached to the previous test
+func parseTAP(name string, data []byte) ([]sdk.TestSuite, error) {
+	s := sdk.TestSuite{Name: name}
+	var current *sdk.Test
+	var yaml []string
+	var inYAML bool
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if len(yaml) > 0 {
+			block := strings.Join(yaml, "\n")
+			if m := tapDurationRegexp.FindStringSubmatch(block); m != nil {
+				if ms, err := strconv.ParseFloat(m[1], 64); err == nil {
+					current.Time = formatTestDuration(ms / 1000)
+				}
+			}
+			if current.Failure != "" {
+				current.Failure = block
+			} else {
+				current.SystemOut = strings.TrimSpace(current.SystemOut + "\n" + block)
+			}
+		} else if current.Failure != "" && current.SystemOut != "" {
+			current.Failure = current.SystemOut
+		}
+		s.Tests = append(s.Tests, *current)
+		current = nil
+		yaml = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+				continue
+			}
+			yaml = append(yaml, strings.TrimPrefix(line, "  "))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "---" && current != nil:
+			inYAML = true
+			continue
+		case strings.HasPrefix(trimmed, "Bail out!"):
+			return nil, fmt.Errorf("%s", trimmed)
+		case strings.HasPrefix(trimmed, "#"):
+			if current != nil {
+				diag := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+				current.SystemOut = strings.TrimSpace(current.SystemOut + "\n" + diag)
+			}
+			continue
+		case line != trimmed:
+			// Indented lines are subtests, summarized by their parent
+			continue
+		}
+
+		m := tapTestRegexp.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		flush()
+
+		t := sdk.Test{Name: m[3]}
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("test %s", m[2])
+		}
+		if d := tapDirectiveRegexp.FindStringSubmatch(m[4]); d != nil {
+			reason := strings.TrimSpace(d[2])
+			if strings.ToLower(d[1]) == "todo" {
+				reason = strings.TrimSpace("TODO " + reason)
+			}
+			t.Skip = &reason
+		} else if m[1] == "not ok" {
+			t.Failure = "not ok"
+		}
+		current = &t
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if len(s.Tests) == 0 {
+		return nil, fmt.Errorf("no test found")
+	}
+	return []sdk.TestSuite{s}, nil
+}
+
+type xunitTest struct {
+	Name    string `xml:"name,attr"`
+	Time    string `xml:"time,attr"`
+	Result  string `xml:"result,attr"`
+	Reason  string `xml:"reason"`
+	Output  string `xml:"output"`
+	Failure *struct {
+		Message    string `xml:"message"`
+		StackTrace string `xml:"stack-trace"`
+	} `xml:"failure"`
+}
+
+type xunitAssembly struct {
+	Name        string `xml:"name,attr"`
+	Collections []struct {
+		Name  string      `xml:"name,attr"`
+		Tests []xunitTest `xml:"test"`
+	} `xml:"collection"`
+}
+
+// parseXUnit parses xUnit.net v2 XML reports, each collection is a test suite
+func parseXUnit(data []byte) ([]sdk.TestSuite, error) {
+	var assemblies []xunitAssembly
+	if xmlRootElement(data) == "assembly" {
+		var a xunitAssembly
+		if err := xml.Unmarshal(data, &a); err != nil {
+			return nil, err
+		}
+		assemblies = append(assemblies, a)
+	} else {
+		var r struct {
+			Assemblies []xunitAssembly `xml:"assembly"`
+		}
+		if err := xml.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		assemblies = r.Assemblies
+	}
+
+	var suites []sdk.TestSuite
+	for _, a := range assemblies {
+		for _, c := range a.Collections {
+			s := sdk.TestSuite{Name: c.Name}
+			if s.Name == "" {
+				s.Name = filepath.Base(a.Name)
+			}
+			for _, xt := range c.Tests {
+				t := sdk.Test{
+					Name:      xt.Name,
+					Time:      xt.Time,
+					SystemOut: strings.TrimSpace(xt.Output),
+				}
+				switch strings.ToLower(xt.Result) {
+				case "fail":
+					t.Failure = "Failed"
+					if xt.Failure != nil {
+						if msg := joinNonEmpty(xt.Failure.Message, xt.Failure.StackTrace); msg != "" {
+							t.Failure = msg
+						}
+					}
+				case "skip", "notrun":
+					reason := strings.TrimSpace(xt.Reason)
+					t.Skip = &reason
+				}
+				s.Tests = append(s.Tests, t)
+			}
+			suites = append(suites, s)
+		}
+	}
+	return suites, nil
+}
+
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// parseGoTestJSON parses output of go test -json, each package is a test suite
+func parseGoTestJSON(data []byte) ([]sdk.TestSuite, error) {
+	type goTest struct {
+		test   sdk.Test
+		output []string
+	}
+	type goPackage struct {
+		name   string
+		tests  []*goTest
+		index  map[string]*goTest
+		output []string
+		failed bool
+	}
+
+	var packages []*goPackage
+	index := map[string]*goPackage{}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var e goTestEvent
+		if err := d.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		p, ok := index[e.Package]
+		if !ok {
+			p = &goPackage{name: e.Package, index: map[string]*goTest{}}
+			index[e.Package] = p
+			packages = append(packages, p)
+		}
+
+		if e.Test == "" {
+			switch e.Action {
+			case "output":
+				p.output = append(p.output, e.Output)
+			case "fail":
+				p.failed = true
+			}
+			continue
+		}
+
+		t, ok := p.index[e.Test]
+		if !ok {
+			t = &goTest{test: sdk.Test{Name: e.Test}}
+			p.index[e.Test] = t
+			p.tests = append(p.tests, t)
+		}
+
+		switch e.Action {
+		case "output":
+			if !isGoTestFramingLine(e.Output) {
+				t.output = append(t.output, e.Output)
+			}
+		case "pass":
+			t.test.Time = formatTestDuration(e.Elapsed)
+		case "fail":
+			t.test.Time = formatTestDuration(e.Elapsed)
+			t.test.Failure = strings.TrimSpace(strings.Join(t.output, ""))
+			if t.test.Failure == "" {
+				t.test.Failure = "FAIL"
+			}
+		case "skip":
+			t.test.Time = formatTestDuration(e.Elapsed)
+			reason := strings.TrimSpace(strings.Join(t.output, ""))
+			t.test.Skip = &reason
+		}
+	}
+
+	var suites []sdk.TestSuite
+	for _, p := range packages {
+		s := sdk.TestSuite{Name: p.name}
+		var testFailed bool
+		for _, t := range p.tests {
+			t.test.SystemOut = strings.TrimSpace(strings.Join(t.output, ""))
+			testFailed = testFailed || t.test.Failure != ""
+			s.Tests = append(s.Tests, t.test)
+		}
+		// A package can fail without any failing test (build failure, panic in TestMain...)
+		if p.failed && !testFailed {
+			s.Tests = append(s.Tests, sdk.Test{
+				Name:  p.name,
+				Error: strings.TrimSpace(strings.Join(p.output, "")),
+			})
+		}
+		suites = append(suites, s)
+	}
+	return suites, nil
+}
+
+func isGoTestFramingLine(output string) bool {
+	line := strings.TrimSpace(output)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type trxTestRun struct {
+	Results []struct {
+		TestID   string `xml:"testId,attr"`
+		TestName string `xml:"testName,attr"`
+		Duration string `xml:"duration,attr"`
+		Outcome  string `xml:"outcome,attr"`
+		Output   struct {
+			StdOut    string `xml:"StdOut"`
+			StdErr    string `xml:"StdErr"`
+			ErrorInfo struct {
+				Message    string `xml:"Message"`
+				StackTrace string `xml:"StackTrace"`
+			} `xml:"ErrorInfo"`
+		} `xml:"Output"`
+	} `xml:"Results>UnitTestResult"`
+	Definitions []struct {
+		ID     string `xml:"id,attr"`
+		Method struct {
+			ClassName string `xml:"className,attr"`
+		} `xml:"TestMethod"`
+	} `xml:"TestDefinitions>UnitTest"`
+}
+
+// parseTRX parses Visual Studio test results, each test class is a test suite
+func parseTRX(data []byte) ([]sdk.TestSuite, error) {
+	var run trxTestRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return nil, err
+	}
+
+	classes := map[string]string{}
+	for _, d := range run.Definitions {
+		classes[d.ID] = d.Method.ClassName
+	}
+
+	var suites []sdk.TestSuite
+	suiteIndex := map[string]int{}
+	for _, r := range run.Results {
+		class := classes[r.TestID]
+		if class == "" {
+			class = "TRX"
+		}
+		i, ok := suiteIndex[class]
+		if !ok {
+			i = len(suites)
+			suiteIndex[class] = i
+			suites = append(suites, sdk.TestSuite{Name: class})
+		}
+
+		t := sdk.Test{
+			Name:      r.TestName,
+			SystemOut: strings.TrimSpace(r.Output.StdOut),
+			SystemErr: strings.TrimSpace(r.Output.StdErr),
+		}
+		if r.Duration != "" {
+			d, err := parseTRXDuration(r.Duration)
+			if err != nil {
+				return nil, err
+			}
+			t.Time = formatTestDuration(d.Seconds())
+		}
+
+		msg := joinNonEmpty(r.Output.ErrorInfo.Message, r.Output.ErrorInfo.StackTrace)
+		switch r.Outcome {
+		case "Passed", "PassedButRunAborted", "Completed":
+		case "NotExecuted", "Inconclusive", "Pending", "Disconnected", "Warning":
+			reason := strings.TrimSpace(r.Output.ErrorInfo.Message)
+			if reason == "" {
+				reason = r.Outcome
+			}
+			t.Skip = &reason
+		case "Error":
+			t.Error = msg
+			if t.Error == "" {
+				t.Error = r.Outcome
+			}
+		default:
+			t.Failure = msg
+			if t.Failure == "" {
+				t.Failure = r.Outcome
+			}
+		}
+		suites[i].Tests = append(suites[i].Tests, t)
+	}
+	return suites, nil
+}
+
+// parseTRXDuration parses durations formatted as hh:mm:ss.fffffff
+func parseTRXDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	sec, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec*float64(time.Second)), nil
+}
+
+// formatTestDuration formats a duration in seconds as JUnit does
+func formatTestDuration(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+func joinNonEmpty(values ...string) string {
+	var res []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return strings.Join(res, "\n")
+}
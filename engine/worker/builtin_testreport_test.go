@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files of test reports")
+
+func TestParseTestReportGolden(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+	}{
+		{"junit.xml", sdk.TestFormatJUnit},
+		{"tap.tap", sdk.TestFormatTAP},
+		{"xunit.xml", sdk.TestFormatXUnit},
+		{"gotest.json", sdk.TestFormatGoTest},
+		{"trx.trx", sdk.TestFormatTRX},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join("testdata", "testreport", tt.file)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("cannot read %s: %s", path, err)
+		}
+
+		if f := detectTestReportFormat(data); f != tt.format {
+			t.Fatalf("%s: expected format %s, got %s", tt.file, tt.format, f)
+		}
+
+		suites, err := parseTestReport(tt.format, path, data)
+		if err != nil {
+			t.Fatalf("%s: parse should not fail: %s", tt.file, err)
+		}
+		v := sdk.Tests{TestSuites: suites}
+		computeTestsStats(&v)
+
+		got, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			t.Fatalf("%s: cannot marshal tests: %s", tt.file, err)
+		}
+		got = append(got, '\n')
+
+		golden := path + ".golden"
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatalf("cannot write %s: %s", golden, err)
+			}
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("cannot read %s: %s", golden, err)
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s: result differs from %s:\n%s", tt.file, golden, got)
+		}
+	}
+}
+
+func TestParseTAPBailOut(t *testing.T) {
+	if _, err := parseTAP("bail", []byte("1..2\nok 1 - first\nBail out! database is down\n")); err == nil {
+		t.Fatalf("parseTAP should fail on bail out")
+	}
+}
+
+func TestParseTRXDuration(t *testing.T) {
+	d, err := parseTRXDuration("01:02:03.5000000")
+	if err != nil {
+		t.Fatalf("parseTRXDuration should not fail: %s", err)
+	}
+	if d.Seconds() != 3723.5 {
+		t.Fatalf("expected 3723.5s, got %s", d)
+	}
+	if _, err := parseTRXDuration("3.5"); err == nil {
+		t.Fatalf("parseTRXDuration should fail on invalid duration")
+	}
+}
@@ -77,14 +77,20 @@ func addBuildVarHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// OK, so now we got our new variable. We need to:
-	// - add it as a build var in API
+	if err := addBuildVariable(v); err != nil {
+		log.Critical("addBuildVarHandler> Cannot export variable: %s", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+}
+
+// addBuildVariable adds the variable as a build var in API
+// and in current building Action
+func addBuildVariable(v sdk.Variable) error {
 	buildVariables = append(buildVariables, v)
-	// - add it in current building Action
-	data, err = json.Marshal(v)
+	data, err := json.Marshal(v)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return err
 	}
 	// Retrieve build info
 	var proj, app, pip, bnS, env string
@@ -108,9 +114,5 @@ func addBuildVarHandler(w http.ResponseWriter, r *http.Request) {
 	if err == nil && code > 300 {
 		err = fmt.Errorf("HTTP %d", code)
 	}
-	if err != nil {
-		log.Critical("addBuildVarHandler> Cannot export variable: %s", err)
-		w.WriteHeader(http.StatusServiceUnavailable)
-		return
-	}
+	return err
 }
@@ -99,10 +99,10 @@ func runAction(a *sdk.Action, pipBuildJob sdk.PipelineBuildJob) sdk.Result {
 	replaceBuildVariablesPlaceholder(a)
 
 	if a.Type == sdk.BuiltinAction {
-		return runBuiltin(a, pipBuildJob)
+		return failedAction(a, runBuiltin(a, pipBuildJob))
 	}
 	if a.Type == sdk.PluginAction {
-		return runPlugin(a, pipBuildJob)
+		return failedAction(a, runPlugin(a, pipBuildJob))
 	}
 
 	// Nothing to do, success !
@@ -164,6 +164,14 @@ func runAction(a *sdk.Action, pipBuildJob sdk.PipelineBuildJob) sdk.Result {
 	return r
 }
 
+// failedAction records in a failed result the action which failed
+func failedAction(a *sdk.Action, r sdk.Result) sdk.Result {
+	if r.Status == sdk.StatusFail && r.FailedAction == "" {
+		r.FailedAction = a.Name
+	}
+	return r
+}
+
 var logsecrets []sdk.Variable
 
 func sendLog(buildid int64, step string, value string, pipelineBuildID int64) error {
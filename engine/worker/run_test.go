@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestFailedAction(t *testing.T) {
+	a := &sdk.Action{Name: sdk.TestReportAction, Type: sdk.BuiltinAction}
+
+	if r := failedAction(a, sdk.Result{Status: sdk.StatusFail}); r.FailedAction != sdk.TestReportAction {
+		t.Fatalf("failed action should be %s, got %q", sdk.TestReportAction, r.FailedAction)
+	}
+	if r := failedAction(a, sdk.Result{Status: sdk.StatusSuccess}); r.FailedAction != "" {
+		t.Fatalf("successful result should have no failed action, got %q", r.FailedAction)
+	}
+	if r := failedAction(a, sdk.Result{Status: sdk.StatusFail, FailedAction: "Script"}); r.FailedAction != "Script" {
+		t.Fatalf("failed action of a child should be kept, got %q", r.FailedAction)
+	}
+}
@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Access token scopes
+const (
+	// AccessTokenScopeRead only allows GET requests
+	AccessTokenScopeRead = "read"
+	// AccessTokenScopeRun allows GET requests and running pipelines
+	AccessTokenScopeRun = "run"
+	// AccessTokenScopeAdmin allows everything the user can do
+	AccessTokenScopeAdmin = "admin"
+)
+
+// AccessTokenScopes lists the valid access token scopes
+var AccessTokenScopes = []string{AccessTokenScopeRead, AccessTokenScopeRun, AccessTokenScopeAdmin}
+
+// AccessToken is a personal access token, used by bots and scripts to call the API as the user who created it
+type AccessToken struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name"`
+	Scope    string     `json:"scope"`
+	Projects []string   `json:"projects,omitempty"`
+	Created  time.Time  `json:"created"`
+	Expire   *time.Time `json:"expire,omitempty"`
+	LastUsed *time.Time `json:"last_used,omitempty"`
+	Revoked  *time.Time `json:"revoked,omitempty"`
+	// Token is only returned once, on creation
+	Token string `json:"token,omitempty"`
+}
+
+// AccessTokenRequest asks for a new personal access token, expiring after the given number of days (never with 0)
+type AccessTokenRequest struct {
+	Name         string   `json:"name"`
+	Scope        string   `json:"scope"`
+	Projects     []string `json:"projects,omitempty"`
+	ExpireInDays int64    `json:"expire_in_days"`
+}
+
+// IsValid returns false if the token has been revoked or is expired
+func (t AccessToken) IsValid(now time.Time) bool {
+	if t.Revoked != nil {
+		return false
+	}
+	return t.Expire == nil || now.Before(*t.Expire)
+}
+
+// AllowsProject returns true if the token is not restricted to some projects, or if the project is one of them
+func (t AccessToken) AllowsProject(key string) bool {
+	if len(t.Projects) == 0 {
+		return true
+	}
+	for _, p := range t.Projects {
+		if p == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAccessTokens returns the personal access tokens of the current user
+func ListAccessTokens() ([]AccessToken, error) {
+	data, code, err := Request("GET", "/user/token", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	tokens := []AccessToken{}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateAccessToken creates a personal access token, its value is only returned here
+func CreateAccessToken(req AccessTokenRequest) (*AccessToken, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, code, err := Request("POST", "/user/token", data)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	t := &AccessToken{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RevokeAccessToken revokes a personal access token of the current user
+func RevokeAccessToken(id int64) error {
+	_, code, err := Request("DELETE", fmt.Sprintf("/user/token/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessTokenIsValid(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	assert.True(t, AccessToken{}.IsValid(now))
+	assert.True(t, AccessToken{Expire: &future}.IsValid(now))
+	assert.False(t, AccessToken{Expire: &past}.IsValid(now))
+	assert.False(t, AccessToken{Revoked: &past}.IsValid(now))
+	assert.False(t, AccessToken{Expire: &future, Revoked: &past}.IsValid(now))
+}
+
+func TestAccessTokenAllowsProject(t *testing.T) {
+	assert.True(t, AccessToken{}.AllowsProject("PROJ"))
+
+	token := AccessToken{Projects: []string{"PROJ", "OTHER"}}
+	assert.True(t, token.AllowsProject("PROJ"))
+	assert.True(t, token.AllowsProject("OTHER"))
+	assert.False(t, token.AllowsProject("SECRET"))
+	assert.False(t, token.AllowsProject(""))
+}
@@ -65,9 +65,12 @@ const (
 
 // Builtin Action
 const (
-	ScriptAction = "Script"
-	NotifAction  = "Notif"
-	JUnitAction  = "JUnit"
+	ScriptAction     = "Script"
+	NotifAction      = "Notif"
+	JUnitAction      = "JUnit"
+	GitCloneAction   = "GitClone"
+	CoverageAction   = "Coverage"
+	TestReportAction = "TestReport"
 )
 
 const (
@@ -25,6 +25,9 @@ type ActionScript struct {
 		ArtifactDownload map[string]string            `json:"artifactDownload,omitempty"`
 		Script           string                       `json:"script,omitempty"`
 		JUnitReport      string                       `json:"jUnitReport,omitempty"`
+		GitClone         map[string]string            `json:"gitClone,omitempty"`
+		Coverage         map[string]string            `json:"coverage,omitempty"`
+		TestReport       map[string]string            `json:"testReport,omitempty"`
 		Plugin           map[string]map[string]string `json:"plugin,omitempty"`
 	} `json:"steps"`
 }
@@ -96,6 +99,33 @@ func NewActionFromScript(btes []byte) (*Action, error) {
 			goto next
 		}
 
+		//Action builtin = GitClone
+		if v.GitClone != nil {
+			newAction = NewActionGitClone(v.GitClone["url"], v.GitClone["branch"], v.GitClone["commit"], v.GitClone["directory"])
+			for _, name := range []string{"depth", "submodules", "lfs", "user", "password"} {
+				if val, ok := v.GitClone[name]; ok {
+					newAction.Parameters = append(newAction.Parameters, Parameter{
+						Name:  name,
+						Value: val,
+						Type:  StringParameter,
+					})
+				}
+			}
+			goto next
+		}
+
+		//Action builtin = Coverage
+		if v.Coverage != nil {
+			newAction = NewActionCoverage(v.Coverage["path"], v.Coverage["format"], v.Coverage["minimum"])
+			goto next
+		}
+
+		//Action builtin = TestReport
+		if v.TestReport != nil {
+			newAction = NewActionTestReport(v.TestReport["path"], v.TestReport["format"])
+			goto next
+		}
+
 		//Action builtin = ArtifactUpload
 		if v.ArtifactUpload != nil {
 			newAction = Action{
@@ -279,6 +309,61 @@ func NewActionJUnit(path string) Action {
 	}
 }
 
+//NewActionGitClone creates a builtin action gitClone
+func NewActionGitClone(url, branch, commit, directory string) Action {
+	return Action{
+		Name: GitCloneAction,
+		Type: BuiltinAction,
+		Parameters: []Parameter{
+			{
+				Name:  "url",
+				Value: url,
+				Type:  StringParameter,
+			},
+			{
+				Name:  "branch",
+				Value: branch,
+				Type:  StringParameter,
+			},
+			{
+				Name:  "commit",
+				Value: commit,
+				Type:  StringParameter,
+			},
+			{
+				Name:  "directory",
+				Value: directory,
+				Type:  StringParameter,
+			},
+		},
+	}
+}
+
+//NewActionCoverage creates a builtin action coverage
+func NewActionCoverage(path, format, minimum string) Action {
+	return Action{
+		Name: CoverageAction,
+		Type: BuiltinAction,
+		Parameters: []Parameter{
+			{
+				Name:  "path",
+				Value: path,
+				Type:  StringParameter,
+			},
+			{
+				Name:  "format",
+				Value: format,
+				Type:  StringParameter,
+			},
+			{
+				Name:  "minimum",
+				Value: minimum,
+				Type:  StringParameter,
+			},
+		},
+	}
+}
+
 //NewActionPlugin  creates a plugin action
 func NewActionPlugin(pluginname string, parameters []Parameter) Action {
 	return Action{
@@ -294,3 +379,23 @@ func NewActionPlugin(pluginname string, parameters []Parameter) Action {
 		},
 	}
 }
+
+//NewActionTestReport creates a builtin action testReport
+func NewActionTestReport(path, format string) Action {
+	return Action{
+		Name: TestReportAction,
+		Type: BuiltinAction,
+		Parameters: []Parameter{
+			{
+				Name:  "path",
+				Value: path,
+				Type:  StringParameter,
+			},
+			{
+				Name:  "format",
+				Value: format,
+				Type:  StringParameter,
+			},
+		},
+	}
+}
@@ -34,10 +34,26 @@ type Application struct {
 
 // ApplicationPipeline Represent the link between an application and a pipeline
 type ApplicationPipeline struct {
-	Pipeline     Pipeline          `json:"pipeline"`
-	Parameters   []Parameter       `json:"parameters"`
-	LastModified int64             `json:"last_modified"`
-	Triggers     []PipelineTrigger `json:"triggers,omitempty"`
+	Pipeline     Pipeline                   `json:"pipeline"`
+	Parameters   []Parameter                `json:"parameters"`
+	LastModified int64                      `json:"last_modified"`
+	Triggers     []PipelineTrigger          `json:"triggers,omitempty"`
+	Options      ApplicationPipelineOptions `json:"options"`
+}
+
+// ApplicationPipelineOptions defines the behaviour of the pipeline builds for an application
+type ApplicationPipelineOptions struct {
+	// RetryFlakyTests restarts once the jobs whose only failing tests are known to be flaky
+	RetryFlakyTests bool `json:"retry_flaky_tests"`
+	// CancelSuperseded stops the older building or waiting builds of a branch when a new build starts on it
+	CancelSuperseded bool `json:"cancel_superseded"`
+	// CommentPullRequests posts the summary of the finished pull request builds on the pull request
+	CommentPullRequests bool `json:"comment_pull_requests"`
+	// TagPatterns makes a release pipeline, only triggered by the tags matching one of the patterns instead of branches
+	TagPatterns []string `json:"tag_patterns,omitempty"`
+	// VersionFromTag sets cds.version to the semantic version of the tag triggering the build instead of the build version,
+	// the version of the build itself is unchanged
+	VersionFromTag bool `json:"version_from_tag"`
 }
 
 // NewApplication instanciate a new NewApplication
@@ -507,7 +523,11 @@ func AddPipelineScheduler(projectKey, appName, pipelineName, cronExpr, envName s
 		Crontab: cronExpr,
 		Args:    params,
 	}
+	return CreatePipelineScheduler(projectKey, appName, pipelineName, envName, &s)
+}
 
+//CreatePipelineScheduler add a pipeline scheduler with its timezone, jitter and skip policy
+func CreatePipelineScheduler(projectKey, appName, pipelineName, envName string, s *PipelineScheduler) (*PipelineScheduler, error) {
 	b, err := json.Marshal(s)
 	if err != nil {
 		return nil, err
@@ -515,7 +535,7 @@ func AddPipelineScheduler(projectKey, appName, pipelineName, cronExpr, envName s
 
 	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/scheduler", projectKey, appName, pipelineName)
 	if envName != "" {
-		path = path + url.QueryEscape("?envName="+envName)
+		path = path + "?envName=" + url.QueryEscape(envName)
 	}
 	data, code, err := Request("POST", path, b)
 	if err != nil {
@@ -530,11 +550,11 @@ func AddPipelineScheduler(projectKey, appName, pipelineName, cronExpr, envName s
 		return nil, fmt.Errorf("Error [%d]: %s", code, data)
 	}
 
-	if err := json.Unmarshal(data, &s); err != nil {
+	if err := json.Unmarshal(data, s); err != nil {
 		return nil, err
 	}
 
-	return &s, nil
+	return s, nil
 }
 
 //UpdatePipelineScheduler update a pipeline scheduler
@@ -583,3 +603,65 @@ func DeletePipelineScheduler(projectKey, appName, pipelineName string, s *Pipeli
 
 	return nil
 }
+
+//GetPipelineSchedulerNextExecutions returns the next planned executions of the schedulers of a pipeline
+func GetPipelineSchedulerNextExecutions(projectKey, appName, pipelineName string, count int) ([]PipelineSchedulerPlannedExecution, error) {
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/scheduler/next?count=%d", projectKey, appName, pipelineName, count)
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := DecodeError(data); err != nil {
+		return nil, err
+	}
+
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("Error [%d]: %s", code, data)
+	}
+
+	planned := []PipelineSchedulerPlannedExecution{}
+	if err := json.Unmarshal(data, &planned); err != nil {
+		return nil, err
+	}
+
+	return planned, nil
+}
+
+// GetApplicationPipelineOptions retrieves the options of a pipeline attached to an application
+func GetApplicationPipelineOptions(projectKey, appName, pipelineName string) (*ApplicationPipelineOptions, error) {
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/options", projectKey, appName, pipelineName)
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("Error [%d]: %s", code, data)
+	}
+
+	var opts ApplicationPipelineOptions
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+// UpdateApplicationPipelineOptions updates the options of a pipeline attached to an application
+func UpdateApplicationPipelineOptions(projectKey, appName, pipelineName string, opts ApplicationPipelineOptions) error {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/options", projectKey, appName, pipelineName)
+	data, code, err := Request("PUT", path, data)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
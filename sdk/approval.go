@@ -0,0 +1,99 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Approval status
+const (
+	ApprovalWaiting  = "Waiting"
+	ApprovalApproved = "Approved"
+	ApprovalRejected = "Rejected"
+)
+
+// ApprovalGate describes a manual approval required before running a stage or a trigger
+type ApprovalGate struct {
+	Groups  []string `json:"groups"`
+	Message string   `json:"message,omitempty"`
+}
+
+// Approval is a manual approval request on a pipeline build, with its decision
+type Approval struct {
+	ID              int64      `json:"id"`
+	PipelineBuildID int64      `json:"pipeline_build_id"`
+	BuildNumber     int64      `json:"build_number"`
+	StageID         int64      `json:"stage_id,omitempty"`
+	StageName       string     `json:"stage_name,omitempty"`
+	TriggerID       int64      `json:"trigger_id,omitempty"`
+	Groups          []string   `json:"groups"`
+	Message         string     `json:"message,omitempty"`
+	Status          string     `json:"status"`
+	Requested       time.Time  `json:"requested"`
+	Decided         *time.Time `json:"decided,omitempty"`
+	Username        string     `json:"username,omitempty"`
+	Comment         string     `json:"comment,omitempty"`
+}
+
+// ApprovalDecision is the body of an approve or reject request
+type ApprovalDecision struct {
+	Comment string `json:"comment"`
+}
+
+func approvalURI(projectKey, appName, pipelineName string, buildNumber int, action, env string) string {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/approval", projectKey, appName, pipelineName, buildNumber)
+	if action != "" {
+		uri = fmt.Sprintf("%s/%s", uri, action)
+	}
+	if env != "" {
+		uri = fmt.Sprintf("%s?envName=%s", uri, url.QueryEscape(env))
+	}
+	return uri
+}
+
+// GetBuildApprovals retrieves approval requests of a pipeline build
+func GetBuildApprovals(projectKey, appName, pipelineName, env string, buildNumber int) ([]Approval, error) {
+	data, code, err := Request("GET", approvalURI(projectKey, appName, pipelineName, buildNumber, "", env), nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var approvals []Approval
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// ApproveBuild approves the pending approval request of a pipeline build
+func ApproveBuild(projectKey, appName, pipelineName, env string, buildNumber int, comment string) error {
+	return decideBuildApproval(projectKey, appName, pipelineName, env, buildNumber, "approve", comment)
+}
+
+// RejectBuild rejects the pending approval request of a pipeline build
+func RejectBuild(projectKey, appName, pipelineName, env string, buildNumber int, comment string) error {
+	return decideBuildApproval(projectKey, appName, pipelineName, env, buildNumber, "reject", comment)
+}
+
+func decideBuildApproval(projectKey, appName, pipelineName, env string, buildNumber int, action, comment string) error {
+	data, err := json.Marshal(ApprovalDecision{Comment: comment})
+	if err != nil {
+		return err
+	}
+
+	data, code, err := Request("POST", approvalURI(projectKey, appName, pipelineName, buildNumber, action, env), data)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
@@ -19,6 +19,7 @@ type PipelineBuildJob struct {
 	Done            time.Time   `json:"done,omitempty" db:"done"`
 	Model           string      `json:"model,omitempty" db:"model"`
 	PipelineBuildID int64       `json:"pipeline_build_id,omitempty" db:"pipeline_build_id"`
+	Retry           int         `json:"retry" db:"retry"`
 }
 
 // BuildState define struct returned when looking for build state informations
@@ -50,6 +51,8 @@ func StatusFromString(in string) Status {
 		return StatusDisabled
 	case StatusSkipped.String():
 		return StatusSkipped
+	case StatusWaitingApproval.String():
+		return StatusWaitingApproval
 	default:
 		return StatusUnknown
 	}
@@ -70,6 +73,8 @@ const (
 	StatusNeverBuilt Status = "Never Built"
 	StatusUnknown    Status = "Unknown"
 	StatusSkipped    Status = "Skipped"
+	// StatusWaitingApproval is the status of a stage or a build paused on an approval gate
+	StatusWaitingApproval Status = "Waiting Approval"
 )
 
 // GetBuildQueue retrieves current CDS build in queue
@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Coverage report formats supported by Coverage builtin action
+const (
+	CoverageFormatCobertura = "cobertura"
+	CoverageFormatLCOV      = "lcov"
+	CoverageFormatGo        = "gocover"
+)
+
+// Coverage contains the code coverage summary of a pipeline build
+type Coverage struct {
+	PipelineBuildID int64          `json:"pipeline_build_id"`
+	BuildNumber     int64          `json:"build_number"`
+	Branch          string         `json:"branch"`
+	Format          string         `json:"format"`
+	Lines           int            `json:"lines"`
+	LinesCovered    int            `json:"lines_covered"`
+	Branches        int            `json:"branches"`
+	BranchesCovered int            `json:"branches_covered"`
+	Files           []CoverageFile `json:"files,omitempty"`
+	Created         time.Time      `json:"created"`
+	// Delta is the evolution of line coverage since the previous build on the same branch
+	Delta *float64 `json:"delta,omitempty"`
+}
+
+// CoverageFile contains the coverage of a single source file
+type CoverageFile struct {
+	Name            string `json:"name"`
+	Lines           int    `json:"lines"`
+	LinesCovered    int    `json:"lines_covered"`
+	Branches        int    `json:"branches"`
+	BranchesCovered int    `json:"branches_covered"`
+}
+
+// Percent returns the line coverage rate
+func (c Coverage) Percent() float64 {
+	if c.Lines == 0 {
+		return 0
+	}
+	return float64(c.LinesCovered) * 100 / float64(c.Lines)
+}
+
+// Add merges coverage of given files in coverage summary
+func (c *Coverage) Add(files ...CoverageFile) {
+	for _, f := range files {
+		var found bool
+		for i := range c.Files {
+			if c.Files[i].Name == f.Name {
+				c.Files[i] = f
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Files = append(c.Files, f)
+		}
+	}
+
+	c.Lines, c.LinesCovered, c.Branches, c.BranchesCovered = 0, 0, 0, 0
+	for _, f := range c.Files {
+		c.Lines += f.Lines
+		c.LinesCovered += f.LinesCovered
+		c.Branches += f.Branches
+		c.BranchesCovered += f.BranchesCovered
+	}
+}
+
+// GetCoverage retrieves coverage summary of a specific build
+func GetCoverage(proj, app, pip, env string, bn int) (*Coverage, error) {
+	if env == "" {
+		env = DefaultEnv.Name
+	}
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/coverage?envName=%s", proj, app, pip, bn, url.QueryEscape(env))
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var c Coverage
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCoverageHistory retrieves coverage history of an application, optionally filtered by branch
+func GetCoverageHistory(proj, app, branch string) ([]Coverage, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/coverage", proj, app)
+	if branch != "" {
+		uri = fmt.Sprintf("%s?branch=%s", uri, url.QueryEscape(branch))
+	}
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var cs []Coverage
+	if err := json.Unmarshal(data, &cs); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
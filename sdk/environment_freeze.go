@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// FreezeWindowDateLayout is the layout of absolute freeze window bounds, expressed in the window timezone
+const FreezeWindowDateLayout = "2006-01-02 15:04"
+
+// FreezeWindow is a period during which deployments on an environment are refused.
+// A window is either recurring (a cron expression of its start and a duration)
+// or absolute (start and end dates).
+type FreezeWindow struct {
+	ID       int64  `json:"id"`
+	Reason   string `json:"reason"`
+	Timezone string `json:"timezone,omitempty"`
+	Cron     string `json:"cron,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Start    string `json:"start,omitempty"`
+	End      string `json:"end,omitempty"`
+}
+
+// FreezeOverride is granted by an administrator to deploy on a frozen environment
+type FreezeOverride struct {
+	Token    string    `json:"token"`
+	Reason   string    `json:"reason"`
+	Username string    `json:"username"`
+	Expires  time.Time `json:"expires"`
+}
+
+// FreezeOverrideRequest is the body of a freeze override request
+type FreezeOverrideRequest struct {
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+}
+
+// GetFreezeWindows retrieves freeze windows of an environment
+func GetFreezeWindows(projectKey, envName string) ([]FreezeWindow, error) {
+	path := fmt.Sprintf("/project/%s/environment/%s/freeze", projectKey, envName)
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("Error [%d]: %s", code, data)
+	}
+
+	var windows []FreezeWindow
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// AddFreezeWindow adds a freeze window on an environment
+func AddFreezeWindow(projectKey, envName string, w FreezeWindow) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/project/%s/environment/%s/freeze", projectKey, envName)
+	data, code, err := Request("POST", path, data)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusCreated && code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
+
+// DeleteFreezeWindow removes a freeze window from an environment
+func DeleteFreezeWindow(projectKey, envName string, id int64) error {
+	path := fmt.Sprintf("/project/%s/environment/%s/freeze/%d", projectKey, envName, id)
+	data, code, err := Request("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
+
+// GrantFreezeOverride creates an override token allowing deployments on a frozen environment (admin only)
+func GrantFreezeOverride(projectKey, envName, reason, duration string) (*FreezeOverride, error) {
+	data, err := json.Marshal(FreezeOverrideRequest{Reason: reason, Duration: duration})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/project/%s/environment/%s/freeze/override", projectKey, envName)
+	data, code, err := Request("POST", path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("Error [%d]: %s", code, data)
+	}
+
+	var o FreezeOverride
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,75 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// EnvironmentLock is a manual lock set by an administrator to freeze deployments on an environment
+type EnvironmentLock struct {
+	Reason   string    `json:"reason"`
+	Username string    `json:"username"`
+	Locked   time.Time `json:"locked"`
+}
+
+// EnvironmentStatus describes deployments currently running or queued on an environment
+type EnvironmentStatus struct {
+	Environment string           `json:"environment"`
+	Lock        *EnvironmentLock `json:"lock,omitempty"`
+	Running     []PipelineBuild  `json:"running"`
+	Queued      []PipelineBuild  `json:"queued"`
+}
+
+// LockEnvironment freezes deployments on an environment
+func LockEnvironment(projectKey, envName, reason string) error {
+	data, err := json.Marshal(EnvironmentLock{Reason: reason})
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/project/%s/environment/%s/lock", projectKey, envName)
+	data, code, err := Request("POST", path, data)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
+
+// UnlockEnvironment removes the manual lock of an environment
+func UnlockEnvironment(projectKey, envName string) error {
+	path := fmt.Sprintf("/project/%s/environment/%s/lock", projectKey, envName)
+	data, code, err := Request("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
+
+// GetEnvironmentStatus retrieves lock, running and queued deployments of an environment
+func GetEnvironmentStatus(projectKey, envName string) (*EnvironmentStatus, error) {
+	path := fmt.Sprintf("/project/%s/environment/%s/status", projectKey, envName)
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("Error [%d]: %s", code, data)
+	}
+
+	var s EnvironmentStatus
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -93,6 +93,17 @@ var (
 	ErrParameterExists                       = &Error{ID: 79, Status: http.StatusConflict}
 	ErrNoHatchery                            = &Error{ID: 80, Status: http.StatusNotFound}
 	ErrInvalidWorkerStatus                   = &Error{ID: 81, Status: http.StatusNotFound}
+	ErrNoApprovalPending                     = &Error{ID: 82, Status: http.StatusNotFound}
+	ErrEnvironmentLocked                     = &Error{ID: 83, Status: http.StatusConflict}
+	ErrEnvironmentFrozen                     = &Error{ID: 84, Status: http.StatusForbidden}
+	ErrInvalidFreezeWindow                   = &Error{ID: 85, Status: http.StatusBadRequest}
+	ErrInvalidProgressiveDeployment          = &Error{ID: 86, Status: http.StatusBadRequest}
+	ErrInvalidTriggerCondition               = &Error{ID: 87, Status: http.StatusBadRequest}
+	ErrInvalidTriggerJoin                    = &Error{ID: 88, Status: http.StatusBadRequest}
+	ErrInvalidHookSignature                  = &Error{ID: 89, Status: http.StatusUnauthorized}
+	ErrOIDCNotEnabled                        = &Error{ID: 90, Status: http.StatusNotFound}
+	ErrGroupSyncNotEnabled                   = &Error{ID: 91, Status: http.StatusNotFound}
+	ErrInvalidAccessToken                    = &Error{ID: 92, Status: http.StatusBadRequest}
 )
 
 // SupportedLanguages on API errors
@@ -185,6 +196,17 @@ You can safely use them in a String or Text parameter`,
 	ErrParameterExists.ID:                       "parameter already exists",
 	ErrNoHatchery.ID:                            "No hatchery found",
 	ErrInvalidWorkerStatus.ID:                   "Worker status is invalid",
+	ErrNoApprovalPending.ID:                     "No approval pending on this build",
+	ErrEnvironmentLocked.ID:                     "Environment is locked, deployments are frozen",
+	ErrEnvironmentFrozen.ID:                     "Environment is in a deployment freeze window, an override granted by an administrator is required",
+	ErrInvalidFreezeWindow.ID:                   "Invalid freeze window: set either a cron expression with a duration, or start and end dates",
+	ErrInvalidProgressiveDeployment.ID:          "Invalid progressive deployment: check strategy, verification window and health check",
+	ErrInvalidTriggerCondition.ID:               "Invalid trigger condition",
+	ErrInvalidTriggerJoin.ID:                    "Invalid fan-in trigger: it needs at least two sources, distinct from its destination",
+	ErrInvalidHookSignature.ID:                  "Invalid webhook signature",
+	ErrOIDCNotEnabled.ID:                        "OpenID Connect login is not enabled",
+	ErrGroupSyncNotEnabled.ID:                   "LDAP group sync is not enabled",
+	ErrInvalidAccessToken.ID:                    "Invalid access token: it needs a name, a valid scope, existing projects and a positive expiry",
 }
 
 var errorsFrench = map[int]string{
@@ -271,6 +293,17 @@ Vous pouvez les utiliser sans problème dans un paramêtre de type String ou Tex
 	ErrParameterExists.ID:                       "le paramètre existe déjà",
 	ErrNoHatchery.ID:                            "La hatchery n'existe pas",
 	ErrInvalidWorkerStatus.ID:                   "Le status du worker est incorrect",
+	ErrNoApprovalPending.ID:                     "Aucune approbation en attente sur ce build",
+	ErrEnvironmentLocked.ID:                     "L'environnement est verrouillé, les déploiements sont gelés",
+	ErrEnvironmentFrozen.ID:                     "L'environnement est dans une période de gel des déploiements, une dérogation accordée par un administrateur est nécessaire",
+	ErrInvalidFreezeWindow.ID:                   "Période de gel invalide : indiquez soit une expression cron avec une durée, soit des dates de début et de fin",
+	ErrInvalidProgressiveDeployment.ID:          "Déploiement progressif invalide : vérifiez la stratégie, la fenêtre de vérification et le contrôle de santé",
+	ErrInvalidTriggerCondition.ID:               "Condition de déclenchement invalide",
+	ErrInvalidTriggerJoin.ID:                    "Déclencheur multiple invalide : il nécessite au moins deux sources, différentes de sa destination",
+	ErrInvalidHookSignature.ID:                  "Signature du webhook invalide",
+	ErrOIDCNotEnabled.ID:                        "La connexion OpenID Connect n'est pas activée",
+	ErrGroupSyncNotEnabled.ID:                   "La synchronisation des groupes LDAP n'est pas activée",
+	ErrInvalidAccessToken.ID:                    "Jeton d'accès invalide : il nécessite un nom, une portée valide, des projets existants et une expiration positive",
 }
 
 var matcher = language.NewMatcher(SupportedLanguages)
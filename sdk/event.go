@@ -63,3 +63,15 @@ type EventNotif struct {
 	Subject    string   `json:"subject,omitempty"`
 	Body       string   `json:"body,omitempty"`
 }
+
+// EventPullRequestComment contains the summary of a pull request build to comment on the pull request
+type EventPullRequestComment struct {
+	ProjectKey            string `json:"projectKey,omitempty"`
+	ApplicationName       string `json:"applicationName,omitempty"`
+	PipelineName          string `json:"pipelineName,omitempty"`
+	BuildNumber           int64  `json:"buildNumber,omitempty"`
+	RepositoryManagerName string `json:"repositoryManagerName,omitempty"`
+	RepositoryFullname    string `json:"repositoryFullname,omitempty"`
+	PullRequestID         int64  `json:"pullRequestID,omitempty"`
+	Body                  string `json:"body,omitempty"`
+}
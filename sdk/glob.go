@@ -0,0 +1,34 @@
+package sdk
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob returns true if the value matches the glob. The glob and the value are split on '/':
+// '*', '?' and character classes match within a single segment, as in path.Match, and a '**' segment
+// matches any number of segments, including none. A malformed glob matches nothing.
+func MatchGlob(glob, value string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(value, "/"))
+}
+
+func matchSegments(glob, value []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(value); i++ {
+				if matchSegments(glob[1:], value[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(value) == 0 {
+			return false
+		}
+		if ok, err := path.Match(glob[0], value[0]); err != nil || !ok {
+			return false
+		}
+		glob, value = glob[1:], value[1:]
+	}
+	return len(value) == 0
+}
@@ -0,0 +1,21 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	assert.True(t, MatchGlob("release/*", "release/1.2"))
+	assert.True(t, MatchGlob("release/**", "release/1.2/hotfix"))
+	assert.True(t, MatchGlob("**/*.go", "main.go"))
+	assert.True(t, MatchGlob("**/*.go", "engine/api/main.go"))
+	assert.True(t, MatchGlob("v?.*", "v1.2"))
+	assert.True(t, MatchGlob("v[0-9]*", "v1.2"))
+
+	assert.False(t, MatchGlob("release/*", "release/1.2/hotfix"))
+	assert.False(t, MatchGlob("docs/*.md", "docs.md"))
+	assert.False(t, MatchGlob("v?", "v/"))
+	assert.False(t, MatchGlob("release/[", "release/1.2"))
+}
@@ -0,0 +1,83 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Group sync actions
+const (
+	GroupSyncCreateGroup  = "create"
+	GroupSyncAddMember    = "add"
+	GroupSyncRemoveMember = "remove"
+	GroupSyncSetAdmin     = "admin"
+	GroupSyncUnsetAdmin   = "unadmin"
+)
+
+// Group sync triggers
+const (
+	GroupSyncPeriodic = "periodic"
+	GroupSyncLogin    = "login"
+	GroupSyncManual   = "manual"
+)
+
+// GroupSyncChange is a change of group membership done by a group sync
+type GroupSyncChange struct {
+	Group    string `json:"group"`
+	Username string `json:"username,omitempty"`
+	Action   string `json:"action"`
+	Admin    bool   `json:"admin,omitempty"`
+}
+
+// GroupSyncLog reports what a group sync with an external directory did, or would have done in dry run
+type GroupSyncLog struct {
+	ID      int64             `json:"id"`
+	Origin  string            `json:"origin"`
+	Trigger string            `json:"trigger"`
+	DryRun  bool              `json:"dry_run"`
+	Started time.Time         `json:"started"`
+	Changes []GroupSyncChange `json:"changes"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// SyncGroups runs the group sync with the external directory now, without changing anything in dry run
+func SyncGroups(dryRun bool) (*GroupSyncLog, error) {
+	uri := "/admin/group/sync"
+	if dryRun {
+		uri += "?dryRun=true"
+	}
+
+	data, code, err := Request("POST", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	l := &GroupSyncLog{}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetGroupSyncLogs returns the last group syncs with the external directory
+func GetGroupSyncLogs() ([]GroupSyncLog, error) {
+	data, code, err := Request("GET", "/admin/group/sync", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	logs := []GroupSyncLog{}
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
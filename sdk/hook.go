@@ -4,20 +4,49 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Hook used to link a git repository to a given pipeline
 type Hook struct {
-	ID            int64    `json:"id"`
-	UID           string   `json:"uid"`
-	Pipeline      Pipeline `json:"pipeline"`
-	ApplicationID int64    `json:"application_id"`
-	Kind          string   `json:"kind"`
-	Host          string   `json:"host"`
-	Project       string   `json:"project"`
-	Repository    string   `json:"repository"`
-	Enabled       bool     `json:"enabled"`
-	Link          string   `json:"link"`
+	ID            int64             `json:"id"`
+	UID           string            `json:"uid"`
+	Pipeline      Pipeline          `json:"pipeline"`
+	ApplicationID int64             `json:"application_id"`
+	Kind          string            `json:"kind"`
+	Host          string            `json:"host"`
+	Project       string            `json:"project"`
+	Repository    string            `json:"repository"`
+	Enabled       bool              `json:"enabled"`
+	Link          string            `json:"link"`
+	Secret        string            `json:"secret,omitempty"`
+	Webhooks      map[string]string `json:"webhooks,omitempty"`
+	Paths         PathFilter        `json:"paths"`
+}
+
+// Status of the hook deliveries
+const (
+	HookDeliveryTriggered = "Triggered"
+	HookDeliverySkipped   = "Skipped"
+	HookDeliveryError     = "Error"
+)
+
+// HookDelivery is a push received by a hook, and what CDS did with it
+type HookDelivery struct {
+	ID          int64       `json:"id"`
+	HookID      int64       `json:"hook_id"`
+	Received    time.Time   `json:"received"`
+	Link        string      `json:"link"`
+	Payload     string      `json:"payload"`
+	Branch      string      `json:"branch"`
+	Hash        string      `json:"hash"`
+	Author      string      `json:"author"`
+	Message     string      `json:"message"`
+	Args        []Parameter `json:"args,omitempty"`
+	Status      string      `json:"status"`
+	BuildNumber int64       `json:"build_number,omitempty"`
+	Reason      string      `json:"reason,omitempty"`
+	ReplayOf    int64       `json:"replay_of,omitempty"`
 }
 
 // AddHook creates a new hook between a pipeline and a repository
@@ -92,3 +121,83 @@ func DeleteHook(project, application, pipeline string, id int64) error {
 
 	return nil
 }
+
+// UpdateHook updates a hook, such as its paths filter
+func UpdateHook(project, application, pipeline string, h Hook) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/hook/%d", project, application, pipeline, h.ID)
+	_, code, err := Request("PUT", uri, data)
+	if err != nil {
+		return err
+	}
+
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+
+	return nil
+}
+
+// RegenerateHookSecret replaces the secret signing the native webhooks of a hook, the new secret is only returned here
+func RegenerateHookSecret(project, application, pipeline string, hookID int64) (*Hook, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/hook/%d/secret", project, application, pipeline, hookID)
+	data, code, err := Request("POST", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var h Hook
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// GetHookDeliveries retrieves the last pushes received by a hook
+func GetHookDeliveries(project, application, pipeline string, hookID int64) ([]HookDelivery, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/hook/%d/delivery", project, application, pipeline, hookID)
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var deliveries []HookDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ReplayHookDelivery processes again a push received by a hook, it returns the new delivery
+func ReplayHookDelivery(project, application, pipeline string, hookID, deliveryID int64) (*HookDelivery, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/hook/%d/delivery/%d/replay", project, application, pipeline, hookID, deliveryID)
+	data, code, err := Request("POST", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", code, data)
+	}
+
+	var d HookDelivery
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,98 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Deployment is the last successful deployment of an application pipeline on an environment
+type Deployment struct {
+	ApplicationID int64     `json:"-"`
+	Application   string    `json:"application"`
+	Pipeline      string    `json:"pipeline"`
+	EnvironmentID int64     `json:"-"`
+	Environment   string    `json:"environment"`
+	BuildNumber   int64     `json:"build_number"`
+	Version       int64     `json:"version"`
+	Branch        string    `json:"branch"`
+	Hash          string    `json:"hash"`
+	Deployer      string    `json:"deployer"`
+	Done          time.Time `json:"done"`
+}
+
+// EnvironmentInventory lists what is currently deployed on an environment
+type EnvironmentInventory struct {
+	Environment string       `json:"environment"`
+	Deployments []Deployment `json:"deployments"`
+}
+
+// ApplicationDrift compares the deployments of an application pipeline on two environments.
+// From or To is nil when the application has never been deployed on the environment.
+type ApplicationDrift struct {
+	Application string      `json:"application"`
+	Pipeline    string      `json:"pipeline"`
+	From        *Deployment `json:"from,omitempty"`
+	To          *Deployment `json:"to,omitempty"`
+	Drift       bool        `json:"drift"`
+}
+
+// GetInventory retrieves the current deployments of all environments of a project
+func GetInventory(projectKey string) ([]EnvironmentInventory, error) {
+	data, code, err := Request("GET", fmt.Sprintf("/project/%s/inventory", projectKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var inventory []EnvironmentInventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// GetInventoryDrift compares deployments of a project between two environments
+func GetInventoryDrift(projectKey, from, to string) ([]ApplicationDrift, error) {
+	uri := fmt.Sprintf("/project/%s/inventory/drift?from=%s&to=%s", projectKey, url.QueryEscape(from), url.QueryEscape(to))
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var drifts []ApplicationDrift
+	if err := json.Unmarshal(data, &drifts); err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+// GetInventoryDiff retrieves commits deployed on environment from but not yet on environment to for an application
+func GetInventoryDiff(projectKey, appName, pipelineName, from, to string) ([]VCSCommit, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/inventory/diff?from=%s&to=%s", projectKey, appName, url.QueryEscape(from), url.QueryEscape(to))
+	if pipelineName != "" {
+		uri = fmt.Sprintf("%s&pipeline=%s", uri, url.QueryEscape(pipelineName))
+	}
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		if e := DecodeError(data); e != nil {
+			return nil, e
+		}
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var commits []VCSCommit
+	if err := json.Unmarshal(data, &commits); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
@@ -96,3 +96,13 @@ func AddParameter(array *[]Parameter, name string, parameterType ParameterType,
 	})
 	*array = params
 }
+
+// ParameterValue returns the value of the parameter with the given name, or an empty string
+func ParameterValue(params []Parameter, name string) string {
+	for _, p := range params {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
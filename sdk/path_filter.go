@@ -0,0 +1,62 @@
+package sdk
+
+import "strings"
+
+// PathFilter restricts the builds triggered by a hook or a poller to the changes of some files of a repository.
+// Globs are matched against the paths from the root of the repository, ** matches any number of directories,
+// and a directory matches all the files below it.
+type PathFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// IsEmpty returns true if the filter lets every change trigger a build
+func (f PathFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Match returns true if the file matches one of the include globs, if any, and none of the exclude globs
+func (f PathFilter) Match(file string) bool {
+	included := len(f.Include) == 0
+	for _, g := range f.Include {
+		if MatchPath(g, file) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, g := range f.Exclude {
+		if MatchPath(g, file) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAny returns the first file matching the filter
+func (f PathFilter) MatchAny(files []string) (string, bool) {
+	for _, file := range files {
+		if f.Match(file) {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// MatchPath returns true if the file, or one of its directories, matches the glob
+func MatchPath(glob, file string) bool {
+	glob = strings.Trim(glob, "/")
+	file = strings.Trim(file, "/")
+	for {
+		if MatchGlob(glob, file) {
+			return true
+		}
+		i := strings.LastIndex(file, "/")
+		if i < 0 {
+			return false
+		}
+		file = file[:i]
+	}
+}
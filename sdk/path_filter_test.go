@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPath(t *testing.T) {
+	assert.True(t, MatchPath("services/api", "services/api/main.go"))
+	assert.True(t, MatchPath("services/api/", "services/api/handlers/user.go"))
+	assert.True(t, MatchPath("services/*/main.go", "services/api/main.go"))
+	assert.True(t, MatchPath("**/*.md", "README.md"))
+	assert.True(t, MatchPath("**/*.md", "doc/tutorials/intro.md"))
+	assert.True(t, MatchPath("services/**/Dockerfile", "services/api/build/Dockerfile"))
+	assert.True(t, MatchPath("libs/**", "libs/common/log.go"))
+
+	assert.False(t, MatchPath("services/api", "services/api2/main.go"))
+	assert.False(t, MatchPath("services/*/main.go", "services/api/cmd/main.go"))
+	assert.False(t, MatchPath("*.md", "doc/intro.md"))
+	assert.False(t, MatchPath("services/[", "services/api"))
+}
+
+func TestPathFilter(t *testing.T) {
+	assert.True(t, PathFilter{}.IsEmpty())
+	assert.True(t, PathFilter{}.Match("anything.go"))
+
+	f := PathFilter{
+		Include: []string{"services/api", "libs"},
+		Exclude: []string{"**/*.md"},
+	}
+	assert.False(t, f.IsEmpty())
+	assert.True(t, f.Match("services/api/main.go"))
+	assert.True(t, f.Match("libs/common/log.go"))
+	assert.False(t, f.Match("services/api/README.md"))
+	assert.False(t, f.Match("services/web/index.html"))
+
+	file, ok := f.MatchAny([]string{"services/web/index.html", "services/api/README.md", "libs/common/log.go"})
+	assert.True(t, ok)
+	assert.Equal(t, "libs/common/log.go", file)
+
+	_, ok = f.MatchAny([]string{"services/web/index.html", "doc/intro.md"})
+	assert.False(t, ok)
+
+	// Without include globs, everything but the excluded files triggers a build
+	f = PathFilter{Exclude: []string{"doc"}}
+	assert.True(t, f.Match("services/api/main.go"))
+	assert.False(t, f.Match("doc/intro.md"))
+}
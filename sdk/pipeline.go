@@ -35,16 +35,19 @@ type PipelineBuild struct {
 	Start       time.Time   `json:"start,omitempty"`
 	Done        time.Time   `json:"done,omitempty"`
 	Stages      []Stage     `json:"stages"`
+	StopReason  string      `json:"stop_reason,omitempty"`
 
 	Pipeline    Pipeline    `json:"pipeline"`
 	Application Application `json:"application"`
 	Environment Environment `json:"environment"`
 
-	Artifacts             []Artifact           `json:"artifacts,omitempty"`
-	Tests                 *Tests               `json:"tests,omitempty"`
-	Commits               []VCSCommit          `json:"commits,omitempty"`
-	Trigger               PipelineBuildTrigger `json:"trigger"`
-	PreviousPipelineBuild *PipelineBuild       `json:"previous_pipeline_build"`
+	Artifacts             []Artifact               `json:"artifacts,omitempty"`
+	Tests                 *Tests                   `json:"tests,omitempty"`
+	Coverage              *Coverage                `json:"coverage,omitempty"`
+	Verifications         []DeploymentVerification `json:"verifications,omitempty"`
+	Commits               []VCSCommit              `json:"commits,omitempty"`
+	Trigger               PipelineBuildTrigger     `json:"trigger"`
+	PreviousPipelineBuild *PipelineBuild           `json:"previous_pipeline_build"`
 }
 
 // PipelineBuildTrigger Struct for history table
@@ -56,6 +59,22 @@ type PipelineBuildTrigger struct {
 	VCSChangesBranch    string         `json:"vcs_branch"`
 	VCSChangesHash      string         `json:"vcs_hash"`
 	VCSChangesAuthor    string         `json:"vcs_author"`
+	FreezeOverride      string         `json:"-"`
+	// TagVersion is the version of the tag triggering a release pipeline with VersionFromTag, set as cds.version
+	TagVersion string `json:"-"`
+}
+
+// SkippedBuild is a commit received by a hook or a poller which did not trigger a build
+type SkippedBuild struct {
+	ID            int64     `json:"id"`
+	ApplicationID int64     `json:"application_id"`
+	PipelineID    int64     `json:"pipeline_id"`
+	Branch        string    `json:"branch"`
+	Hash          string    `json:"hash"`
+	Author        string    `json:"author"`
+	Origin        string    `json:"origin"`
+	Reason        string    `json:"reason"`
+	Skipped       time.Time `json:"skipped"`
 }
 
 // PipelineType defines the purpose of a given pipeline
@@ -104,6 +123,7 @@ type CDPipeline struct {
 	Pipeline     Pipeline        `json:"pipeline"`
 	SubPipelines []CDPipeline    `json:"subPipelines"`
 	Trigger      PipelineTrigger `json:"trigger"`
+	Joins        []TriggerJoin   `json:"joins,omitempty"`
 }
 
 // RunRequest  Request to run a pipeline
@@ -114,6 +134,7 @@ type RunRequest struct {
 	ParentPipelineID    int64       `json:"parent_pipeline_id,omitempty"`
 	ParentEnvironmentID int64       `json:"parent_environment_id,omitempty"`
 	ParentApplicationID int64       `json:"parent_application_id,omitempty"`
+	FreezeOverride      string      `json:"freeze_override,omitempty"`
 }
 
 // ListPipelines retrieves all available pipelines to called
@@ -321,6 +342,27 @@ func GetPipelineBuildHistory(key, appName, name, env string) ([]PipelineBuild, e
 	return res, nil
 }
 
+// GetSkippedBuilds retrieves the last commits which did not trigger the pipeline, and why
+func GetSkippedBuilds(key, appName, name string) ([]SkippedBuild, error) {
+	var res []SkippedBuild
+
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/skipped", key, appName, name)
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // GetBuildLogs retrieve all output from given build
 func GetBuildLogs(key, pipelineName, env string, buildID int) ([]Log, error) {
 	var logs []Log
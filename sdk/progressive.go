@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Progressive deployment strategies
+const (
+	DeploymentCanary    = "canary"
+	DeploymentBlueGreen = "blue_green"
+)
+
+// Blue/green deployment slots
+const (
+	SlotBlue  = "blue"
+	SlotGreen = "green"
+)
+
+// Health check types
+const (
+	HealthCheckHTTP   = "http"
+	HealthCheckMetric = "metric"
+	HealthCheckPlugin = "plugin"
+)
+
+// Deployment verification status
+const (
+	VerificationRunning    = "Verifying"
+	VerificationPromoted   = "Promoted"
+	VerificationRolledBack = "RolledBack"
+)
+
+// ProgressiveDeployment makes a stage deploy to a subset (canary) or to the idle slot (blue/green),
+// then verify the deployment with a health check before promoting it or rolling it back
+type ProgressiveDeployment struct {
+	Strategy           string      `json:"strategy"`
+	Weight             int         `json:"weight,omitempty"`
+	VerificationWindow string      `json:"verification_window"`
+	Interval           string      `json:"interval,omitempty"`
+	MaxFailures        int         `json:"max_failures,omitempty"`
+	AutoRollback       bool        `json:"auto_rollback"`
+	HealthCheck        HealthCheck `json:"health_check"`
+}
+
+// HealthCheck describes how a progressive deployment is verified.
+// An http check probes URL and expects ExpectedStatus (default 200).
+// A metric check queries URL with Query and compares the returned value to Threshold with Operator.
+// A plugin check delegates to the health check plugin registered as Plugin, with Options.
+type HealthCheck struct {
+	Type           string            `json:"type"`
+	URL            string            `json:"url,omitempty"`
+	ExpectedStatus int               `json:"expected_status,omitempty"`
+	Timeout        string            `json:"timeout,omitempty"`
+	Query          string            `json:"query,omitempty"`
+	Operator       string            `json:"operator,omitempty"`
+	Threshold      float64           `json:"threshold,omitempty"`
+	Plugin         string            `json:"plugin,omitempty"`
+	Options        map[string]string `json:"options,omitempty"`
+}
+
+// DeploymentVerification is the verification of a progressive deployment stage in a pipeline build
+type DeploymentVerification struct {
+	ID                  int64      `json:"id"`
+	PipelineBuildID     int64      `json:"pipeline_build_id"`
+	BuildNumber         int64      `json:"build_number"`
+	StageID             int64      `json:"stage_id"`
+	StageName           string     `json:"stage_name"`
+	Strategy            string     `json:"strategy"`
+	Slot                string     `json:"slot"`
+	Status              string     `json:"status"`
+	Started             time.Time  `json:"started"`
+	LastCheck           *time.Time `json:"last_check,omitempty"`
+	Ended               *time.Time `json:"ended,omitempty"`
+	Checks              int        `json:"checks"`
+	Failures            int        `json:"failures"`
+	LastResult          string     `json:"last_result,omitempty"`
+	Reason              string     `json:"reason,omitempty"`
+	RollbackBuildNumber int64      `json:"rollback_build_number,omitempty"`
+}
+
+// GetBuildVerifications retrieves deployment verifications of a pipeline build
+func GetBuildVerifications(projectKey, appName, pipelineName, env string, buildNumber int) ([]DeploymentVerification, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/verification", projectKey, appName, pipelineName, buildNumber)
+	if env != "" {
+		uri = fmt.Sprintf("%s?envName=%s", uri, url.QueryEscape(env))
+	}
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var verifications []DeploymentVerification
+	if err := json.Unmarshal(data, &verifications); err != nil {
+		return nil, err
+	}
+	return verifications, nil
+}
@@ -0,0 +1,140 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Release notes grouping
+const (
+	ReleaseNotesByType  = "type"
+	ReleaseNotesByIssue = "issue"
+)
+
+// ReleaseNotes aggregates commits between two builds or two environments of an application
+type ReleaseNotes struct {
+	Application string                `json:"application"`
+	From        string                `json:"from"`
+	To          string                `json:"to"`
+	FromHash    string                `json:"from_hash,omitempty"`
+	ToHash      string                `json:"to_hash,omitempty"`
+	GroupBy     string                `json:"group_by"`
+	Sections    []ReleaseNotesSection `json:"sections"`
+	Issues      []string              `json:"issues"`
+	Markdown    string                `json:"markdown,omitempty"`
+}
+
+// ReleaseNotesSection groups release notes entries of a conventional commit type or an issue
+type ReleaseNotesSection struct {
+	Key     string              `json:"key"`
+	Title   string              `json:"title"`
+	Entries []ReleaseNotesEntry `json:"entries"`
+}
+
+// ReleaseNotesEntry is a commit parsed as a conventional commit
+type ReleaseNotesEntry struct {
+	Hash     string   `json:"hash"`
+	Type     string   `json:"type,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	Subject  string   `json:"subject"`
+	Breaking bool     `json:"breaking,omitempty"`
+	Issues   []string `json:"issues,omitempty"`
+	Author   string   `json:"author"`
+	URL      string   `json:"url,omitempty"`
+}
+
+// ReleaseNotesRequest is the body of a release notes generation on a pipeline build
+type ReleaseNotesRequest struct {
+	GroupBy      string `json:"group_by"`
+	IssuePattern string `json:"issue_pattern,omitempty"`
+	Artifact     bool   `json:"artifact"`
+	ArtifactTag  string `json:"artifact_tag,omitempty"`
+	Tag          string `json:"tag,omitempty"`
+}
+
+// ReleaseNotesQuery selects what release notes are generated on an application: between two builds of a pipeline,
+// or between the deployments of two environments
+type ReleaseNotesQuery struct {
+	Pipeline     string
+	Env          string
+	FromBuild    int
+	ToBuild      int
+	FromEnv      string
+	ToEnv        string
+	GroupBy      string
+	IssuePattern string
+}
+
+// GetReleaseNotes generates release notes of an application
+func GetReleaseNotes(projectKey, appName string, q ReleaseNotesQuery) (*ReleaseNotes, error) {
+	v := url.Values{}
+	if q.FromEnv != "" {
+		v.Set("fromEnv", q.FromEnv)
+		v.Set("toEnv", q.ToEnv)
+	} else {
+		v.Set("fromBuild", fmt.Sprintf("%d", q.FromBuild))
+		v.Set("toBuild", fmt.Sprintf("%d", q.ToBuild))
+	}
+	if q.Pipeline != "" {
+		v.Set("pipeline", q.Pipeline)
+	}
+	if q.Env != "" {
+		v.Set("envName", q.Env)
+	}
+	if q.GroupBy != "" {
+		v.Set("groupBy", q.GroupBy)
+	}
+	if q.IssuePattern != "" {
+		v.Set("issuePattern", q.IssuePattern)
+	}
+
+	data, code, err := Request("GET", fmt.Sprintf("/project/%s/application/%s/releasenotes?%s", projectKey, appName, v.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		if e := DecodeError(data); e != nil {
+			return nil, e
+		}
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var rn ReleaseNotes
+	if err := json.Unmarshal(data, &rn); err != nil {
+		return nil, err
+	}
+	return &rn, nil
+}
+
+// GenerateBuildReleaseNotes generates release notes of a pipeline build since the previous successful one,
+// and optionally attaches them as an artifact or publishes them on a tag
+func GenerateBuildReleaseNotes(projectKey, appName, pipelineName, env string, buildNumber int, req ReleaseNotesRequest) (*ReleaseNotes, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/releasenotes", projectKey, appName, pipelineName, buildNumber)
+	if env != "" {
+		uri = fmt.Sprintf("%s?envName=%s", uri, url.QueryEscape(env))
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, code, err := Request("POST", uri, body)
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		if e := DecodeError(data); e != nil {
+			return nil, e
+		}
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var rn ReleaseNotes
+	if err := json.Unmarshal(data, &rn); err != nil {
+		return nil, err
+	}
+	return &rn, nil
+}
@@ -15,6 +15,10 @@ const (
 	Stash RepositoriesManagerType = "STASH"
 	//Github is valued to "GITHUB"
 	Github RepositoriesManagerType = "GITHUB"
+	//Gitlab is valued to "GITLAB"
+	Gitlab RepositoriesManagerType = "GITLAB"
+	//Git is valued to "GIT", for any git server without provider API
+	Git RepositoriesManagerType = "GIT"
 )
 
 //RepositoriesManager is the struct for every repositories manager.
@@ -36,6 +40,7 @@ type RepositoryPoller struct {
 	Pipeline     Pipeline    `json:"pipeline"`
 	Enabled      bool        `json:"enabled"`
 	DateCreation time.Time   `json:"date_creation"`
+	Paths        PathFilter  `json:"paths"`
 }
 
 //RepositoriesManagerDriver is the consumer interface
@@ -276,6 +281,7 @@ type RepositoriesManagerClient interface {
 	//Commits
 	Commits(repo, branch, since, until string) ([]VCSCommit, error)
 	Commit(repo, hash string) (VCSCommit, error)
+	ChangedFiles(repo, since, until string) ([]string, error)
 
 	//Hooks
 	CreateHook(repo, url string) error
@@ -286,6 +292,12 @@ type RepositoriesManagerClient interface {
 
 	// Set build status on repository
 	SetStatus(event Event) error
+
+	//Pull requests
+	PullRequestComment(repo string, id int64, body string) error
+
+	//Releases
+	CreateRelease(repo, tag, hash, title, body string) error
 }
 
 //VCSRepo represents data about repository even on stash, or github, etc...
@@ -328,4 +340,5 @@ type VCSBranch struct {
 type VCSPushEvent struct {
 	Branch VCSBranch `json:"branch"`
 	Commit VCSCommit `json:"commit"`
+	Tag    string    `json:"tag,omitempty"`
 }
@@ -24,6 +24,7 @@ var (
 	user           string
 	password       string
 	token          string
+	accessToken    string
 	hash           string
 	skipReadConfig bool
 	retry          int
@@ -35,6 +36,8 @@ var (
 	RequestedWithValue = "X-CDS-SDK"
 	//SessionTokenHeader is user as HTTP header
 	SessionTokenHeader = "Session-Token"
+	// AccessTokenHeader is used as HTTP header to authenticate with a personal access token
+	AccessTokenHeader = "Access-Token"
 	// HTTP client
 	client HTTPClient
 	// current agent calling
@@ -119,6 +122,9 @@ func ReadConfig() error {
 		if viper.GetString("token") != "" {
 			token = viper.GetString("token")
 		}
+		if viper.GetString("access_token") != "" {
+			accessToken = viper.GetString("access_token")
+		}
 	}
 
 	if val := os.Getenv("CDS_VERBOSE"); val == "true" {
@@ -134,11 +140,18 @@ func ReadConfig() error {
 	if val := os.Getenv("CDS_TOKEN"); val != "" {
 		token = val
 	}
+	if val := os.Getenv("CDS_ACCESS_TOKEN"); val != "" {
+		accessToken = val
+	}
 
 	if user != "" && (password != "" || token != "") {
 		return nil
 	}
 
+	if accessToken != "" {
+		return nil
+	}
+
 	if hash != "" {
 		return nil
 	}
@@ -247,12 +260,16 @@ func Stream(method string, path string, args []byte, mods ...RequestModifier) (i
 				basedHash := base64.StdEncoding.EncodeToString([]byte(hash))
 				req.Header.Set(AuthHeader, basedHash)
 			}
-			if user != "" && password != "" {
-				req.SetBasicAuth(user, password)
-			}
-			if user != "" && token != "" {
-				req.Header.Add(SessionTokenHeader, token)
-				req.SetBasicAuth(user, token)
+			if accessToken != "" {
+				req.Header.Set(AccessTokenHeader, accessToken)
+			} else {
+				if user != "" && password != "" {
+					req.SetBasicAuth(user, password)
+				}
+				if user != "" && token != "" {
+					req.Header.Add(SessionTokenHeader, token)
+					req.SetBasicAuth(user, token)
+				}
 			}
 		}
 
@@ -331,12 +348,16 @@ func UploadMultiPart(method string, path string, body *bytes.Buffer, mods ...Req
 		basedHash := base64.StdEncoding.EncodeToString([]byte(hash))
 		req.Header.Set(AuthHeader, basedHash)
 	}
-	if user != "" && password != "" {
-		req.SetBasicAuth(user, password)
-	}
-	if user != "" && token != "" {
-		req.Header.Add(SessionTokenHeader, token)
-		req.SetBasicAuth(user, token)
+	if accessToken != "" {
+		req.Header.Set(AccessTokenHeader, accessToken)
+	} else {
+		if user != "" && password != "" {
+			req.SetBasicAuth(user, password)
+		}
+		if user != "" && token != "" {
+			req.Header.Add(SessionTokenHeader, token)
+			req.SetBasicAuth(user, token)
+		}
 	}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -390,7 +411,9 @@ func Upload(method string, path string, body io.ReadCloser, mods ...RequestModif
 		basedHash := base64.StdEncoding.EncodeToString([]byte(hash))
 		req.Header.Set(AuthHeader, basedHash)
 	}
-	if user != "" && password != "" {
+	if accessToken != "" {
+		req.Header.Set(AccessTokenHeader, accessToken)
+	} else if user != "" && password != "" {
 		req.SetBasicAuth(user, password)
 	}
 	resp, err := client.Do(req)
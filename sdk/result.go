@@ -6,4 +6,6 @@ type Result struct {
 	BuildID int64  `json:"build_id" yaml:"build"`
 	Status  Status `json:"status"`
 	Version int64  `json:"version"`
+	// FailedAction is the name of the builtin or plugin action which made the job fail
+	FailedAction string `json:"failed_action,omitempty"`
 }
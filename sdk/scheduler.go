@@ -14,6 +14,9 @@ type PipelineScheduler struct {
 	Args            []Parameter                 `json:"args,omitempty" db:"-"`
 	Crontab         string                      `json:"crontab,omitempty" db:"crontab"`
 	Disabled        bool                        `json:"disable" db:"disable"`
+	Timezone        string                      `json:"timezone,omitempty" db:"timezone"`
+	Jitter          int64                       `json:"jitter,omitempty" db:"jitter"` // in seconds
+	SkipIfRunning   bool                        `json:"skip_if_running" db:"skip_if_running"`
 	LastExecution   *PipelineSchedulerExecution `json:"last_execution" db:"-"`
 	NextExecution   *PipelineSchedulerExecution `json:"next_execution" db:"-"`
 }
@@ -26,4 +29,15 @@ type PipelineSchedulerExecution struct {
 	ExecutionDate        *time.Time `json:"execution_date" db:"execution_date"`
 	Executed             bool       `json:"executed" db:"executed"`
 	PipelineBuildVersion int64      `json:"pipeline_build_version" db:"pipeline_build_version"`
+	Skipped              bool       `json:"skipped" db:"skipped"`
+}
+
+//PipelineSchedulerPlannedExecution is a future execution of a cron scheduler
+type PipelineSchedulerPlannedExecution struct {
+	PipelineSchedulerID int64     `json:"pipeline_scheduler_id"`
+	EnvironmentName     string    `json:"environment_name"`
+	Crontab             string    `json:"crontab"`
+	Timezone            string    `json:"timezone,omitempty"`
+	Jitter              int64     `json:"jitter,omitempty"`
+	Date                time.Time `json:"date"`
 }
@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a semantic version parsed from a git tag, see http://semver.org
+type SemVer struct {
+	Major      int64
+	Minor      int64
+	Patch      int64
+	PreRelease string
+	Build      string
+}
+
+var semverRegexp = regexp.MustCompile(`^[vV]?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// ParseSemVer parses a tag such as v1.2.3-rc.1+build.5, the v prefix is optional
+func ParseSemVer(tag string) (SemVer, error) {
+	m := semverRegexp.FindStringSubmatch(tag)
+	if m == nil {
+		return SemVer{}, fmt.Errorf("%s is not a semantic version", tag)
+	}
+
+	var v SemVer
+	var err error
+	if v.Major, err = strconv.ParseInt(m[1], 10, 64); err != nil {
+		return SemVer{}, err
+	}
+	if v.Minor, err = strconv.ParseInt(m[2], 10, 64); err != nil {
+		return SemVer{}, err
+	}
+	if v.Patch, err = strconv.ParseInt(m[3], 10, 64); err != nil {
+		return SemVer{}, err
+	}
+	v.PreRelease = m[4]
+	v.Build = m[5]
+	return v, nil
+}
+
+// String returns the version without the v prefix
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// TagParameters returns the git.tag.* parameters of a semantic version tag, nothing if the tag is not a semantic version
+func TagParameters(tag string) []Parameter {
+	v, err := ParseSemVer(tag)
+	if err != nil {
+		return nil
+	}
+
+	var params []Parameter
+	AddParameter(&params, "git.tag.version", StringParameter, v.String())
+	AddParameter(&params, "git.tag.major", StringParameter, strconv.FormatInt(v.Major, 10))
+	AddParameter(&params, "git.tag.minor", StringParameter, strconv.FormatInt(v.Minor, 10))
+	AddParameter(&params, "git.tag.patch", StringParameter, strconv.FormatInt(v.Patch, 10))
+	AddParameter(&params, "git.tag.prerelease", StringParameter, v.PreRelease)
+	AddParameter(&params, "git.tag.build", StringParameter, v.Build)
+	return params
+}
+
+// MatchTagPattern returns true if the tag matches one of the glob patterns, such as v* or release/**
+func MatchTagPattern(patterns []string, tag string) bool {
+	for _, p := range patterns {
+		if MatchGlob(strings.TrimSpace(p), tag) {
+			return true
+		}
+	}
+	return false
+}
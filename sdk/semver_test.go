@@ -0,0 +1,42 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSemVer(t *testing.T) {
+	v, err := ParseSemVer("v1.12.3-rc.1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, SemVer{Major: 1, Minor: 12, Patch: 3, PreRelease: "rc.1", Build: "build.5"}, v)
+	assert.Equal(t, "1.12.3-rc.1+build.5", v.String())
+
+	v, err = ParseSemVer("2.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", v.String())
+
+	for _, tag := range []string{"v1.2", "release-1.2.3", "v01.2.3", "1.2.3-", "latest"} {
+		_, err := ParseSemVer(tag)
+		assert.Error(t, err, tag)
+	}
+}
+
+func TestTagParameters(t *testing.T) {
+	params := TagParameters("v1.2.3-beta")
+	assert.Equal(t, "1.2.3-beta", ParameterValue(params, "git.tag.version"))
+	assert.Equal(t, "1", ParameterValue(params, "git.tag.major"))
+	assert.Equal(t, "2", ParameterValue(params, "git.tag.minor"))
+	assert.Equal(t, "3", ParameterValue(params, "git.tag.patch"))
+	assert.Equal(t, "beta", ParameterValue(params, "git.tag.prerelease"))
+
+	assert.Len(t, TagParameters("nightly"), 0)
+}
+
+func TestMatchTagPattern(t *testing.T) {
+	assert.True(t, MatchTagPattern([]string{"release-*", "v*"}, "v1.0.0"))
+	assert.False(t, MatchTagPattern([]string{"v*"}, "release-1"))
+	assert.False(t, MatchTagPattern([]string{"release/*"}, "release/1.0/rc1"))
+	assert.True(t, MatchTagPattern([]string{"release/**"}, "release/1.0/rc1"))
+	assert.False(t, MatchTagPattern(nil, "v1.0.0"))
+}
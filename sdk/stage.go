@@ -8,16 +8,18 @@ import (
 
 // Stage Pipeline step that parallelize actions by order
 type Stage struct {
-	ID                int64              `json:"id" yaml:"pipeline_stage_id"`
-	Name              string             `json:"name"`
-	PipelineID        int64              `json:"-" yaml:"-"`
-	BuildOrder        int                `json:"build_order"`
-	Enabled           bool               `json:"enabled"`
-	PipelineBuildJobs []PipelineBuildJob `json:"builds"`
-	Prerequisites     []Prerequisite     `json:"prerequisites"`
-	LastModified      int64              `json:"last_modified"`
-	Jobs              []Job              `json:"jobs"`
-	Status            Status             `json:"status"`
+	ID                int64                  `json:"id" yaml:"pipeline_stage_id"`
+	Name              string                 `json:"name"`
+	PipelineID        int64                  `json:"-" yaml:"-"`
+	BuildOrder        int                    `json:"build_order"`
+	Enabled           bool                   `json:"enabled"`
+	PipelineBuildJobs []PipelineBuildJob     `json:"builds"`
+	Prerequisites     []Prerequisite         `json:"prerequisites"`
+	LastModified      int64                  `json:"last_modified"`
+	Jobs              []Job                  `json:"jobs"`
+	Status            Status                 `json:"status"`
+	Approval          *ApprovalGate          `json:"approval,omitempty"`
+	Progressive       *ProgressiveDeployment `json:"progressive,omitempty"`
 }
 
 // NewStage instanciate a new Stage
@@ -135,6 +137,17 @@ func ChangeStageState(projectKey, pipelineName, pipelineStageID string, enabled
 	return updateStage(projectKey, pipelineName, pipelineStageID, s)
 }
 
+// SetStageProgressive sets the progressive deployment of a stage, nil makes it a plain stage again
+func SetStageProgressive(projectKey, pipelineName, pipelineStageID string, p *ProgressiveDeployment) error {
+
+	s, err := GetStage(projectKey, pipelineName, pipelineStageID)
+	if err != nil {
+		return err
+	}
+	s.Progressive = p
+	return updateStage(projectKey, pipelineName, pipelineStageID, s)
+}
+
 // MoveStage Change stage buildOrder
 func MoveStage(projectKey, pipelineName string, pipelineStageID int64, buildOrder int) error {
 
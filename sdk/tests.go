@@ -3,6 +3,7 @@ package sdk
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 )
 
 // Tests contains all informations about tests in a pipeline build
@@ -32,8 +33,20 @@ type Test struct {
 	Failure string  `xml:"failure" json:"failure"`
 	Error   string  `xml:"error" json:"error"`
 	Skip    *string `xml:"skipped" json:"skipped"`
+	// SystemOut and SystemErr are the outputs captured during the test
+	SystemOut string `xml:"system-out" json:"system_out,omitempty"`
+	SystemErr string `xml:"system-err" json:"system_err,omitempty"`
 }
 
+// Test report formats
+const (
+	TestFormatJUnit  = "junit"
+	TestFormatTAP    = "tap"
+	TestFormatXUnit  = "xunit"
+	TestFormatGoTest = "gotest"
+	TestFormatTRX    = "trx"
+)
+
 // GetTestResults retrieves tests results for a specific build
 func GetTestResults(proj, app, pip, env string, bn int) (Tests, error) {
 	if env == "" {
@@ -57,3 +70,62 @@ func GetTestResults(proj, app, pip, env string, bn int) (Tests, error) {
 
 	return t, nil
 }
+
+// Status of a test case
+const (
+	TestStatusSuccess = "success"
+	TestStatusFail    = "fail"
+	TestStatusSkipped = "skipped"
+)
+
+// Status returns the status of the test case
+func (t Test) Status() string {
+	switch {
+	case t.Failure != "" || t.Error != "":
+		return TestStatusFail
+	case t.Skip != nil:
+		return TestStatusSkipped
+	default:
+		return TestStatusSuccess
+	}
+}
+
+// FlakyTest is a test case whose result flip-flops between builds of a branch
+type FlakyTest struct {
+	PipelineID    int64   `json:"pipeline_id"`
+	Pipeline      string  `json:"pipeline"`
+	EnvironmentID int64   `json:"environment_id"`
+	Environment   string  `json:"environment"`
+	Branch        string  `json:"branch"`
+	Suite         string  `json:"suite"`
+	Name          string  `json:"name"`
+	Runs          int     `json:"runs"`
+	Failures      int     `json:"failures"`
+	FailureRate   float64 `json:"failure_rate"`
+	// Flips is the number of status changes between two successive runs
+	Flips int `json:"flips"`
+	// LastFailure is the build number of the last failing run
+	LastFailure int64 `json:"last_failure"`
+}
+
+// GetFlakyTests retrieves the flaky tests of an application, optionally filtered by branch
+func GetFlakyTests(proj, app, branch string) ([]FlakyTest, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/test/flaky", proj, app)
+	if branch != "" {
+		uri = fmt.Sprintf("%s?branch=%s", uri, url.QueryEscape(branch))
+	}
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var tests []FlakyTest
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, err
+	}
+	return tests, nil
+}
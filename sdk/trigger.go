@@ -27,8 +27,10 @@ type PipelineTrigger struct {
 	DestEnvironment Environment `json:"dest_environment" yaml:"-"`
 
 	Manual        bool           `json:"manual"`
+	Approval      *ApprovalGate  `json:"approval,omitempty"`
 	Parameters    []Parameter    `json:"parameters"`
 	Prerequisites []Prerequisite `json:"prerequisites"`
+	Condition     string         `json:"condition,omitempty"`
 	LastModified  int64          `json:"last_modified"`
 }
 
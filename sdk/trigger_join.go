@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Fan-in trigger matching modes
+const (
+	JoinMatchOnHash    = "hash"
+	JoinMatchOnVersion = "version"
+)
+
+// TriggerJoin is a fan-in trigger: its destination pipeline runs once all its source pipelines
+// have succeeded on the same git hash (or the same version) within the wait window
+type TriggerJoin struct {
+	ID int64 `json:"id"`
+
+	DestProject     Project     `json:"dest_project" yaml:"-"`
+	DestApplication Application `json:"dest_application" yaml:"-"`
+	DestPipeline    Pipeline    `json:"dest_pipeline" yaml:"-"`
+	DestEnvironment Environment `json:"dest_environment" yaml:"-"`
+
+	Sources    []TriggerJoinSource  `json:"sources"`
+	MatchOn    string               `json:"match_on"`
+	WaitWindow int64                `json:"wait_window"` // in seconds
+	Parameters []Parameter          `json:"parameters"`
+	Pending    []TriggerJoinPending `json:"pending,omitempty"`
+}
+
+// TriggerJoinSource is one of the pipelines a fan-in trigger waits for
+type TriggerJoinSource struct {
+	ID          int64       `json:"id"`
+	Application Application `json:"application"`
+	Pipeline    Pipeline    `json:"pipeline"`
+	Environment Environment `json:"environment"`
+}
+
+// TriggerJoinArrival records the successful build of a source of a fan-in trigger
+type TriggerJoinArrival struct {
+	SourceID        int64     `json:"source_id"`
+	Key             string    `json:"key"`
+	PipelineBuildID int64     `json:"pipeline_build_id"`
+	BuildNumber     int64     `json:"build_number"`
+	Version         int64     `json:"version"`
+	Hash            string    `json:"hash"`
+	Arrived         time.Time `json:"arrived"`
+}
+
+// TriggerJoinPending describes a fan-in trigger waiting for some of its sources on a git hash or a version
+type TriggerJoinPending struct {
+	Key     string               `json:"key"`
+	Started time.Time            `json:"started"`
+	Expires time.Time            `json:"expires"`
+	Arrived []TriggerJoinArrival `json:"arrived"`
+	Missing []TriggerJoinSource  `json:"missing"`
+}
+
+// GetTriggerJoins retrieves the fan-in triggers of a destination pipeline with their pending builds
+func GetTriggerJoins(projectKey, appName, pipelineName string) ([]TriggerJoin, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/join", projectKey, appName, pipelineName)
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var joins []TriggerJoin
+	if err := json.Unmarshal(data, &joins); err != nil {
+		return nil, err
+	}
+
+	return joins, nil
+}
+
+// AddTriggerJoin adds a fan-in trigger on its destination pipeline
+func AddTriggerJoin(j *TriggerJoin) error {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/join", j.DestProject.Key, j.DestApplication.Name, j.DestPipeline.Name)
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	data, code, err := Request("POST", uri, data)
+	if err != nil {
+		return err
+	}
+
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+
+	return json.Unmarshal(data, j)
+}
+
+// DeleteTriggerJoin removes a fan-in trigger
+func DeleteTriggerJoin(projectKey, appName, pipelineName string, id int64) error {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/join/%d", projectKey, appName, pipelineName, id)
+
+	_, code, err := Request("DELETE", uri, nil)
+	if err != nil {
+		return err
+	}
+
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+
+	return nil
+}
@@ -37,6 +37,34 @@ type UserAPIResponse struct {
 	Token    string `json:"token,omitempty"`
 }
 
+// OIDCAuthorization is the page of the OpenID Connect provider the user has to be redirected to
+type OIDCAuthorization struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+// OIDCCallbackRequest is the code and state the OpenID Connect provider sent back on the redirect URL
+type OIDCCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// OIDCDeviceAuthorization is a device authorization started on the OpenID Connect provider,
+// the user approves it on the verification URI with the user code
+type OIDCDeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// OIDCDeviceTokenRequest asks if a device authorization has been approved
+type OIDCDeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
 // UserEmailPattern  pattern for user email address
 const UserEmailPattern = "(\\w[-._\\w]*\\w@\\w[-._\\w]*\\w\\.\\w{2,3})"
 
@@ -93,6 +121,51 @@ func LoginUser(username, password string) (bool, *UserAPIResponse, error) {
 	return true, loginResponse, nil
 }
 
+// OIDCDeviceAuthorize starts an OpenID Connect device authorization to log in from the CLI
+func OIDCDeviceAuthorize() (*OIDCDeviceAuthorization, error) {
+	data, code, err := Request("POST", "/login/oidc/device", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	a := &OIDCDeviceAuthorization{}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// OIDCDeviceLogin logs in with an approved device authorization.
+// It returns nil while the user has not approved it yet.
+func OIDCDeviceLogin(deviceCode string) (*UserAPIResponse, error) {
+	data, err := json.Marshal(OIDCDeviceTokenRequest{DeviceCode: deviceCode})
+	if err != nil {
+		return nil, err
+	}
+
+	data, code, err := Request("POST", "/login/oidc/device/token", data)
+	if err != nil {
+		return nil, err
+	}
+
+	if code == http.StatusAccepted {
+		return nil, nil
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	loginResponse := &UserAPIResponse{}
+	if err := json.Unmarshal(data, loginResponse); err != nil {
+		return nil, err
+	}
+	return loginResponse, nil
+}
+
 // DeleteUser Call API to delete the given user
 func DeleteUser(name string) error {
 
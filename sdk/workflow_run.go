@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// WorkflowRun groups every pipeline build spawned through triggers from a root pipeline build
+type WorkflowRun struct {
+	RootID   int64             `json:"root_id"`
+	Status   Status            `json:"status"`
+	Start    time.Time         `json:"start"`
+	Done     time.Time         `json:"done,omitempty"`
+	Duration int64             `json:"duration"` // in seconds
+	Nodes    []WorkflowRunNode `json:"nodes"`
+	Edges    []WorkflowRunEdge `json:"edges"`
+}
+
+// WorkflowRunNode is a pipeline build of a workflow run
+type WorkflowRunNode struct {
+	ID            int64     `json:"id"`
+	ParentID      int64     `json:"parent_id,omitempty"`
+	Project       string    `json:"project"`
+	ApplicationID int64     `json:"application_id"`
+	Application   string    `json:"application"`
+	Pipeline      string    `json:"pipeline"`
+	Environment   string    `json:"environment"`
+	BuildNumber   int64     `json:"build_number"`
+	Version       int64     `json:"version"`
+	Branch        string    `json:"branch,omitempty"`
+	Hash          string    `json:"hash,omitempty"`
+	Status        Status    `json:"status"`
+	Start         time.Time `json:"start"`
+	Done          time.Time `json:"done,omitempty"`
+	Duration      int64     `json:"duration"` // in seconds
+}
+
+// WorkflowRunEdge links a pipeline build to a pipeline build it triggered
+type WorkflowRunEdge struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// Children returns the nodes triggered by the given node
+func (r *WorkflowRun) Children(id int64) []WorkflowRunNode {
+	var children []WorkflowRunNode
+	for _, e := range r.Edges {
+		if e.From != id {
+			continue
+		}
+		for _, n := range r.Nodes {
+			if n.ID == e.To {
+				children = append(children, n)
+			}
+		}
+	}
+	return children
+}
+
+// GetWorkflowRun retrieves the workflow run a pipeline build belongs to
+func GetWorkflowRun(projectKey, appName, pipelineName, envName string, buildNumber int64) (*WorkflowRun, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/workflow?envName=%s", projectKey, appName, pipelineName, buildNumber, url.QueryEscape(envName))
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var run WorkflowRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}